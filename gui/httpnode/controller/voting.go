@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,7 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"go.dedis.ch/cs438/peer"
+	"go.dedis.ch/cs438/peer/impl"
 	"go.dedis.ch/cs438/types"
 )
 
@@ -64,6 +66,16 @@ type electionView struct {
 	Results        []resultView
 	ProofsVerified map[string]bool
 	IsReady        bool
+	Phase          types.Phase
+	CancelReason   string
+	// QuorumNotReached tells that the election finished without results
+	QuorumNotReached bool
+
+	// OpensAt is the opening time of a scheduled election, empty otherwise,
+	// and Upcoming tells if it is not open yet
+	OpensAt          string
+	OpensAtUnixMilli int64
+	Upcoming         bool
 }
 
 type resultView struct {
@@ -89,7 +101,16 @@ func (v voting) electionsHTMLGet(w http.ResponseWriter, r *http.Request) {
 			MyVote:     election.MyVote,
 		}
 
-		electionV.IsReady = election.IsElectionStarted()
+		if !election.Base.OpeningTime.IsZero() {
+			electionV.OpensAt = election.Base.OpeningTime.Format(time.ANSIC)
+			electionV.OpensAtUnixMilli = election.Base.OpeningTime.UnixMilli()
+			electionV.Upcoming = election.Phase < types.PhaseOpen
+		}
+
+		electionV.IsReady = election.Phase == types.PhaseOpen
+		electionV.Phase = election.Phase
+		electionV.CancelReason = election.CancelReason
+		electionV.QuorumNotReached = election.QuorumNotReached
 
 		electionV.Winner = GetWinner(election.Results)
 
@@ -194,6 +215,57 @@ type startElectionArgument struct {
 	Choices        []string
 	MixnetServers  []string
 	ExpirationTime uint
+	// TallyMode is the name of a types.TallyMode, optional
+	TallyMode string
+	// BallotType is the name of a types.BallotType, optional
+	BallotType string
+	// MaxSelections makes the election an approval election, optional
+	MaxSelections int
+	// Contests make the election a multi-contest election, Choices is then
+	// empty. Optional.
+	Contests []contestArgument
+	// EligibleVoters are the keys of the voters, any key may vote if empty
+	EligibleVoters [][]byte
+	// VotePolicy is the name of a types.VotePolicy, optional
+	VotePolicy string
+	// AnonymousCredentials makes the voters register before voting
+	AnonymousCredentials bool
+	// Revoting lets the voters replace their ballot
+	Revoting bool
+	// Quorum is a minimum number of ballots, and QuorumPercent a minimum
+	// percentage of the eligible voters. Optional.
+	Quorum        int
+	QuorumPercent int
+	// OpensAt schedules the election to open later, it then closes at
+	// ClosesAt, or ExpirationTime seconds after opening. Optional.
+	OpensAt  time.Time
+	ClosesAt time.Time
+}
+
+// contestArgument is a contest of a multi-contest election, see
+// startElectionArgument for the ballot options
+type contestArgument struct {
+	Title         string
+	Choices       []string
+	BallotType    string
+	MaxSelections int
+}
+
+// ballotOptions returns the election options of the ballot type and the
+// number of selections
+func ballotOptions(ballotTypeName string, maxSelections int) ([]peer.ElectionOption, error) {
+	opts := []peer.ElectionOption{}
+	if ballotTypeName != "" {
+		ballotType, err := types.ParseBallotType(ballotTypeName)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, peer.WithBallotType(ballotType))
+	}
+	if maxSelections != 0 {
+		opts = append(opts, peer.WithMaxSelections(maxSelections))
+	}
+	return opts, nil
 }
 
 func (v voting) electionsGet(w http.ResponseWriter, r *http.Request) {
@@ -234,7 +306,63 @@ func (v voting) electionsPost(w http.ResponseWriter, r *http.Request) {
 
 	expirationTime := time.Second * time.Duration(res.ExpirationTime)
 
-	_, err = v.node.AnnounceElection(res.Title, res.Description, res.Choices, res.MixnetServers, expirationTime)
+	opts := []peer.ElectionOption{}
+	if res.TallyMode != "" {
+		tallyMode, err := types.ParseTallyMode(res.TallyMode)
+		if err != nil {
+			http.Error(w, "failed to start election: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, peer.WithTallyMode(tallyMode))
+	}
+	ballotOpts, err := ballotOptions(res.BallotType, res.MaxSelections)
+	if err != nil {
+		http.Error(w, "failed to start election: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts = append(opts, ballotOpts...)
+
+	if len(res.EligibleVoters) > 0 {
+		opts = append(opts, peer.WithEligibleVoters(res.EligibleVoters...))
+	}
+	if res.AnonymousCredentials {
+		opts = append(opts, peer.WithAnonymousCredentials())
+	}
+	if res.Revoting {
+		opts = append(opts, peer.WithRevoting())
+	}
+	if res.Quorum != 0 {
+		opts = append(opts, peer.WithQuorum(res.Quorum))
+	}
+	if res.QuorumPercent != 0 {
+		opts = append(opts, peer.WithQuorumPercent(res.QuorumPercent))
+	}
+	if !res.OpensAt.IsZero() {
+		closesAt := res.ClosesAt
+		if closesAt.IsZero() {
+			closesAt = res.OpensAt.Add(expirationTime)
+		}
+		opts = append(opts, peer.WithSchedule(res.OpensAt, closesAt))
+	}
+	if res.VotePolicy != "" {
+		votePolicy, err := types.ParseVotePolicy(res.VotePolicy)
+		if err != nil {
+			http.Error(w, "failed to start election: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, peer.WithVotePolicy(votePolicy))
+	}
+
+	for _, contest := range res.Contests {
+		contestOpts, err := ballotOptions(contest.BallotType, contest.MaxSelections)
+		if err != nil {
+			http.Error(w, "failed to start election: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		opts = append(opts, peer.WithContest(contest.Title, contest.Choices, contestOpts...))
+	}
+
+	_, err = v.node.AnnounceElection(res.Title, res.Description, res.Choices, res.MixnetServers, expirationTime, opts...)
 	if err != nil {
 		http.Error(w, "failed to start election: "+err.Error(),
 			http.StatusInternalServerError)
@@ -258,6 +386,42 @@ func (v voting) VoteHandler() http.HandlerFunc {
 type voteArgument struct {
 	ElectionID string
 	ChoiceID   int
+	// Ranking is set instead of ChoiceID for ranked ballots
+	Ranking []int
+	// Selection is set instead of ChoiceID for approval ballots
+	Selection []int
+	// Selections are set instead of ChoiceID for multi-contest ballots, one
+	// selection per contest
+	Selections [][]int
+	// Action is "prepare", "cast" or "audit" to prepare a ballot and then
+	// either cast or audit it, optional: the ballot is cast right away if
+	// empty
+	Action string
+}
+
+// voteResponse is returned when a ballot is prepared or audited
+type voteResponse struct {
+	// BallotHash identifies the prepared ballot, in hex
+	BallotHash string
+	// Audit is set when the ballot is audited
+	Audit *types.BallotAudit `json:",omitempty"`
+	// AuditError is the reason why the audited ballot doesn't encrypt the
+	// selections, empty if it does
+	AuditError string `json:",omitempty"`
+}
+
+// selections returns the selections of the argument, one per contest
+func (a voteArgument) selections() [][]int {
+	switch {
+	case a.Selections != nil:
+		return a.Selections
+	case a.Ranking != nil:
+		return [][]int{a.Ranking}
+	case a.Selection != nil:
+		return [][]int{a.Selection}
+	default:
+		return [][]int{{a.ChoiceID}}
+	}
 }
 
 func (v voting) votePost(w http.ResponseWriter, r *http.Request) {
@@ -276,10 +440,306 @@ func (v voting) votePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = v.node.Vote(res.ElectionID, res.ChoiceID)
+	switch res.Action {
+	case "prepare":
+		v.prepareBallot(w, r, res)
+		return
+	case "cast":
+		_, err = v.node.CastBallot(r.Context(), res.ElectionID)
+	case "audit":
+		v.auditBallot(w, res)
+		return
+	case "":
+		if res.Ranking != nil {
+			_, err = v.node.VoteRanking(r.Context(), res.ElectionID, res.Ranking)
+		} else if res.Selections != nil {
+			_, err = v.node.VoteContests(r.Context(), res.ElectionID, res.Selections)
+		} else if res.Selection != nil {
+			_, err = v.node.VoteApproval(r.Context(), res.ElectionID, res.Selection)
+		} else {
+			_, err = v.node.Vote(r.Context(), res.ElectionID, res.ChoiceID)
+		}
+	default:
+		http.Error(w, "unknown action: "+res.Action, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		http.Error(w, "failed to cast vote: "+err.Error(),
 			http.StatusInternalServerError)
 		return
 	}
 }
+
+// prepareBallot prepares the ballot and returns its hash, so that the voter can
+// then cast or audit it
+func (v voting) prepareBallot(w http.ResponseWriter, r *http.Request, res voteArgument) {
+	prepared, err := v.node.PrepareBallot(r.Context(), res.ElectionID, res.selections())
+	if err != nil {
+		http.Error(w, "failed to prepare ballot: "+err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	v.writeVoteResponse(w, voteResponse{BallotHash: hex.EncodeToString(prepared.BallotHash)})
+}
+
+// auditBallot audits the prepared ballot and checks that it encrypts the
+// selections of the voter
+func (v voting) auditBallot(w http.ResponseWriter, res voteArgument) {
+	audit, err := v.node.AuditBallot(res.ElectionID)
+	if err != nil {
+		http.Error(w, "failed to audit ballot: "+err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	var election *types.Election
+	for _, e := range v.node.GetElections() {
+		if e.Base.ElectionID == res.ElectionID {
+			election = e
+		}
+	}
+	if election == nil {
+		http.Error(w, "unknown election "+res.ElectionID, http.StatusBadRequest)
+		return
+	}
+
+	response := voteResponse{
+		BallotHash: hex.EncodeToString(impl.BallotHash(&audit.Ballot)),
+		Audit:      &audit,
+	}
+
+	err = impl.VerifyBallotAudit(election, &audit)
+	if err != nil {
+		response.AuditError = err.Error()
+	}
+
+	v.writeVoteResponse(w, response)
+}
+
+func (v voting) writeVoteResponse(w http.ResponseWriter, response voteResponse) {
+	res, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to marshal vote response: %v", err),
+			http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	w.Write(res)
+}
+
+// ---
+
+func (v voting) RegisterHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			v.registerPost(w, r)
+		default:
+			http.Error(w, "forbidden method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+type registerArgument struct {
+	ElectionID string
+}
+
+func (v voting) registerPost(w http.ResponseWriter, r *http.Request) {
+	// unmarshal argument
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := registerArgument{}
+	err = json.Unmarshal(buf, &res)
+	if err != nil {
+		http.Error(w, "failed to unmarshal registerArgument: "+err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	err = v.node.Register(r.Context(), res.ElectionID)
+	if err != nil {
+		http.Error(w, "failed to register: "+err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+}
+
+// ---
+
+func (v voting) CancelHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			v.cancelPost(w, r)
+		default:
+			http.Error(w, "forbidden method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+type cancelArgument struct {
+	ElectionID string
+	Reason     string
+}
+
+func (v voting) cancelPost(w http.ResponseWriter, r *http.Request) {
+	// unmarshal argument
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := cancelArgument{}
+	err = json.Unmarshal(buf, &res)
+	if err != nil {
+		http.Error(w, "failed to unmarshal cancelArgument: "+err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	err = v.node.CancelElection(res.ElectionID, res.Reason)
+	if err != nil {
+		http.Error(w, "failed to cancel election: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// ---
+
+func (v voting) ExtendHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			v.extendPost(w, r)
+		default:
+			http.Error(w, "forbidden method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+type extendArgument struct {
+	ElectionID string
+	// ExtraTime is the extension in seconds
+	ExtraTime uint
+}
+
+func (v voting) extendPost(w http.ResponseWriter, r *http.Request) {
+	// unmarshal argument
+	buf, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read body: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := extendArgument{}
+	err = json.Unmarshal(buf, &res)
+	if err != nil {
+		http.Error(w, "failed to unmarshal extendArgument: "+err.Error(),
+			http.StatusInternalServerError)
+		return
+	}
+
+	err = v.node.ExtendElection(res.ElectionID, time.Second*time.Duration(res.ExtraTime))
+	if err != nil {
+		http.Error(w, "failed to extend election: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// ---
+
+func (v voting) BulletinBoardHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			v.bulletinBoardGet(w, r)
+		default:
+			http.Error(w, "forbidden method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// bulletinBoardGet exports the bulletin board of the election given by the
+// "electionID" query parameter. It can be verified offline with the verify
+// command.
+func (v voting) bulletinBoardGet(w http.ResponseWriter, r *http.Request) {
+	electionID := r.URL.Query().Get("electionID")
+	if electionID == "" {
+		http.Error(w, "missing electionID", http.StatusBadRequest)
+		return
+	}
+
+	res, err := json.MarshalIndent(v.node.GetBulletinBoard(electionID), "", "\t")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed marshal bulletin board response: %v", err),
+			http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	w.Write(res)
+}
+
+// ---
+
+func (v voting) PhaseHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			v.phaseGet(w, r)
+		default:
+			http.Error(w, "forbidden method", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// phaseGet creates a SSE connection, where the phases of the election given by
+// the "electionID" query parameter are sent as they change. The stream ends
+// once the election is finished or aborted.
+func (v voting) phaseGet(w http.ResponseWriter, r *http.Request) {
+	electionID := r.URL.Query().Get("electionID")
+	if electionID == "" {
+		http.Error(w, "missing electionID", http.StatusBadRequest)
+		return
+	}
+
+	phases, unsubscribe, err := v.node.SubscribePhase(electionID)
+	if err != nil {
+		http.Error(w, "failed to subscribe: "+err.Error(), http.StatusNotFound)
+		return
+	}
+	defer unsubscribe()
+
+	flusher, _ := w.(http.Flusher)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	for {
+		select {
+		case phase, ok := <-phases:
+			if !ok {
+				return
+			}
+
+			fmt.Fprintf(w, "data: %s\n\n", phase)
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -99,7 +99,12 @@ func NewHTTPNode(node peer.Peer, conf peer.Configuration) Proxy {
 	mux.Handle("/peervote/elections/html", http.HandlerFunc(voting.ElectionsHTMLHandler()))
 	mux.Handle("/peervote/elections", http.HandlerFunc(voting.ElectionsHandler()))
 	mux.Handle("/peervote/vote", http.HandlerFunc(voting.VoteHandler()))
+	mux.Handle("/peervote/register", http.HandlerFunc(voting.RegisterHandler()))
+	mux.Handle("/peervote/cancel", http.HandlerFunc(voting.CancelHandler()))
+	mux.Handle("/peervote/extend", http.HandlerFunc(voting.ExtendHandler()))
 	mux.Handle("/peervote/mixnetservers", http.HandlerFunc(voting.MixnetServerHandler()))
+	mux.Handle("/peervote/board", http.HandlerFunc(voting.BulletinBoardHandler()))
+	mux.Handle("/peervote/phase", http.HandlerFunc(voting.PhaseHandler()))
 
 	dir := http.Dir("./web")
 	fs := http.FileServer(dir)
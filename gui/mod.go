@@ -2,6 +2,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
@@ -15,6 +16,7 @@ import (
 	"go.dedis.ch/cs438/peer"
 	"go.dedis.ch/cs438/peer/impl"
 	"go.dedis.ch/cs438/registry/standard"
+	"go.dedis.ch/cs438/types"
 
 	"go.dedis.ch/cs438/storage"
 	"go.dedis.ch/cs438/storage/file"
@@ -129,6 +131,36 @@ func main() {
 						Usage: "Retry value for the backoff strategy",
 						Value: 5,
 					},
+					&urfave.DurationFlag{
+						Name:  "votebackoffinitial",
+						Usage: "Initial time before resending a ballot which is not acknowledged",
+						Value: time.Second,
+					},
+					&urfave.UintFlag{
+						Name:  "votebackofffactor",
+						Usage: "Factor value for the ballot resending backoff strategy",
+						Value: 2,
+					},
+					&urfave.UintFlag{
+						Name:  "votebackoffretry",
+						Usage: "Retry value for the ballot resending backoff strategy",
+						Value: 3,
+					},
+					&urfave.DurationFlag{
+						Name:  "dkgtimeout",
+						Usage: "Deadline of each phase of the key generation of an election",
+						Value: time.Second * 10,
+					},
+					&urfave.StringFlag{
+						Name:  "pedersensuite",
+						Usage: "Group of the elections announced by the peer: P-256 or P-384",
+						Value: string(types.DefaultPedersenSuite),
+					},
+					&urfave.BoolFlag{
+						Name:  "allowunsigned",
+						Usage: "Accept the packets and rumors which are not signed by their creator, but for the elections",
+						Value: false,
+					},
 					&urfave.UintFlag{
 						Name:  "totalpeers",
 						Usage: "Total number of peers (needed for Paxos)",
@@ -147,6 +179,12 @@ func main() {
 				},
 				Action: start,
 			},
+			{
+				Name:      "verify",
+				Usage:     "verifies offline all the proofs of an exported election bulletin board",
+				ArgsUsage: "<bulletin board JSON file>",
+				Action:    verify,
+			},
 		},
 
 		Action: func(c *urfave.Context) error {
@@ -215,7 +253,15 @@ func start(c *urfave.Context) error {
 			Factor:  c.Uint("backofffactor"),
 			Retry:   c.Uint("backoffretry"),
 		},
-		Storage: storage,
+		BackoffVote: peer.Backoff{
+			Initial: c.Duration("votebackoffinitial"),
+			Factor:  c.Uint("votebackofffactor"),
+			Retry:   c.Uint("votebackoffretry"),
+		},
+		DKGTimeout:    c.Duration("dkgtimeout"),
+		PedersenSuite: types.PedersenSuite(c.String("pedersensuite")),
+		AllowUnsigned: c.Bool("allowunsigned"),
+		Storage:       storage,
 
 		TotalPeers: totalPeers,
 		PaxosThreshold: func(u uint) int {
@@ -255,3 +301,32 @@ func start(c *urfave.Context) error {
 
 	return nil
 }
+
+// verify reads a bulletin board exported from a node (see /peervote/board) and
+// checks every proof it holds.
+func verify(c *urfave.Context) error {
+	if c.NArg() != 1 {
+		return xerrors.Errorf("expected the path of the bulletin board, got %d arguments", c.NArg())
+	}
+
+	buf, err := os.ReadFile(c.Args().First())
+	if err != nil {
+		return xerrors.Errorf("failed to read bulletin board: %v", err)
+	}
+
+	var board types.BulletinBoard
+
+	err = json.Unmarshal(buf, &board)
+	if err != nil {
+		return xerrors.Errorf("failed to unmarshal bulletin board: %v", err)
+	}
+
+	err = impl.VerifyBulletinBoard(board)
+	if err != nil {
+		return xerrors.Errorf("election %s is NOT valid: %v", board.ElectionID, err)
+	}
+
+	fmt.Printf("election %s is valid: all %d entries of the bulletin board verified\n", board.ElectionID, len(board.Entries))
+
+	return nil
+}
@@ -29,6 +29,7 @@ const main = function () {
   application.register("vote", Vote);
   application.register("startelection", StartElection);
   application.register("proofs", Proofs);
+  application.register("countdown", Countdown);
 
   initCollapsible();
 };
@@ -215,6 +216,8 @@ class StartElection extends BaseElement {
     "title",
     "description",
     "expirationtime",
+    "openingdelay",
+    "quorum",
     "mixnetserversselect",
     "mixnetservers",
     "choiceinput",
@@ -341,6 +344,20 @@ class StartElection extends BaseElement {
       Choices: this.choices,
     };
 
+    // optional: the election is invalid with fewer ballots
+    const quorum = parseInt(this.quorumTarget.value);
+    if (quorum > 0) {
+      body.Quorum = quorum;
+    }
+
+    // optional: the election is announced now and opens later
+    const openingDelay = parseInt(this.openingdelayTarget.value);
+    if (openingDelay > 0) {
+      const opensAt = new Date(Date.now() + openingDelay * 1000);
+      body.OpensAt = opensAt.toISOString();
+      body.ClosesAt = new Date(opensAt.getTime() + expirationTime * 1000).toISOString();
+    }
+
     const url = this.peerInfo.getAPIURL("/peervote/elections");
 
     this.post(url, body)
@@ -349,6 +366,8 @@ class StartElection extends BaseElement {
         this.titleTarget.value = "";
         this.descriptionTarget.value = "";
         this.expirationtimeTarget.value = "";
+        this.openingdelayTarget.value = "";
+        this.quorumTarget.value = "";
         this.mixnetserversTarget.innerHTML = "";
         this.choicesTarget.innerHTML = "";
       })
@@ -364,6 +383,8 @@ class Elections extends BaseElement {
   }
 
   initialize() {
+    // electionID -> EventSource of its phases
+    this.phases = new Map();
     this.update();
   }
 
@@ -378,11 +399,78 @@ class Elections extends BaseElement {
       // insecure, but should be fine for demo purposes
       this.electionsTarget.innerHTML = html;
 
+      this.subscribePhases();
+
       // this.flash.printSuccess("Elections updated");
     } catch (e) {
       this.flash.printError("Failed to fetch elections: " + e);
     }
   }
+
+  // refresh the elections each time the phase of a running election changes
+  subscribePhases() {
+    const elections = this.electionsTarget.querySelectorAll("[data-election-id]");
+
+    elections.forEach((election) => {
+      const electionID = election.dataset.electionId;
+
+      if (election.dataset.electionFinal == "true" || this.phases.has(electionID)) {
+        return;
+      }
+
+      const addr = this.peerInfo.getAPIURL("/peervote/phase?electionID=" + electionID);
+      const phases = new EventSource(addr);
+
+      let first = true;
+      phases.onmessage = () => {
+        // the first event is the current phase, already displayed
+        if (first) {
+          first = false;
+          return;
+        }
+        this.update();
+      };
+
+      // the stream ends with the election
+      phases.onerror = () => {
+        phases.close();
+      };
+
+      this.phases.set(electionID, phases);
+    });
+  }
+}
+
+// Countdown shows the time left until a scheduled election opens
+class Countdown extends Controller {
+  static get targets() {
+    return ["remaining"];
+  }
+
+  connect() {
+    this.opening = parseInt(this.element.dataset.countdownOpening);
+    this.refresh();
+    this.timer = setInterval(() => this.refresh(), 1000);
+  }
+
+  disconnect() {
+    clearInterval(this.timer);
+  }
+
+  refresh() {
+    const left = Math.max(0, Math.floor((this.opening - Date.now()) / 1000));
+
+    const hours = Math.floor(left / 3600);
+    const minutes = Math.floor((left % 3600) / 60);
+    const seconds = left % 60;
+
+    const pad = (n) => String(n).padStart(2, "0");
+    this.remainingTarget.innerHTML = `${hours}:${pad(minutes)}:${pad(seconds)}`;
+
+    if (left == 0) {
+      clearInterval(this.timer);
+    }
+  }
 }
 
 class Proofs extends BaseElement {
@@ -412,7 +500,7 @@ class Proofs extends BaseElement {
 
 class Vote extends BaseElement {
   static get targets() {
-    return ["holder"];
+    return ["holder", "hash"];
   }
 
   static outlets = ["elections"];
@@ -444,6 +532,83 @@ class Vote extends BaseElement {
         this.flash.printError("Failed to post vote: " + e);
       });
   }
+
+  // onPrepare encrypts the ballot without casting it, and shows its hash. The
+  // voter then either casts or audits it.
+  async onPrepare() {
+    if (this.choiceID == "") {
+      this.flash.printError("Need to select an option to prepare a ballot");
+      return;
+    }
+
+    const body = {
+      ChoiceID: this.choiceID,
+      ElectionID: this.electionID,
+      Action: "prepare",
+    };
+    const addr = this.peerInfo.getAPIURL("/peervote/vote");
+
+    try {
+      const resp = await this.fetch(addr, this.postArgs(body));
+      const prepared = await resp.json();
+      this.hashTarget.innerText = "Prepared ballot " + prepared.BallotHash;
+    } catch (e) {
+      this.flash.printError("Failed to prepare ballot: " + e);
+    }
+  }
+
+  async onCast(event) {
+    const body = {
+      ElectionID: event.params.electionid,
+      Action: "cast",
+    };
+    const addr = this.peerInfo.getAPIURL("/peervote/vote");
+
+    try {
+      await this.fetch(addr, this.postArgs(body));
+      this.electionsOutlet.update();
+    } catch (e) {
+      this.flash.printError("Failed to cast ballot: " + e);
+    }
+  }
+
+  // onAudit reveals the randomness of the prepared ballot, which is then
+  // discarded, and shows whether it encrypts the selected choice.
+  async onAudit(event) {
+    const body = {
+      ElectionID: event.params.electionid,
+      Action: "audit",
+    };
+    const addr = this.peerInfo.getAPIURL("/peervote/vote");
+
+    try {
+      const resp = await this.fetch(addr, this.postArgs(body));
+      const audit = await resp.json();
+      this.hashTarget.innerText = "";
+
+      if (audit.AuditError) {
+        this.flash.printError(
+          `Ballot ${audit.BallotHash} failed the audit: ${audit.AuditError}`
+        );
+      } else {
+        this.flash.printSuccess(
+          `Ballot ${audit.BallotHash} encrypts choice ${audit.Audit.Selections[0]}, it is discarded`
+        );
+      }
+    } catch (e) {
+      this.flash.printError("Failed to audit ballot: " + e);
+    }
+  }
+
+  postArgs(body) {
+    return {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify(body),
+    };
+  }
 }
 
 class Messaging extends BaseElement {
@@ -656,7 +821,8 @@ class Packets extends BaseElement {
 
     const type = pkt.Msg.Type;
 
-    if (type == "startelection" || type == "result" || type == "election-ready") {
+    // new elections, the phases of known ones are followed by the elections
+    if (type == "startelection") {
       this.electionsOutlet.update();
     }
 
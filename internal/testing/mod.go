@@ -145,13 +145,18 @@ type configTemplate struct {
 	storage storage.Storage
 
 	dataRequestBackoff peer.Backoff
+	voteBackoff        peer.Backoff
+	dkgTimeout         time.Duration
 
 	totalPeers         uint
 	paxosThreshold     func(uint) int
 	paxosID            uint
 	paxosProposerRetry time.Duration
 
-	//pedersenSuite types.PedersenSuite
+	pedersenSuite types.PedersenSuite
+
+	// the tests of the homeworks send unsigned packets
+	allowUnsigned bool
 }
 
 func newConfigTemplate() configTemplate {
@@ -180,6 +185,14 @@ func newConfigTemplate() configTemplate {
 			Retry:   5,
 		},
 
+		voteBackoff: peer.Backoff{
+			Initial: time.Second,
+			Factor:  2,
+			Retry:   3,
+		},
+
+		dkgTimeout: time.Second * 10,
+
 		totalPeers: 1,
 		paxosThreshold: func(u uint) int {
 			return int(u/2 + 1)
@@ -187,7 +200,9 @@ func newConfigTemplate() configTemplate {
 		paxosID:            0,
 		paxosProposerRetry: time.Second * 5,
 
-		//pedersenSuite: types.DefaultPedersenSuite,
+		pedersenSuite: types.DefaultPedersenSuite,
+
+		allowUnsigned: true,
 	}
 }
 
@@ -262,6 +277,25 @@ func WithDataRequestBackoff(initial time.Duration, factor uint, retry uint) Opti
 	}
 }
 
+// WithVoteBackoff sets a specific vote backoff.
+func WithVoteBackoff(initial time.Duration, factor uint, retry uint) Option {
+	return func(ct *configTemplate) {
+		ct.voteBackoff = peer.Backoff{
+			Initial: initial,
+			Factor:  factor,
+			Retry:   retry,
+		}
+	}
+}
+
+// WithDKGTimeout sets a specific deadline for the phases of the key
+// generation.
+func WithDKGTimeout(timeout time.Duration) Option {
+	return func(ct *configTemplate) {
+		ct.dkgTimeout = timeout
+	}
+}
+
 // WithStorage sets a specific storage
 func WithStorage(storage storage.Storage) Option {
 	return func(ct *configTemplate) {
@@ -297,6 +331,21 @@ func WithPaxosProposerRetry(d time.Duration) Option {
 	}
 }
 
+// WithPedersenSuite sets the group of the elections announced by the node.
+func WithPedersenSuite(suite types.PedersenSuite) Option {
+	return func(ct *configTemplate) {
+		ct.pedersenSuite = suite
+	}
+}
+
+// WithRequireSignatures sets the node to drop all the unsigned packets and
+// rumors, not only those of the elections.
+func WithRequireSignatures() Option {
+	return func(ct *configTemplate) {
+		ct.allowUnsigned = false
+	}
+}
+
 // NewTestNode returns a new test node.
 func NewTestNode(t require.TestingT, f peer.Factory, trans transport.Transport,
 	addr string, opts ...Option) TestNode {
@@ -320,12 +369,15 @@ func NewTestNode(t require.TestingT, f peer.Factory, trans transport.Transport,
 	config.Storage = template.storage
 	config.ChunkSize = template.chunkSize
 	config.BackoffDataRequest = template.dataRequestBackoff
+	config.BackoffVote = template.voteBackoff
+	config.DKGTimeout = template.dkgTimeout
 	config.TotalPeers = template.totalPeers
 	config.PaxosThreshold = template.paxosThreshold
 	config.PaxosID = template.paxosID
 	config.PaxosProposerRetry = template.paxosProposerRetry
 
-	//config.PedersenSuite = template.pedersenSuite
+	config.PedersenSuite = template.pedersenSuite
+	config.AllowUnsigned = template.allowUnsigned
 
 	node := f(config)
 
@@ -0,0 +1,257 @@
+package impl
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// CancelElection implements peer.Voting
+func (n *node) CancelElection(electionID, reason string) error {
+	_, err := n.getAnnouncedElection(electionID)
+	if err != nil {
+		return err
+	}
+
+	var phase types.Phase
+	n.electionStore.View(electionID, func(election *types.Election) {
+		phase = election.Phase
+	})
+
+	if phase.IsFinal() {
+		return xerrors.Errorf("election %s is %s, it can't be cancelled", electionID, phase)
+	}
+
+	cancelElectionMessage := types.CancelElectionMessage{
+		ElectionID: electionID,
+		Reason:     reason,
+	}
+
+	signature, err := transport.SignDigest(n.signingKey, cancelElectionDigest(&cancelElectionMessage))
+	if err != nil {
+		return xerrors.Errorf("failed to sign the cancellation: %v", err)
+	}
+	cancelElectionMessage.Signature = signature
+
+	msg, err := marshalMessage(&cancelElectionMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
+
+// ExtendElection implements peer.Voting
+func (n *node) ExtendElection(electionID string, extra time.Duration) error {
+	_, err := n.getAnnouncedElection(electionID)
+	if err != nil {
+		return err
+	}
+
+	if extra <= 0 {
+		return xerrors.Errorf("invalid extension %s", extra)
+	}
+
+	var phase types.Phase
+	var expiration time.Time
+	n.electionStore.View(electionID, func(election *types.Election) {
+		phase = election.Phase
+		expiration = election.Base.Expiration
+	})
+
+	if phase != types.PhaseOpen {
+		return xerrors.Errorf("election %s is %s, it can't be extended", electionID, phase)
+	}
+
+	extendElectionMessage := types.ExtendElectionMessage{
+		ElectionID: electionID,
+		Expiration: expiration.Add(extra),
+	}
+
+	signature, err := transport.SignDigest(n.signingKey, extendElectionDigest(&extendElectionMessage))
+	if err != nil {
+		return xerrors.Errorf("failed to sign the extension: %v", err)
+	}
+	extendElectionMessage.Signature = signature
+
+	msg, err := marshalMessage(&extendElectionMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
+
+// getAnnouncedElection returns the election, if this peer announced it
+func (n *node) getAnnouncedElection(electionID string) (*types.Election, error) {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return nil, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	if election.Base.Announcer != n.myAddr {
+		return nil, xerrors.Errorf("only the announcer %s controls election %s", election.Base.Announcer, electionID)
+	}
+
+	return election, nil
+}
+
+// HandleCancelElectionMessage processes types.CancelElectionMessage. If the
+// announcer signed it, the election is aborted: the initiator stops waiting
+// to mix the ballots (see waitForExpiration).
+func (n *node) HandleCancelElectionMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling CancelElectionMessage from %v", pkt.Header.Source)
+	cancelElectionMessage := types.CancelElectionMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &cancelElectionMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(cancelElectionMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(cancelElectionMessage.ElectionID, pkt)
+	}
+
+	err = transport.VerifyDigest(election.Base.AnnouncerKey, cancelElectionDigest(&cancelElectionMessage),
+		cancelElectionMessage.Signature)
+	if err != nil {
+		return fmt.Errorf("the cancellation of election %s is not signed by its announcer: %v",
+			cancelElectionMessage.ElectionID, err)
+	}
+
+	err = n.recordOnBulletinBoard(cancelElectionMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		if !n.setPhase(election, types.PhaseAborted) {
+			return
+		}
+
+		log.Info().Str("peerAddr", n.myAddr).Msgf("election %s cancelled by its announcer: %s",
+			cancelElectionMessage.ElectionID, cancelElectionMessage.Reason)
+
+		election.CancelReason = cancelElectionMessage.Reason
+	})
+
+	return nil
+}
+
+// HandleExtendElectionMessage processes types.ExtendElectionMessage. If the
+// announcer signed it, the expiration of the election is pushed out: the
+// closing and the mixing wait for it (see scheduleClosing and
+// waitForExpiration).
+func (n *node) HandleExtendElectionMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling ExtendElectionMessage from %v", pkt.Header.Source)
+	extendElectionMessage := types.ExtendElectionMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &extendElectionMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(extendElectionMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(extendElectionMessage.ElectionID, pkt)
+	}
+
+	err = transport.VerifyDigest(election.Base.AnnouncerKey, extendElectionDigest(&extendElectionMessage),
+		extendElectionMessage.Signature)
+	if err != nil {
+		return fmt.Errorf("the extension of election %s is not signed by its announcer: %v",
+			extendElectionMessage.ElectionID, err)
+	}
+
+	err = n.recordOnBulletinBoard(extendElectionMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		if election.Phase > types.PhaseOpen {
+			err = fmt.Errorf("election %s is %s, it can't be extended", election.Base.ElectionID, election.Phase)
+			return
+		}
+
+		// a replayed extension doesn't bring the expiration forward
+		if !extendElectionMessage.Expiration.After(election.Base.Expiration) {
+			return
+		}
+
+		log.Info().Str("peerAddr", n.myAddr).Msgf("election %s extended until %s",
+			election.Base.ElectionID, extendElectionMessage.Expiration)
+
+		election.Base.Expiration = extendElectionMessage.Expiration
+	})
+
+	return err
+}
+
+// waitForExpiration blocks until the election expires, following its
+// extensions. It returns false if the election is aborted meanwhile, or if the
+// peer stops.
+func (n *node) waitForExpiration(election *types.Election) bool {
+	phases, unsubscribe, err := n.SubscribePhase(election.Base.ElectionID)
+	if err != nil {
+		return false
+	}
+	defer unsubscribe()
+
+	expireIn := n.expiresIn(election.Base.ElectionID)
+
+	expired := time.NewTimer(expireIn)
+	defer expired.Stop()
+
+	for {
+		select {
+		case phase, ok := <-phases:
+			if !ok || phase == types.PhaseAborted {
+				return false
+			}
+
+		case <-expired.C:
+			expireIn = n.expiresIn(election.Base.ElectionID)
+
+			if expireIn <= 0 {
+				return true
+			}
+			expired.Reset(expireIn)
+		}
+	}
+}
+
+// expiresIn returns the time left until the election expires
+func (n *node) expiresIn(electionID string) time.Duration {
+	var expireIn time.Duration
+	n.electionStore.View(electionID, func(election *types.Election) {
+		expireIn = time.Until(election.Base.Expiration)
+	})
+	return expireIn
+}
+
+// VerifyCancellation checks that the cancellation of the election is signed by
+// its announcer
+func VerifyCancellation(election *types.Election, cancellation *types.CancelElectionMessage) error {
+	if cancellation.ElectionID != election.Base.ElectionID {
+		return errors.New("the cancellation is for another election")
+	}
+
+	return transport.VerifyDigest(election.Base.AnnouncerKey, cancelElectionDigest(cancellation), cancellation.Signature)
+}
+
+func cancelElectionDigest(cancellation *types.CancelElectionMessage) []byte {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("cancel|%s|%s", cancellation.ElectionID, cancellation.Reason)))
+	return digest[:]
+}
+
+func extendElectionDigest(extension *types.ExtendElectionMessage) []byte {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("extend|%s|%d", extension.ElectionID, extension.Expiration.UnixNano())))
+	return digest[:]
+}
@@ -0,0 +1,116 @@
+package impl
+
+import (
+	"math/big"
+
+	"go.dedis.ch/cs438/types"
+)
+
+// makeApprovalBallot encrypts the selection: the ballot holds one ciphertext
+// per election choice, which encrypts 1 if the choice is selected and 0
+// otherwise. Each ciphertext comes with a proof that it encrypts either 0 or
+// 1, and the ballot with a proof that the ciphertexts add up to between 1 and
+// MaxSelections. It also returns the randomness of each ciphertext.
+func makeApprovalBallot(election *types.Election, publicKey types.Point, selection []int) (types.VoteMessage, []big.Int, error) {
+	g, err := electionGroup(election)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
+	}
+	choices := election.Base.Choices
+
+	encryptedVotes := make([]types.ElGamalCipherText, len(choices))
+	correctVoteProofs := make([]types.Proof, len(choices))
+	rScalars := make([]big.Int, len(choices))
+	rSum := new(big.Int)
+
+	for i, choice := range choices {
+		bit := contains(selection, choice.ChoiceID)
+		plaintext := big.NewInt(0)
+		if bit {
+			plaintext = big.NewInt(1)
+		}
+
+		rScalars[i] = GenerateRandomBigInt(g.Order())
+		rSum.Add(rSum, &rScalars[i])
+
+		encryptedVote, err := ElGamalEncryption(g, &publicKey, &rScalars[i], plaintext)
+		if err != nil {
+			return types.VoteMessage{}, nil, err
+		}
+		encryptedVotes[i] = *encryptedVote
+
+		proof, err := ProveEncryptedBit(&rScalars[i], publicKey, *encryptedVote, bit, g)
+		if err != nil {
+			return types.VoteMessage{}, nil, err
+		}
+		correctVoteProofs[i] = *proof
+	}
+
+	rSum.Mod(rSum, g.Order())
+	encryptedSum, err := ElGamalAddCipherTexts(g, encryptedVotes)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
+	}
+
+	selectionsProof, err := ProveEncryptedRange(rSum, publicKey, encryptedSum, len(selection), 1,
+		election.Base.MaxSelections, g)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
+	}
+
+	return types.VoteMessage{
+		ElectionID:        election.Base.ElectionID,
+		EncryptedVotes:    encryptedVotes,
+		CorrectVoteProofs: correctVoteProofs,
+		SelectionsProof:   *selectionsProof,
+	}, rScalars, nil
+}
+
+// VerifyApprovalBallot checks that the ballot holds one ciphertext per election
+// choice, that each of them encrypts either 0 or 1 and that they add up to
+// between 1 and MaxSelections.
+func VerifyApprovalBallot(election *types.Election, publicKey types.Point, vote *types.VoteMessage) bool {
+	g, err := electionGroup(election)
+	if err != nil {
+		return false
+	}
+	choiceCnt := len(election.Base.Choices)
+
+	if len(vote.EncryptedVotes) != choiceCnt || len(vote.CorrectVoteProofs) != choiceCnt {
+		return false
+	}
+
+	for i, encryptedVote := range vote.EncryptedVotes {
+		if !VerifyEncryptedBit(&vote.CorrectVoteProofs[i], g, publicKey, encryptedVote) {
+			return false
+		}
+	}
+
+	encryptedSum, err := ElGamalAddCipherTexts(g, vote.EncryptedVotes)
+	if err != nil {
+		return false
+	}
+
+	return VerifyEncryptedRange(&vote.SelectionsProof, g, publicKey, encryptedSum, 1, election.Base.MaxSelections)
+}
+
+// isSelection checks that the selection holds between 1 and MaxSelections
+// distinct choice IDs of the election
+func isSelection(election *types.Election, selection []int) bool {
+	if len(selection) < 1 || len(selection) > election.Base.MaxSelections {
+		return false
+	}
+
+	choiceIDs := election.GetChoiceIDs()
+	seen := make(map[int]struct{}, len(selection))
+
+	for _, choiceID := range selection {
+		_, duplicate := seen[choiceID]
+		if duplicate || !contains(choiceIDs, choiceID) {
+			return false
+		}
+		seen[choiceID] = struct{}{}
+	}
+
+	return true
+}
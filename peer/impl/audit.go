@@ -0,0 +1,185 @@
+package impl
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// PrepareBallot implements peer.Voting
+func (n *node) PrepareBallot(ctx context.Context, electionID string, selections [][]int) (types.PreparedBallot, error) {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return types.PreparedBallot{}, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	maker, err := n.selectionsBallotMaker(election, selections)
+	if err != nil {
+		return types.PreparedBallot{}, err
+	}
+
+	prepared, err := n.prepareBallot(ctx, election, maker)
+	if err != nil {
+		return types.PreparedBallot{}, err
+	}
+
+	n.preparedBallots.Store(electionID, prepared)
+
+	return prepared.PreparedBallot, nil
+}
+
+// CastBallot implements peer.Voting
+func (n *node) CastBallot(ctx context.Context, electionID string) (types.BallotReceiptMessage, error) {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	prepared, ok := n.preparedBallots.LoadAndDelete(electionID)
+	if !ok {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("no prepared ballot for election %s", electionID)
+	}
+
+	return n.castPreparedBallot(ctx, election, prepared.(*preparedBallot))
+}
+
+// AuditBallot implements peer.Voting
+func (n *node) AuditBallot(electionID string) (types.BallotAudit, error) {
+	value, ok := n.preparedBallots.LoadAndDelete(electionID)
+	if !ok {
+		return types.BallotAudit{}, xerrors.Errorf("no prepared ballot for election %s", electionID)
+	}
+	prepared := value.(*preparedBallot)
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("audited ballot %x of election %s is discarded",
+		prepared.BallotHash, electionID)
+
+	return types.BallotAudit{
+		ElectionID: electionID,
+		Ballot:     prepared.Ballot,
+		Selections: prepared.maker.selections,
+		Randomness: prepared.randomness,
+	}, nil
+}
+
+// selectionsBallotMaker returns the maker of the ballot of the selections,
+// depending on the contests and the ballot type of the election
+func (n *node) selectionsBallotMaker(election *types.Election, selections [][]int) (ballotMaker, error) {
+	if len(election.Base.Contests) > 0 {
+		return n.contestsBallotMaker(election, selections)
+	}
+
+	if len(selections) != 1 {
+		return ballotMaker{}, xerrors.Errorf("%d selections for the single contest of election %s",
+			len(selections), election.Base.ElectionID)
+	}
+
+	switch election.Base.BallotType {
+	case types.BallotRanked:
+		return rankedBallotMaker(election, selections[0])
+	case types.BallotApproval:
+		return approvalBallotMaker(election, selections[0])
+	}
+
+	if len(selections[0]) != 1 {
+		return ballotMaker{}, xerrors.Errorf("%v is not a single choice of election %s",
+			selections[0], election.Base.ElectionID)
+	}
+
+	return n.singleBallotMaker(election, selections[0][0])
+}
+
+// VerifyBallotAudit checks that the audited ballot encrypts the selections of
+// the audit: each ciphertext must be the encryption, with its revealed
+// randomness, of the bit the selections give it.
+func VerifyBallotAudit(election *types.Election, audit *types.BallotAudit) error {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+	publicKey := election.GetPublicKey()
+
+	bits, err := ballotBits(election, audit.Selections)
+	if err != nil {
+		return err
+	}
+
+	cipherTexts := audit.Ballot.EncryptedVotes
+	if len(cipherTexts) != len(bits) || len(audit.Randomness) != len(bits) {
+		return xerrors.Errorf("the ballot has %d ciphertexts and %d randomness values, expected %d",
+			len(cipherTexts), len(audit.Randomness), len(bits))
+	}
+
+	for i, bit := range bits {
+		plaintext := big.NewInt(0)
+		if bit {
+			plaintext = big.NewInt(1)
+		}
+
+		ct, err := ElGamalEncryption(g, &publicKey, &audit.Randomness[i], plaintext)
+		if err != nil {
+			return err
+		}
+		if !equalCipherTexts(*ct, cipherTexts[i]) {
+			return xerrors.Errorf("ciphertext %d doesn't encrypt %d", i, plaintext)
+		}
+	}
+
+	return nil
+}
+
+// ballotBits returns the bit encrypted by each ciphertext of the ballot of the
+// selections, as made by makeBallot, makeRankedBallot, makeApprovalBallot or
+// makeContestsBallot
+func ballotBits(election *types.Election, selections [][]int) ([]bool, error) {
+	if len(election.Base.Contests) == 0 {
+		if len(selections) != 1 || !isContestSelection(election, selections[0]) {
+			return nil, xerrors.Errorf("%v is not a valid %s selection", selections, election.Base.BallotType)
+		}
+		return contestBits(election, selections[0]), nil
+	}
+
+	if len(selections) != len(election.Base.Contests) {
+		return nil, xerrors.Errorf("%d selections for %d contests", len(selections), len(election.Base.Contests))
+	}
+
+	var bits []bool
+	for i, selection := range selections {
+		contestElection := election.GetContestElection(i)
+		if !isContestSelection(contestElection, selection) {
+			return nil, xerrors.Errorf("%v is not a valid %s selection for contest %q",
+				selection, contestElection.Base.BallotType, election.Base.Contests[i].Title)
+		}
+		bits = append(bits, contestBits(contestElection, selection)...)
+	}
+
+	return bits, nil
+}
+
+// contestBits returns the bits of the ballot of a valid selection of a single
+// contest
+func contestBits(contestElection *types.Election, selection []int) []bool {
+	choices := contestElection.Base.Choices
+
+	// a single choice and an approval selection give one bit per choice, a
+	// ranking one bit per rank and choice
+	lines := [][]int{selection}
+	if contestElection.Base.BallotType == types.BallotRanked {
+		lines = make([][]int, len(selection))
+		for rank, choiceID := range selection {
+			lines[rank] = []int{choiceID}
+		}
+	}
+
+	bits := make([]bool, 0, len(lines)*len(choices))
+	for _, line := range lines {
+		for _, choice := range choices {
+			bits = append(bits, contains(line, choice.ChoiceID))
+		}
+	}
+
+	return bits
+}
@@ -0,0 +1,181 @@
+package impl
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// boardWaitTimeout is how long a message waits for the local bulletin board
+// to hold what it refers to, like the ballots the first hop of the mixing
+// mixed: the local bulletin board may lag behind the messages which refer to
+// it
+const boardWaitTimeout = time.Second * 5
+
+// boardWaiter is a message waiting for the bulletin board of its election
+// (see waitForBulletinBoard)
+type boardWaiter struct {
+	sync.Mutex
+
+	check   func() error
+	ready   func()
+	expired func(error)
+
+	// running is set while check runs, and dirty if the board changed
+	// meanwhile, so that a single goroutine checks the message at a time.
+	// expiring is set if the message expires while check runs: the running
+	// check then decides.
+	running  bool
+	dirty    bool
+	expiring bool
+	done     bool
+	lastErr  error
+}
+
+// waitForBulletinBoard processes a message once the local bulletin board
+// holds what it refers to. check runs right away, and again each time an
+// entry is appended to the bulletin board of the election (see
+// notifyBoardWaiters), so that no handler blocks meanwhile. ready runs once
+// check succeeds, or expired runs with the last error of check after
+// boardWaitTimeout. A check which is running at the timeout completes first.
+// The name of the message is only used to log the errors.
+func (n *node) waitForBulletinBoard(electionID string, name string, check func() error,
+	ready func() error, expired func(error) error) {
+
+	waiter := &boardWaiter{
+		check: check,
+		ready: func() {
+			err := ready()
+			if err != nil {
+				log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to process %s", name)
+			}
+		},
+		expired: func(checkErr error) {
+			err := expired(checkErr)
+			if err != nil {
+				log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to process %s", name)
+			}
+		},
+	}
+
+	n.boardWaitersMutex.Lock()
+	n.boardWaiters[electionID] = append(n.boardWaiters[electionID], waiter)
+	n.boardWaitersMutex.Unlock()
+
+	time.AfterFunc(boardWaitTimeout, func() {
+		waiter.expire()
+		n.notifyBoardWaiters(electionID)
+	})
+
+	waiter.poke()
+}
+
+// notifyBoardWaiters checks again the messages waiting for the bulletin board
+// of the election, and forgets the ones which are processed
+func (n *node) notifyBoardWaiters(electionID string) {
+	n.boardWaitersMutex.Lock()
+
+	var waiters []*boardWaiter
+	for _, waiter := range n.boardWaiters[electionID] {
+		if !waiter.isDone() {
+			waiters = append(waiters, waiter)
+		}
+	}
+
+	if len(waiters) == 0 {
+		delete(n.boardWaiters, electionID)
+	} else {
+		n.boardWaiters[electionID] = waiters
+	}
+
+	n.boardWaitersMutex.Unlock()
+
+	for _, waiter := range waiters {
+		go waiter.poke()
+	}
+}
+
+// poke runs check, and ready if it succeeds. If check is already running,
+// the running goroutine checks once more instead.
+func (w *boardWaiter) poke() {
+	w.Lock()
+	if w.done {
+		w.Unlock()
+		return
+	}
+	if w.running {
+		w.dirty = true
+		w.Unlock()
+		return
+	}
+	w.running = true
+
+	for {
+		w.dirty = false
+		w.Unlock()
+
+		err := w.check()
+
+		w.Lock()
+		w.lastErr = err
+
+		if w.done {
+			w.running = false
+			w.Unlock()
+			return
+		}
+
+		if err == nil {
+			w.done = true
+			w.running = false
+			w.Unlock()
+
+			w.ready()
+			return
+		}
+
+		if w.expiring {
+			w.done = true
+			w.running = false
+			w.Unlock()
+
+			w.expired(err)
+			return
+		}
+
+		if !w.dirty {
+			w.running = false
+			w.Unlock()
+			return
+		}
+	}
+}
+
+// expire runs expired with the last error of check, unless the message is
+// processed already. If check is running, it runs expired once it fails.
+func (w *boardWaiter) expire() {
+	w.Lock()
+	if w.done {
+		w.Unlock()
+		return
+	}
+	if w.running {
+		w.expiring = true
+		w.Unlock()
+		return
+	}
+	w.done = true
+	lastErr := w.lastErr
+	w.Unlock()
+
+	w.expired(lastErr)
+}
+
+// isDone tells if the message is processed, or expired
+func (w *boardWaiter) isDone() bool {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.done
+}
@@ -0,0 +1,833 @@
+package impl
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/peer/impl/bulletinboard"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// GetBulletinBoard returns the bulletin board of the election, as seen by this
+// peer. It holds every artifact needed to verify the election offline (see
+// VerifyBulletinBoard).
+func (n *node) GetBulletinBoard(electionID string) types.BulletinBoard {
+	return n.bulletinBoard.Get(electionID)
+}
+
+// recordOnBulletinBoard appends a message which is already broadcast to every
+// peer to the local bulletin board. The message must be authenticated by the
+// caller. An entry which is already on the board is ignored, it is an error if
+// it conflicts with another one.
+func (n *node) recordOnBulletinBoard(electionID string, msg *transport.Message) error {
+	return n.appendToBulletinBoard(electionID, types.BulletinBoardEntry{
+		Type:    msg.Type,
+		Payload: msg.Payload,
+	})
+}
+
+// appendToBulletinBoard appends the entry to the local bulletin board, under
+// its key (see boardEntryKey), and checks again the messages which wait for
+// the board (see waitForBulletinBoard)
+func (n *node) appendToBulletinBoard(electionID string, entry types.BulletinBoardEntry) error {
+	key, err := boardEntryKey(entry)
+	if err != nil {
+		return err
+	}
+
+	err = n.bulletinBoard.Append(electionID, key, entry)
+	if errors.Is(err, bulletinboard.ErrDuplicate) {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("%s of election %s: %v", entry.Type, electionID, err)
+	}
+
+	n.notifyBoardWaiters(electionID)
+
+	return nil
+}
+
+// postOnBulletinBoard broadcasts a message which is otherwise only sent
+// privately, so that it lands on the bulletin board of every peer. The entry
+// is signed with the signing key of this peer.
+func (n *node) postOnBulletinBoard(electionID string, msg types.Message) error {
+	transportMsg, err := marshalMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	bulletinBoardMessage := types.BulletinBoardMessage{
+		ElectionID: electionID,
+		Entry: types.BulletinBoardEntry{
+			Type:    transportMsg.Type,
+			Payload: transportMsg.Payload,
+			Sender:  n.myAddr,
+		},
+	}
+
+	err = SignBulletinBoardEntry(n.signingKey, electionID, &bulletinBoardMessage.Entry)
+	if err != nil {
+		return err
+	}
+
+	boardMsg, err := marshalMessage(&bulletinBoardMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(boardMsg)
+}
+
+// boardEntryDigest returns what the sender of a posted entry signs
+func boardEntryDigest(electionID string, entry *types.BulletinBoardEntry) []byte {
+	return transport.Digest([]byte(electionID), []byte(entry.Sender), []byte(entry.Type), entry.Payload)
+}
+
+// SignBulletinBoardEntry signs an entry posted by its sender with the
+// long-term signing key of the sender
+func SignBulletinBoardEntry(signingKey *ecdsa.PrivateKey, electionID string, entry *types.BulletinBoardEntry) error {
+	signature, err := transport.SignDigest(signingKey, boardEntryDigest(electionID, entry))
+	if err != nil {
+		return xerrors.Errorf("failed to sign bulletin board entry: %v", err)
+	}
+
+	entry.Signature = signature
+
+	return nil
+}
+
+// verifyPostedEntry checks that a posted entry is signed by its sender, a
+// mixnet server of the election with the key pinned in the announcement. Only
+// the mixnet servers post entries: the DKG commitments, the ballots, the
+// registrations of a re-voting election and the mix batches.
+func verifyPostedEntry(base *types.ElectionBase, entry *types.BulletinBoardEntry) error {
+	mixnetServerID := -1
+	for i, mixnetServer := range base.MixnetServers {
+		if mixnetServer == entry.Sender {
+			mixnetServerID = i
+		}
+	}
+	if mixnetServerID < 0 || mixnetServerID >= len(base.MixnetServerKeys) || base.MixnetServerKeys[mixnetServerID] == nil {
+		return xerrors.Errorf("%s posted by %q, which is not a mixnet server", entry.Type, entry.Sender)
+	}
+
+	switch entry.Type {
+	case types.DKGCommitmentMessage{}.Name():
+		commitment := types.DKGCommitmentMessage{}
+		err := json.Unmarshal(entry.Payload, &commitment)
+		if err != nil {
+			return err
+		}
+		if commitment.MixnetServerID != mixnetServerID {
+			return xerrors.Errorf("DKG commitments of mixnet server %d posted by mixnet server %d",
+				commitment.MixnetServerID, mixnetServerID)
+		}
+	case types.VoteMessage{}.Name(), types.MixMessage{}.Name(), types.RegisterCredentialMessage{}.Name():
+	default:
+		return xerrors.Errorf("%s can't be posted", entry.Type)
+	}
+
+	err := transport.VerifyDigest(base.MixnetServerKeys[mixnetServerID], boardEntryDigest(base.ElectionID, entry),
+		entry.Signature)
+	if err != nil {
+		return xerrors.Errorf("%s not signed by %s: %v", entry.Type, entry.Sender, err)
+	}
+
+	return nil
+}
+
+// boardEntryKey returns the key of an entry on the bulletin board. The
+// entries an election has at most one of, like the announcement or the DKG
+// commitments of a mixnet server, have a key. The others have none.
+func boardEntryKey(entry types.BulletinBoardEntry) (string, error) {
+	var key string
+	var err error
+
+	switch entry.Type {
+	case types.AnnounceElectionMessage{}.Name():
+		key = "announce"
+	case types.CancelElectionMessage{}.Name():
+		key = "cancel"
+	case types.DecryptionRequestMessage{}.Name():
+		key = "decryption-request"
+	case types.ResultMessage{}.Name():
+		key = "result"
+	case types.DKGCommitmentMessage{}.Name():
+		commitment := types.DKGCommitmentMessage{}
+		err = json.Unmarshal(entry.Payload, &commitment)
+		key = fmt.Sprintf("dkg-commitment/%d", commitment.MixnetServerID)
+	case types.ElectionReadyMessage{}.Name():
+		electionReady := types.ElectionReadyMessage{}
+		err = json.Unmarshal(entry.Payload, &electionReady)
+		key = fmt.Sprintf("ready/%d", electionReady.MixnetServerID)
+	case types.StartElectionMessage{}.Name():
+		startElection := types.StartElectionMessage{}
+		err = json.Unmarshal(entry.Payload, &startElection)
+		key = fmt.Sprintf("start/%s", startElection.Initiator)
+	case types.RegisterCredentialMessage{}.Name():
+		registration := types.RegisterCredentialMessage{}
+		err = json.Unmarshal(entry.Payload, &registration)
+		key = fmt.Sprintf("registration/%x", registration.VoterKey)
+	case types.PETRequestMessage{}.Name():
+		petRequest := types.PETRequestMessage{}
+		err = json.Unmarshal(entry.Payload, &petRequest)
+		key = fmt.Sprintf("pet-request/%d", petRequest.Step)
+	case types.PETShareMessage{}.Name():
+		petShare := types.PETShareMessage{}
+		err = json.Unmarshal(entry.Payload, &petShare)
+		key = fmt.Sprintf("pet-share/%d/%d", petShare.Step, petShare.MixnetServerID)
+	}
+
+	if err != nil {
+		return "", xerrors.Errorf("failed to decode %s: %v", entry.Type, err)
+	}
+
+	return key, nil
+}
+
+// HandleBulletinBoardMessage processes types.BulletinBoardMessage. The entry
+// is appended to the local bulletin board if it is signed by the mixnet server
+// which posted it.
+func (n *node) HandleBulletinBoardMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling BulletinBoardMessage from %v", pkt.Header.Source)
+	bulletinBoardMessage := types.BulletinBoardMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &bulletinBoardMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(bulletinBoardMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(bulletinBoardMessage.ElectionID, pkt)
+	}
+
+	err = verifyPostedEntry(&election.Base, &bulletinBoardMessage.Entry)
+	if err != nil {
+		return xerrors.Errorf("election %s: %v", bulletinBoardMessage.ElectionID, err)
+	}
+
+	return n.appendToBulletinBoard(bulletinBoardMessage.ElectionID, bulletinBoardMessage.Entry)
+}
+
+// getPublicShareFromBoard computes the public key share of a qualified mixnet
+// server from the DKG commitments on the bulletin board. Unlike
+// GetPublicShare, it doesn't need the DKG state of a mixnet server.
+func (n *node) getPublicShareFromBoard(election *types.Election, mixnetServerID int) (types.Point, error) {
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(election.Base.ElectionID))
+	if err != nil {
+		return types.Point{}, err
+	}
+
+	commitments, err := qualifiedCommitments(n.electionStore.Get(election.Base.ElectionID), content)
+	if err != nil {
+		return types.Point{}, err
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return types.Point{}, err
+	}
+
+	return ComputePublicShare(g, commitments, mixnetServerID)
+}
+
+// boardContent holds the decoded artifacts of a bulletin board
+type boardContent struct {
+	announcement        *types.AnnounceElectionMessage
+	commitments         map[int][]types.Point
+	electionReadys      []types.ElectionReadyMessage
+	startElections      []types.StartElectionMessage
+	ballots             []types.VoteMessage
+	ballotPosters       []string
+	registrations       []types.RegisterCredentialMessage
+	registrationPosters []string
+	mixMessages         []types.MixMessage
+	complaints          []types.MixComplaintMessage
+	cancellation        *types.CancelElectionMessage
+	extensions          []types.ExtendElectionMessage
+	petRequests         []types.PETRequestMessage
+	petShares           []types.PETShareMessage
+	decryptionRequest   *types.DecryptionRequestMessage
+	decryptShares       []types.DecryptShareMessage
+	result              *types.ResultMessage
+}
+
+// VerifyBulletinBoard re-checks offline every proof of an exported bulletin
+// board: the public key against the DKG commitments of the qualified mixnet
+// servers, the ballot proofs, the shuffle and re-encryption proofs of the mix
+// batches (if any), the decryption shares, and finally the results. The board
+// of an election cancelled by its announcer doesn't verify. If the quorum is
+// not reached, only the outcome signed by the first mixnet server is checked
+// after the ballots.
+func VerifyBulletinBoard(board types.BulletinBoard) error {
+	content, err := decodeBulletinBoard(board)
+	if err != nil {
+		return err
+	}
+
+	// Election setup, as agreed on by the mixnet servers
+	election := &types.Election{Base: content.announcement.Base}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	if content.cancellation != nil && VerifyCancellation(election, content.cancellation) == nil {
+		return xerrors.Errorf("the announcer cancelled the election: %s", content.cancellation.Reason)
+	}
+	// only the signed messages of the mixnet servers count, once each
+	election.Base.MixnetServersPoints = make([]int, len(election.Base.MixnetServers))
+	for i := range content.electionReadys {
+		if VerifyElectionReady(election, &content.electionReadys[i]) == nil {
+			countElectionReady(election, &content.electionReadys[i])
+		}
+	}
+	election.Base.Initiators = make(map[string]types.Point)
+	for i, startElection := range content.startElections {
+		if VerifyStartElection(election, &content.startElections[i]) == nil {
+			election.Base.Initiators[startElection.Initiator] = startElection.PublicKey
+		}
+	}
+
+	// Key generation: the public key is the sum of the free coefficients of
+	// the qualified mixnet servers
+	commitments := make([][]types.Point, 0, len(content.commitments))
+	pk := g.Identity()
+	for id := range election.Base.MixnetServers {
+		if election.Base.MixnetServersPoints[id] < election.Base.Threshold {
+			continue
+		}
+		X, ok := content.commitments[id]
+		if !ok || len(X) != election.Base.Threshold {
+			return xerrors.Errorf("missing DKG commitments of qualified mixnet server %d", id)
+		}
+		X0, err := g.ElementFromPoint(X[0])
+		if err != nil {
+			return xerrors.Errorf("invalid DKG commitments of qualified mixnet server %d: %v", id, err)
+		}
+		commitments = append(commitments, X)
+		pk = pk.Add(X0)
+	}
+
+	if !equalPoints(election.GetPublicKey(), pk.Point()) {
+		return errors.New("the election public key doesn't match the DKG commitments")
+	}
+
+	// Complaints: ejected mixnet servers are skipped by the next hops
+	election.EjectedMixnetServers = make(map[int]struct{})
+	for _, complaint := range content.complaints {
+		publicShare, err := ComputePublicShare(g, commitments, complaint.MixnetServerID)
+		if err == nil && VerifyMixComplaint(g, &complaint, &publicShare) {
+			election.EjectedMixnetServers[complaint.AccusedID] = struct{}{}
+		}
+	}
+
+	// Ballots, then mixing (see checkDecryptionRequest)
+	countedBallots, err := countBallots(election, content)
+	if err != nil {
+		return err
+	}
+
+	// Quorum: with too few ballots, the first mixnet server publishes the
+	// outcome instead of mixing them
+	required := election.Base.Quorum.Required(len(election.Base.EligibleVoters))
+	if len(countedBallots) < required {
+		if content.result == nil || !content.result.QuorumNotReached {
+			return xerrors.Errorf("%d ballots don't reach the quorum of %d, but the election has results",
+				len(countedBallots), required)
+		}
+		if content.result.BallotCnt != len(countedBallots) {
+			return xerrors.Errorf("the quorum outcome counts %d ballots, %d are published",
+				content.result.BallotCnt, len(countedBallots))
+		}
+
+		initiatorID := election.GetMyMixnetServerID(election.GetFirstQualifiedInitiator())
+		if content.result.MixnetServerID != initiatorID {
+			return errors.New("the quorum outcome is not from the first mixnet server")
+		}
+		publicShare, err := ComputePublicShare(g, commitments, initiatorID)
+		if err != nil {
+			return err
+		}
+
+		return VerifyQuorumNotReached(election, content.result, &publicShare)
+	}
+	if content.result != nil && content.result.QuorumNotReached {
+		return xerrors.Errorf("%d ballots reach the quorum of %d", len(countedBallots), required)
+	}
+
+	// Tallying
+	request := content.decryptionRequest
+	if request == nil {
+		return errors.New("no decryption request on the bulletin board")
+	}
+	err = checkDecryptionRequest(election, commitments, content, countedBallots, request)
+	if err != nil {
+		return err
+	}
+
+	if content.result == nil {
+		return errors.New("no results on the bulletin board")
+	}
+
+	return checkResults(election, commitments, content, request, content.result)
+}
+
+// decodeBulletinBoard unmarshals the entries of the board. The posted entries
+// must be signed by the mixnet servers which posted them, with the keys pinned
+// in the announcement. An election has at most one entry of each key (see
+// boardEntryKey): copies are skipped, and conflicting entries are an error.
+func decodeBulletinBoard(board types.BulletinBoard) (*boardContent, error) {
+	content := &boardContent{
+		commitments: make(map[int][]types.Point),
+	}
+
+	// the announcement pins the keys of the mixnet servers
+	for i, entry := range board.Entries {
+		if entry.Type != (types.AnnounceElectionMessage{}).Name() {
+			continue
+		}
+
+		content.announcement = &types.AnnounceElectionMessage{}
+		err := json.Unmarshal(entry.Payload, content.announcement)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode entry %d of the bulletin board: %v", i, err)
+		}
+		break
+	}
+
+	if content.announcement == nil {
+		return nil, errors.New("no election announcement on the bulletin board")
+	}
+	if content.announcement.Base.ElectionID != board.ElectionID {
+		return nil, fmt.Errorf("the bulletin board of election %s holds the announcement of election %s",
+			board.ElectionID, content.announcement.Base.ElectionID)
+	}
+
+	keyed := make(map[string]types.BulletinBoardEntry)
+
+	for i, entry := range board.Entries {
+		key, err := boardEntryKey(entry)
+		if err != nil {
+			return nil, xerrors.Errorf("entry %d of the bulletin board: %v", i, err)
+		}
+		if known, ok := keyed[key]; ok && key != "" {
+			if known.Type == entry.Type && bytes.Equal(known.Payload, entry.Payload) {
+				continue
+			}
+			return nil, xerrors.Errorf("entry %d of the bulletin board conflicts with an earlier %s", i, key)
+		}
+		keyed[key] = entry
+
+		switch entry.Type {
+		case types.DKGCommitmentMessage{}.Name(), types.VoteMessage{}.Name(), types.MixMessage{}.Name(),
+			types.RegisterCredentialMessage{}.Name():
+			err = verifyPostedEntry(&content.announcement.Base, &entry)
+			if err != nil {
+				return nil, xerrors.Errorf("entry %d of the bulletin board: %v", i, err)
+			}
+		}
+
+		switch entry.Type {
+		case types.AnnounceElectionMessage{}.Name():
+		case types.DKGCommitmentMessage{}.Name():
+			commitment := types.DKGCommitmentMessage{}
+			err = json.Unmarshal(entry.Payload, &commitment)
+			content.commitments[commitment.MixnetServerID] = commitment.X
+		case types.ElectionReadyMessage{}.Name():
+			electionReady := types.ElectionReadyMessage{}
+			err = json.Unmarshal(entry.Payload, &electionReady)
+			content.electionReadys = append(content.electionReadys, electionReady)
+		case types.StartElectionMessage{}.Name():
+			startElection := types.StartElectionMessage{}
+			err = json.Unmarshal(entry.Payload, &startElection)
+			content.startElections = append(content.startElections, startElection)
+		case types.VoteMessage{}.Name():
+			ballot := types.VoteMessage{}
+			err = json.Unmarshal(entry.Payload, &ballot)
+			content.ballots = append(content.ballots, ballot)
+			content.ballotPosters = append(content.ballotPosters, entry.Sender)
+		case types.RegisterCredentialMessage{}.Name():
+			registration := types.RegisterCredentialMessage{}
+			err = json.Unmarshal(entry.Payload, &registration)
+			content.registrations = append(content.registrations, registration)
+			content.registrationPosters = append(content.registrationPosters, entry.Sender)
+		case types.MixMessage{}.Name():
+			mixMessage := types.MixMessage{}
+			err = json.Unmarshal(entry.Payload, &mixMessage)
+			content.mixMessages = append(content.mixMessages, mixMessage)
+		case types.MixComplaintMessage{}.Name():
+			complaint := types.MixComplaintMessage{}
+			err = json.Unmarshal(entry.Payload, &complaint)
+			content.complaints = append(content.complaints, complaint)
+		case types.CancelElectionMessage{}.Name():
+			content.cancellation = &types.CancelElectionMessage{}
+			err = json.Unmarshal(entry.Payload, content.cancellation)
+		case types.ExtendElectionMessage{}.Name():
+			extension := types.ExtendElectionMessage{}
+			err = json.Unmarshal(entry.Payload, &extension)
+			content.extensions = append(content.extensions, extension)
+		case types.PETRequestMessage{}.Name():
+			petRequest := types.PETRequestMessage{}
+			err = json.Unmarshal(entry.Payload, &petRequest)
+			content.petRequests = append(content.petRequests, petRequest)
+		case types.PETShareMessage{}.Name():
+			petShare := types.PETShareMessage{}
+			err = json.Unmarshal(entry.Payload, &petShare)
+			content.petShares = append(content.petShares, petShare)
+		case types.DecryptionRequestMessage{}.Name():
+			content.decryptionRequest = &types.DecryptionRequestMessage{}
+			err = json.Unmarshal(entry.Payload, content.decryptionRequest)
+		case types.DecryptShareMessage{}.Name():
+			decryptShare := types.DecryptShareMessage{}
+			err = json.Unmarshal(entry.Payload, &decryptShare)
+			content.decryptShares = append(content.decryptShares, decryptShare)
+		case types.ResultMessage{}.Name():
+			content.result = &types.ResultMessage{}
+			err = json.Unmarshal(entry.Payload, content.result)
+		default:
+			err = xerrors.Errorf("unknown entry type %q", entry.Type)
+		}
+
+		if err != nil {
+			return nil, xerrors.Errorf("failed to decode entry %d of the bulletin board: %v", i, err)
+		}
+	}
+
+	return content, nil
+}
+
+// countBallots returns the ballots the election counts, as posted on the
+// bulletin board by the first qualified mixnet server. Every ballot must be
+// valid. A voter has one ballot, chosen as by CountedBallots. In a re-voting
+// election, the equivalence tests on the board keep the last ballot of each
+// credential (see verifyDeduplication).
+func countBallots(election *types.Election, content *boardContent) ([]types.VoteMessage, error) {
+	if election.Base.Revoting {
+		ballots, err := credentialBallots(election, content)
+		if err != nil {
+			return nil, err
+		}
+		return verifyDeduplication(election, content, ballots)
+	}
+
+	publicKey := election.GetPublicKey()
+
+	for i := range content.ballots {
+		if content.ballotPosters[i] != election.GetFirstQualifiedInitiator() {
+			return nil, xerrors.Errorf("ballot %d is posted by %s, which doesn't store the ballots", i, content.ballotPosters[i])
+		}
+		if !VerifyBallot(election, publicKey, &content.ballots[i]) {
+			return nil, xerrors.Errorf("invalid proofs for ballot %d", i)
+		}
+	}
+
+	return CountedBallots(election, content.ballots), nil
+}
+
+// getCountedBallotsFromBoard returns the ballots the election counts,
+// recomputed from the local bulletin board (see countBallots)
+func (n *node) getCountedBallotsFromBoard(electionID string) ([]types.VoteMessage, error) {
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(electionID))
+	if err != nil {
+		return nil, err
+	}
+
+	return countBallots(n.electionStore.Get(electionID), content)
+}
+
+// talliedBallots returns the ballots the tally adds up and the ID of the
+// mixnet server which tallies them. In mixnet mode, they are the final mix
+// batch, signed by the last mix hop, with valid proofs, and which mixes the
+// counted ballots. In homomorphic mode, they are the counted ballots, tallied
+// by the first qualified mixnet server.
+func talliedBallots(election *types.Election, commitments [][]types.Point, content *boardContent,
+	countedBallots []types.VoteMessage) ([]types.VoteMessage, int, error) {
+
+	if election.Base.TallyMode != types.TallyMixnet {
+		return countedBallots, election.GetMyMixnetServerID(election.GetFirstQualifiedInitiator()), nil
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	finalMix, err := getFinalMix(content)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	if !election.IsMixHop(finalMix.MixnetServerID) {
+		return nil, -1, xerrors.Errorf("the final mix batch is mixed by mixnet server %d, which doesn't mix",
+			finalMix.MixnetServerID)
+	}
+	publicShare, err := ComputePublicShare(g, commitments, finalMix.MixnetServerID)
+	if err != nil {
+		return nil, -1, err
+	}
+	if !VerifyMixMessage(g, finalMix, &publicShare) {
+		return nil, -1, errors.New("the final mix batch is not signed by its mixnet server")
+	}
+
+	ballotSize := election.GetBallotSize()
+	_, ok := VerifyMixProofs(g, election.GetPublicKey(), ballotSize, finalMix)
+	if !ok {
+		return nil, -1, errors.New("invalid proofs in the final mix batch")
+	}
+
+	if !isFirstBatchOf(ballotSize, finalMix, countedBallots) {
+		return nil, -1, errors.New("the first mix batch is not made of the published ballots")
+	}
+
+	return finalMix.Votes, finalMix.MixnetServerID, nil
+}
+
+// checkDecryptionRequest checks that the decryption request is signed by the
+// mixnet server which tallies the ballots (see talliedBallots), and that it
+// asks to decrypt exactly their tally
+func checkDecryptionRequest(election *types.Election, commitments [][]types.Point, content *boardContent,
+	countedBallots []types.VoteMessage, request *types.DecryptionRequestMessage) error {
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	talliedVotes, tallierID, err := talliedBallots(election, commitments, content, countedBallots)
+	if err != nil {
+		return err
+	}
+
+	if request.MixnetServerID != tallierID {
+		return xerrors.Errorf("decryption request from mixnet server %d, mixnet server %d tallies the ballots",
+			request.MixnetServerID, tallierID)
+	}
+	publicShare, err := ComputePublicShare(g, commitments, tallierID)
+	if err != nil {
+		return err
+	}
+	if !VerifyDecryptionRequest(g, request, &publicShare) {
+		return xerrors.Errorf("decryption request not signed by mixnet server %d", tallierID)
+	}
+
+	cipherTexts, err := TallyCipherTexts(election, talliedVotes)
+	if err != nil {
+		return err
+	}
+	if request.VoteCnt != len(talliedVotes) || len(request.CipherTexts) != len(cipherTexts) {
+		return errors.New("the decryption request doesn't match the tallied ballots")
+	}
+	for j := range cipherTexts {
+		if !equalCipherTexts(request.CipherTexts[j], cipherTexts[j]) {
+			return xerrors.Errorf("the tally ciphertext %d doesn't match the ballots", j)
+		}
+	}
+
+	return nil
+}
+
+// checkDecryptionRequestOnBoard checks the decryption request against the
+// local bulletin board (see checkDecryptionRequest). The counted ballots must
+// reach the quorum.
+func (n *node) checkDecryptionRequestOnBoard(request *types.DecryptionRequestMessage) error {
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(request.ElectionID))
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(request.ElectionID)
+	commitments, err := qualifiedCommitments(election, content)
+	if err != nil {
+		return err
+	}
+
+	countedBallots, err := countBallots(election, content)
+	if err != nil {
+		return err
+	}
+
+	required := election.Base.Quorum.Required(len(election.Base.EligibleVoters))
+	if len(countedBallots) < required {
+		return xerrors.Errorf("%d ballots don't reach the quorum of %d", len(countedBallots), required)
+	}
+
+	return checkDecryptionRequest(election, commitments, content, countedBallots, request)
+}
+
+// checkResults checks the results against the tally of the decryption
+// request, decrypted with the valid decryption shares of Threshold qualified
+// mixnet servers
+func checkResults(election *types.Election, commitments [][]types.Point, content *boardContent,
+	request *types.DecryptionRequestMessage, result *types.ResultMessage) error {
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	cipherTexts := request.CipherTexts
+
+	decryptShares := make(map[int][]types.Point)
+	for _, share := range content.decryptShares {
+		id := share.MixnetServerID
+		if id < 0 || id >= len(election.Base.MixnetServers) || election.Base.MixnetServersPoints[id] < election.Base.Threshold {
+			continue
+		}
+		if len(share.DecryptShares) != len(cipherTexts) || len(share.DecryptProofs) != len(cipherTexts) {
+			continue
+		}
+
+		publicShare, err := ComputePublicShare(g, commitments, id)
+		isValid := err == nil
+		for j := range cipherTexts {
+			isValid = isValid && VerifyDecryptShare(g, &cipherTexts[j], &publicShare, &share.DecryptShares[j], &share.DecryptProofs[j])
+		}
+		if isValid {
+			decryptShares[id] = share.DecryptShares
+		}
+	}
+
+	if len(decryptShares) < election.Base.Threshold {
+		return xerrors.Errorf("only %d valid decryption shares, %d needed", len(decryptShares), election.Base.Threshold)
+	}
+
+	shareIDs := make([]int, 0, len(decryptShares))
+	for id := range decryptShares {
+		shareIDs = append(shareIDs, id)
+	}
+	sort.Ints(shareIDs)
+	shareIDs = shareIDs[:election.Base.Threshold]
+
+	plaintexts, err := RecoverPlaintexts(election, cipherTexts, shareIDs, decryptShares, request.VoteCnt)
+	if err != nil {
+		return err
+	}
+
+	expected, err := CountVotes(election, plaintexts, request.VoteCnt)
+	if err != nil {
+		return err
+	}
+
+	if !sameResults(&expected, result) {
+		return errors.New("the announced results don't match the decrypted ballots")
+	}
+
+	return nil
+}
+
+// checkResultsOnBoard checks the results against the decryption request and
+// the decryption shares of the local bulletin board (see checkResults). The
+// request is on the board once it is verified (see
+// HandleDecryptionRequestMessage).
+func (n *node) checkResultsOnBoard(result *types.ResultMessage) error {
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(result.ElectionID))
+	if err != nil {
+		return err
+	}
+
+	if content.decryptionRequest == nil {
+		return errors.New("no decryption request on the bulletin board")
+	}
+
+	election := n.electionStore.Get(result.ElectionID)
+	commitments, err := qualifiedCommitments(election, content)
+	if err != nil {
+		return err
+	}
+
+	return checkResults(election, commitments, content, content.decryptionRequest, result)
+}
+
+// qualifiedCommitments returns the DKG commitments of the qualified mixnet
+// servers on the bulletin board
+func qualifiedCommitments(election *types.Election, content *boardContent) ([][]types.Point, error) {
+	commitments := make([][]types.Point, 0, len(content.commitments))
+	for id := range election.Base.MixnetServers {
+		if election.Base.MixnetServersPoints[id] < election.Base.Threshold {
+			continue
+		}
+		X, ok := content.commitments[id]
+		if !ok {
+			return nil, xerrors.Errorf("missing DKG commitments of qualified mixnet server %d", id)
+		}
+		commitments = append(commitments, X)
+	}
+
+	return commitments, nil
+}
+
+// isFirstBatchOf checks that the input of the first hop of the mixing is
+// made of the ballots
+func isFirstBatchOf(ballotSize int, mixMessage *types.MixMessage, ballots []types.VoteMessage) bool {
+	if len(mixMessage.ShuffleProofs) == 0 {
+		return len(ballots) == 0
+	}
+
+	firstBatch, err := getLastValidBatch(ballotSize, mixMessage.ShuffleProofs, 0)
+	if err != nil {
+		return false
+	}
+
+	return isBatchOfBallots(firstBatch, ballots)
+}
+
+// getFinalMix returns the batch the last mixnet server handed over to the
+// tallying
+func getFinalMix(content *boardContent) (*types.MixMessage, error) {
+	for i := len(content.mixMessages) - 1; i >= 0; i-- {
+		if content.mixMessages[i].NextHop == -1 {
+			return &content.mixMessages[i], nil
+		}
+	}
+
+	return nil, errors.New("no final mix batch on the bulletin board")
+}
+
+// isBatchOfBallots checks that the batch holds exactly the ciphertexts of the
+// ballots, in any order
+func isBatchOfBallots(batch []types.VoteMessage, ballots []types.VoteMessage) bool {
+	if len(batch) != len(ballots) {
+		return false
+	}
+
+	used := make([]bool, len(ballots))
+	for _, vote := range batch {
+		found := false
+		for i, ballot := range ballots {
+			if !used[i] && equalBallotCipherTexts(vote, ballot) {
+				used[i] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func equalBallotCipherTexts(vote, other types.VoteMessage) bool {
+	if len(vote.EncryptedVotes) != len(other.EncryptedVotes) {
+		return false
+	}
+
+	for j := range vote.EncryptedVotes {
+		if !equalCipherTexts(vote.EncryptedVotes[j], other.EncryptedVotes[j]) {
+			return false
+		}
+	}
+
+	return true
+}
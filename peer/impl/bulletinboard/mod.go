@@ -0,0 +1,161 @@
+package bulletinboard
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/storage"
+	"go.dedis.ch/cs438/types"
+)
+
+var (
+	// ErrDuplicate is returned by Append if the board already holds the entry
+	// under its key. The board is unchanged.
+	ErrDuplicate = errors.New("the entry is already on the bulletin board")
+
+	// ErrConflict is returned by Append if the board holds another entry under
+	// its key. The board is unchanged.
+	ErrConflict = errors.New("the bulletin board holds a conflicting entry")
+)
+
+// BulletinBoardStore keeps one append-only bulletin board per election.
+type BulletinBoardStore interface {
+	// Append adds the entry at the end of the board of the election. The key
+	// identifies the entry among the ones of the same kind, such as the
+	// commitments of a mixnet server: the board holds at most one entry per
+	// key. An empty key is never checked.
+	Append(electionID string, key string, entry types.BulletinBoardEntry) error
+
+	// Get returns a copy of the board of the election, with no entries if
+	// nothing was posted yet
+	Get(electionID string) types.BulletinBoard
+}
+
+// New returns an in-memory bulletin board store.
+func New() BulletinBoardStore {
+	return &store{
+		data: make(map[string]*board),
+	}
+}
+
+// NewPersistent returns a bulletin board store which writes the boards
+// through to persistency, serialized as JSON. The boards already in
+// persistency are loaded, so that a peer keeps them after a restart.
+func NewPersistent(persistency storage.Store) BulletinBoardStore {
+	s := &store{
+		data:        make(map[string]*board),
+		persistency: persistency,
+	}
+
+	persistency.ForEach(func(key string, val []byte) bool {
+		b := &board{}
+		err := json.Unmarshal(val, &b.Entries)
+		if err != nil {
+			log.Err(err).Msgf("failed to load bulletin board %s", key)
+			return true
+		}
+
+		b.index()
+		s.data[key] = b
+		return true
+	})
+
+	return s
+}
+
+// store implements an in-memory bulletin board store, optionally backed by
+// persistency.
+type store struct {
+	sync.Mutex
+	data        map[string]*board
+	persistency storage.Store
+}
+
+// board holds the entries of an election with their keys
+type board struct {
+	Entries []keyedEntry
+	keys    map[string]int
+}
+
+// keyedEntry is an entry of the board and its key, as persisted
+type keyedEntry struct {
+	Key   string
+	Entry types.BulletinBoardEntry
+}
+
+// index maps the keys of the entries to their position
+func (b *board) index() {
+	b.keys = make(map[string]int)
+	for i, entry := range b.Entries {
+		if entry.Key != "" {
+			b.keys[entry.Key] = i
+		}
+	}
+}
+
+// Append implements BulletinBoardStore
+func (s *store) Append(electionID string, key string, entry types.BulletinBoardEntry) error {
+	s.Lock()
+	defer s.Unlock()
+
+	b, ok := s.data[electionID]
+	if !ok {
+		b = &board{keys: make(map[string]int)}
+		s.data[electionID] = b
+	}
+
+	if i, ok := b.keys[key]; ok && key != "" {
+		known := b.Entries[i].Entry
+		if known.Type == entry.Type && bytes.Equal(known.Payload, entry.Payload) {
+			return ErrDuplicate
+		}
+		return ErrConflict
+	}
+
+	if key != "" {
+		b.keys[key] = len(b.Entries)
+	}
+	b.Entries = append(b.Entries, keyedEntry{Key: key, Entry: entry})
+
+	s.persist(electionID, b)
+
+	return nil
+}
+
+// persist writes the board through to persistency. The caller must hold the
+// lock.
+func (s *store) persist(electionID string, b *board) {
+	if s.persistency == nil {
+		return
+	}
+
+	buf, err := json.Marshal(b.Entries)
+	if err != nil {
+		log.Err(err).Msgf("failed to persist bulletin board %s", electionID)
+		return
+	}
+
+	s.persistency.Set(electionID, buf)
+}
+
+// Get implements BulletinBoardStore
+func (s *store) Get(electionID string) types.BulletinBoard {
+	s.Lock()
+	defer s.Unlock()
+
+	entries := []types.BulletinBoardEntry{}
+	if b, ok := s.data[electionID]; ok {
+		entries = make([]types.BulletinBoardEntry, len(b.Entries))
+		for i, entry := range b.Entries {
+			entries[i] = entry.Entry
+		}
+	}
+
+	return types.BulletinBoard{
+		ElectionID: electionID,
+		Entries:    entries,
+	}
+}
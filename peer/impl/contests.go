@@ -0,0 +1,222 @@
+package impl
+
+import (
+	"context"
+	"math/big"
+
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// VoteContests implements peer.Voting
+func (n *node) VoteContests(ctx context.Context, electionID string, selections [][]int) (types.BallotReceiptMessage, error) {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	if len(election.Base.Contests) == 0 {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("election %s has a single contest", electionID)
+	}
+
+	maker, err := n.contestsBallotMaker(election, selections)
+	if err != nil {
+		return types.BallotReceiptMessage{}, err
+	}
+
+	return n.castBallot(ctx, election, maker)
+}
+
+// contestsBallotMaker checks the selection of each contest and returns the
+// maker of the multi-contest ballot
+func (n *node) contestsBallotMaker(election *types.Election, selections [][]int) (ballotMaker, error) {
+	if len(selections) != len(election.Base.Contests) {
+		return ballotMaker{}, xerrors.Errorf("%d selections for the %d contests of election %s",
+			len(selections), len(election.Base.Contests), election.Base.ElectionID)
+	}
+
+	for i, selection := range selections {
+		contestElection := election.GetContestElection(i)
+		if !isContestSelection(contestElection, selection) {
+			return ballotMaker{}, xerrors.Errorf("%v is not a valid %s selection for contest %q",
+				selection, contestElection.Base.BallotType, election.Base.Contests[i].Title)
+		}
+	}
+
+	return ballotMaker{
+		selections: selections,
+		record: func(election *types.Election) {
+			election.MyVote = selections[0][0]
+			election.MyContestSelections = selections
+		},
+		makeBallot: func(publicKey types.Point) (types.VoteMessage, []big.Int, error) {
+			return n.makeContestsBallot(election, publicKey, selections)
+		},
+	}, nil
+}
+
+// isContestSelection checks the selection of a contest against its ballot
+// type: a single choice ID, a ranking or an approval selection
+func isContestSelection(contestElection *types.Election, selection []int) bool {
+	switch contestElection.Base.BallotType {
+	case types.BallotSingle:
+		return len(selection) == 1 && contains(contestElection.GetChoiceIDs(), selection[0])
+	case types.BallotRanked:
+		return isRanking(contestElection.Base.Choices, selection)
+	case types.BallotApproval:
+		return isSelection(contestElection, selection)
+	default:
+		return false
+	}
+}
+
+// makeContestsBallot makes the ballot of each contest as for a single-contest
+// election, and joins them into one ballot. It also returns the randomness of
+// each ciphertext.
+func (n *node) makeContestsBallot(election *types.Election, publicKey types.Point, selections [][]int) (types.VoteMessage, []big.Int, error) {
+	ballot := types.VoteMessage{
+		ElectionID:    election.Base.ElectionID,
+		ContestProofs: make([]types.ContestProofs, len(election.Base.Contests)),
+	}
+	var rScalars []big.Int
+
+	for i, selection := range selections {
+		contestElection := election.GetContestElection(i)
+
+		var contestBallot types.VoteMessage
+		var contestScalars []big.Int
+		var err error
+
+		switch contestElection.Base.BallotType {
+		case types.BallotRanked:
+			contestBallot, contestScalars, err = makeRankedBallot(contestElection, publicKey, selection)
+		case types.BallotApproval:
+			contestBallot, contestScalars, err = makeApprovalBallot(contestElection, publicKey, selection)
+		default:
+			contestBallot, contestScalars, err = n.makeBallot(contestElection, publicKey, selection[0])
+		}
+		if err != nil {
+			return types.VoteMessage{}, nil, err
+		}
+
+		rScalars = append(rScalars, contestScalars...)
+		ballot.EncryptedVotes = append(ballot.EncryptedVotes, contestBallot.EncryptedVotes...)
+		ballot.CorrectVoteProofs = append(ballot.CorrectVoteProofs, contestBallot.CorrectVoteProofs...)
+		ballot.ContestProofs[i] = types.ContestProofs{
+			SumProof:        contestBallot.SumProof,
+			RankProofs:      contestBallot.RankProofs,
+			SelectionsProof: contestBallot.SelectionsProof,
+		}
+	}
+
+	return ballot, rScalars, nil
+}
+
+// VerifyContestsBallot checks the ballot of each contest of a multi-contest
+// ballot (see VerifyBallot)
+func VerifyContestsBallot(election *types.Election, publicKey types.Point, vote *types.VoteMessage) bool {
+	if len(vote.CorrectVoteProofs) != len(vote.EncryptedVotes) ||
+		len(vote.ContestProofs) != len(election.Base.Contests) {
+		return false
+	}
+
+	contestBallots, ok := splitContests(election, vote)
+	if !ok {
+		return false
+	}
+
+	for i := range contestBallots {
+		if !VerifyBallot(election.GetContestElection(i), publicKey, &contestBallots[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitContests splits a multi-contest ballot into the ballots of its
+// contests. The proofs are only copied if the ballot carries them, mixed
+// ballots only carry ciphertexts.
+func splitContests(election *types.Election, vote *types.VoteMessage) ([]types.VoteMessage, bool) {
+	if len(vote.EncryptedVotes) != election.GetBallotSize() {
+		return nil, false
+	}
+
+	hasProofs := len(vote.CorrectVoteProofs) == len(vote.EncryptedVotes)
+	hasContestProofs := len(vote.ContestProofs) == len(election.Base.Contests)
+
+	contestBallots := make([]types.VoteMessage, len(election.Base.Contests))
+	offset := 0
+
+	for i := range election.Base.Contests {
+		ballotSize := election.GetContestElection(i).GetBallotSize()
+
+		contestBallots[i] = types.VoteMessage{
+			ElectionID:     vote.ElectionID,
+			EncryptedVotes: vote.EncryptedVotes[offset : offset+ballotSize],
+		}
+		if hasProofs {
+			contestBallots[i].CorrectVoteProofs = vote.CorrectVoteProofs[offset : offset+ballotSize]
+		}
+		if hasContestProofs {
+			contestBallots[i].SumProof = vote.ContestProofs[i].SumProof
+			contestBallots[i].RankProofs = vote.ContestProofs[i].RankProofs
+			contestBallots[i].SelectionsProof = vote.ContestProofs[i].SelectionsProof
+		}
+
+		offset += ballotSize
+	}
+
+	return contestBallots, true
+}
+
+// contestVotes returns the ballots of the i-th contest of the multi-contest
+// ballots
+func contestVotes(election *types.Election, votes []types.VoteMessage, i int) []types.VoteMessage {
+	contestBallots := make([]types.VoteMessage, 0, len(votes))
+	for j := range votes {
+		split, ok := splitContests(election, &votes[j])
+		if ok {
+			contestBallots = append(contestBallots, split[i])
+		}
+	}
+	return contestBallots
+}
+
+// tallySize returns the number of tally ciphertexts of voteCnt ballots (see
+// TallyCipherTexts)
+func tallySize(election *types.Election, voteCnt int) int {
+	if len(election.Base.Contests) > 0 {
+		size := 0
+		for i := range election.Base.Contests {
+			size += tallySize(election.GetContestElection(i), voteCnt)
+		}
+		return size
+	}
+
+	if election.Base.BallotType == types.BallotRanked {
+		return voteCnt * election.GetBallotSize()
+	}
+	return len(election.Base.Choices)
+}
+
+// resultsOfContests joins the results of the contests into the results of a
+// multi-contest election
+func resultsOfContests(election *types.Election, contestResults []types.ResultMessage) types.ResultMessage {
+	resultMessage := types.ResultMessage{
+		ElectionID: election.Base.ElectionID,
+		Contests:   make([]types.ContestResult, len(contestResults)),
+	}
+
+	for i, result := range contestResults {
+		resultMessage.Contests[i] = types.ContestResult{
+			ContestID:       election.Base.Contests[i].ContestID,
+			Results:         result.Results,
+			Rankings:        result.Rankings,
+			Rounds:          result.Rounds,
+			RejectedBallots: result.RejectedBallots,
+		}
+	}
+
+	return resultMessage
+}
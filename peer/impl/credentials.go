@@ -0,0 +1,359 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/storage"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// credentialKeyBits is the size of the RSA key which signs the credentials
+	credentialKeyBits = 2048
+	// credentialLabel separates the credential hashes from other hashes
+	credentialLabel = "peervote credential"
+)
+
+// credentialKeyName is the name of the announcer's credential key of the
+// election in the key store
+func credentialKeyName(electionID string) string {
+	return "credential-" + electionID
+}
+
+// pseudonymKeyName is the name of the voter's pseudonymous key of the election
+// in the key store
+func pseudonymKeyName(electionID string) string {
+	return "pseudonym-" + electionID
+}
+
+// blindingFactorName is the name of the voter's blinding factor of the
+// election in the key store
+func blindingFactorName(electionID string) string {
+	return "blinding-" + electionID
+}
+
+// createCredentialKey generates the RSA key which signs the credentials of the
+// election, stores it and returns its public key
+func createCredentialKey(store storage.Store, electionID string) ([]byte, error) {
+	credentialKey, err := rsa.GenerateKey(rand.Reader, credentialKeyBits)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to generate credential key: %v", err)
+	}
+
+	store.Set(credentialKeyName(electionID), x509.MarshalPKCS1PrivateKey(credentialKey))
+
+	return x509.MarshalPKCS1PublicKey(&credentialKey.PublicKey), nil
+}
+
+// loadCredentialKey returns the RSA key which signs the credentials of the
+// election, if this peer announced it
+func loadCredentialKey(store storage.Store, electionID string) (*rsa.PrivateKey, error) {
+	stored := store.Get(credentialKeyName(electionID))
+	if stored == nil {
+		return nil, xerrors.Errorf("no credential key for election %s", electionID)
+	}
+
+	return x509.ParsePKCS1PrivateKey(stored)
+}
+
+// loadOrCreateBlindingFactor returns the factor which blinds the credential
+// request of this peer. It is uniform among the invertible integers modulo N,
+// and kept so that the same request is resent after a restart.
+func loadOrCreateBlindingFactor(store storage.Store, electionID string, modulus *big.Int) (*big.Int, error) {
+	stored := store.Get(blindingFactorName(electionID))
+	if stored != nil {
+		return new(big.Int).SetBytes(stored), nil
+	}
+
+	for {
+		r, err := rand.Int(rand.Reader, modulus)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to generate blinding factor: %v", err)
+		}
+
+		if r.Sign() > 0 && new(big.Int).GCD(nil, nil, r, modulus).Cmp(big.NewInt(1)) == 0 {
+			store.Set(blindingFactorName(electionID), r.Bytes())
+			return r, nil
+		}
+	}
+}
+
+// CredentialHash hashes the pseudonymous key to an integer modulo N, the full
+// domain of the RSA signature
+func CredentialHash(electionID string, pseudonymKey []byte, modulus *big.Int) *big.Int {
+	size := (modulus.BitLen() + 7) / 8
+	expanded := make([]byte, 0, size+sha256.Size)
+
+	for counter := uint32(0); len(expanded) < size; counter++ {
+		var counterBytes [4]byte
+		binary.BigEndian.PutUint32(counterBytes[:], counter)
+
+		h := sha256.New()
+		h.Write([]byte(credentialLabel))
+		h.Write(counterBytes[:])
+		h.Write(transport.Digest([]byte(electionID), pseudonymKey))
+		expanded = h.Sum(expanded)
+	}
+
+	return new(big.Int).Mod(new(big.Int).SetBytes(expanded[:size]), modulus)
+}
+
+// VerifyCredential checks that the credential is the signature of the
+// announcer of the election on the pseudonymous key
+func VerifyCredential(election *types.Election, pseudonymKey, credential []byte) bool {
+	credentialKey, err := x509.ParsePKCS1PublicKey(election.Base.CredentialKey)
+	if err != nil {
+		return false
+	}
+
+	signature := new(big.Int).SetBytes(credential)
+	if signature.Cmp(credentialKey.N) >= 0 {
+		return false
+	}
+
+	e := big.NewInt(int64(credentialKey.E))
+	hash := CredentialHash(election.Base.ElectionID, pseudonymKey, credentialKey.N)
+
+	return new(big.Int).Exp(signature, e, credentialKey.N).Cmp(hash) == 0
+}
+
+// credentialRequestDigest returns the hash signed by the voter in its request
+func credentialRequestDigest(request *types.CredentialRequestMessage) []byte {
+	return transport.Digest([]byte(request.ElectionID), []byte(request.Voter), request.VoterKey, request.Blinded)
+}
+
+// Register implements peer.Voting. The peer blinds the hash of a fresh
+// pseudonymous key, asks the announcer to sign it, and unblinds the signature:
+// the announcer never sees the pseudonymous key it signs.
+func (n *node) Register(ctx context.Context, electionID string) error {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	if !election.Base.AnonymousCredentials {
+		return xerrors.Errorf("election %s has no anonymous credentials", electionID)
+	}
+
+	registered := false
+	n.electionStore.View(electionID, func(election *types.Election) {
+		registered = election.MyCredential != nil
+	})
+	if registered {
+		return nil
+	}
+
+	credentialKey, err := x509.ParsePKCS1PublicKey(election.Base.CredentialKey)
+	if err != nil {
+		return xerrors.Errorf("invalid credential key: %v", err)
+	}
+
+	keyStore := n.conf.Storage.GetKeyStore()
+
+	pseudonym, err := loadOrCreateSigningKey(keyStore, pseudonymKeyName(electionID))
+	if err != nil {
+		return err
+	}
+	pseudonymKey := transport.MarshalPublicKey(&pseudonym.PublicKey)
+
+	r, err := loadOrCreateBlindingFactor(keyStore, electionID, credentialKey.N)
+	if err != nil {
+		return err
+	}
+
+	// blinded = H(pseudonym) * r^e (mod N)
+	e := big.NewInt(int64(credentialKey.E))
+	hash := CredentialHash(electionID, pseudonymKey, credentialKey.N)
+	blinded := new(big.Int).Exp(r, e, credentialKey.N)
+	blinded.Mul(blinded, hash).Mod(blinded, credentialKey.N)
+
+	request := types.CredentialRequestMessage{
+		ElectionID: electionID,
+		Voter:      n.myAddr,
+		VoterKey:   n.GetVoterKey(),
+		Blinded:    blinded.Bytes(),
+	}
+
+	signature, err := transport.SignDigest(n.signingKey, credentialRequestDigest(&request))
+	if err != nil {
+		return xerrors.Errorf("failed to sign credential request: %v", err)
+	}
+	request.Signature = signature
+
+	response, err := n.sendCredentialRequest(ctx, election.Base.Announcer, &request)
+	if err != nil {
+		return err
+	}
+
+	// credential = blind signature * r^-1 (mod N)
+	rInverse := new(big.Int).ModInverse(r, credentialKey.N)
+	credential := new(big.Int).SetBytes(response.BlindSignature)
+	credential.Mul(credential, rInverse).Mod(credential, credentialKey.N)
+
+	if !VerifyCredential(election, pseudonymKey, credential.Bytes()) {
+		return errors.New("the announcer sent an invalid credential")
+	}
+
+	n.electionStore.Update(electionID, func(election *types.Election) {
+		election.MyCredential = credential.Bytes()
+	})
+
+	return nil
+}
+
+// sendCredentialRequest sends the request to the announcer, and resends it
+// until it gets the credential or the context is done
+func (n *node) sendCredentialRequest(ctx context.Context, announcer string,
+	request *types.CredentialRequestMessage) (types.CredentialMessage, error) {
+
+	// filled by HandleCredentialMessage
+	responses := make(chan types.CredentialMessage, 1)
+	requestKey := hex.EncodeToString(request.Blinded)
+	n.credentials.Store(requestKey, responses)
+	defer n.credentials.Delete(requestKey)
+
+	waitTime := n.conf.BackoffVote.Initial
+	if waitTime <= 0 {
+		waitTime = defaultVoteBackoff
+	}
+
+	resend := time.NewTicker(waitTime)
+	defer resend.Stop()
+
+	recipients := map[string]struct{}{announcer: {}}
+
+	for {
+		err := n.sendPrivateMessage(recipients, request)
+		if err != nil {
+			return types.CredentialMessage{}, err
+		}
+
+		select {
+		case response := <-responses:
+			return response, nil
+		case <-ctx.Done():
+			return types.CredentialMessage{}, ctx.Err()
+		case <-resend.C:
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("no credential from announcer %s, resending the request", announcer)
+		}
+	}
+}
+
+// HandleCredentialRequestMessage processes types.CredentialRequestMessage. The
+// announcer signs the blinded request of a voter on the eligibility roll, and
+// only the first request of each voter.
+func (n *node) HandleCredentialRequestMessage(t types.Message, pkt transport.Packet) error {
+	request, ok := t.(*types.CredentialRequestMessage)
+	if !ok {
+		return fmt.Errorf("wrong type: %T", t)
+	}
+
+	election := n.electionStore.Get(request.ElectionID)
+	if election == nil {
+		return fmt.Errorf("received CredentialRequestMessage for unknown election %s", request.ElectionID)
+	}
+
+	if election.Base.Announcer != n.myAddr || !election.Base.AnonymousCredentials {
+		return nil
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling CredentialRequestMessage from %v", pkt.Header.Source)
+
+	if !isEligible(election, request.VoterKey) {
+		return errors.New("the voter is not on the eligibility roll - no credential")
+	}
+
+	err := transport.VerifyDigest(request.VoterKey, credentialRequestDigest(request), request.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid credential request signature: %v", err)
+	}
+
+	// the credential goes to the address which signs its rumors with the
+	// voter's key (see verifyRumor), not to whoever relayed the request
+	if !bytes.Equal(n.getSigningKey(request.Voter), request.VoterKey) {
+		return fmt.Errorf("%s doesn't sign with the key of the credential request", request.Voter)
+	}
+
+	credentialKey, err := loadCredentialKey(n.conf.Storage.GetKeyStore(), election.Base.ElectionID)
+	if err != nil {
+		return err
+	}
+
+	blinded := new(big.Int).SetBytes(request.Blinded)
+	if blinded.Sign() <= 0 || blinded.Cmp(credentialKey.N) >= 0 {
+		return errors.New("invalid blinded credential request")
+	}
+
+	// a voter gets one credential, resent if the voter resends its request
+	voterID := hex.EncodeToString(request.VoterKey)
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		if election.Phase >= types.PhaseClosed {
+			err = fmt.Errorf("election %s is %s - no credential", election.Base.ElectionID, election.Phase)
+			return
+		}
+
+		issued, ok := election.IssuedCredentials[voterID]
+		if ok && new(big.Int).SetBytes(issued).Cmp(blinded) != 0 {
+			err = errors.New("this voter already has a credential - no credential")
+			return
+		}
+		if !ok {
+			if election.IssuedCredentials == nil {
+				election.IssuedCredentials = make(map[string][]byte)
+			}
+			election.IssuedCredentials[voterID] = request.Blinded
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	response := types.CredentialMessage{
+		ElectionID:     request.ElectionID,
+		Blinded:        request.Blinded,
+		BlindSignature: new(big.Int).Exp(blinded, credentialKey.D, credentialKey.N).Bytes(),
+	}
+
+	recipients := map[string]struct{}{request.Voter: {}}
+
+	return n.sendPrivateMessage(recipients, &response)
+}
+
+// HandleCredentialMessage processes types.CredentialMessage. If this peer waits
+// for the credential, it hands it over to Register.
+func (n *node) HandleCredentialMessage(t types.Message, pkt transport.Packet) error {
+	response, ok := t.(*types.CredentialMessage)
+	if !ok {
+		return fmt.Errorf("wrong type: %T", t)
+	}
+
+	value, ok := n.credentials.Load(hex.EncodeToString(response.Blinded))
+	if !ok {
+		// not one of my requests, or already answered
+		return nil
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling CredentialMessage from %v", pkt.Header.Source)
+
+	select {
+	case value.(chan types.CredentialMessage) <- *response:
+	default:
+	}
+
+	return nil
+}
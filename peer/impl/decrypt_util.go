@@ -8,10 +8,10 @@ package impl
 //https://docs.zkproof.org/pages/standards/accepted-workshop4/proposal-sigma.pdf
 
 import (
-	"crypto/elliptic"
 	"crypto/rand"
 	"math/big"
 
+	"go.dedis.ch/cs438/peer/impl/group"
 	"go.dedis.ch/cs438/types"
 
 	"golang.org/x/xerrors"
@@ -24,18 +24,15 @@ type BSGSPoint struct {
 
 // Each party generates its' decryption share based on the ciphertext pair, and broadcasts it
 // together with the proof of the correct share generation (Chaum-Pedersen protocol)
-func MakeDecryptShare(ciphertext *types.ElGamalCipherText, publicShare *types.Point, secretShare []byte) (*types.Point, *types.Proof, error) {
-	curve := elliptic.P256()
-
-	shareCtPointX, shareCtPointY := curve.ScalarMult(&ciphertext.Ct1.X, &ciphertext.Ct1.Y, secretShare)
-	shareCtPoint := types.Point{}
-	shareCtPoint.X = *shareCtPointX
-	shareCtPoint.Y = *shareCtPointY
-
-	bPointOther := types.Point{}
-	bPointOther.X = ciphertext.Ct1.X
-	bPointOther.Y = ciphertext.Ct1.Y
-	proof, err := ProveDlogEq(secretShare, *publicShare, bPointOther, shareCtPoint, curve)
+func MakeDecryptShare(g group.Group, ciphertext *types.ElGamalCipherText, publicShare *types.Point, secretShare []byte) (*types.Point, *types.Proof, error) {
+	ct1, err := g.ElementFromPoint(ciphertext.Ct1)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("Error in Decrypt partial: %v", err)
+	}
+
+	shareCtPoint := ct1.Mul(g.Scalar(secretShare)).Point()
+
+	proof, err := ProveDlogEq(secretShare, *publicShare, ciphertext.Ct1, shareCtPoint, g)
 	if err != nil {
 		return nil, nil, xerrors.Errorf("Error in Decrypt  partial, error in generating proof")
 	}
@@ -44,91 +41,118 @@ func MakeDecryptShare(ciphertext *types.ElGamalCipherText, publicShare *types.Po
 
 }
 
-func RecoverVoteCount(cipherText *types.ElGamalCipherText, shareCtPointList []types.Point, participantNum int) (*big.Int, bool) {
-	curve := elliptic.P256()
-	curveParams := curve.Params()
-	minusOne := new(big.Int).Sub(curveParams.N, big.NewInt(1))
+// VerifyDecryptShare checks the proof that decryptShare = x_j*Ct1, where x_j is
+// the secret share behind publicShare = x_j*G.
+func VerifyDecryptShare(g group.Group, ciphertext *types.ElGamalCipherText, publicShare *types.Point, decryptShare *types.Point, proof *types.Proof) bool {
+	instance, err := decodePoints(g, *publicShare, ciphertext.Ct1, *decryptShare)
+	if err != nil {
+		return false
+	}
+
+	checkInstance := checkChallBytes(instance[0].Bytes(), proof.PPoint) &&
+		checkChallBytes(instance[1].Bytes(), proof.BPointOther) &&
+		checkChallBytes(instance[2].Bytes(), proof.PPointOther)
 
-	result := types.Point{}
-	result.X.Set(&cipherText.Ct2.X)
-	result.Y.Set(&cipherText.Ct2.Y)
+	if !checkInstance {
+		return false
+	}
 
-	for _, p := range shareCtPointList {
-		minusP := types.Point{}
-		//  = curve.ScalarMult(&p.X, &p.Y, minusOne.Bytes())
+	withSuite := *proof
+	withSuite.Suite = g.Suite()
 
-		minusPX, minusPY := curve.ScalarMult(&p.X, &p.Y, minusOne.Bytes())
+	isValid, err := VerifyDlogEq(&withSuite)
 
-		minusP.X, minusP.Y = *minusPX, *minusPY
+	return err == nil && isValid
+}
 
-		resX, resY := curve.Add(&result.X, &result.Y, &minusP.X, &minusP.Y)
-		result.X, result.Y = *resX, *resY
+// LagrangeCoefficient computes the Lagrange coefficient at 0 of the share with
+// ID shareID, given the IDs of all the shares taking part in the recovery.
+// Share IDs are the mixnet server IDs, the polynomial is evaluated at ID+1.
+func LagrangeCoefficient(shareID int, shareIDs []int, order *big.Int) *big.Int {
+	xj := big.NewInt(int64(shareID + 1))
+	num := big.NewInt(1)
+	den := big.NewInt(1)
+
+	for _, id := range shareIDs {
+		if id == shareID {
+			continue
+		}
+		xm := big.NewInt(int64(id + 1))
+		num.Mul(num, xm)
+		den.Mul(den, new(big.Int).Sub(xm, xj))
 	}
 
-	return BsgsFunction(&result, curve, participantNum)
+	num.Mod(num, order)
+	den.Mod(den, order)
+
+	lambda := new(big.Int).ModInverse(den, order)
+	lambda.Mul(lambda, num)
 
+	return lambda.Mod(lambda, order)
 }
 
-// Shank's baby step-giant step algorithm, used for obtaining final vote tallying
-func BsgsFunction(target *types.Point, curve elliptic.Curve, participantNum int) (*big.Int, bool) {
+// RecoverVoteCount combines the decryption shares of at least threshold mixnet
+// servers (shareIDs[i] produced shareCtPointList[i]) with Lagrange interpolation
+// and returns the discrete log of the plaintext, that is, the vote count.
+func RecoverVoteCount(g group.Group, cipherText *types.ElGamalCipherText, shareIDs []int, shareCtPointList []types.Point, participantNum int) (*big.Int, bool) {
+	if len(shareIDs) != len(shareCtPointList) {
+		return nil, false
+	}
 
-	table := make(map[BSGSPoint]int, 0)
-	current := BSGSPoint{}
+	shares, err := decodePoints(g, shareCtPointList...)
+	if err != nil {
+		return nil, false
+	}
+
+	ct2, err := g.ElementFromPoint(cipherText.Ct2)
+	if err != nil {
+		return nil, false
+	}
 
-	// fmt.Printf("Target Point is (%d,%d):\n", target.X.Uint64(), target.Y.Uint64())
+	combined := g.Identity()
+	for i, share := range shares {
+		lambda := LagrangeCoefficient(shareIDs[i], shareIDs, g.Order())
+		combined = combined.Add(share.Mul(g.ScalarFromInt(lambda)))
+	}
 
-	zero := make([]byte, 32)
-	//currX, currY := curve.ScalarBaseMult(zero)
-	currX, currY := curve.ScalarBaseMult(zero)
-	current.X = currX.Uint64()
-	current.Y = currY.Uint64()
+	// result = Ct2 - x*Ct1
+	result := ct2.Sub(combined).Point()
 
-	// fmt.Printf("Initial Point is (%d,%d):\n", current.X, current.Y)
+	return BsgsFunction(&result, g, participantNum)
+}
+
+// Shank's baby step-giant step algorithm, used for obtaining final vote tallying
+func BsgsFunction(target *types.Point, g group.Group, participantNum int) (*big.Int, bool) {
+	targetElement, err := g.ElementFromPoint(*target)
+	if err != nil {
+		return nil, false
+	}
+
+	table := make(map[BSGSPoint]int, 0)
 
-	table[current] = 0
+	table[bsgsKey(g.Identity())] = 0
 
 	sq := new(big.Int).SetUint64(uint64(participantNum))
 	sq = sq.Sqrt(sq)
 
 	for i := 0; i <= participantNum; i++ {
 		curr := new(big.Int).SetUint64(uint64(i))
-		cX, cY := curve.ScalarBaseMult(curr.Bytes())
-
-		current.X = cX.Uint64()
-		current.Y = cY.Uint64()
-		store := BSGSPoint{}
-		// fmt.Printf("For dlog %d, Stored Point is (%d,%d):\n", i, current.X, current.Y)
-
-		store.X = cX.Uint64()
-		store.Y = cY.Uint64()
-		table[store] = i
+		table[bsgsKey(g.BaseMul(g.ScalarFromInt(curr)))] = i
 	}
 
 	for i := 0; i <= participantNum; i++ {
 		iInt := new(big.Int).SetUint64(uint64(i))
 		prod := new(big.Int).Mul(sq, iInt)
 
-		negProd := new(big.Int).Neg(prod)
-		negProd = prod.Mod(negProd, curve.Params().N)
-
-		prodBaseX, prodBaseY := curve.ScalarBaseMult(negProd.Bytes())
+		challPoint := targetElement.Sub(g.BaseMul(g.ScalarFromInt(prod)))
 
-		challPointX, challPointY := curve.Add(&target.X, &target.Y, prodBaseX, prodBaseY)
-		// fmt.Printf("For dlog %d, Stored Point is (%d,%d):\n", i, challPointX, challPointY)
-
-		challP := BSGSPoint{
-			X: challPointX.Uint64(),
-			Y: challPointY.Uint64(),
-		}
-
-		val, ok := table[challP]
+		val, ok := table[bsgsKey(challPoint)]
 
 		valBigInt := new(big.Int).SetUint64(uint64(val))
 		if ok {
 			result := new(big.Int).Add(prod, valBigInt)
-			result = result.Mod(result, curve.Params().N)
+			result = result.Mod(result, g.Order())
 
-			// fmt.Printf("Result is: %d", result.Uint64())
 			return result, true
 		}
 	}
@@ -136,6 +160,15 @@ func BsgsFunction(target *types.Point, curve elliptic.Curve, participantNum int)
 	return nil, false
 }
 
+// bsgsKey returns the key of the element in the table of the baby steps
+func bsgsKey(e group.Element) BSGSPoint {
+	p := e.Point()
+	return BSGSPoint{
+		X: p.X.Uint64(),
+		Y: p.Y.Uint64(),
+	}
+}
+
 // Generate random permutation based on the
 func GenerateRandPermutation(k int) ([]int, error) {
 	permList := make([]int, k)
@@ -0,0 +1,165 @@
+package impl
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/types"
+)
+
+// defaultDKGTimeout is the deadline of each phase of the key generation if
+// peer.Configuration.DKGTimeout is not set
+const defaultDKGTimeout = time.Second * 10
+
+// getDKGTimeout returns the deadline of each phase of the key generation
+func (n *node) getDKGTimeout() time.Duration {
+	if n.conf.DKGTimeout <= 0 {
+		return defaultDKGTimeout
+	}
+	return n.conf.DKGTimeout
+}
+
+// scheduleDKGDeadlines keeps the key generation of a mixnet server going
+// when other mixnet servers don't answer. After one timeout, the mixnet
+// servers whose share is missing are disqualified, and this mixnet server
+// complains about them. After two timeouts, the mixnet servers which are not
+// decided yet are qualified if enough mixnet servers validated their share
+// and none complained.
+func (n *node) scheduleDKGDeadlines(election *types.Election) {
+	timeout := n.getDKGTimeout()
+
+	time.AfterFunc(timeout, func() {
+		n.disqualifyMissingShares(election)
+	})
+
+	time.AfterFunc(timeout*2, func() {
+		n.decideLateMixnetServers(election)
+	})
+}
+
+// disqualifyMissingShares disqualifies the mixnet servers which didn't send
+// their share by the first deadline of the key generation
+func (n *node) disqualifyMissingShares(election *types.Election) {
+	missing := []int{}
+	ready := false
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		if election.Phase.IsFinal() || election.IsElectionStarted() {
+			return
+		}
+
+		for i, mixnetServerInfo := range election.Base.MixnetServerInfos {
+			if mixnetServerInfo != nil && (mixnetServerInfo.ShareReceived ||
+				mixnetServerInfo.QualifiedStatus != types.NOT_DECIDED_YET) {
+				continue
+			}
+
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("no DKG share from mixnet server %d, disqualifying it", i)
+			election.Base.MixnetServerInfos[i] = disqualifiedMixnetServer(election, mixnetServerInfo)
+			missing = append(missing, i)
+		}
+
+		ready = len(missing) > 0 && n.ShouldSendElectionReadyMessage(election)
+	})
+
+	if ready {
+		n.sendElectionReadyMessage(election)
+	}
+
+	// the other mixnet servers don't qualify a mixnet server this one has no
+	// share of
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+	for _, i := range missing {
+		go n.sendDKGShareValidationMessage(election.Base.ElectionID, election.Base.MixnetServers, i,
+			myMixnetServerID, false)
+	}
+}
+
+// decideLateMixnetServers decides the status of the mixnet servers which are
+// still undecided at the second deadline of the key generation, and sends the
+// types.ElectionReadyMessage if they were the last ones
+func (n *node) decideLateMixnetServers(election *types.Election) {
+	ready := false
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		if election.Phase.IsFinal() || election.IsElectionStarted() {
+			return
+		}
+
+		decided := false
+		for i, mixnetServerInfo := range election.Base.MixnetServerInfos {
+			if mixnetServerInfo != nil && mixnetServerInfo.QualifiedStatus != types.NOT_DECIDED_YET {
+				continue
+			}
+			decided = true
+
+			if mixnetServerInfo != nil && mixnetServerInfo.ComplainedCnt == 0 &&
+				mixnetServerInfo.VerifiedCnt >= election.Base.Threshold {
+				mixnetServerInfo.QualifiedStatus = types.QUALIFIED
+				continue
+			}
+
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("mixnet server %d not validated in time, disqualifying it", i)
+			election.Base.MixnetServerInfos[i] = disqualifiedMixnetServer(election, mixnetServerInfo)
+		}
+
+		ready = decided && n.ShouldSendElectionReadyMessage(election)
+	})
+
+	if ready {
+		n.sendElectionReadyMessage(election)
+	}
+}
+
+// disqualifiedMixnetServer returns the info of a disqualified mixnet server,
+// whose commitments don't count in the election key
+func disqualifiedMixnetServer(election *types.Election, mixnetServerInfo *types.MixnetServerInfo) *types.MixnetServerInfo {
+	if mixnetServerInfo == nil {
+		mixnetServerInfo = &types.MixnetServerInfo{}
+	}
+	if len(mixnetServerInfo.X) == 0 {
+		mixnetServerInfo.X = make([]types.Point, election.Base.Threshold)
+	}
+
+	mixnetServerInfo.QualifiedStatus = types.DISQUALIFIED
+	mixnetServerInfo.X[0] = types.Point{} // the identity
+
+	return mixnetServerInfo
+}
+
+// scheduleReadyDeadline waits for the types.ElectionReadyMessage of the mixnet
+// servers until the end of the key generation, after its two deadlines. The
+// election then starts if enough mixnet servers are ready, it is aborted if
+// too few mixnet servers qualify.
+func (n *node) scheduleReadyDeadline(election *types.Election) {
+	time.AfterFunc(n.getDKGTimeout()*3, func() {
+		n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+			if election.Phase.IsFinal() || election.DKGTimedOut {
+				return
+			}
+
+			wasStarted := election.IsElectionStarted()
+			election.DKGTimedOut = true
+
+			switch {
+			case !wasStarted && election.IsElectionStarted():
+				n.openElection(election)
+			case !wasStarted && countQualifiedMixnetServers(election) < election.Base.Threshold:
+				log.Warn().Str("peerAddr", n.myAddr).Msgf("too few mixnet servers qualified in time, aborting the election")
+				n.setPhase(election, types.PhaseAborted)
+			}
+		})
+	})
+}
+
+// countQualifiedMixnetServers returns the number of mixnet servers qualified
+// by enough types.ElectionReadyMessage
+func countQualifiedMixnetServers(election *types.Election) int {
+	qualifiedCnt := 0
+	for _, points := range election.Base.MixnetServersPoints {
+		if points >= election.Base.Threshold {
+			qualifiedCnt++
+		}
+	}
+	return qualifiedCnt
+}
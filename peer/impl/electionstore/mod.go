@@ -1,8 +1,11 @@
 package electionstore
 
 import (
+	"encoding/json"
 	"sync"
 
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/storage"
 	"go.dedis.ch/cs438/types"
 )
 
@@ -10,65 +13,161 @@ import (
 
 // Store describes the primitives of a simple storage.
 type ElectionStore interface {
-	// Get returns nil if not found
+	// Get returns a snapshot of the election, copied while holding its lock,
+	// nil if not found. Changes to the snapshot are not saved, the election is
+	// changed with Update.
 	Get(key string) *types.Election
 
 	Set(key string, val *types.Election)
 
+	// Add stores the election if the key is not stored yet, it returns false
+	// otherwise
+	Add(key string, val *types.Election) bool
+
 	StoreVote(key string, encryptedChoice types.VoteMessage)
 
+	// Update calls f with the election while holding the lock of this
+	// election, then saves it. Each election has its own lock: the updates of
+	// different elections run in parallel. It returns false if the election is
+	// not found. f must not call the store for the same election.
+	Update(key string, f func(election *types.Election)) bool
+
+	// View is like Update, but doesn't save the election: f must not modify
+	// it.
+	View(key string, f func(election *types.Election)) bool
+
 	Delete(key string)
 
 	Exists(key string) bool
 
 	Len() int
 
+	// GetAll returns snapshots of the elections, see Get
 	GetAll() []*types.Election
 
-	// Calls the function on each key/value pair. Aborts if the function returns
-	// false.
+	// Calls the function on each key/value pair, the value is a snapshot (see
+	// Get). Aborts if the function returns false.
 	ForEach(func(key string, val *types.Election) bool)
 }
 
 // Storage implements an in-memory storage.
 func New() ElectionStore {
 	return &store{
-		data: make(map[string]*types.Election),
+		data: make(map[string]*entry),
 	}
 }
 
-// store implements an in-memory store.
+// NewPersistent returns an election store which writes the elections through
+// to persistency, serialized as JSON. Elections already in persistency are
+// loaded, so that a peer can resume them after a restart.
+func NewPersistent(persistency storage.Store) ElectionStore {
+	s := &store{
+		data:        make(map[string]*entry),
+		persistency: persistency,
+	}
+
+	persistency.ForEach(func(key string, val []byte) bool {
+		election := &types.Election{}
+		err := json.Unmarshal(val, election)
+		if err != nil {
+			log.Err(err).Msgf("failed to load election %s", key)
+			return true
+		}
+
+		s.data[key] = &entry{election: election}
+		return true
+	})
+
+	return s
+}
+
+// store implements an in-memory store, optionally backed by persistency. Its
+// lock guards the map only, each election is guarded by the lock of its entry.
 type store struct {
 	sync.Mutex
-	data map[string]*types.Election
+	data        map[string]*entry
+	persistency storage.Store
 }
 
-// Get implements storage.Store
-func (s *store) Get(key string) *types.Election {
+// entry holds an election and the lock guarding its state
+type entry struct {
+	sync.Mutex
+	election *types.Election
+}
+
+// getEntry returns nil if not found
+func (s *store) getEntry(key string) *entry {
 	s.Lock()
 	defer s.Unlock()
 
 	return s.data[key]
 }
 
+// persist writes the election through to persistency. The caller must hold
+// the lock of the entry.
+func (s *store) persist(key string, val *types.Election) {
+	if s.persistency == nil {
+		return
+	}
+
+	buf, err := json.Marshal(val)
+	if err != nil {
+		log.Err(err).Msgf("failed to persist election %s", key)
+		return
+	}
+
+	s.persistency.Set(key, buf)
+}
+
+// snapshot returns a deep copy of the election of the entry, taken while
+// holding its lock. The copy goes through JSON, like the persisted elections.
+func (e *entry) snapshot() *types.Election {
+	e.Lock()
+	defer e.Unlock()
+
+	buf, err := json.Marshal(e.election)
+	if err == nil {
+		election := &types.Election{}
+		err = json.Unmarshal(buf, election)
+		if err == nil {
+			return election
+		}
+	}
+
+	log.Err(err).Msgf("failed to copy election %s", e.election.Base.ElectionID)
+
+	election := *e.election
+	return &election
+}
+
 // Get implements storage.Store
-func (s *store) Exists(key string) bool {
-	s.Lock()
-	defer s.Unlock()
+func (s *store) Get(key string) *types.Election {
+	e := s.getEntry(key)
+	if e == nil {
+		return nil
+	}
 
-	_, ok := s.data[key]
-	return ok
+	return e.snapshot()
+}
+
+// Get implements storage.Store
+func (s *store) Exists(key string) bool {
+	return s.getEntry(key) != nil
 }
 
 // Get implements storage.Store
 func (s *store) GetAll() []*types.Election {
 	s.Lock()
-	defer s.Unlock()
+	entries := make([]*entry, 0, len(s.data))
+	for _, e := range s.data {
+		entries = append(entries, e)
+	}
+	s.Unlock()
 
-	elections := make([]*types.Election, 0)
+	elections := make([]*types.Election, 0, len(entries))
 
-	for _, election := range s.data {
-		elections = append(elections, election)
+	for _, e := range entries {
+		elections = append(elections, e.snapshot())
 	}
 
 	return elections
@@ -77,20 +176,76 @@ func (s *store) GetAll() []*types.Election {
 // Set implements storage.Store
 func (s *store) Set(key string, val *types.Election) {
 	s.Lock()
-	defer s.Unlock()
+	e, ok := s.data[key]
+	if !ok {
+		e = &entry{}
+		s.data[key] = e
+	}
+	s.Unlock()
 
-	s.data[key] = val
+	e.Lock()
+	defer e.Unlock()
+
+	e.election = val
+	s.persist(key, val)
 }
 
-func (s *store) StoreVote(key string, vote types.VoteMessage) {
+// Add implements ElectionStore
+func (s *store) Add(key string, val *types.Election) bool {
 	s.Lock()
-	defer s.Unlock()
+	_, ok := s.data[key]
+	if ok {
+		s.Unlock()
+		return false
+	}
+
+	e := &entry{election: val}
+	e.Lock()
+	defer e.Unlock()
+
+	s.data[key] = e
+	s.Unlock()
+
+	s.persist(key, val)
 
-	election := s.data[key]
+	return true
+}
+
+func (s *store) StoreVote(key string, vote types.VoteMessage) {
+	s.Update(key, func(election *types.Election) {
+		election.Votes = append(election.Votes, vote)
+	})
+}
+
+// Update implements ElectionStore
+func (s *store) Update(key string, f func(election *types.Election)) bool {
+	e := s.getEntry(key)
+	if e == nil {
+		return false
+	}
+
+	e.Lock()
+	defer e.Unlock()
+
+	f(e.election)
+	s.persist(key, e.election)
 
-	election.Votes = append(election.Votes, vote)
+	return true
+}
+
+// View implements ElectionStore
+func (s *store) View(key string, f func(election *types.Election)) bool {
+	e := s.getEntry(key)
+	if e == nil {
+		return false
+	}
+
+	e.Lock()
+	defer e.Unlock()
 
-	s.data[key] = election
+	f(e.election)
+
+	return true
 }
 
 // Delete implements storage.Store
@@ -99,15 +254,22 @@ func (s *store) Delete(key string) {
 	defer s.Unlock()
 
 	delete(s.data, string(key))
+	if s.persistency != nil {
+		s.persistency.Delete(key)
+	}
 }
 
-// ForEach implements storage.Store
+// ForEach implements storage.Store. f may call the store.
 func (s *store) ForEach(f func(key string, val *types.Election) bool) {
 	s.Lock()
-	defer s.Unlock()
+	data := make(map[string]*entry, len(s.data))
+	for k, e := range s.data {
+		data[k] = e
+	}
+	s.Unlock()
 
-	for k, v := range s.data {
-		cont := f(k, v)
+	for k, e := range data {
+		cont := f(k, e.snapshot())
 		if !cont {
 			return
 		}
@@ -0,0 +1,170 @@
+package impl
+
+import (
+	"bytes"
+
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// GetVoterKey implements peer.Voting. Ballots are signed with the long-term
+// signing key of the peer.
+func (n *node) GetVoterKey() []byte {
+	return transport.MarshalPublicKey(&n.signingKey.PublicKey)
+}
+
+// signBallot signs the hash of the ballot with the key of this peer, or with
+// its pseudonymous key if the election has anonymous credentials (see
+// Register). The ballots of a re-voting election are not signed, they carry an
+// encrypted credential instead (see attachCredential).
+func (n *node) signBallot(election *types.Election, ballot *types.VoteMessage) error {
+	voterKey, credential, signature, err := n.signAsVoter(election, BallotHash(ballot))
+	if err != nil {
+		return xerrors.Errorf("failed to sign ballot: %v", err)
+	}
+
+	ballot.VoterKey = voterKey
+	ballot.VoterSignature = signature
+	ballot.Credential = credential
+
+	return nil
+}
+
+// signAsVoter signs the digest with the key of this peer, or with its
+// pseudonymous key and its credential if the election has anonymous
+// credentials. It returns the key, the credential and the signature.
+func (n *node) signAsVoter(election *types.Election, digest []byte) ([]byte, []byte, []byte, error) {
+	voterKey := n.signingKey
+	var credential []byte
+
+	if election.Base.AnonymousCredentials {
+		pseudonym, err := loadOrCreateSigningKey(n.conf.Storage.GetKeyStore(), pseudonymKeyName(election.Base.ElectionID))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		voterKey = pseudonym
+		credential = election.MyCredential
+	}
+
+	signature, err := transport.SignDigest(voterKey, digest)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return transport.MarshalPublicKey(&voterKey.PublicKey), credential, signature, nil
+}
+
+// VerifyBallotSignature checks that the ballot is signed by its voter, and that
+// the voter is on the eligibility roll of the election, if any. With anonymous
+// credentials, the voter is a pseudonym which must hold a credential instead.
+func VerifyBallotSignature(election *types.Election, ballot *types.VoteMessage) error {
+	return verifyVoterSignature(election, ballot.VoterKey, ballot.Credential, BallotHash(ballot), ballot.VoterSignature)
+}
+
+// verifyVoterSignature checks the signature of the digest by the voter key, and
+// that the voter may vote (see VerifyBallotSignature)
+func verifyVoterSignature(election *types.Election, voterKey, credential, digest, signature []byte) error {
+	if len(voterKey) == 0 || len(signature) == 0 {
+		return xerrors.New("the ballot is not signed")
+	}
+
+	switch {
+	case election.Base.AnonymousCredentials:
+		if !VerifyCredential(election, voterKey, credential) {
+			return xerrors.New("the pseudonym of the voter has no valid credential")
+		}
+	case len(election.Base.EligibleVoters) > 0:
+		if !isEligible(election, voterKey) {
+			return xerrors.New("the voter is not on the eligibility roll")
+		}
+	}
+
+	err := transport.VerifyDigest(voterKey, digest, signature)
+	if err != nil {
+		return xerrors.Errorf("invalid ballot signature: %v", err)
+	}
+
+	return nil
+}
+
+// isEligible tells if the key is on the eligibility roll of the election
+func isEligible(election *types.Election, voterKey []byte) bool {
+	for _, eligibleKey := range election.Base.EligibleVoters {
+		if bytes.Equal(eligibleKey, voterKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkEligibleVoters checks that the keys of the eligibility roll are valid
+func checkEligibleVoters(voterKeys [][]byte) error {
+	for i, voterKey := range voterKeys {
+		_, err := transport.UnmarshalPublicKey(voterKey)
+		if err != nil {
+			return xerrors.Errorf("eligible voter %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// voterBallotIndex returns the index of the ballot of the voter, -1 if the
+// voter has no ballot
+func voterBallotIndex(ballots []types.VoteMessage, voterKey []byte) int {
+	for i := range ballots {
+		if bytes.Equal(ballots[i].VoterKey, voterKey) {
+			return i
+		}
+	}
+	return -1
+}
+
+// CountedBallots returns the ballots which count among the ballots stored by
+// the first mixnet server, in the order they were stored: one ballot per
+// voter, chosen by the vote policy of the election. Ballots which are not
+// validly signed don't count. The ballots of a re-voting election have no
+// voter key, they are returned as is: the plaintext equivalence tests keep the
+// last one of each credential (see deduplicateBallots).
+func CountedBallots(election *types.Election, ballots []types.VoteMessage) []types.VoteMessage {
+	if election.Base.Revoting {
+		return ballots
+	}
+
+	counted := []types.VoteMessage{}
+
+	for _, ballot := range ballots {
+		if VerifyBallotSignature(election, &ballot) != nil {
+			continue
+		}
+
+		i := voterBallotIndex(counted, ballot.VoterKey)
+		switch {
+		case i < 0:
+			counted = append(counted, ballot)
+		case election.Base.VotePolicy == types.LastVoteCounts:
+			counted[i] = ballot
+		}
+	}
+
+	return counted
+}
+
+// stripVoterSignatures returns copies of the ballots without the keys and
+// signatures of their voters, nor their encrypted credentials, so that the
+// mixed ballots can't be linked to them
+func stripVoterSignatures(ballots []types.VoteMessage) []types.VoteMessage {
+	stripped := make([]types.VoteMessage, len(ballots))
+	for i, ballot := range ballots {
+		ballot.VoterKey = nil
+		ballot.VoterSignature = nil
+		ballot.Credential = nil
+		ballot.EncryptedCredential = types.ElGamalCipherText{}
+		ballot.CredentialSignature = nil
+		ballot.RollSize = 0
+		ballot.RollProof = nil
+		stripped[i] = ballot
+	}
+	return stripped
+}
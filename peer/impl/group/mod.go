@@ -0,0 +1,341 @@
+package group
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// Group is a cyclic group of prime order in which the discrete logarithm is
+// hard. The proofs, ElGamal, the DKG and BSGS are written against it, so that
+// an election may run in any of the groups of ForSuite. It is written
+// additively: G is the generator and x*G the multiplication by a scalar.
+type Group interface {
+	// Suite returns the identifier of the group, as recorded in ElectionBase
+	Suite() types.PedersenSuite
+
+	// Order returns the prime order of the group
+	Order() *big.Int
+
+	// ScalarLen returns the length in bytes of an encoded scalar. It is also
+	// the length of the challenges of the proofs.
+	ScalarLen() int
+
+	// Generator returns the generator G of the group
+	Generator() Element
+
+	// Identity returns the neutral element of the group
+	Identity() Element
+
+	// BaseMul returns s*G, it is faster than Generator().Mul(s)
+	BaseMul(s Scalar) Element
+
+	// ElementFromBytes decodes a compressed element. It fails if the bytes
+	// are not the encoding of an element of the group.
+	ElementFromBytes(b []byte) (Element, error)
+
+	// ElementFromPoint returns the element with the given coordinates. It
+	// fails if the point is not an element of the group.
+	ElementFromPoint(p types.Point) (Element, error)
+
+	// Scalar returns the scalar with the given big-endian bytes, reduced
+	// modulo the order
+	Scalar(b []byte) Scalar
+
+	// ScalarFromInt returns the scalar i, reduced modulo the order
+	ScalarFromInt(i *big.Int) Scalar
+
+	// RandomScalar returns a uniformly random scalar
+	RandomScalar() (Scalar, error)
+}
+
+// Element is an element of a Group. Elements are immutable, and may only be
+// combined with elements and scalars of the same group.
+type Element interface {
+	// Add returns e + other
+	Add(other Element) Element
+
+	// Sub returns e - other
+	Sub(other Element) Element
+
+	// Neg returns -e
+	Neg() Element
+
+	// Mul returns s*e
+	Mul(s Scalar) Element
+
+	// Equal tells whether e and other are the same element
+	Equal(other Element) bool
+
+	// Bytes returns the compressed encoding of the element. The identity has
+	// no compressed encoding, it is the single byte 0x00.
+	Bytes() []byte
+
+	// Point returns the coordinates of the element. The identity is (0, 0).
+	Point() types.Point
+}
+
+// Scalar is an integer modulo the order of a Group. Scalars are immutable, and
+// may only be combined with scalars of the same group.
+type Scalar interface {
+	// Add returns s + other
+	Add(other Scalar) Scalar
+
+	// Sub returns s - other
+	Sub(other Scalar) Scalar
+
+	// Mul returns s * other
+	Mul(other Scalar) Scalar
+
+	// Neg returns -s
+	Neg() Scalar
+
+	// Equal tells whether s and other are the same scalar
+	Equal(other Scalar) bool
+
+	// Bytes returns the big-endian encoding of the scalar, ScalarLen bytes
+	// long
+	Bytes() []byte
+
+	// BigInt returns the scalar as an integer in [0, order)
+	BigInt() *big.Int
+}
+
+var (
+	p256 = newCurveGroup(types.P256Suite, elliptic.P256())
+	p384 = newCurveGroup(types.P384Suite, elliptic.P384())
+)
+
+// ForSuite returns the group identified by the suite. The empty suite is
+// types.DefaultPedersenSuite, it is the one of the elections announced before
+// the suite was recorded.
+func ForSuite(suite types.PedersenSuite) (Group, error) {
+	switch suite {
+	case types.P256Suite, "":
+		return p256, nil
+	case types.P384Suite:
+		return p384, nil
+	default:
+		return nil, xerrors.Errorf("unknown suite %q", suite)
+	}
+}
+
+// P256 returns the group of the points of the NIST P-256 curve
+func P256() Group {
+	return p256
+}
+
+// P384 returns the group of the points of the NIST P-384 curve
+func P384() Group {
+	return p384
+}
+
+// curveGroup is the group of the points of a NIST curve. The point at
+// infinity is represented as (0, 0), as crypto/elliptic does.
+type curveGroup struct {
+	suite     types.PedersenSuite
+	curve     elliptic.Curve
+	scalarLen int
+}
+
+func newCurveGroup(suite types.PedersenSuite, curve elliptic.Curve) *curveGroup {
+	return &curveGroup{
+		suite:     suite,
+		curve:     curve,
+		scalarLen: (curve.Params().N.BitLen() + 7) / 8,
+	}
+}
+
+// Suite implements Group
+func (g *curveGroup) Suite() types.PedersenSuite {
+	return g.suite
+}
+
+// Order implements Group
+func (g *curveGroup) Order() *big.Int {
+	return new(big.Int).Set(g.curve.Params().N)
+}
+
+// ScalarLen implements Group
+func (g *curveGroup) ScalarLen() int {
+	return g.scalarLen
+}
+
+// Generator implements Group
+func (g *curveGroup) Generator() Element {
+	params := g.curve.Params()
+	return g.element(params.Gx, params.Gy)
+}
+
+// identityEncoding is the encoding of the identity, which is not a point of
+// the curve: the compressed encodings start with 0x02 or 0x03
+const identityEncoding = 0x00
+
+// Identity implements Group
+func (g *curveGroup) Identity() Element {
+	return g.element(new(big.Int), new(big.Int))
+}
+
+// BaseMul implements Group
+func (g *curveGroup) BaseMul(s Scalar) Element {
+	return g.element(g.curve.ScalarBaseMult(s.Bytes()))
+}
+
+// ElementFromBytes implements Group
+func (g *curveGroup) ElementFromBytes(b []byte) (Element, error) {
+	if len(b) == 1 && b[0] == identityEncoding {
+		return g.Identity(), nil
+	}
+
+	x, y := elliptic.UnmarshalCompressed(g.curve, b)
+	if x == nil {
+		return nil, xerrors.Errorf("not a compressed point of %s", g.suite)
+	}
+
+	return g.element(x, y), nil
+}
+
+// ElementFromPoint implements Group
+func (g *curveGroup) ElementFromPoint(p types.Point) (Element, error) {
+	if p.X.Sign() == 0 && p.Y.Sign() == 0 {
+		return g.Identity(), nil
+	}
+
+	if !g.curve.IsOnCurve(&p.X, &p.Y) {
+		return nil, xerrors.Errorf("point is not on %s", g.suite)
+	}
+
+	return g.element(new(big.Int).Set(&p.X), new(big.Int).Set(&p.Y)), nil
+}
+
+// Scalar implements Group
+func (g *curveGroup) Scalar(b []byte) Scalar {
+	return g.ScalarFromInt(new(big.Int).SetBytes(b))
+}
+
+// ScalarFromInt implements Group
+func (g *curveGroup) ScalarFromInt(i *big.Int) Scalar {
+	return g.scalar(new(big.Int).Mod(i, g.curve.Params().N))
+}
+
+// RandomScalar implements Group
+func (g *curveGroup) RandomScalar() (Scalar, error) {
+	v, err := rand.Int(rand.Reader, g.curve.Params().N)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to draw a scalar: %v", err)
+	}
+
+	return g.scalar(v), nil
+}
+
+func (g *curveGroup) element(x, y *big.Int) *curveElement {
+	return &curveElement{g: g, x: x, y: y}
+}
+
+func (g *curveGroup) scalar(v *big.Int) *curveScalar {
+	return &curveScalar{g: g, v: v}
+}
+
+// curveElement is a point of the curve of its group
+type curveElement struct {
+	g    *curveGroup
+	x, y *big.Int
+}
+
+// Add implements Element
+func (e *curveElement) Add(other Element) Element {
+	o := other.(*curveElement)
+	return e.g.element(e.g.curve.Add(e.x, e.y, o.x, o.y))
+}
+
+// Sub implements Element
+func (e *curveElement) Sub(other Element) Element {
+	return e.Add(other.Neg())
+}
+
+// Neg implements Element
+func (e *curveElement) Neg() Element {
+	if e.y.Sign() == 0 {
+		return e
+	}
+
+	return e.g.element(e.x, new(big.Int).Sub(e.g.curve.Params().P, e.y))
+}
+
+// Mul implements Element
+func (e *curveElement) Mul(s Scalar) Element {
+	return e.g.element(e.g.curve.ScalarMult(e.x, e.y, s.Bytes()))
+}
+
+// Equal implements Element
+func (e *curveElement) Equal(other Element) bool {
+	o := other.(*curveElement)
+	return e.x.Cmp(o.x) == 0 && e.y.Cmp(o.y) == 0
+}
+
+// Bytes implements Element
+func (e *curveElement) Bytes() []byte {
+	if e.isIdentity() {
+		return []byte{identityEncoding}
+	}
+
+	return elliptic.MarshalCompressed(e.g.curve, e.x, e.y)
+}
+
+// isIdentity tells whether e is the point (0, 0), which stands for the
+// identity
+func (e *curveElement) isIdentity() bool {
+	return e.x.Sign() == 0 && e.y.Sign() == 0
+}
+
+// Point implements Element
+func (e *curveElement) Point() types.Point {
+	p := types.Point{}
+	p.X.Set(e.x)
+	p.Y.Set(e.y)
+	return p
+}
+
+// curveScalar is an integer modulo the order of the curve of its group
+type curveScalar struct {
+	g *curveGroup
+	v *big.Int
+}
+
+// Add implements Scalar
+func (s *curveScalar) Add(other Scalar) Scalar {
+	return s.g.ScalarFromInt(new(big.Int).Add(s.v, other.(*curveScalar).v))
+}
+
+// Sub implements Scalar
+func (s *curveScalar) Sub(other Scalar) Scalar {
+	return s.g.ScalarFromInt(new(big.Int).Sub(s.v, other.(*curveScalar).v))
+}
+
+// Mul implements Scalar
+func (s *curveScalar) Mul(other Scalar) Scalar {
+	return s.g.ScalarFromInt(new(big.Int).Mul(s.v, other.(*curveScalar).v))
+}
+
+// Neg implements Scalar
+func (s *curveScalar) Neg() Scalar {
+	return s.g.ScalarFromInt(new(big.Int).Neg(s.v))
+}
+
+// Equal implements Scalar
+func (s *curveScalar) Equal(other Scalar) bool {
+	return s.v.Cmp(other.(*curveScalar).v) == 0
+}
+
+// Bytes implements Scalar
+func (s *curveScalar) Bytes() []byte {
+	return s.v.FillBytes(make([]byte, s.g.scalarLen))
+}
+
+// BigInt implements Scalar
+func (s *curveScalar) BigInt() *big.Int {
+	return new(big.Int).Set(s.v)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"go.dedis.ch/cs438/transport"
 	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
 )
 
 // --- Message Handlers ---
@@ -40,6 +41,14 @@ func (n *node) HandleRumorsMessage(t types.Message, pkt transport.Packet) error
 	forward := false
 	for _, rumor := range rumorsMessage.Rumors {
 
+		// a rumor with an invalid signature is dropped before it is stored, so
+		// that the genuine one can still be received
+		err = n.verifyRumor(rumor)
+		if err != nil {
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("dropped rumor %d of %s: %v", rumor.Sequence, rumor.Origin, err)
+			continue
+		}
+
 		// is rumor expected?
 		// Store only works if the rumor is expected, otherwise an error is passed
 		err = n.rumorStore.Store(rumor)
@@ -56,9 +65,11 @@ func (n *node) HandleRumorsMessage(t types.Message, pkt transport.Packet) error
 
 		// pkt.Header.Source = rumor.Origin
 
-		// process rumor locally
+		// process rumor locally, the packet signature does not cover the
+		// rumor's message
+		rumorHeader := pkt.Header.Unsigned()
 		rumorPkt := transport.Packet{
-			Header: pkt.Header,
+			Header: &rumorHeader,
 			Msg:    rumor.Msg,
 		}
 
@@ -260,14 +271,44 @@ func (n *node) HandlePrivateMessage(t types.Message, pkt transport.Packet) error
 		return err
 	}
 
-	_, ok := privateMessage.Recipients[n.myAddr]
-	if !ok {
-		return nil
+	msg := privateMessage.Msg
+	localHeader := pkt.Header.Unsigned()
+
+	if privateMessage.Payloads != nil {
+		// only the payload encrypted for my key can be read
+		decrypted, ok, err := openPrivateMessage(n.privateKey, n.publicKey, &privateMessage)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+
+		err = n.checkPrivateMessageSender(&privateMessage)
+		if err != nil {
+			return err
+		}
+
+		// the inner message comes from the authenticated sender, not from the
+		// peer which relayed it
+		msg = decrypted
+		localHeader.Source = privateMessage.Sender
+	} else {
+		// the cleartext private messages of the homework protocol, which
+		// anyone can read and forge
+		if !n.acceptsUnsigned(privateMessage.Msg) {
+			return xerrors.New("dropped cleartext private message")
+		}
+
+		_, ok := privateMessage.Recipients[n.myAddr]
+		if !ok {
+			return nil
+		}
 	}
 
 	localPkt := transport.Packet{
-		Header: pkt.Header,
-		Msg:    privateMessage.Msg,
+		Header: &localHeader,
+		Msg:    msg,
 	}
 
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling privateMessage from %v", pkt.Header.Source)
@@ -0,0 +1,88 @@
+package keyring
+
+import (
+	"sync"
+	"time"
+
+	"go.dedis.ch/cs438/types"
+)
+
+// KeyRing maps the addresses of the peers to their long-term public keys.
+type KeyRing interface {
+	// Set records the key of the peer. The first key of a peer is kept, Set
+	// returns false if it differs from the given one.
+	Set(addr string, key types.Point) bool
+
+	// Get returns the key of the peer, if known
+	Get(addr string) (types.Point, bool)
+
+	// Wait blocks until the key of the peer is known or the timeout expires
+	Wait(addr string, timeout time.Duration) (types.Point, bool)
+}
+
+// New returns a new in-memory key ring.
+func New() KeyRing {
+	return &keyRing{
+		keys:    make(map[string]types.Point),
+		updated: make(chan struct{}),
+	}
+}
+
+// keyRing implements KeyRing
+type keyRing struct {
+	sync.Mutex
+	keys map[string]types.Point
+
+	// closed and replaced each time a key is added
+	updated chan struct{}
+}
+
+// Set implements KeyRing
+func (k *keyRing) Set(addr string, key types.Point) bool {
+	k.Lock()
+	defer k.Unlock()
+
+	known, ok := k.keys[addr]
+	if ok {
+		return known.X.Cmp(&key.X) == 0 && known.Y.Cmp(&key.Y) == 0
+	}
+
+	k.keys[addr] = key
+
+	close(k.updated)
+	k.updated = make(chan struct{})
+
+	return true
+}
+
+// Get implements KeyRing
+func (k *keyRing) Get(addr string) (types.Point, bool) {
+	k.Lock()
+	defer k.Unlock()
+
+	key, ok := k.keys[addr]
+	return key, ok
+}
+
+// Wait implements KeyRing
+func (k *keyRing) Wait(addr string, timeout time.Duration) (types.Point, bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		k.Lock()
+		key, ok := k.keys[addr]
+		updated := k.updated
+		k.Unlock()
+
+		if ok {
+			return key, true
+		}
+
+		select {
+		case <-updated:
+		case <-timer.C:
+			return types.Point{}, false
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package impl
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"math/big"
 	"math/rand"
 	"os"
 	"strconv"
@@ -11,7 +14,11 @@ import (
 	"github.com/rs/zerolog/log"
 	"go.dedis.ch/cs438/peer"
 	"go.dedis.ch/cs438/peer/impl/asyncnotify"
+	"go.dedis.ch/cs438/peer/impl/bulletinboard"
 	"go.dedis.ch/cs438/peer/impl/electionstore"
+	"go.dedis.ch/cs438/peer/impl/keyring"
+	"go.dedis.ch/cs438/peer/impl/pendingqueue"
+	"go.dedis.ch/cs438/peer/impl/phasewatch"
 	"go.dedis.ch/cs438/peer/impl/routingtable"
 	"go.dedis.ch/cs438/peer/impl/rumorstore"
 	"go.dedis.ch/cs438/storage"
@@ -75,7 +82,30 @@ func NewPeer(conf peer.Configuration) peer.Peer {
 	dataBlobStore := conf.Storage.GetDataBlobStore()
 	namingStore := conf.Storage.GetNamingStore()
 	blockStore := conf.Storage.GetBlockchainStore()
-	electionStore := electionstore.New()
+	electionStore := electionstore.NewPersistent(conf.Storage.GetElectionStore())
+	bulletinBoard := bulletinboard.NewPersistent(conf.Storage.GetBulletinBoardStore())
+	phaseWatch := phasewatch.New()
+	pendingMessages := pendingqueue.New(maxPendingMessages, maxPendingElections, pendingMessageTTL)
+
+	// long-term key, private messages are encrypted for it
+	privateKey, err := loadOrCreateKey(conf.Storage.GetKeyStore(), encryptionKeyName)
+	if err != nil {
+		log.Fatal().Err(err).Str("peerAddr", myAddr).Msg("failed to load the node key")
+	}
+	publicKeyX, publicKeyY := elliptic.P256().ScalarBaseMult(privateKey.Bytes())
+	publicKey := NewPoint(publicKeyX, publicKeyY)
+
+	keyRing := keyring.New()
+	keyRing.Set(myAddr, publicKey)
+
+	// long-term key, packets and rumors are signed with it
+	signingKey, err := loadOrCreateSigningKey(conf.Storage.GetKeyStore(), signingKeyName)
+	if err != nil {
+		log.Fatal().Err(err).Str("peerAddr", myAddr).Msg("failed to load the signing key")
+	}
+
+	signingKeys := keyring.New()
+	signingKeys.Set(myAddr, NewPoint(signingKey.X, signingKey.Y))
 
 	catalog := make(peer.Catalog)
 
@@ -100,8 +130,15 @@ func NewPeer(conf peer.Configuration) peer.Peer {
 		paxosInstances:      paxosInstances,
 		threshold:           threshold,
 		electionStore:       electionStore,
-
-		dkgMutex: sync.Mutex{},
+		bulletinBoard:       bulletinBoard,
+		phaseWatch:          phaseWatch,
+		pendingMessages:     pendingMessages,
+		boardWaiters:        make(map[string][]*boardWaiter),
+		privateKey:          privateKey,
+		publicKey:           publicKey,
+		keyRing:             keyRing,
+		signingKey:          signingKey,
+		signingKeys:         signingKeys,
 	}
 
 	// register Callbacks
@@ -110,6 +147,8 @@ func NewPeer(conf peer.Configuration) peer.Peer {
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.AckMessage{}, peer.HandleAckMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.StatusMessage{}, peer.HandleStatusMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.PrivateMessage{}, peer.HandlePrivateMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.NodeKeyMessage{}, peer.HandleNodeKeyMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.NodeKeyRequestMessage{}, peer.HandleNodeKeyRequestMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.EmptyMessage{}, peer.HandleEmptyMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.DataReplyMessage{}, peer.HandleDataReplyMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.DataRequestMessage{}, peer.HandleDataRequestMessage)
@@ -122,9 +161,21 @@ func NewPeer(conf peer.Configuration) peer.Peer {
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.TLCMessage{}, peer.HandleTLCMessage)
 
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.AnnounceElectionMessage{}, peer.HandleAnnounceElectionMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.CancelElectionMessage{}, peer.HandleCancelElectionMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.ExtendElectionMessage{}, peer.HandleExtendElectionMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.VoteMessage{}, peer.HandleVoteMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.BallotReceiptMessage{}, peer.HandleBallotReceiptMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.MixMessage{}, peer.HandleMixMessage)
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.ResultMessage{}, peer.HandleResultMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.MixComplaintMessage{}, peer.HandleMixComplaintMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.BulletinBoardMessage{}, peer.HandleBulletinBoardMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.DecryptionRequestMessage{}, peer.HandleDecryptionRequestMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.DecryptShareMessage{}, peer.HandleDecryptShareMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.RegisterCredentialMessage{}, peer.HandleRegisterCredentialMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.PETRequestMessage{}, peer.HandlePETRequestMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.PETShareMessage{}, peer.HandlePETShareMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.CredentialRequestMessage{}, peer.HandleCredentialRequestMessage)
+	peer.conf.MessageRegistry.RegisterMessageCallback(types.CredentialMessage{}, peer.HandleCredentialMessage)
 
 	// Pedersen DKG
 	peer.conf.MessageRegistry.RegisterMessageCallback(types.DKGShareMessage{}, peer.HandleDKGShareMessage)
@@ -150,6 +201,9 @@ type node struct {
 	routingTable routingtable.RoutingTable
 
 	rumorStore rumorstore.RumorStore
+	// broadcastMutex makes concurrent broadcasts take consecutive sequence
+	// numbers
+	broadcastMutex sync.Mutex
 
 	statusTicker     *time.Ticker
 	stopStatusTicker chan struct{}
@@ -159,6 +213,18 @@ type node struct {
 
 	myAddr string
 
+	// long-term key of the peer, and the known keys of the other peers (see
+	// sendPrivateMessage)
+	privateKey     *big.Int
+	publicKey      types.Point
+	keyRing        keyring.KeyRing
+	publishKeyOnce sync.Once
+
+	// long-term signing key of the peer, and the known signing keys of the
+	// other peers (see verifyRumor)
+	signingKey  *ecdsa.PrivateKey
+	signingKeys keyring.KeyRing
+
 	// maps from packet ID to timer
 	notfify asyncnotify.AsyncNotify
 
@@ -181,6 +247,28 @@ type node struct {
 
 	// peervote
 	electionStore electionstore.ElectionStore
-
-	dkgMutex sync.Mutex
+	bulletinBoard bulletinboard.BulletinBoardStore
+	phaseWatch    phasewatch.PhaseWatch
+	// the messages of the elections which are not announced yet (see
+	// parkMessage)
+	pendingMessages pendingqueue.PendingQueue
+	// election ID -> the messages waiting for the bulletin board of the
+	// election (see waitForBulletinBoard)
+	boardWaiters      map[string][]*boardWaiter
+	boardWaitersMutex sync.Mutex
+	// hex ballot hash -> chan types.BallotReceiptMessage, for the ballots
+	// waiting for a receipt
+	ballotReceipts sync.Map
+	// hex blinded request -> chan types.CredentialMessage, for the
+	// registrations waiting for a credential
+	credentials sync.Map
+	// election ID -> *preparedBallot, for the ballots prepared but neither
+	// cast nor audited yet
+	preparedBallots sync.Map
+	// election ID -> struct{}, for the elections in which this peer is
+	// sending a ballot
+	castingBallots sync.Map
+	// election ID and step -> chan types.PETShareMessage, for the plaintext
+	// equivalence tests waiting for shares
+	petShares sync.Map
 }
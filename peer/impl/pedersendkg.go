@@ -1,13 +1,15 @@
 package impl
 
 import (
-	"crypto/elliptic"
-	"errors"
+	"crypto/ecdsa"
+	"encoding/binary"
 	"fmt"
 	"math/big"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/peer/impl/group"
 	"go.dedis.ch/cs438/transport"
 	"go.dedis.ch/cs438/types"
 )
@@ -20,34 +22,58 @@ import (
 // Key Generation Protocol (Rosario Gennaro, Stanislaw Jarecki,
 // Hugo Krawczyk, and Tal Rabin)
 func (n *node) PedersenDkg(election *types.Election) {
-	// Choose a random polynomial f(z) over Zq of degree t:
-	// f(z) = a0 + a1*z + ... + at*z^t
-	a := GenerateRandomPolynomial(election.Base.Threshold, elliptic.P256().Params().N)
-	X := make([]types.Point, election.Base.Threshold+1)
+	g, err := electionGroup(election)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to start the DKG")
+		return
+	}
+
+	// Choose a random polynomial f(z) over Zq of degree t-1, so that any t
+	// qualified mixnet servers can decrypt together:
+	// f(z) = a0 + a1*z + ... + a(t-1)*z^(t-1)
+	a := GenerateRandomPolynomial(election.Base.Threshold-1, g.Order())
+	X := make([]types.Point, election.Base.Threshold)
 	for i := 0; i < len(a); i++ {
 		// X[i] = g^a[i]
-		// X[i].X, X[i].Y = elliptic.P256().ScalarBaseMult(a[i].Bytes())
-
-		xix, xiy := elliptic.P256().ScalarBaseMult(a[i].Bytes())
-		X[i].X, X[i].Y = *xix, *xiy
+		X[i] = g.BaseMul(g.ScalarFromInt(&a[i])).Point()
 	}
 	f := func(id int) big.Int {
 		base := big.NewInt(int64(id))
 		sum := new(big.Int)
 		sum = sum.Set(&a[0])
-		for i := 1; i <= election.Base.Threshold; i++ {
+		for i := 1; i < election.Base.Threshold; i++ {
 			exp := big.NewInt(int64(i))
 			factor := new(big.Int).Exp(base, exp, nil)
 			tmp := new(big.Int).Mul(&a[i], factor)
 			sum.Add(sum, tmp)
 		}
-		return *new(big.Int).Mod(sum, elliptic.P256().Params().N)
+		return *new(big.Int).Mod(sum, g.Order())
 	}
 	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
-	// Compute the share xij and send it to each mixnetServer
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		n.setPhase(election, types.PhaseDKG)
+	})
+
+	// don't wait forever for the mixnet servers which don't answer
+	n.scheduleDKGDeadlines(election)
+
+	// The commitments are public, post them on the bulletin board
+	dkgCommitmentMessage := types.DKGCommitmentMessage{
+		ElectionID:     election.Base.ElectionID,
+		MixnetServerID: myMixnetServerID,
+		X:              X,
+	}
+	err = n.postOnBulletinBoard(election.Base.ElectionID, &dkgCommitmentMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to post DKG commitments")
+	}
+
+	// Compute the share xij and send it to each mixnetServer. Sending waits for
+	// the key of the mixnet server, which must not block the announcement.
 	for i := 0; i < len(election.Base.MixnetServers); i++ {
 		share := f(i + 1) // IDs of the mixnet server starts from 1
-		n.sendDKGShareMessage(election.Base.ElectionID, election.Base.MixnetServers[i], myMixnetServerID, share, X)
+		go n.sendDKGShareMessage(election.Base.ElectionID, election.Base.MixnetServers[i], myMixnetServerID, share, X)
 	}
 }
 
@@ -68,22 +94,15 @@ func (n *node) sendDKGShareMessage(electionID string, mixnetServer string, mixne
 		X:              X,
 	}
 
-	dkgShareTransportMessage, err := marshalMessage(&dkgShareMessage)
-
-	privateMessage := types.PrivateMessage{
-		Recipients: recipients,
-		Msg:        &dkgShareTransportMessage,
-	}
-
-	msg, err := marshalMessage(privateMessage)
+	err := SignDKGShare(n.signingKey, &dkgShareMessage)
 	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign DKG share")
 		return
 	}
 
-	err = n.Broadcast(msg)
-
+	err = n.sendPrivateMessage(recipients, &dkgShareMessage)
 	if err != nil {
-		return
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to send DKG share to %s", mixnetServer)
 	}
 }
 
@@ -95,22 +114,12 @@ func (n *node) HandleDKGShareMessage(msg types.Message, pkt transport.Packet) er
 		return fmt.Errorf("wrong type: %T", msg)
 	}
 
-	n.dkgMutex.Lock()
-
 	// Processing DKGShareMessage
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling DKGShareMessage from %v", pkt.Header.Source)
 
 	election := n.electionStore.Get(dkgMessage.ElectionID)
 	if election == nil {
-		timeout := time.Second * 30
-		n.notfify.RegisterTimer(dkgMessage.ElectionID, timeout)
-		n.dkgMutex.Unlock()
-		_, ok := n.notfify.Wait(dkgMessage.ElectionID, timeout)
-		if !ok {
-			return errors.New("received DKGShareMessage before AnnounceElectionMessage")
-		}
-		n.dkgMutex.Lock()
-		election = n.electionStore.Get(dkgMessage.ElectionID)
+		return n.parkMessage(dkgMessage.ElectionID, pkt)
 	}
 
 	mixnetServers := election.Base.MixnetServers
@@ -120,71 +129,88 @@ func (n *node) HandleDKGShareMessage(msg types.Message, pkt transport.Packet) er
 			" but the node is not one of the mixnetServers", dkgMessage.ElectionID)
 	}
 
-	// store info about mixnetserver
-	if election.Base.MixnetServerInfos[dkgMessage.MixnetServerID] == nil {
-		election.Base.MixnetServerInfos[dkgMessage.MixnetServerID] = &types.MixnetServerInfo{
-			ReceivedShare:   dkgMessage.Share,
-			X:               dkgMessage.X,
-			VerifiedCnt:     0,
-			ComplainedCnt:   0,
-			QualifiedStatus: types.NOT_DECIDED_YET,
-		}
-	} else {
-		if election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].QualifiedStatus != types.NOT_DECIDED_YET {
-			n.dkgMutex.Unlock()
-			return nil
+	err := VerifyDKGShare(election, dkgMessage)
+	if err != nil {
+		return err
+	}
+
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+
+	// malformed commitments are not stored, the dealer gets a complaint
+	if len(dkgMessage.X) != election.Base.Threshold {
+		go n.sendDKGShareValidationMessage(dkgMessage.ElectionID, election.Base.MixnetServers,
+			dkgMessage.MixnetServerID, myMixnetServerID, false)
+		return fmt.Errorf("DKGShareMessage of mixnet server %d has %d commitments instead of %d",
+			dkgMessage.MixnetServerID, len(dkgMessage.X), election.Base.Threshold)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	decided := false
+
+	// store info about mixnetserver, the update persists the received share,
+	// needed to decrypt after a restart
+	n.electionStore.Update(dkgMessage.ElectionID, func(election *types.Election) {
+		if election.Base.MixnetServerInfos[dkgMessage.MixnetServerID] == nil {
+			election.Base.MixnetServerInfos[dkgMessage.MixnetServerID] = &types.MixnetServerInfo{
+				ReceivedShare:   dkgMessage.Share,
+				X:               dkgMessage.X,
+				VerifiedCnt:     0,
+				ComplainedCnt:   0,
+				QualifiedStatus: types.NOT_DECIDED_YET,
+				ShareReceived:   true,
+			}
 		} else {
-			election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].ReceivedShare = dkgMessage.Share
-			election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].X = dkgMessage.X
+			if election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].QualifiedStatus != types.NOT_DECIDED_YET {
+				decided = true
+			} else {
+				election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].ReceivedShare = dkgMessage.Share
+				election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].X = dkgMessage.X
+				election.Base.MixnetServerInfos[dkgMessage.MixnetServerID].ShareReceived = true
+			}
 		}
+	})
+	if decided {
+		return nil
 	}
 
-	n.dkgMutex.Unlock()
-
-	myMixnetID := big.NewInt(int64(election.GetMyMixnetServerID(n.myAddr) + 1))
-	isValid := n.VerifyEquation(myMixnetID, &dkgMessage.Share, dkgMessage.X, election.Base.Threshold)
+	myMixnetID := big.NewInt(int64(myMixnetServerID + 1))
+	isValid := n.VerifyEquation(g, myMixnetID, &dkgMessage.Share, dkgMessage.X, election.Base.Threshold)
 	//if isValid {
 	//	fmt.Printf("share received from %s is valid | says %s\n", pkt.Header.Source, n.myAddr)
 	//} else {
 	//	fmt.Printf("share received from %s is invalid | says %s\n", pkt.Header.Source, n.myAddr)
 	//}
-	n.sendDKGShareValidationMessage(dkgMessage.ElectionID, election.Base.MixnetServers, dkgMessage.MixnetServerID, isValid)
+	go n.sendDKGShareValidationMessage(dkgMessage.ElectionID, election.Base.MixnetServers, dkgMessage.MixnetServerID,
+		myMixnetServerID, isValid)
 
 	return nil
 }
 
 // sendDKGShareValidationMessage creates a new types.DKGShareValidationMessage, wraps it inside a
-// types.PrivateMessage and sends it secretly to other mixnet servers
-func (n *node) sendDKGShareValidationMessage(electionID string, mixnetServers []string, mixnetServerID int, isShareValid bool) {
-	log.Info().Str("peerAddr", n.myAddr).Msgf("sending DKG Share Validation Message")
+// types.PrivateMessage and sends it secretly to each of the other mixnet servers
+func (n *node) sendDKGShareValidationMessage(electionID string, mixnetServers []string, mixnetServerID int,
+	validatorID int, isShareValid bool) {
 
-	recipients := make(map[string]struct{})
-	for _, mixnetServer := range mixnetServers {
-		recipients[mixnetServer] = struct{}{}
-	}
+	log.Info().Str("peerAddr", n.myAddr).Msgf("sending DKG Share Validation Message")
 
 	dkgShareValidationMessage := types.DKGShareValidationMessage{
 		ElectionID:     electionID,
 		MixnetServerID: mixnetServerID,
+		ValidatorID:    validatorID,
 		IsShareValid:   isShareValid,
 	}
 
-	dkgShareValidationTransportMessage, err := marshalMessage(&dkgShareValidationMessage)
-
-	privateMessage := types.PrivateMessage{
-		Recipients: recipients,
-		Msg:        &dkgShareValidationTransportMessage,
-	}
-
-	msg, err := marshalMessage(privateMessage)
+	err := SignDKGShareValidation(n.signingKey, &dkgShareValidationMessage)
 	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign DKG share validation")
 		return
 	}
 
-	err = n.Broadcast(msg)
-	if err != nil {
-		return
-	}
+	n.sendPrivateMessageToEach(mixnetServers, &dkgShareValidationMessage)
 }
 
 // HandleDKGShareValidationMessage handles types.DKGShareValidationMessage
@@ -195,21 +221,12 @@ func (n *node) HandleDKGShareValidationMessage(msg types.Message, pkt transport.
 		return fmt.Errorf("wrong type: %T", msg)
 	}
 
-	n.dkgMutex.Lock()
 	// Processing DKGShareValidationMessage
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling DKGShareValidationMessage from %v", pkt.Header.Source)
 
 	election := n.electionStore.Get(dkgShareValidationMessage.ElectionID)
 	if election == nil {
-		timeout := time.Second * 30
-		n.notfify.RegisterTimer(dkgShareValidationMessage.ElectionID, timeout)
-		n.dkgMutex.Unlock()
-		_, ok := n.notfify.Wait(dkgShareValidationMessage.ElectionID, timeout)
-		if !ok {
-			return errors.New("received DKGShareValidationMessage before AnnounceElectionMessage")
-		}
-		n.dkgMutex.Lock()
-		election = n.electionStore.Get(dkgShareValidationMessage.ElectionID)
+		return n.parkMessage(dkgShareValidationMessage.ElectionID, pkt)
 	}
 
 	mixnetServers := election.Base.MixnetServers
@@ -219,50 +236,69 @@ func (n *node) HandleDKGShareValidationMessage(msg types.Message, pkt transport.
 			" but the node is not one of the mixnetServers", dkgShareValidationMessage.ElectionID)
 	}
 
-	mixnetServerInfo := election.Base.MixnetServerInfos[dkgShareValidationMessage.MixnetServerID]
-	if mixnetServerInfo == nil {
-		mixnetServerInfo = &types.MixnetServerInfo{
-			ReceivedShare:   big.Int{},
-			X:               make([]types.Point, len(election.Base.MixnetServers)),
-			VerifiedCnt:     0,
-			ComplainedCnt:   0,
-			QualifiedStatus: types.NOT_DECIDED_YET,
-		}
-		election.Base.MixnetServerInfos[dkgShareValidationMessage.MixnetServerID] = mixnetServerInfo
+	err := VerifyDKGShareValidation(election, dkgShareValidationMessage)
+	if err != nil {
+		return err
 	}
 
-	if dkgShareValidationMessage.IsShareValid {
-		mixnetServerInfo.VerifiedCnt++
-		if mixnetServerInfo.VerifiedCnt == len(election.Base.MixnetServers) {
-			mixnetServerInfo.QualifiedStatus = types.QUALIFIED
-			if n.ShouldSendElectionReadyMessage(election) {
-				n.dkgMutex.Unlock()
-				n.sendElectionReadyMessage(election)
-				return nil
+	ready := false
+	reveal := false
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+	validatorID := dkgShareValidationMessage.ValidatorID
+
+	n.electionStore.Update(dkgShareValidationMessage.ElectionID, func(election *types.Election) {
+		mixnetServerInfo := election.Base.MixnetServerInfos[dkgShareValidationMessage.MixnetServerID]
+		if mixnetServerInfo == nil {
+			mixnetServerInfo = &types.MixnetServerInfo{
+				ReceivedShare:   big.Int{},
+				X:               make([]types.Point, len(election.Base.MixnetServers)),
+				VerifiedCnt:     0,
+				ComplainedCnt:   0,
+				QualifiedStatus: types.NOT_DECIDED_YET,
 			}
+			election.Base.MixnetServerInfos[dkgShareValidationMessage.MixnetServerID] = mixnetServerInfo
 		}
-	} else {
-		mixnetServerInfo.ComplainedCnt++
-		if mixnetServerInfo.ComplainedCnt > election.Base.Threshold {
-			mixnetServerInfo.QualifiedStatus = types.DISQUALIFIED
-			XX, XY := elliptic.P256().ScalarBaseMult(make([]byte, 32))
-			mixnetServerInfo.X[0].X, mixnetServerInfo.X[0].Y = *XX, *XY
-			if n.ShouldSendElectionReadyMessage(election) {
-				n.dkgMutex.Unlock()
-				n.sendElectionReadyMessage(election)
-				return nil
+
+		// decided at a deadline of the key generation (see scheduleDKGDeadlines)
+		if mixnetServerInfo.QualifiedStatus != types.NOT_DECIDED_YET {
+			return
+		}
+
+		// each mixnet server validates the share once
+		_, validated := mixnetServerInfo.Validations[validatorID]
+		if validated {
+			return
+		}
+		if mixnetServerInfo.Validations == nil {
+			mixnetServerInfo.Validations = make(map[int]bool)
+		}
+		mixnetServerInfo.Validations[validatorID] = dkgShareValidationMessage.IsShareValid
+
+		if dkgShareValidationMessage.IsShareValid {
+			mixnetServerInfo.VerifiedCnt++
+			if mixnetServerInfo.VerifiedCnt == len(election.Base.MixnetServers) {
+				mixnetServerInfo.QualifiedStatus = types.QUALIFIED
+				ready = n.ShouldSendElectionReadyMessage(election)
 			}
 		} else {
-			myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
-			if myMixnetServerID == dkgShareValidationMessage.MixnetServerID {
-				n.dkgMutex.Unlock()
-				n.sendDKGRevealShareMessage(election, myMixnetServerID, dkgShareValidationMessage.MixnetServerID)
-				return nil
+			mixnetServerInfo.ComplainedCnt++
+			if mixnetServerInfo.ComplainedCnt > election.Base.Threshold {
+				mixnetServerInfo.QualifiedStatus = types.DISQUALIFIED
+				mixnetServerInfo.X[0] = types.Point{} // the identity
+				ready = n.ShouldSendElectionReadyMessage(election)
+			} else {
+				reveal = myMixnetServerID == dkgShareValidationMessage.MixnetServerID
 			}
 		}
+	})
+
+	if ready {
+		n.sendElectionReadyMessage(election)
+	}
+	if reveal {
+		go n.sendDKGRevealShareMessage(election, myMixnetServerID, dkgShareValidationMessage.MixnetServerID)
 	}
 
-	n.dkgMutex.Unlock()
 	return nil
 }
 
@@ -270,10 +306,10 @@ func (n *node) HandleDKGShareValidationMessage(msg types.Message, pkt transport.
 func (n *node) sendDKGRevealShareMessage(election *types.Election, myMixnetServerID int, complainingServerID int) {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("sending DKGRevealShareMessage")
 
-	recipients := make(map[string]struct{})
+	recipients := make([]string, 0, len(election.Base.MixnetServers))
 	for _, mixnetServer := range election.Base.MixnetServers {
 		if mixnetServer != n.myAddr {
-			recipients[mixnetServer] = struct{}{}
+			recipients = append(recipients, mixnetServer)
 		}
 	}
 
@@ -283,25 +319,13 @@ func (n *node) sendDKGRevealShareMessage(election *types.Election, myMixnetServe
 		ComplainingServerID: complainingServerID,
 	}
 
-	dkgRevealShareTransportMessage, err := marshalMessage(&dkgRevealShareMessage)
+	err := SignDKGRevealShare(n.signingKey, &dkgRevealShareMessage)
 	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign DKGRevealShareMessage")
 		return
 	}
 
-	privateMessage := types.PrivateMessage{
-		Recipients: recipients,
-		Msg:        &dkgRevealShareTransportMessage,
-	}
-
-	msg, err := marshalMessage(privateMessage)
-	if err != nil {
-		return
-	}
-
-	err = n.Broadcast(msg)
-	if err != nil {
-		return
-	}
+	n.sendPrivateMessageToEach(recipients, &dkgRevealShareMessage)
 }
 
 // HandleDKGRevealShareMessage handles types.DKGRevealShareMessage
@@ -316,60 +340,76 @@ func (n *node) HandleDKGRevealShareMessage(msg types.Message, pkt transport.Pack
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling DKGRevealShareMessage from %v", pkt.Header.Source)
 
 	election := n.electionStore.Get(dkgRevealShareMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(dkgRevealShareMessage.ElectionID, pkt)
+	}
 
-	n.dkgMutex.Lock()
 	mixnetServers := election.Base.MixnetServers
 
 	if !contains(mixnetServers, n.myAddr) {
-		n.dkgMutex.Unlock()
 		return fmt.Errorf("node received DKGShareValidationMessage for electionID %s,"+
 			" but the node is not one of the mixnetServers", dkgRevealShareMessage.ElectionID)
 	}
 
-	mixnetServerInfo := election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID]
-	if mixnetServerInfo == nil {
-		mixnetServerInfo = &types.MixnetServerInfo{
-			ReceivedShare:   big.Int{},
-			X:               make([]types.Point, len(election.Base.MixnetServers)),
-			VerifiedCnt:     0,
-			ComplainedCnt:   0,
-			QualifiedStatus: types.NOT_DECIDED_YET,
-		}
-		election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID] = mixnetServerInfo
+	err := VerifyDKGRevealShare(election, dkgRevealShareMessage)
+	if err != nil {
+		return err
 	}
 
-	if election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].QualifiedStatus == types.NOT_DECIDED_YET {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	ready := false
+	complainingServerID := dkgRevealShareMessage.ComplainingServerID
+
+	n.electionStore.Update(dkgRevealShareMessage.ElectionID, func(election *types.Election) {
+		mixnetServerInfo := election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID]
+		if mixnetServerInfo == nil {
+			mixnetServerInfo = &types.MixnetServerInfo{
+				ReceivedShare:   big.Int{},
+				X:               make([]types.Point, len(election.Base.MixnetServers)),
+				VerifiedCnt:     0,
+				ComplainedCnt:   0,
+				QualifiedStatus: types.NOT_DECIDED_YET,
+			}
+			election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID] = mixnetServerInfo
+		}
+
+		if mixnetServerInfo.QualifiedStatus != types.NOT_DECIDED_YET {
+			return
+		}
+
+		// the share is revealed once, to answer a complaint
+		verified, validated := mixnetServerInfo.Validations[complainingServerID]
+		if !validated || verified {
+			return
+		}
+
 		j := big.NewInt(int64(dkgRevealShareMessage.MixnetServerID))
 		share := dkgRevealShareMessage.Share
-		X := election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].X
-		isValid := n.VerifyEquation(j, &share, X, election.Base.Threshold)
+		isValid := n.VerifyEquation(g, j, &share, mixnetServerInfo.X, election.Base.Threshold)
 
 		if !isValid {
-
-			election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].QualifiedStatus = types.DISQUALIFIED
-			XX, XY := elliptic.P256().ScalarBaseMult(make([]byte, 32))
-			election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].X[0].X,
-				election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].X[0].Y = *XX, *XY
-			if n.ShouldSendElectionReadyMessage(election) {
-				n.dkgMutex.Unlock()
-				n.sendElectionReadyMessage(election)
-				return nil
-			}
+			mixnetServerInfo.QualifiedStatus = types.DISQUALIFIED
+			mixnetServerInfo.X[0] = types.Point{} // the identity
+			ready = n.ShouldSendElectionReadyMessage(election)
 		} else {
-			election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].VerifiedCnt++
-			election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].ComplainedCnt--
-			if election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].VerifiedCnt == len(election.Base.MixnetServers) {
-				election.Base.MixnetServerInfos[dkgRevealShareMessage.MixnetServerID].QualifiedStatus = types.QUALIFIED
-				if n.ShouldSendElectionReadyMessage(election) {
-					n.dkgMutex.Unlock()
-					n.sendElectionReadyMessage(election)
-					return nil
-				}
+			mixnetServerInfo.Validations[complainingServerID] = true
+			mixnetServerInfo.VerifiedCnt++
+			mixnetServerInfo.ComplainedCnt--
+			if mixnetServerInfo.VerifiedCnt == len(election.Base.MixnetServers) {
+				mixnetServerInfo.QualifiedStatus = types.QUALIFIED
+				ready = n.ShouldSendElectionReadyMessage(election)
 			}
 		}
+	})
+
+	if ready {
+		n.sendElectionReadyMessage(election)
 	}
 
-	n.dkgMutex.Unlock()
 	return nil
 }
 
@@ -377,12 +417,23 @@ func (n *node) HandleDKGRevealShareMessage(msg types.Message, pkt transport.Pack
 func (n *node) sendElectionReadyMessage(election *types.Election) {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("sending ElectionReadyMessage")
 
-	qualifiedServers := n.GetQualifiedMixnetServers(election)
+	var qualifiedServers []int
+	shouldInitiate := false
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		qualifiedServers = n.GetQualifiedMixnetServers(election)
+		shouldInitiate = n.ShouldInitiateElection(election)
+	})
 
 	electionReadyMessage := types.ElectionReadyMessage{
 		ElectionID:       election.Base.ElectionID,
+		MixnetServerID:   election.GetMyMixnetServerID(n.myAddr),
 		QualifiedServers: qualifiedServers,
 	}
+	err := SignElectionReady(n.signingKey, &electionReadyMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign ElectionReadyMessage")
+		return
+	}
 	electionReadyTransportMessage, err := marshalMessage(&electionReadyMessage)
 	if err != nil {
 		return
@@ -393,7 +444,7 @@ func (n *node) sendElectionReadyMessage(election *types.Election) {
 	}
 
 	// send election start message if I am among the qualified nodes with the lowest ID
-	if n.ShouldInitiateElection(election) {
+	if shouldInitiate {
 		n.InitiateElection(election)
 	}
 }
@@ -409,47 +460,265 @@ func (n *node) HandleElectionReadyMessage(msg types.Message, pkt transport.Packe
 	}
 
 	// Processing ElectionReadyMessage
-	n.dkgMutex.Lock()
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling ElectionReadyMessage from %v", pkt.Header.Source)
 
-	// update QualifiedCnt for each mixnet server
 	election := n.electionStore.Get(electionReadyMessage.ElectionID)
 	if election == nil {
-		timeout := time.Second * 30
-		n.notfify.RegisterTimer(electionReadyMessage.ElectionID, timeout)
-		n.dkgMutex.Unlock()
-		_, ok := n.notfify.Wait(electionReadyMessage.ElectionID, timeout)
-		if !ok {
-			return errors.New("received ElectionReadyMessage before AnnounceElectionMessage")
+		return n.parkMessage(electionReadyMessage.ElectionID, pkt)
+	}
+
+	err := VerifyElectionReady(election, electionReadyMessage)
+	if err != nil {
+		return err
+	}
+
+	err = n.recordOnBulletinBoard(electionReadyMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	// update QualifiedCnt for each mixnet server
+	n.electionStore.Update(electionReadyMessage.ElectionID, func(election *types.Election) {
+		wasStarted := election.IsElectionStarted()
+
+		if !countElectionReady(election, electionReadyMessage) {
+			return
 		}
-		n.dkgMutex.Lock()
-		election = n.electionStore.Get(electionReadyMessage.ElectionID)
+		n.setPhase(election, types.PhaseDKG)
+
+		if !wasStarted && election.IsElectionStarted() {
+			n.openElection(election)
+		} else if election.Base.ElectionReadyCnt == len(election.Base.MixnetServers) &&
+			countQualifiedMixnetServers(election) < election.Base.Threshold {
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("too few mixnet servers qualified, aborting the election")
+			n.setPhase(election, types.PhaseAborted)
+		}
+	})
+
+	return nil
+}
+
+// countElectionReady adds the points of the mixnet servers qualified by the
+// message. A mixnet server counts once, and it gives at most one point to each
+// mixnet server. It returns false if the mixnet server was already counted.
+func countElectionReady(election *types.Election, electionReadyMessage *types.ElectionReadyMessage) bool {
+	if election.ReadyMixnetServers == nil {
+		election.ReadyMixnetServers = make(map[int]struct{})
+	}
+	if _, ok := election.ReadyMixnetServers[electionReadyMessage.MixnetServerID]; ok {
+		return false
 	}
+	election.ReadyMixnetServers[electionReadyMessage.MixnetServerID] = struct{}{}
 
+	qualified := make(map[int]struct{})
 	for _, qualifiedServerID := range electionReadyMessage.QualifiedServers {
+		if qualifiedServerID < 0 || qualifiedServerID >= len(election.Base.MixnetServersPoints) {
+			continue
+		}
+		if _, ok := qualified[qualifiedServerID]; ok {
+			continue
+		}
+		qualified[qualifiedServerID] = struct{}{}
 		election.Base.MixnetServersPoints[qualifiedServerID]++
 	}
 	election.Base.ElectionReadyCnt++
 
-	if election.IsElectionStarted() {
-		election.VoteWG.Done()
-		log.Info().Str("peerAddr", n.myAddr).Msgf("election started, I am allowed to cast a vote", pkt.Header.Source)
+	return true
+}
+
+// electionReadyDigest returns what a mixnet server signs to tell which mixnet
+// servers it qualified
+func electionReadyDigest(electionReadyMessage *types.ElectionReadyMessage) []byte {
+	return transport.Digest([]byte("ready"), []byte(electionReadyMessage.ElectionID),
+		[]byte(strconv.Itoa(electionReadyMessage.MixnetServerID)),
+		[]byte(fmt.Sprint(electionReadyMessage.QualifiedServers)))
+}
+
+// SignElectionReady signs the message with the long-term signing key of the
+// mixnet server
+func SignElectionReady(signingKey *ecdsa.PrivateKey, electionReadyMessage *types.ElectionReadyMessage) error {
+	signature, err := transport.SignDigest(signingKey, electionReadyDigest(electionReadyMessage))
+	if err != nil {
+		return fmt.Errorf("failed to sign ElectionReadyMessage: %v", err)
+	}
+
+	electionReadyMessage.Signature = signature
+
+	return nil
+}
+
+// VerifyElectionReady checks that the message is signed by the mixnet server
+// it comes from, with the key pinned in the announcement
+func VerifyElectionReady(election *types.Election, electionReadyMessage *types.ElectionReadyMessage) error {
+	signingKey, err := mixnetServerKey(election, electionReadyMessage.MixnetServerID)
+	if err != nil {
+		return err
+	}
+
+	err = transport.VerifyDigest(signingKey, electionReadyDigest(electionReadyMessage), electionReadyMessage.Signature)
+	if err != nil {
+		return fmt.Errorf("ElectionReadyMessage not signed by mixnet server %d: %v",
+			electionReadyMessage.MixnetServerID, err)
 	}
-	n.dkgMutex.Unlock()
+
 	return nil
 }
 
+// dkgShareDigest returns what a mixnet server signs to deal a share
+func dkgShareDigest(dkgShareMessage *types.DKGShareMessage) []byte {
+	fields := [][]byte{[]byte("dkg-share"), []byte(dkgShareMessage.ElectionID),
+		[]byte(strconv.Itoa(dkgShareMessage.MixnetServerID)), dkgShareMessage.Share.Bytes()}
+	for _, x := range dkgShareMessage.X {
+		fields = append(fields, x.X.Bytes(), x.Y.Bytes())
+	}
+
+	return transport.Digest(fields...)
+}
+
+// SignDKGShare signs the share with the long-term signing key of the dealer
+func SignDKGShare(signingKey *ecdsa.PrivateKey, dkgShareMessage *types.DKGShareMessage) error {
+	signature, err := transport.SignDigest(signingKey, dkgShareDigest(dkgShareMessage))
+	if err != nil {
+		return fmt.Errorf("failed to sign DKGShareMessage: %v", err)
+	}
+
+	dkgShareMessage.Signature = signature
+
+	return nil
+}
+
+// VerifyDKGShare checks that the share is signed by the mixnet server which
+// deals it, with the key pinned in the announcement
+func VerifyDKGShare(election *types.Election, dkgShareMessage *types.DKGShareMessage) error {
+	return verifyMixnetServerSignature(election, dkgShareMessage.MixnetServerID,
+		dkgShareDigest(dkgShareMessage), dkgShareMessage.Signature, dkgShareMessage.Name())
+}
+
+// dkgShareValidationDigest returns what a mixnet server signs to validate or
+// complain about a share
+func dkgShareValidationDigest(dkgShareValidationMessage *types.DKGShareValidationMessage) []byte {
+	return transport.Digest([]byte("dkg-share-validation"), []byte(dkgShareValidationMessage.ElectionID),
+		[]byte(strconv.Itoa(dkgShareValidationMessage.MixnetServerID)),
+		[]byte(strconv.Itoa(dkgShareValidationMessage.ValidatorID)),
+		[]byte(strconv.FormatBool(dkgShareValidationMessage.IsShareValid)))
+}
+
+// SignDKGShareValidation signs the validation with the long-term signing key
+// of the validator
+func SignDKGShareValidation(signingKey *ecdsa.PrivateKey,
+	dkgShareValidationMessage *types.DKGShareValidationMessage) error {
+
+	signature, err := transport.SignDigest(signingKey, dkgShareValidationDigest(dkgShareValidationMessage))
+	if err != nil {
+		return fmt.Errorf("failed to sign DKGShareValidationMessage: %v", err)
+	}
+
+	dkgShareValidationMessage.Signature = signature
+
+	return nil
+}
+
+// VerifyDKGShareValidation checks that the validation is signed by its
+// validator, with the key pinned in the announcement, and that it is about the
+// share of a mixnet server of the election
+func VerifyDKGShareValidation(election *types.Election,
+	dkgShareValidationMessage *types.DKGShareValidationMessage) error {
+
+	_, err := mixnetServerKey(election, dkgShareValidationMessage.MixnetServerID)
+	if err != nil {
+		return err
+	}
+
+	return verifyMixnetServerSignature(election, dkgShareValidationMessage.ValidatorID,
+		dkgShareValidationDigest(dkgShareValidationMessage), dkgShareValidationMessage.Signature,
+		dkgShareValidationMessage.Name())
+}
+
+// dkgRevealShareDigest returns what a mixnet server signs to reveal a share
+func dkgRevealShareDigest(dkgRevealShareMessage *types.DKGRevealShareMessage) []byte {
+	return transport.Digest([]byte("dkg-reveal-share"), []byte(dkgRevealShareMessage.ElectionID),
+		dkgRevealShareMessage.Share.Bytes(), []byte(strconv.Itoa(dkgRevealShareMessage.MixnetServerID)),
+		[]byte(strconv.Itoa(dkgRevealShareMessage.ComplainingServerID)))
+}
+
+// SignDKGRevealShare signs the revealed share with the long-term signing key
+// of its dealer
+func SignDKGRevealShare(signingKey *ecdsa.PrivateKey, dkgRevealShareMessage *types.DKGRevealShareMessage) error {
+	signature, err := transport.SignDigest(signingKey, dkgRevealShareDigest(dkgRevealShareMessage))
+	if err != nil {
+		return fmt.Errorf("failed to sign DKGRevealShareMessage: %v", err)
+	}
+
+	dkgRevealShareMessage.Signature = signature
+
+	return nil
+}
+
+// VerifyDKGRevealShare checks that the revealed share is signed by its dealer,
+// with the key pinned in the announcement, and answers a mixnet server of the
+// election
+func VerifyDKGRevealShare(election *types.Election, dkgRevealShareMessage *types.DKGRevealShareMessage) error {
+	_, err := mixnetServerKey(election, dkgRevealShareMessage.ComplainingServerID)
+	if err != nil {
+		return err
+	}
+
+	return verifyMixnetServerSignature(election, dkgRevealShareMessage.MixnetServerID,
+		dkgRevealShareDigest(dkgRevealShareMessage), dkgRevealShareMessage.Signature, dkgRevealShareMessage.Name())
+}
+
+// verifyMixnetServerSignature checks the signature of a message of the mixnet
+// server against the key pinned in the announcement
+func verifyMixnetServerSignature(election *types.Election, mixnetServerID int, digest []byte, signature []byte,
+	name string) error {
+
+	signingKey, err := mixnetServerKey(election, mixnetServerID)
+	if err != nil {
+		return err
+	}
+
+	err = transport.VerifyDigest(signingKey, digest, signature)
+	if err != nil {
+		return fmt.Errorf("%s not signed by mixnet server %d: %v", name, mixnetServerID, err)
+	}
+
+	return nil
+}
+
+// mixnetServerKey returns the signing key of the mixnet server pinned in the
+// announcement
+func mixnetServerKey(election *types.Election, mixnetServerID int) ([]byte, error) {
+	if mixnetServerID < 0 || mixnetServerID >= len(election.Base.MixnetServers) ||
+		mixnetServerID >= len(election.Base.MixnetServerKeys) || election.Base.MixnetServerKeys[mixnetServerID] == nil {
+		return nil, fmt.Errorf("mixnet server %d has no pinned key", mixnetServerID)
+	}
+
+	return election.Base.MixnetServerKeys[mixnetServerID], nil
+}
+
 // sendStartElectionMessage creates a new types.StartElectionMessage, and broadcasts it to all the peers in the network,
 func (n *node) sendStartElectionMessage(election *types.Election) {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("sending StartElectionMessage")
 
-	publicKey := n.ReconstructPublicKey(election)
 	startElectionMessage := types.StartElectionMessage{
 		ElectionID: election.Base.ElectionID,
-		Expiration: election.Base.Expiration,
-		PublicKey:  publicKey,
 		Initiator:  n.myAddr,
 	}
+	var err error
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		startElectionMessage.Expiration = election.Base.Expiration
+		startElectionMessage.PublicKey, err = n.ReconstructPublicKey(election)
+	})
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to reconstruct the public key")
+		return
+	}
+
+	err = SignStartElection(n.signingKey, &startElectionMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign StartElectionMessage")
+		return
+	}
 
 	msg, err := marshalMessage(&startElectionMessage)
 
@@ -473,44 +742,81 @@ func (n *node) HandleStartElectionMessage(msg types.Message, pkt transport.Packe
 	}
 
 	// Processing types.StartElectionMessage
-	n.dkgMutex.Lock()
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling StartElectionMessage from %v", pkt.Header.Source)
 
 	election := n.electionStore.Get(startElectionMessage.ElectionID)
 	if election == nil {
-		timeout := time.Second * 30
-		n.notfify.RegisterTimer(startElectionMessage.ElectionID, timeout)
-		n.dkgMutex.Unlock()
-		_, ok := n.notfify.Wait(startElectionMessage.ElectionID, timeout)
-		if !ok {
-			return errors.New("received StartElectionMessage before AnnounceElectionMessage")
-		}
-		n.dkgMutex.Lock()
-		election = n.electionStore.Get(startElectionMessage.ElectionID)
-	}
-
-	election.Base.Initiators[startElectionMessage.Initiator] = startElectionMessage.PublicKey
-	election.Base.Expiration = startElectionMessage.Expiration
-
-	if election.IsElectionStarted() {
-		election.VoteWG.Done()
-		log.Info().Str("peerAddr", n.myAddr).Msgf("election started, I am allowed to cast a vote!")
-	}
-	//else {
-	//	initiator := election.GetFirstQualifiedInitiator()
-	//	_, e := election.Base.Initiators[initiator]
-	//
-	//	fmt.Printf("myaddr: %s | src %s, cnt %t, initiatortreba: %s,initiator ex: %t\n", n.myAddr, pkt.Header.Source, election.Base.ElectionReadyCnt == len(election.Base.MixnetServers), initiator, e)
-	//
-	//}
+		return n.parkMessage(startElectionMessage.ElectionID, pkt)
+	}
 
-	n.dkgMutex.Unlock()
+	err := VerifyStartElection(election, startElectionMessage)
+	if err != nil {
+		return err
+	}
+
+	err = n.recordOnBulletinBoard(startElectionMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	n.electionStore.Update(startElectionMessage.ElectionID, func(election *types.Election) {
+		wasStarted := election.IsElectionStarted()
+
+		election.Base.Initiators[startElectionMessage.Initiator] = startElectionMessage.PublicKey
+		// the announcer may have extended the election already
+		if startElectionMessage.Expiration.After(election.Base.Expiration) {
+			election.Base.Expiration = startElectionMessage.Expiration
+		}
+
+		if !wasStarted && election.IsElectionStarted() {
+			n.openElection(election)
+		}
+	})
 
 	log.Info().Str("peerAddr", n.myAddr).Msgf("processing StartElectionMessage from %v done", pkt.Header.Source)
 
 	return nil
 }
 
+// startElectionDigest returns what the initiator signs to start the election
+func startElectionDigest(startElectionMessage *types.StartElectionMessage) []byte {
+	var expiration [8]byte
+	binary.BigEndian.PutUint64(expiration[:], uint64(startElectionMessage.Expiration.UnixNano()))
+
+	return transport.Digest([]byte("start"), []byte(startElectionMessage.ElectionID), expiration[:],
+		startElectionMessage.PublicKey.X.Bytes(), startElectionMessage.PublicKey.Y.Bytes(),
+		[]byte(startElectionMessage.Initiator))
+}
+
+// SignStartElection signs the message with the long-term signing key of the
+// initiator
+func SignStartElection(signingKey *ecdsa.PrivateKey, startElectionMessage *types.StartElectionMessage) error {
+	signature, err := transport.SignDigest(signingKey, startElectionDigest(startElectionMessage))
+	if err != nil {
+		return fmt.Errorf("failed to sign StartElectionMessage: %v", err)
+	}
+
+	startElectionMessage.Signature = signature
+
+	return nil
+}
+
+// VerifyStartElection checks that the message is signed by its initiator, a
+// mixnet server with the key pinned in the announcement
+func VerifyStartElection(election *types.Election, startElectionMessage *types.StartElectionMessage) error {
+	signingKey, err := mixnetServerKey(election, election.GetMyMixnetServerID(startElectionMessage.Initiator))
+	if err != nil {
+		return err
+	}
+
+	err = transport.VerifyDigest(signingKey, startElectionDigest(startElectionMessage), startElectionMessage.Signature)
+	if err != nil {
+		return fmt.Errorf("StartElectionMessage not signed by %s: %v", startElectionMessage.Initiator, err)
+	}
+
+	return nil
+}
+
 // ShouldInitiateElection checks whether mixnet node should start the election
 func (n *node) ShouldInitiateElection(election *types.Election) bool {
 	myID := election.GetMyMixnetServerID(n.myAddr)
@@ -519,26 +825,125 @@ func (n *node) ShouldInitiateElection(election *types.Election) bool {
 }
 
 // InitiateElection sends types.StartElectionMessage indicating that the election
-// has officially started and that the peers are allowed to cast their votes.
+// has officially started and that the peers are allowed to cast their votes,
+// from its opening time if it is scheduled.
 func (n *node) InitiateElection(election *types.Election) {
-
-	election.Base.Expiration = time.Now().Add(election.Base.Duration)
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		if election.Base.OpeningTime.IsZero() {
+			election.Base.Expiration = time.Now().Add(election.Base.Duration)
+		}
+	})
 	n.sendStartElectionMessage(election)
 
+	n.scheduleMixing(election)
+}
+
+// scheduleMixing starts the mixing once the election expires, or stops if
+// the announcer cancels it (see waitForExpiration). The initiator is the first
+// mixnet server of the chain. In homomorphic mode, the initiator tallies the
+// ballots it stored right away. In a re-voting election, it first keeps the
+// last ballot of each credential (see deduplicateBallots). If the quorum is not
+// reached, the election finishes without results (see checkTurnout).
+func (n *node) scheduleMixing(election *types.Election) {
 	go func() {
 		// wait until the set expiration date until tallying votes
-		expireIn := election.Base.Expiration.Sub(time.Now())
-		<-time.After(expireIn)
+		if !n.waitForExpiration(election) {
+			log.Info().Str("peerAddr", n.myAddr).Msgf("election %s aborted, not mixing", election.Base.ElectionID)
+			return
+		}
+
+		if election.Base.Revoting {
+			kept, err := n.deduplicateBallots(election.Base.ElectionID)
+			if err != nil {
+				log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to deduplicate the ballots, aborting the election")
+				n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+					n.setPhase(election, types.PhaseAborted)
+				})
+				return
+			}
+
+			n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+				election.Votes = kept
+			})
+		}
+
+		if !n.checkTurnout(election) {
+			return
+		}
+
+		if election.Base.TallyMode == types.TallyHomomorphic {
+			log.Info().Str("peerAddr", n.myAddr).Msgf("Election expired, starting tallying")
+			n.Tally(election.Base.ElectionID, n.getVotes(election))
+			return
+		}
 
 		// mix and forward
 		log.Info().Str("peerAddr", n.myAddr).Msgf("Election expired, starting mixing")
-		// send to ourselves a MixMessage (hop 0) so we can bootstrap the mixing process
-		n.Mix(election.Base.ElectionID, INITIAL_MIX_HOP, make([]types.ShuffleProof, 0), make([]types.Proof, 0))
-		election.MixingStartedTimestamp = time.Now()
-		// n.Mix(election.Base.ElectionID, 0)
+		n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+			election.MixingStartedTimestamp = time.Now()
+			n.setPhase(election, types.PhaseMixing)
+		})
+		err := n.Mix(election.Base.ElectionID, election.GetMyMixnetServerID(n.myAddr), make([]types.ShuffleProof, 0),
+			make([]types.ShuffleProof, 0), make([]types.Proof, 0))
+		if err != nil {
+			log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to mix votes")
+		}
 	}()
 }
 
+// openElection moves the election to the open phase, in which votes are
+// accepted, until it expires. A scheduled election waits for its opening time.
+// It must be called within an update of the election.
+func (n *node) openElection(election *types.Election) {
+	wait := time.Until(election.Base.OpeningTime)
+	if wait > 0 {
+		log.Info().Str("peerAddr", n.myAddr).Msgf("election %s opens at %s",
+			election.Base.ElectionID, election.Base.OpeningTime)
+		time.AfterFunc(wait, func() {
+			n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+				n.openElection(election)
+			})
+		})
+		return
+	}
+
+	if n.setPhase(election, types.PhaseOpen) {
+		log.Info().Str("peerAddr", n.myAddr).Msgf("election started, I am allowed to cast a vote")
+		n.scheduleClosing(election)
+	}
+}
+
+// resumeElections re-arms the timers of the elections loaded from the storage:
+// the key generations get a new deadline, scheduled elections are opened at
+// their opening time, open elections are closed once they expire, and the
+// initiator starts the mixing if it did not already.
+func (n *node) resumeElections() {
+	for _, election := range n.electionStore.GetAll() {
+		var phase types.Phase
+		var started, isInitiator bool
+
+		n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+			phase = election.Phase
+			started = election.IsElectionStarted()
+			isInitiator = election.Base.MixnetServerInfos != nil && n.ShouldInitiateElection(election)
+			if started && phase < types.PhaseOpen {
+				n.openElection(election)
+			}
+			if !started && !phase.IsFinal() {
+				n.scheduleReadyDeadline(election)
+			}
+			if phase == types.PhaseOpen {
+				n.scheduleClosing(election)
+			}
+		})
+
+		if isInitiator && started && phase <= types.PhaseClosed {
+			log.Info().Str("peerAddr", n.myAddr).Msgf("resuming election %s", election.Base.ElectionID)
+			n.scheduleMixing(election)
+		}
+	}
+}
+
 // GetMixnetServerInitiatorID returns the ID of the mixnet node which is responsible for
 // starting the election, that is, the ID of a qualified mixnet node with the lowest ID
 func (n *node) GetMixnetServerInitiatorID(election *types.Election) int {
@@ -567,7 +972,8 @@ func (n *node) GetQualifiedMixnetServers(election *types.Election) []int {
 // (types.QUALIFIED or types.DISQUALIFIED)
 func (n *node) ShouldSendElectionReadyMessage(election *types.Election) bool {
 	for _, mixnetServerInfo := range election.Base.MixnetServerInfos {
-		if mixnetServerInfo.QualifiedStatus == types.NOT_DECIDED_YET {
+		// nothing was received yet from this mixnet server
+		if mixnetServerInfo == nil || mixnetServerInfo.QualifiedStatus == types.NOT_DECIDED_YET {
 			return false
 		}
 	}
@@ -575,24 +981,91 @@ func (n *node) ShouldSendElectionReadyMessage(election *types.Election) bool {
 }
 
 // VerifyEquation verifies if the received share is valid as a part of the second step
-// of the Pedersen DKG protocol.
-func (n *node) VerifyEquation(j *big.Int, share *big.Int, X []types.Point, t int) bool {
-	leftSideX, leftSideY := elliptic.P256().ScalarBaseMult(share.Bytes())
-	productValX, productValY := elliptic.P256().ScalarBaseMult(make([]byte, 32))
-	for k := 0; k <= t; k++ {
+// of the Pedersen DKG protocol. X holds the t commitments to the coefficients
+// of the dealer's polynomial, which must be elements of the group.
+func (n *node) VerifyEquation(g group.Group, j *big.Int, share *big.Int, X []types.Point, t int) bool {
+	if len(X) < t {
+		return false
+	}
+
+	commitments, err := decodePoints(g, X[:t]...)
+	if err != nil {
+		return false
+	}
+
+	leftSide := g.BaseMul(g.ScalarFromInt(share))
+	productVal := g.Identity()
+	for k := 0; k < t; k++ {
 		exp := new(big.Int).Exp(j, big.NewInt(int64(k)), nil)
-		factorX, factorY := elliptic.P256().ScalarMult(&X[k].X, &X[k].Y, exp.Bytes())
-		productValX, productValY = elliptic.P256().Add(factorX, factorY, productValX, productValY)
+		productVal = productVal.Add(commitments[k].Mul(g.ScalarFromInt(exp)))
 	}
-	return leftSideX.Cmp(productValX) == 0 && leftSideY.Cmp(productValY) == 0
+	return leftSide.Equal(productVal)
 }
 
 // ReconstructPublicKey reconstructs the public value of the distributed shared key
-func (n *node) ReconstructPublicKey(election *types.Election) types.Point {
-	productValX, productValY := elliptic.P256().ScalarBaseMult(make([]byte, 32))
+func (n *node) ReconstructPublicKey(election *types.Election) (types.Point, error) {
+	g, err := electionGroup(election)
+	if err != nil {
+		return types.Point{}, err
+	}
+
+	productVal := g.Identity()
+	for _, server := range election.Base.MixnetServerInfos {
+		X0, err := g.ElementFromPoint(server.X[0])
+		if err != nil {
+			return types.Point{}, err
+		}
+		productVal = productVal.Add(X0)
+	}
+
+	return productVal.Point(), nil
+}
+
+// GetSecretShare returns the share of the distributed secret key held by this
+// mixnet server, that is, the sum of the shares received from the qualified
+// mixnet servers.
+func (n *node) GetSecretShare(g group.Group, election *types.Election) big.Int {
+	secretShare := new(big.Int)
 	for _, server := range election.Base.MixnetServerInfos {
-		productValX, productValY = elliptic.P256().Add(&server.X[0].X, &server.X[0].Y, productValX, productValY)
+		if server != nil && server.QualifiedStatus == types.QUALIFIED {
+			secretShare.Add(secretShare, &server.ReceivedShare)
+		}
+	}
+
+	return *secretShare.Mod(secretShare, g.Order())
+}
+
+// GetPublicShare returns the public value x_j*G of the secret key share of the
+// mixnet server with the given ID. It is derived from the commitments X of the
+// qualified mixnet servers.
+func (n *node) GetPublicShare(g group.Group, election *types.Election, mixnetServerID int) (types.Point, error) {
+	commitments := make([][]types.Point, 0, len(election.Base.MixnetServerInfos))
+	for _, server := range election.Base.MixnetServerInfos {
+		if server != nil && server.QualifiedStatus == types.QUALIFIED {
+			commitments = append(commitments, server.X)
+		}
+	}
+
+	return ComputePublicShare(g, commitments, mixnetServerID)
+}
+
+// ComputePublicShare computes x_j*G = sum_i sum_k X_i[k]*(j+1)^k over the
+// commitments X_i of the qualified mixnet servers, the same way VerifyEquation
+// checks a single share. It fails if a commitment is not an element of the
+// group.
+func ComputePublicShare(g group.Group, commitments [][]types.Point, mixnetServerID int) (types.Point, error) {
+	j := big.NewInt(int64(mixnetServerID + 1))
+	productVal := g.Identity()
+	for _, X := range commitments {
+		elements, err := decodePoints(g, X...)
+		if err != nil {
+			return types.Point{}, err
+		}
+		for k := range elements {
+			exp := new(big.Int).Exp(j, big.NewInt(int64(k)), nil)
+			productVal = productVal.Add(elements[k].Mul(g.ScalarFromInt(exp)))
+		}
 	}
 
-	return NewPoint(productValX, productValY)
+	return productVal.Point(), nil
 }
@@ -0,0 +1,52 @@
+package impl
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/peer/impl/pendingqueue"
+	"go.dedis.ch/cs438/transport"
+)
+
+const (
+	// maxPendingMessages is the number of messages parked for an election
+	// which is not announced yet
+	maxPendingMessages = 256
+	// maxPendingElections is the number of unknown elections with parked
+	// messages
+	maxPendingElections = 64
+	// pendingMessageTTL is how long a message waits for the announcement of
+	// its election
+	pendingMessageTTL = time.Second * 30
+)
+
+// parkMessage parks the message of an election which is not announced yet,
+// the announcement replays it (see replayPendingMessages). If the
+// announcement arrived meanwhile, the message is processed right away.
+func (n *node) parkMessage(electionID string, pkt transport.Packet) error {
+	err := n.pendingMessages.Park(electionID, pkt)
+
+	switch {
+	case errors.Is(err, pendingqueue.ErrReleased) && n.electionStore.Exists(electionID):
+		return n.conf.MessageRegistry.ProcessPacket(pkt)
+	case err != nil:
+		return fmt.Errorf("received %s for unknown election %s: %v", pkt.Msg.Type, electionID, err)
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("parking %s until election %s is announced", pkt.Msg.Type, electionID)
+
+	return nil
+}
+
+// replayPendingMessages processes the messages which arrived before the
+// announcement of the election, in order
+func (n *node) replayPendingMessages(electionID string) {
+	for _, pkt := range n.pendingMessages.Release(electionID) {
+		err := n.conf.MessageRegistry.ProcessPacket(pkt)
+		if err != nil {
+			log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to replay %s", pkt.Msg.Type)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package pendingqueue
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.dedis.ch/cs438/transport"
+)
+
+var (
+	// ErrReleased is returned by Park if the election is already released:
+	// the packet can be processed right away.
+	ErrReleased = errors.New("the election is already released")
+
+	// ErrFull is returned by Park if the queue of the election, or the number
+	// of elections with parked packets, reached its bound.
+	ErrFull = errors.New("too many pending messages")
+)
+
+// PendingQueue parks the packets of the elections which are not known yet,
+// until their announcement releases them.
+type PendingQueue interface {
+	// Park queues the packet of the election. It returns ErrReleased if the
+	// election was released, and ErrFull if the packet is dropped.
+	Park(electionID string, pkt transport.Packet) error
+
+	// Release returns the packets of the election which did not expire, in
+	// the order they were parked. The election is released: its next packets
+	// are not parked anymore.
+	Release(electionID string) []transport.Packet
+}
+
+// New returns a new in-memory pending queue. It holds up to maxPackets
+// packets for each of up to maxElections elections, for ttl each.
+func New(maxPackets, maxElections int, ttl time.Duration) PendingQueue {
+	return &queue{
+		maxPackets:   maxPackets,
+		maxElections: maxElections,
+		ttl:          ttl,
+		parked:       make(map[string][]parkedPacket),
+		released:     make(map[string]struct{}),
+	}
+}
+
+type parkedPacket struct {
+	pkt        transport.Packet
+	expiration time.Time
+}
+
+// queue implements PendingQueue
+type queue struct {
+	sync.Mutex
+	maxPackets   int
+	maxElections int
+	ttl          time.Duration
+
+	parked   map[string][]parkedPacket
+	released map[string]struct{}
+}
+
+// Park implements PendingQueue
+func (q *queue) Park(electionID string, pkt transport.Packet) error {
+	q.Lock()
+	defer q.Unlock()
+
+	if _, ok := q.released[electionID]; ok {
+		return ErrReleased
+	}
+
+	q.expire(time.Now())
+
+	packets, ok := q.parked[electionID]
+	if !ok && len(q.parked) >= q.maxElections {
+		return ErrFull
+	}
+	if len(packets) >= q.maxPackets {
+		return ErrFull
+	}
+
+	q.parked[electionID] = append(packets, parkedPacket{
+		pkt:        pkt,
+		expiration: time.Now().Add(q.ttl),
+	})
+
+	return nil
+}
+
+// Release implements PendingQueue
+func (q *queue) Release(electionID string) []transport.Packet {
+	q.Lock()
+	defer q.Unlock()
+
+	q.expire(time.Now())
+
+	packets := make([]transport.Packet, len(q.parked[electionID]))
+	for i, parked := range q.parked[electionID] {
+		packets[i] = parked.pkt
+	}
+
+	delete(q.parked, electionID)
+	q.released[electionID] = struct{}{}
+
+	return packets
+}
+
+// expire drops the packets parked for longer than the ttl. The lock must be
+// held.
+func (q *queue) expire(now time.Time) {
+	for electionID, packets := range q.parked {
+		// packets are parked in order, so they expire in order
+		i := 0
+		for i < len(packets) && !now.Before(packets[i].expiration) {
+			i++
+		}
+
+		if i == len(packets) {
+			delete(q.parked, electionID)
+		} else {
+			q.parked[electionID] = packets[i:]
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package impl
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// SubscribePhase implements peer.Voting
+func (n *node) SubscribePhase(electionID string) (<-chan types.Phase, func(), error) {
+	var phases <-chan types.Phase
+	var unsubscribe func()
+
+	// phases are published under the lock of the election, the subscriber
+	// can't miss one
+	found := n.electionStore.View(electionID, func(election *types.Election) {
+		phases, unsubscribe = n.phaseWatch.Subscribe(electionID, election.Phase)
+	})
+	if !found {
+		return nil, nil, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	return phases, unsubscribe, nil
+}
+
+// waitForPhase blocks until the election reaches at least the given phase, and
+// returns the phase it is in at that time. It returns early if the context is
+// done.
+func (n *node) waitForPhase(ctx context.Context, electionID string, phase types.Phase) (types.Phase, error) {
+	phases, unsubscribe, err := n.SubscribePhase(electionID)
+	if err != nil {
+		return 0, err
+	}
+	defer unsubscribe()
+
+	for {
+		select {
+		case current, ok := <-phases:
+			if !ok {
+				return 0, xerrors.Errorf("stopped waiting for election %s to be %s", electionID, phase)
+			}
+			if current >= phase {
+				return current, nil
+			}
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// setPhase moves the election to the given phase and notifies the subscribers.
+// Invalid transitions, such as going back to a previous phase because of a
+// late message, are ignored. It must be called within an update of the
+// election, which persists it.
+func (n *node) setPhase(election *types.Election, phase types.Phase) bool {
+	if !election.Phase.CanTransitionTo(phase) {
+		log.Debug().Str("peerAddr", n.myAddr).Msgf("ignoring transition of election %s from %s to %s",
+			election.Base.ElectionID, election.Phase, phase)
+		return false
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("election %s: %s -> %s", election.Base.ElectionID, election.Phase, phase)
+
+	election.Phase = phase
+	n.phaseWatch.Publish(election.Base.ElectionID, phase)
+
+	return true
+}
+
+// scheduleClosing closes the election once it expires. If the announcer
+// extended it meanwhile, the closing is scheduled again.
+func (n *node) scheduleClosing(election *types.Election) {
+	time.AfterFunc(time.Until(election.Base.Expiration), func() {
+		n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+			if time.Now().Before(election.Base.Expiration) {
+				n.scheduleClosing(election)
+				return
+			}
+
+			n.setPhase(election, types.PhaseClosed)
+		})
+	})
+}
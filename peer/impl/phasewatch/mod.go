@@ -0,0 +1,133 @@
+package phasewatch
+
+import (
+	"sync"
+
+	"go.dedis.ch/cs438/types"
+)
+
+// PhaseWatch dispatches the phase changes of the elections to their
+// subscribers.
+type PhaseWatch interface {
+	// Subscribe returns a channel receiving the current phase of the election
+	// followed by the next ones, and a function to unsubscribe. The channel is
+	// closed once a final phase was sent, on unsubscribe, or when the watch is
+	// closed.
+	Subscribe(electionID string, current types.Phase) (<-chan types.Phase, func())
+
+	// Publish sends the phase to all the subscribers of the election. It never
+	// blocks.
+	Publish(electionID string, phase types.Phase)
+
+	// Close closes all the subscriptions
+	Close()
+}
+
+// New returns a new phase watch.
+func New() PhaseWatch {
+	return &watch{
+		subscribers: make(map[string]map[*subscriber]struct{}),
+	}
+}
+
+// subscriberBuffer is large enough to hold all the phases of an election, as
+// phases only move forward. Publish can therefore never block.
+const subscriberBuffer = int(types.PhaseAborted) + 1
+
+type subscriber struct {
+	c chan types.Phase
+}
+
+// watch implements PhaseWatch
+type watch struct {
+	sync.Mutex
+	subscribers map[string]map[*subscriber]struct{}
+	closed      bool
+}
+
+// Subscribe implements PhaseWatch
+func (w *watch) Subscribe(electionID string, current types.Phase) (<-chan types.Phase, func()) {
+	w.Lock()
+	defer w.Unlock()
+
+	sub := &subscriber{
+		c: make(chan types.Phase, subscriberBuffer),
+	}
+
+	if w.closed {
+		close(sub.c)
+		return sub.c, func() {}
+	}
+
+	sub.c <- current
+	if current.IsFinal() {
+		close(sub.c)
+		return sub.c, func() {}
+	}
+
+	if w.subscribers[electionID] == nil {
+		w.subscribers[electionID] = make(map[*subscriber]struct{})
+	}
+	w.subscribers[electionID][sub] = struct{}{}
+
+	unsubscribe := func() {
+		w.Lock()
+		defer w.Unlock()
+
+		w.remove(electionID, sub)
+	}
+
+	return sub.c, unsubscribe
+}
+
+// Publish implements PhaseWatch
+func (w *watch) Publish(electionID string, phase types.Phase) {
+	w.Lock()
+	defer w.Unlock()
+
+	for sub := range w.subscribers[electionID] {
+		select {
+		case sub.c <- phase:
+		default:
+			// only a misbehaving publisher can fill the buffer
+		}
+
+		if phase.IsFinal() {
+			w.remove(electionID, sub)
+		}
+	}
+}
+
+// Close implements PhaseWatch
+func (w *watch) Close() {
+	w.Lock()
+	defer w.Unlock()
+
+	for electionID, subs := range w.subscribers {
+		for sub := range subs {
+			w.remove(electionID, sub)
+		}
+	}
+	w.closed = true
+}
+
+// remove closes the channel of the subscriber, if not already done. The lock
+// must be held.
+func (w *watch) remove(electionID string, sub *subscriber) {
+	subs, ok := w.subscribers[electionID]
+	if !ok {
+		return
+	}
+
+	_, ok = subs[sub]
+	if !ok {
+		return
+	}
+
+	close(sub.c)
+	delete(subs, sub)
+
+	if len(subs) == 0 {
+		delete(w.subscribers, electionID)
+	}
+}
@@ -0,0 +1,285 @@
+package impl
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/storage"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+const (
+	// encryptionKeyName is the name of the long-term ECDH key in the key store
+	encryptionKeyName = "encryption"
+
+	// privateMessageLabel separates the keys of the private messages from
+	// other uses of the shared secret
+	privateMessageLabel = "cs438 private message"
+
+	// nodeKeyTimeout is how long a peer waits for the public key of a
+	// recipient before giving up on sending it a private message
+	nodeKeyTimeout = time.Second * 10
+)
+
+// loadOrCreateKey returns the long-term key stored under the name, and
+// generates and stores a new one if there is none.
+func loadOrCreateKey(store storage.Store, name string) (*big.Int, error) {
+	stored := store.Get(name)
+	if stored != nil {
+		return new(big.Int).SetBytes(stored), nil
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to generate key %s: %v", name, err)
+	}
+
+	store.Set(name, privateKey.D.Bytes())
+
+	return privateKey.D, nil
+}
+
+// KeyFingerprint returns the fingerprint which identifies a long-term public key
+func KeyFingerprint(publicKey types.Point) string {
+	h := sha256.New()
+	h.Write(publicKey.X.Bytes())
+	h.Write(publicKey.Y.Bytes())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// privateMessageKey derives the AEAD key shared by the owner of secret and the
+// owner of the public key (static ECDH on P-256)
+func privateMessageKey(secret *big.Int, publicKey types.Point) (cipher.AEAD, error) {
+	curve := elliptic.P256()
+	if !curve.IsOnCurve(&publicKey.X, &publicKey.Y) {
+		return nil, errors.New("public key is not on the curve")
+	}
+
+	sharedX, _ := curve.ScalarMult(&publicKey.X, &publicKey.Y, secret.Bytes())
+
+	h := sha256.New()
+	h.Write([]byte(privateMessageLabel))
+	h.Write(sharedX.FillBytes(make([]byte, 32)))
+
+	block, err := aes.NewCipher(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// privateMessageData returns the data authenticated with a payload: the
+// fingerprints of the sender's and of the recipient's keys, and the address of
+// the sender.
+func privateMessageData(sender string, senderKey types.Point, recipientFingerprint string) []byte {
+	return []byte(KeyFingerprint(senderKey) + recipientFingerprint + sender)
+}
+
+// SealPrivateMessage encrypts the message for each recipient public key. The
+// sender and the recipient are authenticated with the payload.
+func SealPrivateMessage(secret *big.Int, sender string, senderKey types.Point, recipientKeys []types.Point,
+	msg *transport.Message) (types.PrivateMessage, error) {
+
+	plaintext, err := json.Marshal(msg)
+	if err != nil {
+		return types.PrivateMessage{}, err
+	}
+
+	payloads := make(map[string][]byte, len(recipientKeys))
+
+	for _, recipientKey := range recipientKeys {
+		aead, err := privateMessageKey(secret, recipientKey)
+		if err != nil {
+			return types.PrivateMessage{}, err
+		}
+
+		nonce := make([]byte, aead.NonceSize())
+		_, err = rand.Read(nonce)
+		if err != nil {
+			return types.PrivateMessage{}, err
+		}
+
+		fingerprint := KeyFingerprint(recipientKey)
+		additionalData := privateMessageData(sender, senderKey, fingerprint)
+
+		payloads[fingerprint] = aead.Seal(nonce, nonce, plaintext, additionalData)
+	}
+
+	return types.PrivateMessage{
+		Sender:    sender,
+		SenderKey: &senderKey,
+		Payloads:  payloads,
+	}, nil
+}
+
+// openPrivateMessage decrypts the payload of the owner of secret. It returns
+// false if there is no payload for this key. The caller checks that SenderKey
+// is the key published by the sender (see checkPrivateMessageSender).
+func openPrivateMessage(secret *big.Int, publicKey types.Point, privateMessage *types.PrivateMessage) (*transport.Message, bool, error) {
+	fingerprint := KeyFingerprint(publicKey)
+
+	payload, ok := privateMessage.Payloads[fingerprint]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if privateMessage.SenderKey == nil {
+		return nil, true, errors.New("encrypted private message without sender key")
+	}
+
+	aead, err := privateMessageKey(secret, *privateMessage.SenderKey)
+	if err != nil {
+		return nil, true, err
+	}
+
+	if len(payload) < aead.NonceSize() {
+		return nil, true, errors.New("encrypted private message is too short")
+	}
+
+	nonce, ciphertext := payload[:aead.NonceSize()], payload[aead.NonceSize():]
+	additionalData := privateMessageData(privateMessage.Sender, *privateMessage.SenderKey, fingerprint)
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, true, xerrors.Errorf("failed to decrypt private message: %v", err)
+	}
+
+	msg := &transport.Message{}
+	err = json.Unmarshal(plaintext, msg)
+	if err != nil {
+		return nil, true, err
+	}
+
+	return msg, true, nil
+}
+
+// checkPrivateMessageSender checks that the sender of a private message
+// published the key of the message (see HandleNodeKeyMessage): only then is
+// the sender authenticated by the payload. The sender publishes its key before
+// its first private message (see sendPrivateMessage), which may still be on
+// its way.
+func (n *node) checkPrivateMessageSender(privateMessage *types.PrivateMessage) error {
+	senderKey, ok := n.keyRing.Wait(privateMessage.Sender, nodeKeyTimeout)
+	if !ok {
+		return xerrors.Errorf("unknown public key of %s, dropped its private message", privateMessage.Sender)
+	}
+
+	if senderKey.X.Cmp(&privateMessage.SenderKey.X) != 0 || senderKey.Y.Cmp(&privateMessage.SenderKey.Y) != 0 {
+		return xerrors.Errorf("private message of %s under another key than its published one",
+			privateMessage.Sender)
+	}
+
+	return nil
+}
+
+// publishNodeKey broadcasts the long-term public key of this peer, once, so
+// that the other peers can send it private messages.
+func (n *node) publishNodeKey() {
+	n.publishKeyOnce.Do(n.sendNodeKey)
+}
+
+// sendNodeKey broadcasts the long-term public keys of this peer
+func (n *node) sendNodeKey() {
+	nodeKeyMessage := types.NodeKeyMessage{
+		Address:   n.myAddr,
+		PublicKey: n.publicKey,
+	}
+
+	err := SignNodeKey(n.signingKey, &nodeKeyMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msg("failed to sign node key")
+		return
+	}
+
+	msg, err := marshalMessage(&nodeKeyMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msg("failed to marshal node key")
+		return
+	}
+
+	err = n.Broadcast(msg)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msg("failed to publish node key")
+	}
+}
+
+// HandleNodeKeyRequestMessage processes types.NodeKeyRequestMessage. The keys
+// are published again, the announcer may have missed them.
+func (n *node) HandleNodeKeyRequestMessage(t types.Message, pkt transport.Packet) error {
+	nodeKeyRequestMessage, ok := t.(*types.NodeKeyRequestMessage)
+	if !ok {
+		return xerrors.Errorf("wrong type: %T", t)
+	}
+
+	if inList(n.myAddr, nodeKeyRequestMessage.Addresses) {
+		n.sendNodeKey()
+	}
+
+	return nil
+}
+
+// nodeKeyDigest returns what a peer signs to publish its keys
+func nodeKeyDigest(nodeKeyMessage *types.NodeKeyMessage) []byte {
+	return transport.Digest([]byte(nodeKeyMessage.Address), nodeKeyMessage.PublicKey.X.Bytes(),
+		nodeKeyMessage.PublicKey.Y.Bytes(), nodeKeyMessage.SigningKey)
+}
+
+// SignNodeKey signs the keys published by a peer with its long-term signing key
+func SignNodeKey(signingKey *ecdsa.PrivateKey, nodeKeyMessage *types.NodeKeyMessage) error {
+	nodeKeyMessage.SigningKey = transport.MarshalPublicKey(&signingKey.PublicKey)
+
+	signature, err := transport.SignDigest(signingKey, nodeKeyDigest(nodeKeyMessage))
+	if err != nil {
+		return xerrors.Errorf("failed to sign node key: %v", err)
+	}
+
+	nodeKeyMessage.Signature = signature
+
+	return nil
+}
+
+// HandleNodeKeyMessage processes types.NodeKeyMessage. The keys are those of
+// the origin of the signed rumor: the signing key must be the one the address
+// signs its rumors with (see verifyRumor), and it must sign the message, so that
+// nobody can publish another encryption key for the address.
+func (n *node) HandleNodeKeyMessage(t types.Message, pkt transport.Packet) error {
+	nodeKeyMessage, ok := t.(*types.NodeKeyMessage)
+	if !ok {
+		return xerrors.Errorf("wrong type: %T", t)
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling NodeKeyMessage of %s", nodeKeyMessage.Address)
+
+	if !elliptic.P256().IsOnCurve(&nodeKeyMessage.PublicKey.X, &nodeKeyMessage.PublicKey.Y) {
+		return xerrors.Errorf("public key of %s is not on the curve", nodeKeyMessage.Address)
+	}
+
+	if !bytes.Equal(n.getSigningKey(nodeKeyMessage.Address), nodeKeyMessage.SigningKey) {
+		return xerrors.Errorf("keys of %s are not published by %s", nodeKeyMessage.Address, nodeKeyMessage.Address)
+	}
+
+	err := transport.VerifyDigest(nodeKeyMessage.SigningKey, nodeKeyDigest(nodeKeyMessage), nodeKeyMessage.Signature)
+	if err != nil {
+		return xerrors.Errorf("invalid signature of the keys of %s: %v", nodeKeyMessage.Address, err)
+	}
+
+	if !n.keyRing.Set(nodeKeyMessage.Address, nodeKeyMessage.PublicKey) {
+		return xerrors.Errorf("%s announced a different public key, keeping the first one", nodeKeyMessage.Address)
+	}
+
+	return nil
+}
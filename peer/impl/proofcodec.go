@@ -0,0 +1,536 @@
+package impl
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+
+	"go.dedis.ch/cs438/peer/impl/group"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// ProofEncodingVersion is the version of the encoding of EncodeProof,
+// EncodeRangeProof and EncodeShuffleProof. It is the first byte of an encoded
+// proof, and changes with any change of the layout below.
+//
+// An encoded proof is:
+//
+//	version | kind | suite | proof type | fields
+//
+// where the suite identifies the group of the proof, the points are compressed
+// elements of that group, the scalars are ScalarLen bytes long and every
+// variable-length field is prefixed with its length as a big-endian uint32.
+// The encoding is canonical: a proof has exactly one encoding, and decoding
+// rejects anything else.
+const ProofEncodingVersion byte = 1
+
+func init() {
+	types.RegisterProofCodec(proofCodec{})
+}
+
+// proofCodec sends the proofs over the network in their canonical encoding
+//
+// - implements types.ProofCodec
+type proofCodec struct{}
+
+// EncodeProof implements types.ProofCodec
+func (proofCodec) EncodeProof(proof *types.Proof) ([]byte, error) {
+	return EncodeProof(proof)
+}
+
+// DecodeProof implements types.ProofCodec
+func (proofCodec) DecodeProof(data []byte) (*types.Proof, error) {
+	return DecodeProof(data)
+}
+
+// EncodeRangeProof implements types.ProofCodec
+func (proofCodec) EncodeRangeProof(proof *types.RangeProof) ([]byte, error) {
+	return EncodeRangeProof(proof)
+}
+
+// DecodeRangeProof implements types.ProofCodec
+func (proofCodec) DecodeRangeProof(data []byte) (*types.RangeProof, error) {
+	return DecodeRangeProof(data)
+}
+
+// EncodeShuffleProof implements types.ProofCodec
+func (proofCodec) EncodeShuffleProof(proof *types.ShuffleProof) ([]byte, error) {
+	return EncodeShuffleProof(proof)
+}
+
+// DecodeShuffleProof implements types.ProofCodec
+func (proofCodec) DecodeShuffleProof(data []byte) (*types.ShuffleProof, error) {
+	return DecodeShuffleProof(data)
+}
+
+// kinds of encoded proofs, the second byte of the encoding
+const (
+	proofKind        byte = 'P'
+	rangeProofKind   byte = 'R'
+	shuffleProofKind byte = 'S'
+)
+
+// EncodeProof returns the canonical encoding of a proof, in the group of its
+// suite
+func EncodeProof(proof *types.Proof) ([]byte, error) {
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := newProofEncoder(g, proofKind, proof.ProofType)
+	enc.proofFields(proof)
+
+	return enc.bytes()
+}
+
+// DecodeProof decodes a proof of EncodeProof. It fails if the version or the
+// suite is unknown, or if a point is not an element of the group.
+func DecodeProof(data []byte) (*types.Proof, error) {
+	dec, proofType, err := newProofDecoder(data, proofKind)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := dec.proofFields(proofType)
+
+	err = dec.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// EncodeRangeProof returns the canonical encoding of a range proof, in the
+// group of its suite
+func EncodeRangeProof(proof *types.RangeProof) ([]byte, error) {
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := newProofEncoder(g, rangeProofKind, proof.ProofType)
+	enc.challenge(proof.VerifierChall)
+	enc.length(len(proof.Branches))
+	for i := range proof.Branches {
+		enc.str(proof.Branches[i].ProofType)
+		enc.proofFields(&proof.Branches[i])
+	}
+
+	return enc.bytes()
+}
+
+// DecodeRangeProof decodes a range proof of EncodeRangeProof, see DecodeProof
+func DecodeRangeProof(data []byte) (*types.RangeProof, error) {
+	dec, proofType, err := newProofDecoder(data, rangeProofKind)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &types.RangeProof{
+		ProofType:     proofType,
+		Suite:         dec.g.Suite(),
+		VerifierChall: dec.challenge(),
+	}
+
+	branchCnt := dec.length()
+	for i := 0; i < branchCnt && dec.err == nil; i++ {
+		proof.Branches = append(proof.Branches, *dec.proofFields(dec.str()))
+	}
+
+	err = dec.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// EncodeShuffleProof returns the canonical encoding of a shuffle proof, in the
+// group of the suite of its instance
+func EncodeShuffleProof(proof *types.ShuffleProof) ([]byte, error) {
+	g, err := group.ForSuite(proof.Instance.Suite)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := newProofEncoder(g, shuffleProofKind, proof.ProofType)
+
+	enc.point(proof.Instance.PPoint)
+	enc.cipherTexts(proof.Instance.CtBefore)
+	enc.cipherTexts(proof.Instance.CtAfter)
+
+	enc.length(len(proof.VerifierChallList))
+	for _, chall := range proof.VerifierChallList {
+		enc.challenge(chall)
+	}
+
+	for _, p := range [][]byte{proof.TPoint, proof.VPoint, proof.WPoint, proof.UPoint} {
+		enc.element(p)
+	}
+	enc.elements(proof.UPointListBytes)
+	enc.element(proof.GPrimePoint)
+	enc.element(proof.MPrimePoint)
+	enc.elements(proof.TCapPointListBytes)
+	enc.elements(proof.VCapPointListBytes)
+	enc.element(proof.VCapPoint)
+	enc.elements(proof.WCapPointListBytes)
+	enc.element(proof.WCapPoint)
+
+	enc.scalar(&proof.SZeroScalar)
+	enc.length(len(proof.SList))
+	for i := range proof.SList {
+		enc.scalar(&proof.SList[i])
+	}
+	enc.scalar(&proof.DScalar)
+
+	return enc.bytes()
+}
+
+// DecodeShuffleProof decodes a shuffle proof of EncodeShuffleProof, see
+// DecodeProof
+func DecodeShuffleProof(data []byte) (*types.ShuffleProof, error) {
+	dec, proofType, err := newProofDecoder(data, shuffleProofKind)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &types.ShuffleProof{
+		ProofType: proofType,
+		Instance: types.ShuffleInstance{
+			Suite:    dec.g.Suite(),
+			PPoint:   dec.point(),
+			CtBefore: dec.cipherTexts(),
+			CtAfter:  dec.cipherTexts(),
+		},
+	}
+
+	challCnt := dec.length()
+	for i := 0; i < challCnt && dec.err == nil; i++ {
+		proof.VerifierChallList = append(proof.VerifierChallList, dec.challenge())
+	}
+
+	proof.TPoint = dec.element()
+	proof.VPoint = dec.element()
+	proof.WPoint = dec.element()
+	proof.UPoint = dec.element()
+	proof.UPointListBytes = dec.elements()
+	proof.GPrimePoint = dec.element()
+	proof.MPrimePoint = dec.element()
+	proof.TCapPointListBytes = dec.elements()
+	proof.VCapPointListBytes = dec.elements()
+	proof.VCapPoint = dec.element()
+	proof.WCapPointListBytes = dec.elements()
+	proof.WCapPoint = dec.element()
+
+	proof.SZeroScalar = dec.scalar()
+	sCnt := dec.length()
+	for i := 0; i < sCnt && dec.err == nil; i++ {
+		proof.SList = append(proof.SList, dec.scalar())
+	}
+	proof.DScalar = dec.scalar()
+
+	err = dec.finish()
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// proofEncoder writes the fields of a proof. The first error is kept, the
+// following writes do nothing.
+type proofEncoder struct {
+	g   group.Group
+	buf bytes.Buffer
+	err error
+}
+
+func newProofEncoder(g group.Group, kind byte, proofType string) *proofEncoder {
+	enc := &proofEncoder{g: g}
+	enc.buf.WriteByte(ProofEncodingVersion)
+	enc.buf.WriteByte(kind)
+	enc.str(string(g.Suite()))
+	enc.str(proofType)
+	return enc
+}
+
+func (enc *proofEncoder) bytes() ([]byte, error) {
+	if enc.err != nil {
+		return nil, xerrors.Errorf("failed to encode proof: %v", enc.err)
+	}
+	return enc.buf.Bytes(), nil
+}
+
+func (enc *proofEncoder) length(n int) {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	enc.buf.Write(b[:])
+}
+
+func (enc *proofEncoder) raw(b []byte) {
+	enc.length(len(b))
+	enc.buf.Write(b)
+}
+
+func (enc *proofEncoder) str(s string) {
+	enc.raw([]byte(s))
+}
+
+// challenge writes public coins, they are empty or ScalarLen bytes long
+func (enc *proofEncoder) challenge(b []byte) {
+	if len(b) != 0 && len(b) != enc.g.ScalarLen() {
+		enc.fail(xerrors.Errorf("challenge of %d bytes", len(b)))
+	}
+	enc.raw(b)
+}
+
+// element writes a compressed element, or nothing for the fields a proof
+// doesn't use
+func (enc *proofEncoder) element(b []byte) {
+	if len(b) != 0 {
+		_, err := enc.g.ElementFromBytes(b)
+		enc.fail(err)
+	}
+	enc.raw(b)
+}
+
+func (enc *proofEncoder) elements(list [][]byte) {
+	enc.length(len(list))
+	for _, b := range list {
+		enc.element(b)
+	}
+}
+
+func (enc *proofEncoder) point(p types.Point) {
+	b, err := compressPoint(enc.g, p)
+	enc.fail(err)
+	enc.raw(b)
+}
+
+func (enc *proofEncoder) cipherTexts(cts []types.ElGamalCipherText) {
+	enc.length(len(cts))
+	for _, ct := range cts {
+		enc.point(ct.Ct1)
+		enc.point(ct.Ct2)
+	}
+}
+
+func (enc *proofEncoder) scalar(s *big.Int) {
+	if s.Sign() < 0 || s.Cmp(enc.g.Order()) >= 0 {
+		enc.fail(xerrors.New("scalar out of the range of the group order"))
+		return
+	}
+	enc.buf.Write(enc.g.ScalarFromInt(s).Bytes())
+}
+
+// proofFields writes the fields of a proof but its type
+func (enc *proofEncoder) proofFields(proof *types.Proof) {
+	for _, p := range [][]byte{proof.BPointOther, proof.PPoint, proof.PPointOther, proof.CPoint, proof.CPointOther,
+		proof.OtherBPointOther, proof.OtherPPoint, proof.OtherPPointOther, proof.OtherCPoint, proof.OtherCPointOther} {
+		enc.element(p)
+	}
+	enc.challenge(proof.VerifierChall)
+	enc.challenge(proof.ProverChall)
+	enc.challenge(proof.ProverChallOther)
+	enc.scalar(&proof.Result)
+	enc.scalar(&proof.ResultOther)
+}
+
+func (enc *proofEncoder) fail(err error) {
+	if enc.err == nil {
+		enc.err = err
+	}
+}
+
+// proofDecoder reads the fields of an encoded proof. The first error is kept,
+// the following reads return zero values.
+type proofDecoder struct {
+	g    group.Group
+	data []byte
+	err  error
+}
+
+// newProofDecoder checks the version and the kind of the encoding, and
+// returns the decoder of the group of its suite and the proof type
+func newProofDecoder(data []byte, kind byte) (*proofDecoder, string, error) {
+	if len(data) < 2 {
+		return nil, "", xerrors.New("failed to decode proof: too short")
+	}
+	if data[0] != ProofEncodingVersion {
+		return nil, "", xerrors.Errorf("failed to decode proof: unknown version %d", data[0])
+	}
+	if data[1] != kind {
+		return nil, "", xerrors.Errorf("failed to decode proof: kind %q, %q expected", data[1], kind)
+	}
+
+	dec := &proofDecoder{data: data[2:]}
+	suite := types.PedersenSuite(dec.str())
+	proofType := dec.str()
+	if dec.err != nil {
+		return nil, "", xerrors.Errorf("failed to decode proof: %v", dec.err)
+	}
+
+	// the empty suite is only a default in the configuration
+	if suite == "" {
+		return nil, "", xerrors.New("failed to decode proof: no suite")
+	}
+
+	g, err := group.ForSuite(suite)
+	if err != nil {
+		return nil, "", xerrors.Errorf("failed to decode proof: %v", err)
+	}
+	dec.g = g
+
+	return dec, proofType, nil
+}
+
+// finish checks that the whole encoding was read without error
+func (dec *proofDecoder) finish() error {
+	if dec.err == nil && len(dec.data) != 0 {
+		dec.err = xerrors.Errorf("%d trailing bytes", len(dec.data))
+	}
+	if dec.err != nil {
+		return xerrors.Errorf("failed to decode proof: %v", dec.err)
+	}
+	return nil
+}
+
+func (dec *proofDecoder) next(n int) []byte {
+	if dec.err != nil {
+		return nil
+	}
+	if n > len(dec.data) {
+		dec.err = xerrors.New("unexpected end of the encoding")
+		return nil
+	}
+
+	b := dec.data[:n]
+	dec.data = dec.data[n:]
+	return b
+}
+
+// length reads a length, it can't exceed the rest of the encoding, as every
+// counted item takes at least a byte
+func (dec *proofDecoder) length() int {
+	b := dec.next(4)
+	if b == nil {
+		return 0
+	}
+
+	n := binary.BigEndian.Uint32(b)
+	if uint64(n) > uint64(len(dec.data)) {
+		dec.err = xerrors.Errorf("length %d exceeds the encoding", n)
+		return 0
+	}
+	return int(n)
+}
+
+func (dec *proofDecoder) raw() []byte {
+	b := dec.next(dec.length())
+	if len(b) == 0 {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
+
+func (dec *proofDecoder) str() string {
+	return string(dec.raw())
+}
+
+func (dec *proofDecoder) challenge() []byte {
+	b := dec.raw()
+	if len(b) != 0 && len(b) != dec.g.ScalarLen() {
+		dec.fail(xerrors.Errorf("challenge of %d bytes", len(b)))
+	}
+	return b
+}
+
+// element reads a compressed element, it must be in the group
+func (dec *proofDecoder) element() []byte {
+	b := dec.raw()
+	if len(b) != 0 {
+		_, err := dec.g.ElementFromBytes(b)
+		dec.fail(err)
+	}
+	return b
+}
+
+func (dec *proofDecoder) elements() [][]byte {
+	n := dec.length()
+	var list [][]byte
+	for i := 0; i < n && dec.err == nil; i++ {
+		list = append(list, dec.element())
+	}
+	return list
+}
+
+// point reads a compressed element and returns its coordinates
+func (dec *proofDecoder) point() types.Point {
+	b := dec.raw()
+	if dec.err != nil {
+		return types.Point{}
+	}
+
+	e, err := dec.g.ElementFromBytes(b)
+	if err != nil {
+		dec.fail(err)
+		return types.Point{}
+	}
+	return e.Point()
+}
+
+func (dec *proofDecoder) cipherTexts() []types.ElGamalCipherText {
+	n := dec.length()
+	var cts []types.ElGamalCipherText
+	for i := 0; i < n && dec.err == nil; i++ {
+		cts = append(cts, types.ElGamalCipherText{Ct1: dec.point(), Ct2: dec.point()})
+	}
+	return cts
+}
+
+// scalar reads a scalar, it must be lower than the order of the group
+func (dec *proofDecoder) scalar() big.Int {
+	s := big.Int{}
+	b := dec.next(dec.g.ScalarLen())
+	if b == nil {
+		return s
+	}
+
+	s.SetBytes(b)
+	if s.Cmp(dec.g.Order()) >= 0 {
+		dec.fail(xerrors.New("scalar out of the range of the group order"))
+	}
+	return s
+}
+
+// proofFields reads the fields of a proof but its type
+func (dec *proofDecoder) proofFields(proofType string) *types.Proof {
+	return &types.Proof{
+		ProofType:        proofType,
+		Suite:            dec.g.Suite(),
+		BPointOther:      dec.element(),
+		PPoint:           dec.element(),
+		PPointOther:      dec.element(),
+		CPoint:           dec.element(),
+		CPointOther:      dec.element(),
+		OtherBPointOther: dec.element(),
+		OtherPPoint:      dec.element(),
+		OtherPPointOther: dec.element(),
+		OtherCPoint:      dec.element(),
+		OtherCPointOther: dec.element(),
+		VerifierChall:    dec.challenge(),
+		ProverChall:      dec.challenge(),
+		ProverChallOther: dec.challenge(),
+		Result:           dec.scalar(),
+		ResultOther:      dec.scalar(),
+	}
+}
+
+func (dec *proofDecoder) fail(err error) {
+	if dec.err == nil {
+		dec.err = err
+	}
+}
@@ -0,0 +1,149 @@
+package impl
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// checkQuorum checks that the quorum of an election can be reached
+func checkQuorum(base *types.ElectionBase) error {
+	quorum := base.Quorum
+
+	if quorum.Count < 0 {
+		return xerrors.Errorf("invalid quorum of %d ballots", quorum.Count)
+	}
+
+	if quorum.Percent < 0 || quorum.Percent > 100 {
+		return xerrors.Errorf("invalid quorum of %d%%", quorum.Percent)
+	}
+
+	if quorum.Percent > 0 && len(base.EligibleVoters) == 0 {
+		return errors.New("a quorum in percent needs an eligibility roll")
+	}
+
+	return nil
+}
+
+// checkTurnout returns true if enough ballots are stored to reach the quorum
+// of the election. Otherwise, the first mixnet server publishes that the
+// quorum is not reached, which finishes the election without results.
+func (n *node) checkTurnout(election *types.Election) bool {
+	g, err := electionGroup(election)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to check the turnout")
+		return false
+	}
+
+	var ballotCnt, required, myMixnetServerID int
+	var secretShare big.Int
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		ballotCnt = len(election.Votes)
+		required = election.Base.Quorum.Required(len(election.Base.EligibleVoters))
+		myMixnetServerID = election.GetMyMixnetServerID(n.myAddr)
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	if ballotCnt >= required {
+		return true
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("quorum not reached: %d ballots, %d needed", ballotCnt, required)
+
+	resultMessage := types.ResultMessage{
+		ElectionID:       election.Base.ElectionID,
+		QuorumNotReached: true,
+		BallotCnt:        ballotCnt,
+		MixnetServerID:   myMixnetServerID,
+	}
+
+	signature, err := signWithShare(g, quorumDigest(&resultMessage), &secretShare)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign the quorum outcome")
+		return false
+	}
+	resultMessage.Signature = signature
+
+	err = n.sendResultsMessage(resultMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to send the quorum outcome")
+	}
+
+	return false
+}
+
+// VerifyQuorumNotReached checks that the first mixnet server of the election
+// signed the outcome with its key share, and that the ballot count is below
+// the quorum
+func VerifyQuorumNotReached(election *types.Election, resultMessage *types.ResultMessage,
+	publicShare *types.Point) error {
+
+	required := election.Base.Quorum.Required(len(election.Base.EligibleVoters))
+	if resultMessage.BallotCnt >= required {
+		return fmt.Errorf("%d ballots reach the quorum of %d", resultMessage.BallotCnt, required)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	if !verifyWithShare(g, quorumDigest(resultMessage), resultMessage.Signature, publicShare) {
+		return errors.New("the quorum outcome is not signed by the first mixnet server")
+	}
+
+	return nil
+}
+
+func quorumDigest(resultMessage *types.ResultMessage) []byte {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("quorum|%s|%d|%d", resultMessage.ElectionID,
+		resultMessage.MixnetServerID, resultMessage.BallotCnt)))
+	return digest[:]
+}
+
+// finishWithoutQuorum verifies the outcome published by the first mixnet
+// server of an election which didn't reach its quorum, records it, and
+// finishes the election without results
+func (n *node) finishWithoutQuorum(election *types.Election, resultMessage *types.ResultMessage,
+	msg *transport.Message) error {
+
+	var mixnetServerID int
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		mixnetServerID = election.GetMyMixnetServerID(election.GetFirstQualifiedInitiator())
+	})
+
+	if resultMessage.MixnetServerID != mixnetServerID {
+		return fmt.Errorf("quorum outcome from mixnet server %d, the ballots are stored by %d",
+			resultMessage.MixnetServerID, mixnetServerID)
+	}
+
+	publicShare, err := n.getPublicShareFromBoard(election, mixnetServerID)
+	if err != nil {
+		return err
+	}
+
+	err = VerifyQuorumNotReached(election, resultMessage, &publicShare)
+	if err != nil {
+		return err
+	}
+
+	err = n.recordOnBulletinBoard(election.Base.ElectionID, msg)
+	if err != nil {
+		return err
+	}
+
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		election.QuorumNotReached = true
+		election.ReceivedResultsTimestamp = time.Now()
+		n.setPhase(election, types.PhaseFinished)
+	})
+
+	return nil
+}
@@ -0,0 +1,314 @@
+package impl
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// makeRankedBallot encrypts the ranking as a permutation matrix: the ballot
+// holds one ciphertext per rank and choice, which encrypts 1 if the choice has
+// this rank and 0 otherwise. Each ciphertext comes with a proof that it
+// encrypts either 0 or 1, each rank and each choice with a proof that its
+// ciphertexts add up to exactly one. It also returns the randomness of each
+// ciphertext.
+func makeRankedBallot(election *types.Election, publicKey types.Point, ranking []int) (types.VoteMessage, []big.Int, error) {
+	g, err := electionGroup(election)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
+	}
+	choices := election.Base.Choices
+	choiceCnt := len(choices)
+
+	encryptedVotes := make([]types.ElGamalCipherText, choiceCnt*choiceCnt)
+	correctVoteProofs := make([]types.Proof, choiceCnt*choiceCnt)
+	rScalars := make([]big.Int, choiceCnt*choiceCnt)
+
+	for rank, choiceID := range ranking {
+		for i, choice := range choices {
+			k := rank*choiceCnt + i
+
+			bit := choice.ChoiceID == choiceID
+			plaintext := big.NewInt(0)
+			if bit {
+				plaintext = big.NewInt(1)
+			}
+
+			rScalars[k] = GenerateRandomBigInt(g.Order())
+
+			encryptedVote, err := ElGamalEncryption(g, &publicKey, &rScalars[k], plaintext)
+			if err != nil {
+				return types.VoteMessage{}, nil, err
+			}
+			encryptedVotes[k] = *encryptedVote
+
+			proof, err := ProveEncryptedBit(&rScalars[k], publicKey, *encryptedVote, bit, g)
+			if err != nil {
+				return types.VoteMessage{}, nil, err
+			}
+			correctVoteProofs[k] = *proof
+		}
+	}
+
+	rankProofs := make([]types.Proof, 0, 2*choiceCnt)
+
+	for _, line := range rankMatrixLines(choiceCnt) {
+		cipherTexts := make([]types.ElGamalCipherText, len(line))
+		rSum := new(big.Int)
+		for i, k := range line {
+			cipherTexts[i] = encryptedVotes[k]
+			rSum.Add(rSum, &rScalars[k])
+		}
+		rSum.Mod(rSum, g.Order())
+
+		encryptedSum, err := ElGamalAddCipherTexts(g, cipherTexts)
+		if err != nil {
+			return types.VoteMessage{}, nil, err
+		}
+
+		proof, err := ProveEncryptedOne(rSum, publicKey, encryptedSum, g)
+		if err != nil {
+			return types.VoteMessage{}, nil, err
+		}
+		rankProofs = append(rankProofs, *proof)
+	}
+
+	return types.VoteMessage{
+		ElectionID:        election.Base.ElectionID,
+		EncryptedVotes:    encryptedVotes,
+		CorrectVoteProofs: correctVoteProofs,
+		RankProofs:        rankProofs,
+	}, rScalars, nil
+}
+
+// VerifyRankedBallot checks that the ballot encrypts a permutation matrix: each
+// ciphertext encrypts either 0 or 1, and each rank and each choice adds up to
+// exactly one.
+func VerifyRankedBallot(election *types.Election, publicKey types.Point, vote *types.VoteMessage) bool {
+	g, err := electionGroup(election)
+	if err != nil {
+		return false
+	}
+	choiceCnt := len(election.Base.Choices)
+	ballotSize := election.GetBallotSize()
+
+	if len(vote.EncryptedVotes) != ballotSize || len(vote.CorrectVoteProofs) != ballotSize ||
+		len(vote.RankProofs) != 2*choiceCnt {
+		return false
+	}
+
+	for k, encryptedVote := range vote.EncryptedVotes {
+		if !VerifyEncryptedBit(&vote.CorrectVoteProofs[k], g, publicKey, encryptedVote) {
+			return false
+		}
+	}
+
+	for l, line := range rankMatrixLines(choiceCnt) {
+		cipherTexts := make([]types.ElGamalCipherText, len(line))
+		for i, k := range line {
+			cipherTexts[i] = vote.EncryptedVotes[k]
+		}
+
+		encryptedSum, err := ElGamalAddCipherTexts(g, cipherTexts)
+		if err != nil {
+			return false
+		}
+
+		if !VerifyEncryptedOne(&vote.RankProofs[l], g, publicKey, encryptedSum) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rankMatrixLines returns the indices of the ciphertexts of each row (one per
+// rank), then of each column (one per choice) of a ranked ballot
+func rankMatrixLines(choiceCnt int) [][]int {
+	lines := make([][]int, 0, 2*choiceCnt)
+
+	for rank := 0; rank < choiceCnt; rank++ {
+		row := make([]int, choiceCnt)
+		for i := range row {
+			row[i] = rank*choiceCnt + i
+		}
+		lines = append(lines, row)
+	}
+
+	for i := 0; i < choiceCnt; i++ {
+		column := make([]int, choiceCnt)
+		for rank := range column {
+			column[rank] = rank*choiceCnt + i
+		}
+		lines = append(lines, column)
+	}
+
+	return lines
+}
+
+// isRanking checks that the ranking holds each choice ID exactly once
+func isRanking(choices []types.Choice, ranking []int) bool {
+	if len(ranking) != len(choices) {
+		return false
+	}
+
+	seen := make(map[int]struct{}, len(ranking))
+	for _, choiceID := range ranking {
+		_, duplicate := seen[choiceID]
+		if duplicate {
+			return false
+		}
+		seen[choiceID] = struct{}{}
+	}
+
+	for _, choice := range choices {
+		if _, ok := seen[choice.ChoiceID]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DecodeRankings turns the decrypted permutation matrices of the ranked
+// ballots, concatenated, into rankings of choice IDs. The ballots which don't
+// decrypt to a permutation matrix are rejected: they are left out of the
+// rankings, and their indexes are returned.
+func DecodeRankings(choices []types.Choice, plaintexts []uint) ([][]int, []int, error) {
+	choiceCnt := len(choices)
+	ballotSize := choiceCnt * choiceCnt
+
+	if ballotSize == 0 || len(plaintexts)%ballotSize != 0 {
+		return nil, nil, xerrors.Errorf("%d plaintexts are not a list of ranked ballots", len(plaintexts))
+	}
+
+	rankings := make([][]int, 0, len(plaintexts)/ballotSize)
+	rejected := []int{}
+
+	for b := 0; b < len(plaintexts); b += ballotSize {
+		ranking := make([]int, 0, choiceCnt)
+		isMatrix := true
+
+		for rank := 0; rank < choiceCnt; rank++ {
+			for i, choice := range choices {
+				switch plaintexts[b+rank*choiceCnt+i] {
+				case 0:
+				case 1:
+					ranking = append(ranking, choice.ChoiceID)
+				default:
+					isMatrix = false
+				}
+			}
+		}
+
+		if !isMatrix || !isRanking(choices, ranking) {
+			rejected = append(rejected, b/ballotSize)
+			continue
+		}
+
+		rankings = append(rankings, ranking)
+	}
+
+	return rankings, rejected, nil
+}
+
+// InstantRunoff counts the rankings round by round. In each round, a ranking
+// counts for its preferred choice among the ones still in the race. The count
+// stops when a choice has a strict majority or is the last one, otherwise the
+// choice with the fewest votes is eliminated; on a tie, the last one in the
+// order of the choices.
+func InstantRunoff(choices []types.Choice, rankings [][]int) []types.RunoffRound {
+	eliminated := make(map[int]struct{})
+	rounds := []types.RunoffRound{}
+
+	for {
+		counts := make(map[int]uint)
+		for _, choice := range choices {
+			if _, ok := eliminated[choice.ChoiceID]; !ok {
+				counts[choice.ChoiceID] = 0
+			}
+		}
+
+		for _, ranking := range rankings {
+			for _, choiceID := range ranking {
+				if _, ok := counts[choiceID]; ok {
+					counts[choiceID]++
+					break
+				}
+			}
+		}
+
+		hasMajority := false
+		loser := -1
+		for _, choice := range choices {
+			count, ok := counts[choice.ChoiceID]
+			if !ok {
+				continue
+			}
+			if 2*int(count) > len(rankings) {
+				hasMajority = true
+			}
+			if loser == -1 || count <= counts[loser] {
+				loser = choice.ChoiceID
+			}
+		}
+
+		if hasMajority || len(counts) <= 1 || len(rankings) == 0 {
+			return append(rounds, types.RunoffRound{Counts: counts, Eliminated: -1})
+		}
+
+		rounds = append(rounds, types.RunoffRound{Counts: counts, Eliminated: loser})
+		eliminated[loser] = struct{}{}
+	}
+}
+
+// runoffResults returns the counts of the final round, the eliminated choices
+// have a count of 0
+func runoffResults(choices []types.Choice, rounds []types.RunoffRound) map[int]uint {
+	results := make(map[int]uint, len(choices))
+	for _, choice := range choices {
+		results[choice.ChoiceID] = 0
+	}
+
+	if len(rounds) > 0 {
+		for choiceID, count := range rounds[len(rounds)-1].Counts {
+			results[choiceID] = count
+		}
+	}
+
+	return results
+}
+
+// VerifyRunoff recomputes the instant-runoff rounds from the rankings published
+// in the results, and checks them against the published rounds and counts.
+func VerifyRunoff(election *types.Election, resultMessage *types.ResultMessage) error {
+	for i, ranking := range resultMessage.Rankings {
+		if !isRanking(election.Base.Choices, ranking) {
+			return xerrors.Errorf("published ballot %d is not a ranking", i)
+		}
+	}
+
+	expected := types.ResultMessage{
+		ElectionID: resultMessage.ElectionID,
+		Rankings:   resultMessage.Rankings,
+		Rounds:     InstantRunoff(election.Base.Choices, resultMessage.Rankings),
+	}
+	expected.Results = runoffResults(election.Base.Choices, expected.Rounds)
+
+	if !sameResults(&expected, resultMessage) {
+		return xerrors.New("the published rounds don't match the published rankings")
+	}
+
+	return nil
+}
+
+// sameResults compares the results through their encoding, so that results
+// received from the network compare equal to locally computed ones
+func sameResults(a, b *types.ResultMessage) bool {
+	bufA, errA := json.Marshal(a)
+	bufB, errB := json.Marshal(b)
+
+	return errA == nil && errB == nil && string(bufA) == string(bufB)
+}
@@ -0,0 +1,1472 @@
+package impl
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/peer/impl/group"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// petTimeout is how long the first mixnet server of a re-voting election waits
+// for the shares of each step of the plaintext equivalence tests
+const petTimeout = 10 * time.Second
+
+// revotingCredentialName is the name of the voter's secret credential of the
+// re-voting election in the key store
+func revotingCredentialName(electionID string) string {
+	return "revoting-credential-" + electionID
+}
+
+// registrationRandomnessName is the name of the randomness which encrypts the
+// registered credential of the voter in the key store
+func registrationRandomnessName(electionID string) string {
+	return "revoting-registration-" + electionID
+}
+
+// encryptCredential encrypts the credential point s*G for the election key pk
+// with the randomness r: (r*G, r*pk + s*G)
+func encryptCredential(g group.Group, pk group.Element, s, r group.Scalar) types.ElGamalCipherText {
+	return types.ElGamalCipherText{
+		Ct1: g.BaseMul(r).Point(),
+		Ct2: pk.Mul(r).Add(g.BaseMul(s)).Point(),
+	}
+}
+
+// attachCredential sets the encrypted credential of a ballot of a re-voting
+// election, registering the credential of this peer first if needed (see
+// registerCredential). The credential is encrypted anew, so that the ballots of
+// a voter can't be told apart from the ones of other voters.
+func (n *node) attachCredential(ctx context.Context, election *types.Election, publicKey types.Point,
+	ballot *types.VoteMessage) error {
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	pk, err := g.ElementFromPoint(publicKey)
+	if err != nil {
+		return xerrors.Errorf("invalid election key: %v", err)
+	}
+
+	store := n.conf.Storage.GetKeyStore()
+	sInt, err := loadOrCreateKey(store, revotingCredentialName(election.Base.ElectionID))
+	if err != nil {
+		return err
+	}
+	r0Int, err := loadOrCreateKey(store, registrationRandomnessName(election.Base.ElectionID))
+	if err != nil {
+		return err
+	}
+	s := g.ScalarFromInt(sInt)
+	r0 := g.ScalarFromInt(r0Int)
+
+	roll, index, err := n.registerCredential(ctx, election, pk, s, r0)
+	if err != nil {
+		return err
+	}
+
+	r, err := g.RandomScalar()
+	if err != nil {
+		return err
+	}
+
+	ballot.EncryptedCredential = encryptCredential(g, pk, s, r)
+	ballot.RollSize = len(roll)
+
+	digest := ballotCredentialDigest(ballot)
+
+	ballot.CredentialSignature, err = signWithCredential(g, pk, ballot.EncryptedCredential, r, s, digest)
+	if err != nil {
+		return err
+	}
+
+	// the ballot and the registration encrypt the same credential, their
+	// quotient encrypts zero with the randomness r - r0
+	ballot.RollProof, err = proveRollMembership(g, pk, ballot.EncryptedCredential, rollCipherTexts(roll), index,
+		r.Sub(r0), digest)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// registerCredential registers the credential of this peer with the first
+// qualified mixnet server, unless it is already on the bulletin board. It
+// resends the registration until it is posted on the bulletin board, and
+// returns the registrations on the board with the index of the one of this
+// peer.
+func (n *node) registerCredential(ctx context.Context, election *types.Election, pk group.Element,
+	s, r0 group.Scalar) ([]types.RegisterCredentialMessage, int, error) {
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	electionID := election.Base.ElectionID
+	encryptedCredential := encryptCredential(g, pk, s, r0)
+
+	findRegistration := func() ([]types.RegisterCredentialMessage, int, error) {
+		roll, err := n.getCredentialRoll(electionID)
+		if err != nil {
+			return nil, -1, err
+		}
+		for i := range roll {
+			if equalCipherTexts(roll[i].EncryptedCredential, encryptedCredential) {
+				return roll, i, nil
+			}
+		}
+		return nil, -1, xerrors.Errorf("the credential of this peer is not registered for election %s", electionID)
+	}
+
+	roll, index, err := findRegistration()
+	if err == nil {
+		return roll, index, nil
+	}
+
+	registration := types.RegisterCredentialMessage{
+		ElectionID:          electionID,
+		EncryptedCredential: encryptedCredential,
+	}
+
+	registration.CredentialSignature, err = signWithCredential(g, pk, encryptedCredential, r0, s,
+		registrationProofDigest(electionID))
+	if err != nil {
+		return nil, -1, err
+	}
+
+	registration.VoterKey, registration.Credential, registration.VoterSignature, err =
+		n.signAsVoter(election, registrationDigest(&registration))
+	if err != nil {
+		return nil, -1, xerrors.Errorf("failed to sign registration: %v", err)
+	}
+
+	for {
+		var mixnetServer string
+		var phase types.Phase
+		n.electionStore.View(electionID, func(election *types.Election) {
+			mixnetServer = election.GetFirstQualifiedInitiator()
+			phase = election.Phase
+		})
+		if phase != types.PhaseOpen {
+			return nil, -1, xerrors.Errorf("election %s is %s, the credential was not registered", electionID, phase)
+		}
+
+		log.Info().Str("peerAddr", n.myAddr).Msgf("registering credential with mixnet server %s", mixnetServer)
+		err = n.sendPrivateMessage(map[string]struct{}{mixnetServer: {}}, &registration)
+		if err != nil {
+			return nil, -1, err
+		}
+
+		registered := make(chan error, 1)
+		n.waitForBulletinBoard(electionID, "RegisterCredentialMessage", func() error {
+			_, _, err := findRegistration()
+			return err
+		}, func() error {
+			registered <- nil
+			return nil
+		}, func(err error) error {
+			registered <- err
+			return nil
+		})
+
+		select {
+		case err := <-registered:
+			if err == nil {
+				return findRegistration()
+			}
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("credential not registered yet, resending: %v", err)
+		case <-ctx.Done():
+			return nil, -1, ctx.Err()
+		}
+	}
+}
+
+// HandleRegisterCredentialMessage processes types.RegisterCredentialMessage.
+// The first qualified mixnet server of an open re-voting election posts the
+// registration on the bulletin board, once per voter.
+func (n *node) HandleRegisterCredentialMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling RegisterCredentialMessage from %v", pkt.Header.Source)
+	registration := types.RegisterCredentialMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &registration)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(registration.ElectionID)
+	if election == nil {
+		return n.parkMessage(registration.ElectionID, pkt)
+	}
+
+	if !election.Base.Revoting {
+		return errors.New("this election doesn't allow re-voting - registration won't be accepted")
+	}
+	if election.GetFirstQualifiedInitiator() != n.myAddr {
+		return errors.New("this peer doesn't store the registrations - registration won't be accepted")
+	}
+	if election.Phase != types.PhaseOpen || !time.Now().Before(election.Base.Expiration) {
+		return errors.New("this election is not open - registration won't be accepted")
+	}
+
+	err = VerifyRegistration(election, &registration)
+	if err != nil {
+		return fmt.Errorf("%v - registration won't be accepted", err)
+	}
+
+	roll, err := n.getCredentialRoll(registration.ElectionID)
+	if err != nil {
+		return err
+	}
+
+	for i := range roll {
+		if !bytes.Equal(roll[i].VoterKey, registration.VoterKey) {
+			continue
+		}
+		if equalCipherTexts(roll[i].EncryptedCredential, registration.EncryptedCredential) {
+			// a resent registration, the voter finds it on the bulletin board
+			return nil
+		}
+		return errors.New("this voter already registered a credential - registration won't be accepted")
+	}
+
+	// registrations are sent privately to the mixnet server, publish them
+	return n.postOnBulletinBoard(registration.ElectionID, &registration)
+}
+
+// VerifyRegistration checks that the registration is signed by a voter of the
+// election (as a ballot, see VerifyBallotSignature), and proves the knowledge
+// of its credential
+func VerifyRegistration(election *types.Election, registration *types.RegisterCredentialMessage) error {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	pk, err := g.ElementFromPoint(election.GetPublicKey())
+	if err != nil {
+		return xerrors.Errorf("invalid election key: %v", err)
+	}
+
+	if !verifyCredentialSignature(g, pk, registration.EncryptedCredential, registrationProofDigest(election.Base.ElectionID),
+		registration.CredentialSignature) {
+		return xerrors.New("the registration doesn't prove the knowledge of its credential")
+	}
+
+	return verifyVoterSignature(election, registration.VoterKey, registration.Credential, registrationDigest(registration),
+		registration.VoterSignature)
+}
+
+// VerifyBallotCredential checks that a ballot of a re-voting election carries
+// no voter key, and an encrypted credential that its voter knows and which is
+// registered among the first RollSize registrations of the roll
+func VerifyBallotCredential(election *types.Election, roll []types.RegisterCredentialMessage, ballot *types.VoteMessage) error {
+	if len(ballot.VoterKey) != 0 || len(ballot.VoterSignature) != 0 || len(ballot.Credential) != 0 {
+		return xerrors.New("the ballot of a re-voting election must not identify its voter")
+	}
+	if ballot.RollSize <= 0 || ballot.RollSize > len(roll) {
+		return xerrors.Errorf("the ballot refers to %d registrations, %d are known", ballot.RollSize, len(roll))
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	pk, err := g.ElementFromPoint(election.GetPublicKey())
+	if err != nil {
+		return xerrors.Errorf("invalid election key: %v", err)
+	}
+
+	digest := ballotCredentialDigest(ballot)
+
+	if !verifyCredentialSignature(g, pk, ballot.EncryptedCredential, digest, ballot.CredentialSignature) {
+		return xerrors.New("the ballot doesn't prove the knowledge of its credential")
+	}
+
+	if !verifyRollMembership(g, pk, ballot.EncryptedCredential, rollCipherTexts(roll[:ballot.RollSize]), digest,
+		ballot.RollProof) {
+		return xerrors.New("the credential of the ballot is not registered")
+	}
+
+	return nil
+}
+
+// registrationDigest returns what the voter signs to register its credential
+func registrationDigest(registration *types.RegisterCredentialMessage) []byte {
+	ct := registration.EncryptedCredential
+	return transport.Digest([]byte("registration"), []byte(registration.ElectionID),
+		ct.Ct1.X.Bytes(), ct.Ct1.Y.Bytes(), ct.Ct2.X.Bytes(), ct.Ct2.Y.Bytes(), registration.CredentialSignature)
+}
+
+// registrationProofDigest returns what the proof of knowledge of a registered
+// credential is bound to
+func registrationProofDigest(electionID string) []byte {
+	return transport.Digest([]byte("registration proof"), []byte(electionID))
+}
+
+// ballotCredentialDigest returns what the proofs of the encrypted credential of
+// a ballot are bound to: the ballot hash and the size of the roll
+func ballotCredentialDigest(ballot *types.VoteMessage) []byte {
+	var rollSize [8]byte
+	binary.BigEndian.PutUint64(rollSize[:], uint64(ballot.RollSize))
+
+	return transport.Digest([]byte("ballot credential"), BallotHash(ballot), rollSize[:])
+}
+
+// signWithCredential proves the knowledge of the credential s and of the
+// randomness r of ct = (r*G, r*pk + s*G), bound to the digest. The signature is
+// the compressed commitments A1 = a*G and A2 = a*pk + b*G followed by the
+// responses a + c*r and b + c*s, where c hashes the instance, the commitments
+// and the digest.
+func signWithCredential(g group.Group, pk group.Element, ct types.ElGamalCipherText, r, s group.Scalar,
+	digest []byte) ([]byte, error) {
+
+	a, err := g.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+	b, err := g.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := decodePoints(g, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return nil, xerrors.Errorf("invalid credential: %v", err)
+	}
+
+	a1 := g.BaseMul(a)
+	a2 := pk.Mul(a).Add(g.BaseMul(b))
+	c := credentialChallenge(g, pk, points[0], points[1], a1, a2, digest)
+
+	signature := append(a1.Bytes(), a2.Bytes()...)
+	signature = append(signature, a.Add(c.Mul(r)).Bytes()...)
+	return append(signature, b.Add(c.Mul(s)).Bytes()...), nil
+}
+
+// verifyCredentialSignature checks a signature of signWithCredential:
+// z1*G = A1 + c*ct1 and z1*pk + z2*G = A2 + c*ct2
+func verifyCredentialSignature(g group.Group, pk group.Element, ct types.ElGamalCipherText, digest []byte,
+	signature []byte) bool {
+
+	pointLen := len(g.Generator().Bytes())
+	if len(signature) != 2*pointLen+2*g.ScalarLen() {
+		return false
+	}
+
+	points, err := decodePoints(g, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return false
+	}
+
+	a1, err := g.ElementFromBytes(signature[:pointLen])
+	if err != nil {
+		return false
+	}
+	a2, err := g.ElementFromBytes(signature[pointLen : 2*pointLen])
+	if err != nil {
+		return false
+	}
+	z1 := g.Scalar(signature[2*pointLen : 2*pointLen+g.ScalarLen()])
+	z2 := g.Scalar(signature[2*pointLen+g.ScalarLen():])
+
+	c := credentialChallenge(g, pk, points[0], points[1], a1, a2, digest)
+
+	return g.BaseMul(z1).Equal(a1.Add(points[0].Mul(c))) &&
+		pk.Mul(z1).Add(g.BaseMul(z2)).Equal(a2.Add(points[1].Mul(c)))
+}
+
+func credentialChallenge(g group.Group, pk, ct1, ct2, a1, a2 group.Element, digest []byte) group.Scalar {
+	h := sha256.New()
+	h.Write([]byte("credential"))
+	for _, element := range []group.Element{pk, ct1, ct2, a1, a2} {
+		h.Write(element.Bytes())
+	}
+	h.Write(digest)
+	return g.Scalar(h.Sum(nil))
+}
+
+// rollCipherTexts returns the encrypted credentials of the registrations
+func rollCipherTexts(roll []types.RegisterCredentialMessage) []types.ElGamalCipherText {
+	cipherTexts := make([]types.ElGamalCipherText, len(roll))
+	for i := range roll {
+		cipherTexts[i] = roll[i].EncryptedCredential
+	}
+	return cipherTexts
+}
+
+// rollQuotients returns the quotients ct - roll[k] as pairs of elements. The
+// quotient encrypts zero if and only if ct and roll[k] encrypt the same
+// credential.
+func rollQuotients(g group.Group, ct types.ElGamalCipherText, roll []types.ElGamalCipherText) ([][]group.Element, error) {
+	quotients := make([][]group.Element, len(roll))
+	for k := range roll {
+		quotient, err := ElGamalSubtractCipherTexts(g, ct, roll[k])
+		if err != nil {
+			return nil, xerrors.Errorf("invalid credential: %v", err)
+		}
+
+		quotients[k], err = decodePoints(g, quotient.Ct1, quotient.Ct2)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid credential: %v", err)
+		}
+	}
+	return quotients, nil
+}
+
+// proveRollMembership proves that ct encrypts the same credential as one of the
+// registrations of the roll, without telling which: an OR of Chaum-Pedersen
+// proofs that the quotient ct - roll[k] is (d*G, d*pk), with the witness d of
+// the registration at index. The other branches are simulated. The proof is
+// the challenge and the response of each branch, bound to the digest.
+func proveRollMembership(g group.Group, pk group.Element, ct types.ElGamalCipherText, roll []types.ElGamalCipherText,
+	index int, d group.Scalar, digest []byte) ([]byte, error) {
+
+	if index < 0 || index >= len(roll) {
+		return nil, xerrors.Errorf("registration %d is not on the roll of %d registrations", index, len(roll))
+	}
+
+	quotients, err := rollQuotients(g, ct, roll)
+	if err != nil {
+		return nil, err
+	}
+
+	challenges := make([]group.Scalar, len(roll))
+	responses := make([]group.Scalar, len(roll))
+	commitments := make([][]group.Element, len(roll))
+
+	a, err := g.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range roll {
+		if k == index {
+			commitments[k] = []group.Element{g.BaseMul(a), pk.Mul(a)}
+			continue
+		}
+
+		challenges[k], err = g.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		responses[k], err = g.RandomScalar()
+		if err != nil {
+			return nil, err
+		}
+		commitments[k] = simulatedRollCommitments(g, pk, quotients[k], challenges[k], responses[k])
+	}
+
+	c := rollChallenge(g, pk, quotients, commitments, digest)
+	for k := range roll {
+		if k != index {
+			c = c.Sub(challenges[k])
+		}
+	}
+	challenges[index] = c
+	responses[index] = a.Add(c.Mul(d))
+
+	proof := make([]byte, 0, 2*len(roll)*g.ScalarLen())
+	for k := range roll {
+		proof = append(proof, challenges[k].Bytes()...)
+		proof = append(proof, responses[k].Bytes()...)
+	}
+	return proof, nil
+}
+
+// verifyRollMembership checks a proof of proveRollMembership: the challenges
+// of the branches add up to the hash of the commitments they imply
+func verifyRollMembership(g group.Group, pk group.Element, ct types.ElGamalCipherText, roll []types.ElGamalCipherText,
+	digest []byte, proof []byte) bool {
+
+	scalarLen := g.ScalarLen()
+	if len(roll) == 0 || len(proof) != 2*len(roll)*scalarLen {
+		return false
+	}
+
+	quotients, err := rollQuotients(g, ct, roll)
+	if err != nil {
+		return false
+	}
+
+	commitments := make([][]group.Element, len(roll))
+	sum := g.ScalarFromInt(big.NewInt(0))
+	for k := range roll {
+		offset := 2 * k * scalarLen
+		challenge := g.Scalar(proof[offset : offset+scalarLen])
+		response := g.Scalar(proof[offset+scalarLen : offset+2*scalarLen])
+
+		commitments[k] = simulatedRollCommitments(g, pk, quotients[k], challenge, response)
+		sum = sum.Add(challenge)
+	}
+
+	return sum.Equal(rollChallenge(g, pk, quotients, commitments, digest))
+}
+
+// simulatedRollCommitments returns the commitments of a branch of the roll
+// proof which hold for the challenge c and the response z: z*G - c*q1 and
+// z*pk - c*q2
+func simulatedRollCommitments(g group.Group, pk group.Element, quotient []group.Element, c, z group.Scalar) []group.Element {
+	return []group.Element{
+		g.BaseMul(z).Sub(quotient[0].Mul(c)),
+		pk.Mul(z).Sub(quotient[1].Mul(c)),
+	}
+}
+
+func rollChallenge(g group.Group, pk group.Element, quotients, commitments [][]group.Element, digest []byte) group.Scalar {
+	h := sha256.New()
+	h.Write([]byte("roll"))
+	h.Write(pk.Bytes())
+	for k := range quotients {
+		for _, element := range append(quotients[k], commitments[k]...) {
+			h.Write(element.Bytes())
+		}
+	}
+	h.Write(digest)
+	return g.Scalar(h.Sum(nil))
+}
+
+// credentialRoll returns the registrations of a re-voting election, in the
+// order they were posted on the bulletin board by the first qualified mixnet
+// server. Every registration must be valid.
+func credentialRoll(election *types.Election, content *boardContent) ([]types.RegisterCredentialMessage, error) {
+	for i := range content.registrations {
+		if content.registrationPosters[i] != election.GetFirstQualifiedInitiator() {
+			return nil, xerrors.Errorf("registration %d is posted by %s, which doesn't store the registrations",
+				i, content.registrationPosters[i])
+		}
+		err := VerifyRegistration(election, &content.registrations[i])
+		if err != nil {
+			return nil, xerrors.Errorf("registration %d: %v", i, err)
+		}
+	}
+
+	return content.registrations, nil
+}
+
+// getCredentialRoll returns the registrations of the election, from the local
+// bulletin board (see credentialRoll)
+func (n *node) getCredentialRoll(electionID string) ([]types.RegisterCredentialMessage, error) {
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(electionID))
+	if err != nil {
+		return nil, err
+	}
+
+	return credentialRoll(n.electionStore.Get(electionID), content)
+}
+
+// credentialBallots returns the ballots of a re-voting election on the bulletin
+// board, before the equivalence tests. Every ballot must be valid, with a
+// registered credential.
+func credentialBallots(election *types.Election, content *boardContent) ([]types.VoteMessage, error) {
+	roll, err := credentialRoll(election, content)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := election.GetPublicKey()
+
+	for i := range content.ballots {
+		if content.ballotPosters[i] != election.GetFirstQualifiedInitiator() {
+			return nil, xerrors.Errorf("ballot %d is posted by %s, which doesn't store the ballots", i, content.ballotPosters[i])
+		}
+		if !VerifyBallot(election, publicKey, &content.ballots[i]) {
+			return nil, xerrors.Errorf("invalid proofs for ballot %d", i)
+		}
+		err := VerifyBallotCredential(election, roll, &content.ballots[i])
+		if err != nil {
+			return nil, xerrors.Errorf("ballot %d: %v", i, err)
+		}
+	}
+
+	return content.ballots, nil
+}
+
+// reusesCredentialCipherText tells if the encrypted credential of the ballot
+// shares a point with the one of another ballot. Such a ballot is refused, the
+// plaintext equivalence test of the pair would be degenerate.
+func reusesCredentialCipherText(ballots []types.VoteMessage, ballot *types.VoteMessage) bool {
+	ct := ballot.EncryptedCredential
+	for i := range ballots {
+		other := ballots[i].EncryptedCredential
+		if equalPoints(ct.Ct1, other.Ct1) || equalPoints(ct.Ct2, other.Ct2) {
+			return true
+		}
+	}
+	return false
+}
+
+// deduplicateBallots keeps the last ballot of each credential of a re-voting
+// election, as run by the first qualified mixnet server before the mixing. The
+// qualified mixnet servers test the pairs of ballots on the bulletin board for
+// plaintext equivalence of their credentials (see PETRequestMessage), without
+// learning the credentials nor the voters.
+func (n *node) deduplicateBallots(electionID string) ([]types.VoteMessage, error) {
+	election := n.electionStore.Get(electionID)
+
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(electionID))
+	if err != nil {
+		return nil, err
+	}
+
+	ballots, err := credentialBallots(election, content)
+	if err != nil {
+		return nil, err
+	}
+	if len(ballots) < 2 {
+		return ballots, nil
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	quotients, err := petQuotients(g, ballots)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("testing %d pairs of ballots of election %s for equivalence",
+		len(quotients), electionID)
+
+	blindShares, err := n.runPETStep(election, types.PETRequestMessage{
+		ElectionID: electionID,
+		Step:       types.PETBlind,
+		BallotCnt:  len(ballots),
+	}, func(share *types.PETShareMessage) bool {
+		return VerifyPETBlindShare(g, quotients, share)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	blindIDs := firstShareIDs(blindShares, election.Base.Threshold)
+	sums, err := sumBlindedQuotients(g, blindIDs, blindShares)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptShares, err := n.runPETStep(election, types.PETRequestMessage{
+		ElectionID:      electionID,
+		Step:            types.PETDecrypt,
+		BallotCnt:       len(ballots),
+		MixnetServerIDs: blindIDs,
+	}, func(share *types.PETShareMessage) bool {
+		publicShare, err := n.getPublicShareFromBoard(election, share.MixnetServerID)
+		return err == nil && VerifyPETDecryptShare(g, sums, &publicShare, share)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	equivalent, err := petEquivalences(g, sums, firstShareIDs(decryptShares, election.Base.Threshold), decryptShares)
+	if err != nil {
+		return nil, err
+	}
+
+	return keepLatestBallots(ballots, equivalent), nil
+}
+
+// runPETStep signs and broadcasts the request, and returns the first Threshold
+// valid shares of qualified mixnet servers, by mixnet server ID. It gives up
+// after petTimeout.
+func (n *node) runPETStep(election *types.Election, request types.PETRequestMessage,
+	verify func(*types.PETShareMessage) bool) (map[int]types.PETShareMessage, error) {
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	var secretShare big.Int
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	request.MixnetServerID = election.GetMyMixnetServerID(n.myAddr)
+	request.Signature, err = SignPETRequest(g, &request, &secretShare)
+	if err != nil {
+		return nil, err
+	}
+
+	// filled by HandlePETShareMessage, the shares may come back while
+	// broadcasting
+	shares := make(chan types.PETShareMessage, len(election.Base.MixnetServers))
+	key := petSharesKey(request.ElectionID, request.Step)
+	n.petShares.Store(key, shares)
+	defer n.petShares.Delete(key)
+
+	msg, err := marshalMessage(&request)
+	if err != nil {
+		return nil, err
+	}
+
+	err = n.Broadcast(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(petTimeout)
+	defer timeout.Stop()
+
+	valid := make(map[int]types.PETShareMessage)
+	for len(valid) < election.Base.Threshold {
+		select {
+		case share := <-shares:
+			id := share.MixnetServerID
+			if _, known := valid[id]; known {
+				continue
+			}
+
+			if !verify(&share) {
+				log.Warn().Str("peerAddr", n.myAddr).Msgf("invalid equivalence test share from mixnet server %d", id)
+				continue
+			}
+
+			valid[id] = share
+
+		case <-timeout.C:
+			return nil, xerrors.Errorf("only %d valid equivalence test shares for election %s, %d needed",
+				len(valid), request.ElectionID, election.Base.Threshold)
+		}
+	}
+
+	return valid, nil
+}
+
+// petSharesKey is the key of the channel waiting for the shares of a step of
+// the plaintext equivalence tests of the election
+func petSharesKey(electionID string, step types.PETStep) string {
+	return fmt.Sprintf("%s/%d", electionID, step)
+}
+
+// HandlePETRequestMessage processes types.PETRequestMessage. The request must
+// be signed by the first qualified mixnet server. A qualified mixnet server
+// recomputes the quotients from the ballots on the bulletin board, and answers
+// with its shares of the step: it blinds the quotients once, and only decrypts
+// the sums which hold its own blinded quotients.
+func (n *node) HandlePETRequestMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling PETRequestMessage from %v", pkt.Header.Source)
+	petRequestMessage := types.PETRequestMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &petRequestMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(petRequestMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(petRequestMessage.ElectionID, pkt)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	requesterID := election.GetMyMixnetServerID(election.GetFirstQualifiedInitiator())
+	if petRequestMessage.MixnetServerID != requesterID {
+		return fmt.Errorf("equivalence tests requested by mixnet server %d, only mixnet server %d runs them",
+			petRequestMessage.MixnetServerID, requesterID)
+	}
+
+	publicShare, err := n.getPublicShareFromBoard(election, requesterID)
+	if err != nil {
+		return err
+	}
+	if !VerifyPETRequest(g, &petRequestMessage, &publicShare) {
+		return errors.New("equivalence test request signature is not valid")
+	}
+
+	err = n.recordOnBulletinBoard(petRequestMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	if !isQualifiedMixnetServer(election, election.GetMyMixnetServerID(n.myAddr)) {
+		return nil
+	}
+
+	var cipherTexts []types.ElGamalCipherText
+
+	switch petRequestMessage.Step {
+	case types.PETBlind:
+		n.waitForBulletinBoard(petRequestMessage.ElectionID, "PETRequestMessage", func() error {
+			quotients, err := n.petQuotientsOnBoard(petRequestMessage.ElectionID, petRequestMessage.BallotCnt)
+			cipherTexts = quotients
+			return err
+		}, func() error {
+			return n.blindQuotients(election, cipherTexts)
+		}, func(err error) error {
+			return fmt.Errorf("refusing equivalence tests: %v", err)
+		})
+	case types.PETDecrypt:
+		if !contains(petRequestMessage.MixnetServerIDs, election.GetMyMixnetServerID(n.myAddr)) {
+			// the sums hold the blinded quotients of other mixnet servers
+			return nil
+		}
+		n.waitForBulletinBoard(petRequestMessage.ElectionID, "PETRequestMessage", func() error {
+			sums, err := n.petSumsOnBoard(&petRequestMessage)
+			cipherTexts = sums
+			return err
+		}, func() error {
+			return n.decryptBlindedSums(election, cipherTexts)
+		}, func(err error) error {
+			return fmt.Errorf("refusing equivalence tests: %v", err)
+		})
+	default:
+		return fmt.Errorf("unknown equivalence test step %d", petRequestMessage.Step)
+	}
+
+	return nil
+}
+
+// petQuotientsOnBoard returns the quotients of the pairs of ballots on the local
+// bulletin board (see petQuotients), which must hold the ballotCnt ballots of
+// the equivalence tests
+func (n *node) petQuotientsOnBoard(electionID string, ballotCnt int) ([]types.ElGamalCipherText, error) {
+	election := n.electionStore.Get(electionID)
+
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(electionID))
+	if err != nil {
+		return nil, err
+	}
+
+	ballots, err := credentialBallots(election, content)
+	if err != nil {
+		return nil, err
+	}
+	if len(ballots) != ballotCnt {
+		return nil, xerrors.Errorf("%d ballots on the bulletin board, the equivalence tests are on %d", len(ballots), ballotCnt)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	return petQuotients(g, ballots)
+}
+
+// petSumsOnBoard returns the sums of the blinded quotients the request asks to
+// decrypt, recomputed from the valid blinded quotients on the local bulletin
+// board. This mixnet server must have blinded the quotients, and its blinded
+// quotients must be in the sums, so that they don't decrypt to anything but
+// zero or a random element.
+func (n *node) petSumsOnBoard(request *types.PETRequestMessage) ([]types.ElGamalCipherText, error) {
+	election := n.electionStore.Get(request.ElectionID)
+
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+	if election.MyPETBlindShare == nil {
+		return nil, xerrors.New("this mixnet server didn't blind the quotients")
+	}
+	if len(request.MixnetServerIDs) != election.Base.Threshold || !contains(request.MixnetServerIDs, myMixnetServerID) {
+		return nil, xerrors.New("the sums don't hold the blinded quotients of this mixnet server")
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	quotients, err := n.petQuotientsOnBoard(request.ElectionID, request.BallotCnt)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := decodeBulletinBoard(n.GetBulletinBoard(request.ElectionID))
+	if err != nil {
+		return nil, err
+	}
+	commitments, err := qualifiedCommitments(election, content)
+	if err != nil {
+		return nil, err
+	}
+
+	blindShares, err := verifiedBlindShares(g, election, commitments, content, quotients, request.MixnetServerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	myShare := blindShares[myMixnetServerID]
+	if len(myShare.Blinded) != len(election.MyPETBlindShare.Blinded) {
+		return nil, xerrors.New("the blinded quotients of this mixnet server don't match its share")
+	}
+	for k := range myShare.Blinded {
+		if !equalCipherTexts(myShare.Blinded[k], election.MyPETBlindShare.Blinded[k]) {
+			return nil, xerrors.New("the blinded quotients of this mixnet server don't match its share")
+		}
+	}
+
+	return sumBlindedQuotients(g, request.MixnetServerIDs, blindShares)
+}
+
+// blindQuotients blinds the quotients (see blindCipherTexts) and broadcasts the
+// signed share of this mixnet server, once per election
+func (n *node) blindQuotients(election *types.Election, quotients []types.ElGamalCipherText) error {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	share := types.PETShareMessage{
+		ElectionID:     election.Base.ElectionID,
+		Step:           types.PETBlind,
+		MixnetServerID: election.GetMyMixnetServerID(n.myAddr),
+	}
+
+	err = blindCipherTexts(g, quotients, &share)
+	if err != nil {
+		return err
+	}
+
+	var secretShare big.Int
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	share.Signature, err = SignPETShare(g, &share, &secretShare)
+	if err != nil {
+		return err
+	}
+
+	blinded := false
+	n.electionStore.Update(election.Base.ElectionID, func(election *types.Election) {
+		blinded = election.MyPETBlindShare != nil
+		if !blinded {
+			election.MyPETBlindShare = &share
+		}
+	})
+	if blinded {
+		return xerrors.Errorf("this mixnet server already blinded the quotients of election %s", election.Base.ElectionID)
+	}
+
+	msg, err := marshalMessage(&share)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
+
+// decryptBlindedSums broadcasts the signed decryption shares of this mixnet
+// server of the blinded sums
+func (n *node) decryptBlindedSums(election *types.Election, sums []types.ElGamalCipherText) error {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+	var secretShare big.Int
+	var publicShare types.Point
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		secretShare = n.GetSecretShare(g, election)
+		publicShare, err = n.GetPublicShare(g, election, myMixnetServerID)
+	})
+	if err != nil {
+		return err
+	}
+
+	share := types.PETShareMessage{
+		ElectionID:     election.Base.ElectionID,
+		Step:           types.PETDecrypt,
+		MixnetServerID: myMixnetServerID,
+	}
+
+	for _, sum := range sums {
+		sum := sum
+		decryptShare, proof, err := MakeDecryptShare(g, &sum, &publicShare, secretShare.Bytes())
+		if err != nil {
+			return err
+		}
+		share.DecryptShares = append(share.DecryptShares, *decryptShare)
+		share.Proofs = append(share.Proofs, *proof)
+	}
+
+	share.Signature, err = SignPETShare(g, &share, &secretShare)
+	if err != nil {
+		return err
+	}
+
+	msg, err := marshalMessage(&share)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
+
+// HandlePETShareMessage processes types.PETShareMessage. A share signed by a
+// qualified mixnet server is recorded, and if this peer runs the step, handed
+// over to deduplicateBallots.
+func (n *node) HandlePETShareMessage(t types.Message, pkt transport.Packet) error {
+	petShareMessage := types.PETShareMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &petShareMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(petShareMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(petShareMessage.ElectionID, pkt)
+	}
+
+	if !isQualifiedMixnetServer(election, petShareMessage.MixnetServerID) {
+		return fmt.Errorf("mixnet server %d is not qualified to test equivalences", petShareMessage.MixnetServerID)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	publicShare, err := n.getPublicShareFromBoard(election, petShareMessage.MixnetServerID)
+	if err != nil {
+		return err
+	}
+	if !VerifyPETShare(g, &petShareMessage, &publicShare) {
+		return fmt.Errorf("equivalence test share of mixnet server %d is not signed by it", petShareMessage.MixnetServerID)
+	}
+
+	err = n.recordOnBulletinBoard(petShareMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	value, ok := n.petShares.Load(petSharesKey(petShareMessage.ElectionID, petShareMessage.Step))
+	if !ok {
+		return nil
+	}
+
+	select {
+	case value.(chan types.PETShareMessage) <- petShareMessage:
+	default:
+	}
+
+	return nil
+}
+
+// isQualifiedMixnetServer tells if the mixnet server holds a valid share of the
+// election key
+func isQualifiedMixnetServer(election *types.Election, mixnetServerID int) bool {
+	return mixnetServerID >= 0 && mixnetServerID < len(election.Base.MixnetServers) &&
+		election.Base.MixnetServersPoints[mixnetServerID] >= election.Base.Threshold
+}
+
+// SignPETRequest signs the request with the secret key share of the first
+// qualified mixnet server
+func SignPETRequest(g group.Group, request *types.PETRequestMessage, secretShare *big.Int) ([]byte, error) {
+	digest, err := petRequestDigest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return signWithShare(g, digest, secretShare)
+}
+
+// VerifyPETRequest checks the signature of the request against the public key
+// share of the first qualified mixnet server
+func VerifyPETRequest(g group.Group, request *types.PETRequestMessage, publicShare *types.Point) bool {
+	digest, err := petRequestDigest(request)
+	if err != nil {
+		return false
+	}
+
+	return verifyWithShare(g, digest, request.Signature, publicShare)
+}
+
+// SignPETShare signs the share with the secret key share of its mixnet server
+func SignPETShare(g group.Group, share *types.PETShareMessage, secretShare *big.Int) ([]byte, error) {
+	digest, err := petShareDigest(share)
+	if err != nil {
+		return nil, err
+	}
+
+	return signWithShare(g, digest, secretShare)
+}
+
+// VerifyPETShare checks the signature of the share against the public key share
+// of its mixnet server
+func VerifyPETShare(g group.Group, share *types.PETShareMessage, publicShare *types.Point) bool {
+	digest, err := petShareDigest(share)
+	if err != nil {
+		return false
+	}
+
+	return verifyWithShare(g, digest, share.Signature, publicShare)
+}
+
+// petRequestDigest hashes the whole request but its signature
+func petRequestDigest(request *types.PETRequestMessage) ([]byte, error) {
+	unsigned := *request
+	unsigned.Signature = nil
+
+	buf, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(append([]byte("pet-request|"), buf...))
+	return digest[:], nil
+}
+
+// petShareDigest hashes the whole share but its signature
+func petShareDigest(share *types.PETShareMessage) ([]byte, error) {
+	unsigned := *share
+	unsigned.Signature = nil
+
+	buf, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(append([]byte("pet-share|"), buf...))
+	return digest[:], nil
+}
+
+// blindCipherTexts raises each ciphertext to a fresh secret exponent z, and
+// fills the share with z*ct, the commitment z*G and the proofs that both
+// points of z*ct use the exponent of the commitment
+func blindCipherTexts(g group.Group, cipherTexts []types.ElGamalCipherText, share *types.PETShareMessage) error {
+	for _, ct := range cipherTexts {
+		points, err := decodePoints(g, ct.Ct1, ct.Ct2)
+		if err != nil {
+			return xerrors.Errorf("invalid ciphertext: %v", err)
+		}
+
+		zInt := GenerateRandomBigInt(g.Order())
+		z := g.ScalarFromInt(&zInt)
+		commitment := g.BaseMul(z).Point()
+
+		blinded := types.ElGamalCipherText{}
+		for i, point := range points {
+			blindedPoint := point.Mul(z).Point()
+			proof, err := ProveDlogEq(z.Bytes(), commitment, point.Point(), blindedPoint, g)
+			if err != nil {
+				return xerrors.Errorf("failed to prove the blinding: %v", err)
+			}
+
+			if i == 0 {
+				blinded.Ct1 = blindedPoint
+			} else {
+				blinded.Ct2 = blindedPoint
+			}
+			share.Proofs = append(share.Proofs, *proof)
+		}
+
+		share.Blinded = append(share.Blinded, blinded)
+		share.Commitments = append(share.Commitments, commitment)
+	}
+
+	return nil
+}
+
+// VerifyPETBlindShare checks that the share holds the ciphertexts, each
+// blinded with the exponent of its commitment
+func VerifyPETBlindShare(g group.Group, cipherTexts []types.ElGamalCipherText, share *types.PETShareMessage) bool {
+	if share.Step != types.PETBlind || len(share.Blinded) != len(cipherTexts) ||
+		len(share.Commitments) != len(cipherTexts) || len(share.Proofs) != 2*len(cipherTexts) {
+		return false
+	}
+
+	for k, ct := range cipherTexts {
+		blinded := share.Blinded[k]
+		commitment := share.Commitments[k]
+
+		if !verifyDlogEqInstance(g, &share.Proofs[2*k], commitment, ct.Ct1, blinded.Ct1) ||
+			!verifyDlogEqInstance(g, &share.Proofs[2*k+1], commitment, ct.Ct2, blinded.Ct2) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// VerifyPETDecryptShare checks the decryption shares of the blinded sums
+// against the public share of the mixnet server
+func VerifyPETDecryptShare(g group.Group, sums []types.ElGamalCipherText, publicShare *types.Point,
+	share *types.PETShareMessage) bool {
+	if share.Step != types.PETDecrypt || len(share.DecryptShares) != len(sums) || len(share.Proofs) != len(sums) {
+		return false
+	}
+
+	for k := range sums {
+		if !VerifyDecryptShare(g, &sums[k], publicShare, &share.DecryptShares[k], &share.Proofs[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// verifyDlogEqInstance checks that the proof is a valid Chaum-Pedersen proof
+// that log_G(p) = log_bOther(pOther)
+func verifyDlogEqInstance(g group.Group, proof *types.Proof, p, bOther, pOther types.Point) bool {
+	instance, err := decodePoints(g, p, bOther, pOther)
+	if err != nil {
+		return false
+	}
+
+	checkInstance := checkChallBytes(instance[0].Bytes(), proof.PPoint) &&
+		checkChallBytes(instance[1].Bytes(), proof.BPointOther) &&
+		checkChallBytes(instance[2].Bytes(), proof.PPointOther)
+
+	if !checkInstance {
+		return false
+	}
+
+	withSuite := *proof
+	withSuite.Suite = g.Suite()
+
+	isValid, err := VerifyDlogEq(&withSuite)
+
+	return err == nil && isValid
+}
+
+// petQuotients returns the quotient of the encrypted credentials of each pair
+// (i, j) of ballots, i < j, ordered by i then j. It encrypts zero if and only
+// if both ballots have the same credential.
+func petQuotients(g group.Group, ballots []types.VoteMessage) ([]types.ElGamalCipherText, error) {
+	quotients := make([]types.ElGamalCipherText, 0, len(ballots)*(len(ballots)-1)/2)
+	for i := range ballots {
+		for j := i + 1; j < len(ballots); j++ {
+			quotient, err := ElGamalSubtractCipherTexts(g,
+				ballots[i].EncryptedCredential, ballots[j].EncryptedCredential)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid credential: %v", err)
+			}
+			quotients = append(quotients, quotient)
+		}
+	}
+	return quotients, nil
+}
+
+// firstShareIDs returns the threshold lowest mixnet server IDs of the shares
+func firstShareIDs(shares map[int]types.PETShareMessage, threshold int) []int {
+	ids := make([]int, 0, len(shares))
+	for id := range shares {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids[:threshold]
+}
+
+// sumBlindedQuotients adds the blinded quotients of the given mixnet servers:
+// the sum is blinded by the sum of their secret exponents, so that none of
+// them knows it. A sum whose first point is the identity is refused: the
+// exponents would cancel out, and the sum would decrypt to zero whatever the
+// credentials.
+func sumBlindedQuotients(g group.Group, ids []int, shares map[int]types.PETShareMessage) ([]types.ElGamalCipherText, error) {
+	sums := make([]types.ElGamalCipherText, len(shares[ids[0]].Blinded))
+	for k := range sums {
+		blinded := make([]types.ElGamalCipherText, len(ids))
+		for i, id := range ids {
+			blinded[i] = shares[id].Blinded[k]
+		}
+
+		var err error
+		sums[k], err = ElGamalAddCipherTexts(g, blinded)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid blinded quotient: %v", err)
+		}
+
+		if equalPoints(sums[k].Ct1, g.Identity().Point()) {
+			return nil, xerrors.Errorf("the blinded quotients %d cancel out", k)
+		}
+	}
+	return sums, nil
+}
+
+// petEquivalences combines the decryption shares of the given mixnet servers
+// and tells, for each blinded sum, if it decrypts to zero, that is, if both
+// ballots of the pair have the same credential
+func petEquivalences(g group.Group, sums []types.ElGamalCipherText, ids []int,
+	shares map[int]types.PETShareMessage) ([]bool, error) {
+
+	equivalent := make([]bool, len(sums))
+	for k, sum := range sums {
+		combined := g.Identity()
+		for _, id := range ids {
+			lambda := g.ScalarFromInt(LagrangeCoefficient(id, ids, g.Order()))
+			share, err := g.ElementFromPoint(shares[id].DecryptShares[k])
+			if err != nil {
+				return nil, xerrors.Errorf("invalid decryption share: %v", err)
+			}
+			combined = combined.Add(share.Mul(lambda))
+		}
+
+		equivalent[k] = equalPoints(sum.Ct2, combined.Point())
+	}
+	return equivalent, nil
+}
+
+// keepLatestBallots groups the ballots by credential, as told by the
+// equivalence of each pair (see petQuotients), and keeps the last ballot of
+// each group in the order of the bulletin board. The kept ballots are returned
+// in that order.
+func keepLatestBallots(ballots []types.VoteMessage, equivalent []bool) []types.VoteMessage {
+	group := make([]int, len(ballots))
+	for i := range group {
+		group[i] = i
+	}
+	find := func(i int) int {
+		for group[i] != i {
+			i = group[i]
+		}
+		return i
+	}
+
+	k := 0
+	for i := range ballots {
+		for j := i + 1; j < len(ballots); j++ {
+			if equivalent[k] {
+				group[find(j)] = find(i)
+			}
+			k++
+		}
+	}
+
+	latest := make(map[int]int)
+	for i := range ballots {
+		latest[find(i)] = i
+	}
+
+	kept := make([]types.VoteMessage, 0, len(latest))
+	for i := range ballots {
+		if latest[find(i)] == i {
+			kept = append(kept, ballots[i])
+		}
+	}
+	return kept
+}
+
+// verifiedBlindShares returns the blinded quotients of the given mixnet servers
+// on the bulletin board, which must be signed by them and blind the quotients
+func verifiedBlindShares(g group.Group, election *types.Election, commitments [][]types.Point, content *boardContent,
+	quotients []types.ElGamalCipherText, ids []int) (map[int]types.PETShareMessage, error) {
+
+	blindShares := make(map[int]types.PETShareMessage)
+	for _, share := range content.petShares {
+		id := share.MixnetServerID
+		if share.Step != types.PETBlind || !contains(ids, id) || !isQualifiedMixnetServer(election, id) {
+			continue
+		}
+		publicShare, err := ComputePublicShare(g, commitments, id)
+		if err != nil {
+			return nil, err
+		}
+		if VerifyPETShare(g, &share, &publicShare) && VerifyPETBlindShare(g, quotients, &share) {
+			blindShares[id] = share
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := blindShares[id]; !ok {
+			return nil, xerrors.Errorf("no valid blinded quotients of mixnet server %d", id)
+		}
+	}
+
+	return blindShares, nil
+}
+
+// verifyDeduplication recomputes from the bulletin board which ballots of a
+// re-voting election are kept by the plaintext equivalence tests, checking
+// the request of the first qualified mixnet server and the signed shares of the
+// qualified mixnet servers
+func verifyDeduplication(election *types.Election, content *boardContent, ballots []types.VoteMessage) ([]types.VoteMessage, error) {
+	if len(ballots) < 2 {
+		return ballots, nil
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	commitments, err := qualifiedCommitments(election, content)
+	if err != nil {
+		return nil, err
+	}
+
+	requesterID := election.GetMyMixnetServerID(election.GetFirstQualifiedInitiator())
+	requesterShare, err := ComputePublicShare(g, commitments, requesterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var decryptRequest *types.PETRequestMessage
+	for i := range content.petRequests {
+		request := &content.petRequests[i]
+		if request.Step == types.PETDecrypt && request.MixnetServerID == requesterID &&
+			VerifyPETRequest(g, request, &requesterShare) {
+			decryptRequest = request
+		}
+	}
+	if decryptRequest == nil {
+		return nil, xerrors.New("no plaintext equivalence tests on the bulletin board")
+	}
+	if decryptRequest.BallotCnt != len(ballots) {
+		return nil, xerrors.Errorf("the equivalence tests are on %d ballots, %d are published", decryptRequest.BallotCnt, len(ballots))
+	}
+
+	quotients, err := petQuotients(g, ballots)
+	if err != nil {
+		return nil, err
+	}
+
+	// the blinded quotients the sums are made of
+	threshold := election.Base.Threshold
+	blindIDs := decryptRequest.MixnetServerIDs
+	if len(blindIDs) != threshold {
+		return nil, xerrors.Errorf("the equivalence tests combine %d blinded quotients, %d expected", len(blindIDs), threshold)
+	}
+	blindShares, err := verifiedBlindShares(g, election, commitments, content, quotients, blindIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := sumBlindedQuotients(g, blindIDs, blindShares)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptShares := make(map[int]types.PETShareMessage)
+	for _, share := range content.petShares {
+		id := share.MixnetServerID
+		if share.Step != types.PETDecrypt || !isQualifiedMixnetServer(election, id) {
+			continue
+		}
+		publicShare, err := ComputePublicShare(g, commitments, id)
+		if err != nil {
+			return nil, err
+		}
+		if VerifyPETShare(g, &share, &publicShare) && VerifyPETDecryptShare(g, sums, &publicShare, &share) {
+			decryptShares[id] = share
+		}
+	}
+	if len(decryptShares) < threshold {
+		return nil, xerrors.Errorf("only %d valid equivalence test decryption shares, %d needed", len(decryptShares), threshold)
+	}
+
+	equivalent, err := petEquivalences(g, sums, firstShareIDs(decryptShares, threshold), decryptShares)
+	if err != nil {
+		return nil, err
+	}
+
+	return keepLatestBallots(ballots, equivalent), nil
+}
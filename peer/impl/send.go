@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"go.dedis.ch/cs438/transport"
 	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
 )
 
 func marshalMessage(msg types.Message) (transport.Message, error) {
@@ -52,17 +53,7 @@ func (n *node) Unicast(dest string, msg transport.Message) error {
 
 // Broadcast implements peer.Messaging
 func (n *node) Broadcast(msg transport.Message) error {
-	mySequenceNumber := n.rumorStore.GetSequence(n.myAddr) + 1
-
-	// create rumor
-	rumor := types.Rumor{
-		Origin:   n.myAddr,
-		Sequence: mySequenceNumber,
-		Msg:      &msg,
-	}
-
-	// store own rumor
-	err := n.rumorStore.Store(rumor)
+	rumor, err := n.storeOwnRumor(msg)
 	if err != nil {
 		return err
 	}
@@ -103,6 +94,32 @@ func (n *node) Broadcast(msg transport.Message) error {
 	return nil
 }
 
+// storeOwnRumor creates, signs and stores the next rumor of this peer
+func (n *node) storeOwnRumor(msg transport.Message) (types.Rumor, error) {
+	n.broadcastMutex.Lock()
+	defer n.broadcastMutex.Unlock()
+
+	// create rumor
+	rumor := types.Rumor{
+		Origin:   n.myAddr,
+		Sequence: n.rumorStore.GetSequence(n.myAddr) + 1,
+		Msg:      &msg,
+	}
+
+	err := n.signRumor(&rumor)
+	if err != nil {
+		return types.Rumor{}, err
+	}
+
+	// store own rumor
+	err = n.rumorStore.Store(rumor)
+	if err != nil {
+		return types.Rumor{}, err
+	}
+
+	return rumor, nil
+}
+
 // forward packet
 func (n *node) forward(dest string, pkt transport.Packet) error {
 	relayPkt := pkt.Copy()
@@ -128,7 +145,7 @@ func (n *node) route(dest string, pkt transport.Packet) error {
 	}
 
 	// send to destination
-	err := n.conf.Socket.Send(relayAddr, pkt, 0)
+	err := n.send(relayAddr, pkt)
 	if err != nil {
 		return err
 	}
@@ -165,7 +182,7 @@ func (n *node) sendAck(pkt transport.Packet) error {
 		Msg:    &msg,
 	}
 
-	err = n.conf.Socket.Send(dest, ackPkt, 0)
+	err = n.send(dest, ackPkt)
 	if err != nil {
 		return err
 	}
@@ -222,7 +239,7 @@ func (n *node) unicastDirect(dest string, msg transport.Message) error {
 	}
 
 	// send to destination
-	err := n.conf.Socket.Send(dest, pkt, 0)
+	err := n.send(dest, pkt)
 	if err != nil {
 		return err
 	}
@@ -252,7 +269,7 @@ func (n *node) sendRumors(dest string, rumors []types.Rumor) (transport.Packet,
 		Msg:    &msg,
 	}
 
-	err = n.conf.Socket.Send(dest, pkt, 0)
+	err = n.send(dest, pkt)
 	if err != nil {
 		return transport.Packet{}, err
 	}
@@ -414,7 +431,7 @@ func (n *node) waitForAckOrResend(pkt transport.Packet) {
 
 	newPkt.Header = &header
 
-	err := n.conf.Socket.Send(randomNeighborAddr, newPkt, 0)
+	err := n.send(randomNeighborAddr, newPkt)
 	if err != nil {
 		log.Err(err).Str("peerAddr", n.myAddr)
 	}
@@ -423,6 +440,12 @@ func (n *node) waitForAckOrResend(pkt transport.Packet) {
 func (n *node) sendPaxosPrepareMessage(paxosPrepareMessage types.PaxosPrepareMessage) error {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("sending Paxos Prepare")
 
+	// the promises are encrypted for the proposer's key, published before
+	// the prepare (see sendPaxosPromiseMessage)
+	if !n.conf.AllowUnsigned {
+		n.publishNodeKey()
+	}
+
 	msg, err := marshalMessage(paxosPrepareMessage)
 	if err != nil {
 		return err
@@ -439,6 +462,11 @@ func (n *node) sendPaxosPrepareMessage(paxosPrepareMessage types.PaxosPrepareMes
 func (n *node) sendPaxosPromiseMessage(dest string, paxosPromiseMessage types.PaxosPromiseMessage) error {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("sending Paxos Promise to %v", dest)
 
+	// peers which require signatures drop cleartext private messages
+	if !n.conf.AllowUnsigned {
+		return n.sendPrivateMessage(map[string]struct{}{dest: {}}, &paxosPromiseMessage)
+	}
+
 	// send private message
 	paxosPromiseTransportMessage, err := marshalMessage(paxosPromiseMessage)
 	if err != nil {
@@ -515,18 +543,33 @@ func (n *node) sendTLCMessage(step uint, block types.BlockchainBlock) {
 	}
 }
 
+// sendPrivateMessage encrypts the message for the long-term key of each
+// recipient and broadcasts it, only the recipients can read it. It waits for
+// the keys of the recipients which did not publish it yet. The key of this
+// peer is published before, the recipients authenticate the sender with it.
 func (n *node) sendPrivateMessage(recipients map[string]struct{}, message types.Message) error {
+	n.publishNodeKey()
+
 	transportMessage, err := marshalMessage(message)
 	if err != nil {
 		return err
 	}
 
-	privateMessage := types.PrivateMessage{
-		Recipients: recipients,
-		Msg:        &transportMessage,
+	recipientKeys := make([]types.Point, 0, len(recipients))
+	for recipient := range recipients {
+		recipientKey, ok := n.keyRing.Wait(recipient, nodeKeyTimeout)
+		if !ok {
+			return xerrors.Errorf("unknown public key of %s, can't send it a private message", recipient)
+		}
+		recipientKeys = append(recipientKeys, recipientKey)
 	}
 
-	msg, err := marshalMessage(privateMessage)
+	privateMessage, err := SealPrivateMessage(n.privateKey, n.myAddr, n.publicKey, recipientKeys, &transportMessage)
+	if err != nil {
+		return err
+	}
+
+	msg, err := marshalMessage(&privateMessage)
 	if err != nil {
 		return err
 	}
@@ -538,3 +581,17 @@ func (n *node) sendPrivateMessage(recipients map[string]struct{}, message types.
 
 	return nil
 }
+
+// sendPrivateMessageToEach sends the message privately to each recipient on
+// its own, in the background: a recipient which never publishes its key (see
+// sendPrivateMessage) doesn't hold back the others.
+func (n *node) sendPrivateMessageToEach(recipients []string, message types.Message) {
+	for _, recipient := range recipients {
+		go func(recipient string) {
+			err := n.sendPrivateMessage(map[string]struct{}{recipient: {}}, message)
+			if err != nil {
+				log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to send %s to %s", message.Name(), recipient)
+			}
+		}(recipient)
+	}
+}
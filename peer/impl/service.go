@@ -99,6 +99,9 @@ func (n *node) Start() error {
 	// start listening asynchronously
 	go n.receiveLoop()
 
+	// resume the elections loaded from the storage
+	n.resumeElections()
+
 	return nil
 }
 
@@ -128,11 +131,22 @@ func (n *node) Stop() error {
 	n.stopPeer <- struct{}{}
 	close(n.stopPeer)
 
+	// unblock the phase subscribers, such as peers waiting to vote
+	n.phaseWatch.Close()
+
 	log.Info().Str("peerAddr", n.myAddr).Msg("peer shut down")
 	return nil
 }
 
 func (n *node) handlePacket(pkt transport.Packet) {
+	// the peer's own packets are processed locally without a signature, the
+	// ones from the socket must be signed by their creator
+	if !pkt.Header.IsSigned() && !n.acceptsUnsigned(pkt.Msg) {
+		log.Warn().Str("peerAddr", n.myAddr).Msgf("dropped unsigned packet %v from %v",
+			pkt.Header.PacketID, pkt.Header.Source)
+		return
+	}
+
 	// forward packet if it's not meant for this node
 	dest := pkt.Header.Destination
 	if dest != n.myAddr {
@@ -0,0 +1,230 @@
+package impl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/binary"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/storage"
+	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
+	"golang.org/x/xerrors"
+)
+
+// signingKeyName is the name of the long-term signing key in the key store
+const signingKeyName = "signing"
+
+// loadOrCreateSigningKey returns the signing key stored under the name, such as
+// the long-term signing key of the peer (see loadOrCreateKey)
+func loadOrCreateSigningKey(store storage.Store, name string) (*ecdsa.PrivateKey, error) {
+	secret, err := loadOrCreateKey(store, name)
+	if err != nil {
+		return nil, err
+	}
+
+	signingKey := &ecdsa.PrivateKey{D: secret}
+	signingKey.Curve = elliptic.P256()
+	signingKey.X, signingKey.Y = elliptic.P256().ScalarBaseMult(secret.Bytes())
+
+	return signingKey, nil
+}
+
+// send signs the packets created by this peer and sends them to dest. Relayed
+// packets keep the signature of their creator.
+func (n *node) send(dest string, pkt transport.Packet) error {
+	if pkt.Header.Source == n.myAddr && !pkt.Header.IsSigned() {
+		err := pkt.Sign(n.signingKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.conf.Socket.Send(dest, pkt, 0)
+}
+
+// rumorDigest returns the hash of the signed content of the rumor
+func rumorDigest(rumor types.Rumor) []byte {
+	var sequence [8]byte
+	binary.BigEndian.PutUint64(sequence[:], uint64(rumor.Sequence))
+
+	fields := [][]byte{[]byte(rumor.Origin), sequence[:], rumor.PublicKey}
+	if rumor.Msg != nil {
+		fields = append(fields, []byte(rumor.Msg.Type), rumor.Msg.Payload)
+	}
+
+	return transport.Digest(fields...)
+}
+
+// signRumor signs a rumor originating from this peer
+func (n *node) signRumor(rumor *types.Rumor) error {
+	return SignRumor(n.signingKey, rumor)
+}
+
+// SignRumor signs the rumor with the long-term signing key of its origin
+func SignRumor(signingKey *ecdsa.PrivateKey, rumor *types.Rumor) error {
+	rumor.PublicKey = transport.MarshalPublicKey(&signingKey.PublicKey)
+
+	signature, err := transport.SignDigest(signingKey, rumorDigest(*rumor))
+	if err != nil {
+		return xerrors.Errorf("failed to sign rumor: %v", err)
+	}
+
+	rumor.Signature = signature
+
+	return nil
+}
+
+// electionMessages are the names of the messages of the elections, which must
+// be signed by their creator even when the peer accepts unsigned traffic (see
+// peer.Configuration.AllowUnsigned)
+var electionMessages = map[string]struct{}{
+	types.AnnounceElectionMessage{}.Name():   {},
+	types.DKGShareMessage{}.Name():           {},
+	types.DKGCommitmentMessage{}.Name():      {},
+	types.DKGShareValidationMessage{}.Name(): {},
+	types.DKGRevealShareMessage{}.Name():     {},
+	types.ElectionReadyMessage{}.Name():      {},
+	types.StartElectionMessage{}.Name():      {},
+	types.VoteMessage{}.Name():               {},
+	types.BallotReceiptMessage{}.Name():      {},
+	types.CredentialRequestMessage{}.Name():  {},
+	types.CredentialMessage{}.Name():         {},
+	types.MixMessage{}.Name():                {},
+	types.MixComplaintMessage{}.Name():       {},
+	types.DecryptionRequestMessage{}.Name():  {},
+	types.DecryptShareMessage{}.Name():       {},
+	types.RegisterCredentialMessage{}.Name(): {},
+	types.PETRequestMessage{}.Name():         {},
+	types.PETShareMessage{}.Name():           {},
+	types.ResultMessage{}.Name():             {},
+	types.CancelElectionMessage{}.Name():     {},
+	types.ExtendElectionMessage{}.Name():     {},
+	types.BulletinBoardMessage{}.Name():      {},
+	types.NodeKeyMessage{}.Name():            {},
+	types.NodeKeyRequestMessage{}.Name():     {},
+}
+
+// acceptsUnsigned tells if the message may come unsigned: only if the peer
+// allows unsigned traffic, and never for the messages of the elections
+func (n *node) acceptsUnsigned(msg *transport.Message) bool {
+	if !n.conf.AllowUnsigned {
+		return false
+	}
+
+	if msg == nil {
+		return true
+	}
+
+	_, isElectionMessage := electionMessages[msg.Type]
+	return !isElectionMessage
+}
+
+// verifyRumor checks the signature of the rumor, and that its origin always
+// signs with the same key. Unsigned rumors are only accepted from origins which
+// never signed, if the peer accepts them (see acceptsUnsigned).
+func (n *node) verifyRumor(rumor types.Rumor) error {
+	if len(rumor.Signature) == 0 && len(rumor.PublicKey) == 0 {
+		_, known := n.signingKeys.Get(rumor.Origin)
+		if known || !n.acceptsUnsigned(rumor.Msg) {
+			return xerrors.Errorf("unsigned rumor from %s", rumor.Origin)
+		}
+		return nil
+	}
+
+	err := transport.VerifyDigest(rumor.PublicKey, rumorDigest(rumor), rumor.Signature)
+	if err != nil {
+		return err
+	}
+
+	return n.setSigningKey(rumor.Origin, rumor.PublicKey)
+}
+
+// setSigningKey records the signing key of the peer. The first key of a peer is
+// kept, it is an error to present another one.
+func (n *node) setSigningKey(addr string, signingKey []byte) error {
+	publicKey, err := transport.UnmarshalPublicKey(signingKey)
+	if err != nil {
+		return err
+	}
+
+	if !n.signingKeys.Set(addr, NewPoint(publicKey.X, publicKey.Y)) {
+		return xerrors.Errorf("%s signed with another key", addr)
+	}
+
+	return nil
+}
+
+// getSigningKey returns the encoded signing key of the peer, nil if unknown
+func (n *node) getSigningKey(addr string) []byte {
+	key, ok := n.signingKeys.Get(addr)
+	if !ok {
+		return nil
+	}
+
+	return elliptic.Marshal(elliptic.P256(), &key.X, &key.Y)
+}
+
+// nodeKeyPollInterval is how often the announcer checks whether the mixnet
+// servers published their keys
+const nodeKeyPollInterval = time.Millisecond * 10
+
+// requestMixnetServerKeys returns the signing keys of the mixnet servers, which
+// the announcer pins in the announcement of the election. The mixnet servers
+// whose key is unknown are asked to publish it. Those which don't before the
+// deadline of the key generation have no key, and can't take part.
+func (n *node) requestMixnetServerKeys(mixnetServers []string) ([][]byte, error) {
+	unknown := []string{}
+	for _, mixnetServer := range mixnetServers {
+		if n.getSigningKey(mixnetServer) == nil {
+			unknown = append(unknown, mixnetServer)
+		}
+	}
+
+	if len(unknown) > 0 {
+		msg, err := marshalMessage(&types.NodeKeyRequestMessage{Addresses: unknown})
+		if err != nil {
+			return nil, err
+		}
+
+		err = n.Broadcast(msg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ticker := time.NewTicker(nodeKeyPollInterval)
+	defer ticker.Stop()
+
+	deadline := time.After(n.getDKGTimeout())
+
+	for len(unknown) > 0 {
+		select {
+		case <-ticker.C:
+		case <-deadline:
+			log.Warn().Str("peerAddr", n.myAddr).Msgf("mixnet servers %v didn't publish their keys", unknown)
+			return n.mixnetServerKeys(mixnetServers), nil
+		}
+
+		stillUnknown := unknown[:0]
+		for _, mixnetServer := range unknown {
+			if n.getSigningKey(mixnetServer) == nil {
+				stillUnknown = append(stillUnknown, mixnetServer)
+			}
+		}
+		unknown = stillUnknown
+	}
+
+	return n.mixnetServerKeys(mixnetServers), nil
+}
+
+// mixnetServerKeys returns the known signing keys of the mixnet servers, nil
+// for the unknown ones
+func (n *node) mixnetServerKeys(mixnetServers []string) [][]byte {
+	keys := make([][]byte, len(mixnetServers))
+	for i, mixnetServer := range mixnetServers {
+		keys[i] = n.getSigningKey(mixnetServer)
+	}
+	return keys
+}
@@ -1,15 +1,20 @@
 package impl
 
 import (
-	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
 	"math/big"
 
+	"go.dedis.ch/cs438/peer/impl/group"
 	"go.dedis.ch/cs438/types"
 )
 
+// electionGroup returns the group in which the election runs its cryptography
+func electionGroup(election *types.Election) (group.Group, error) {
+	return group.ForSuite(election.Base.Suite)
+}
+
 func inList(element string, list []string) bool {
 	for _, el := range list {
 		if element == el {
@@ -88,40 +93,48 @@ func findNonZeroIndex(list []uint32) int {
 	return -1
 }
 
-func MarshalPointList(pointList []types.Point, curve elliptic.Curve) [][]byte {
+func MarshalPointList(pointList []types.Point, g group.Group) ([][]byte, error) {
 	bytePointList := make([][]byte, 0)
 	for _, p := range pointList {
-		pBytes := elliptic.MarshalCompressed(curve, &p.X, &p.Y)
+		pBytes, err := compressPoint(g, p)
+		if err != nil {
+			return nil, err
+		}
 		bytePointList = append(bytePointList, pBytes)
-
-		// fmt.Printf("In MarshalPointList, Checking for entry %d, expected: %v\n", i, pBytes)
 	}
 
-	return bytePointList
+	return bytePointList, nil
 }
 
-func UnmarshalPointList(pointListBytes [][]byte, curve elliptic.Curve) []types.Point {
+func UnmarshalPointList(pointListBytes [][]byte, g group.Group) ([]types.Point, error) {
 	pointList := make([]types.Point, 0)
 	for _, p := range pointListBytes {
-
-		px, py := elliptic.UnmarshalCompressed(curve, p)
-		point := NewPoint(px, py)
-		pointList = append(pointList, point)
+		e, err := g.ElementFromBytes(p)
+		if err != nil {
+			return nil, err
+		}
+		pointList = append(pointList, e.Point())
 	}
 
-	return pointList
+	return pointList, nil
 }
 
-func MarshalElGamalList(ctList []types.ElGamalCipherText, curve elliptic.Curve) [][]byte {
+func MarshalElGamalList(ctList []types.ElGamalCipherText, g group.Group) ([][]byte, error) {
 	bytePointList := make([][]byte, 0)
 	for _, ct := range ctList {
-		pBytes := elliptic.MarshalCompressed(curve, &ct.Ct1.X, &ct.Ct1.Y)
+		pBytes, err := compressPoint(g, ct.Ct1)
+		if err != nil {
+			return nil, err
+		}
 		bytePointList = append(bytePointList, pBytes)
-		pBytesOther := elliptic.MarshalCompressed(curve, &ct.Ct2.X, &ct.Ct2.Y)
+		pBytesOther, err := compressPoint(g, ct.Ct2)
+		if err != nil {
+			return nil, err
+		}
 		bytePointList = append(bytePointList, pBytesOther)
 	}
 
-	return bytePointList
+	return bytePointList, nil
 }
 
 func MarshalBIntList(intList []big.Int) ([][]byte, error) {
@@ -1,23 +1,33 @@
 package impl
 
 import (
-	"crypto/elliptic"
-	"crypto/rand"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
 	"github.com/rs/xid"
 	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/peer"
+	"go.dedis.ch/cs438/peer/impl/group"
 	"go.dedis.ch/cs438/types"
 	"golang.org/x/xerrors"
 )
 
-const (
-	INITIAL_MIX_HOP = -1
-)
+// defaultVoteBackoff is the time before resending a ballot if
+// peer.Configuration.BackoffVote is not set
+const defaultVoteBackoff = time.Second
+
+// AnnounceElection implements peer.Voting
+func (n *node) AnnounceElection(title, description string, choices, mixnetServers []string, electionDuration time.Duration,
+	opts ...peer.ElectionOption) (string, error) {
 
-func (n *node) AnnounceElection(title, description string, choices, mixnetServers []string, electionDuration time.Duration) (string, error) {
 	// generate election id
 	electionChoices := []types.Choice{}
 	for i, choice := range choices {
@@ -34,17 +44,21 @@ func (n *node) AnnounceElection(title, description string, choices, mixnetServer
 
 	announceElectionMessage := types.AnnounceElectionMessage{
 		Base: types.ElectionBase{
-			ElectionID:  electionID,
-			Announcer:   n.myAddr,
-			Title:       title,
-			Description: description,
-			Choices:     electionChoices,
+			ElectionID:   electionID,
+			Announcer:    n.myAddr,
+			AnnouncerKey: n.GetVoterKey(),
+			Title:        title,
+			Description:  description,
+			Choices:      electionChoices,
 
 			Duration: electionDuration,
 
-			// initiated later (see HandleInitiateElectionMessage)
+			// initiated later (see InitiateElection), unless scheduled (see
+			// peer.WithSchedule)
 			// Expiration:    expirationTime,
 			MixnetServers: mixnetServers,
+			// pinned once the election is valid (see requestMixnetServerKeys)
+			// MixnetServerKeys: ...,
 
 			// initiated only if needed (see HandleAnnounceElectionMessage)
 			// MixnetServerInfos:   make(make([]types.MixnetServerInfo, len(mixnetServers)),
@@ -55,10 +69,91 @@ func (n *node) AnnounceElection(title, description string, choices, mixnetServer
 			Threshold:        threshold,
 			ElectionReadyCnt: 0,
 			Initiators:       initiators,
+
+			Suite: n.conf.PedersenSuite,
 		},
 	}
 
-	err := n.sendAnnounceElectionMessage(announceElectionMessage)
+	for _, opt := range opts {
+		opt(&announceElectionMessage.Base)
+	}
+
+	if announceElectionMessage.Base.TallyMode != types.TallyMixnet &&
+		announceElectionMessage.Base.TallyMode != types.TallyHomomorphic {
+		return "", xerrors.Errorf("unknown tally mode %s", announceElectionMessage.Base.TallyMode)
+	}
+
+	if announceElectionMessage.Base.VotePolicy != types.FirstVoteCounts &&
+		announceElectionMessage.Base.VotePolicy != types.LastVoteCounts {
+		return "", xerrors.Errorf("unknown vote policy %s", announceElectionMessage.Base.VotePolicy)
+	}
+
+	if announceElectionMessage.Base.Suite == "" {
+		announceElectionMessage.Base.Suite = types.DefaultPedersenSuite
+	}
+	_, err := group.ForSuite(announceElectionMessage.Base.Suite)
+	if err != nil {
+		return "", err
+	}
+
+	if !announceElectionMessage.Base.OpeningTime.IsZero() {
+		if !announceElectionMessage.Base.Expiration.After(announceElectionMessage.Base.OpeningTime) {
+			return "", errors.New("the election must close after it opens")
+		}
+		if !announceElectionMessage.Base.Expiration.After(time.Now()) {
+			return "", errors.New("the election must close in the future")
+		}
+	}
+
+	err = checkEligibleVoters(announceElectionMessage.Base.EligibleVoters)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkQuorum(&announceElectionMessage.Base)
+	if err != nil {
+		return "", err
+	}
+
+	if announceElectionMessage.Base.AnonymousCredentials {
+		if len(announceElectionMessage.Base.EligibleVoters) == 0 {
+			return "", errors.New("anonymous credentials need an eligibility roll")
+		}
+
+		credentialKey, err := createCredentialKey(n.conf.Storage.GetKeyStore(), electionID)
+		if err != nil {
+			return "", err
+		}
+		announceElectionMessage.Base.CredentialKey = credentialKey
+	}
+
+	election := types.Election{Base: announceElectionMessage.Base}
+
+	if len(election.Base.Contests) > 0 {
+		if len(choices) > 0 {
+			return "", errors.New("the choices of a multi-contest election are given per contest")
+		}
+
+		for i, contest := range election.Base.Contests {
+			err := checkBallotRules(election.GetContestElection(i))
+			if err != nil {
+				return "", xerrors.Errorf("contest %q: %v", contest.Title, err)
+			}
+		}
+	} else {
+		err := checkBallotRules(&election)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// the other peers only accept these keys for the mixnet servers
+	announceElectionMessage.Base.MixnetServerKeys, err = n.requestMixnetServerKeys(mixnetServers)
+	if err != nil {
+		return "", err
+	}
+
+	err = n.sendAnnounceElectionMessage(announceElectionMessage)
 	if err != nil {
 		return "", err
 	}
@@ -66,177 +161,527 @@ func (n *node) AnnounceElection(title, description string, choices, mixnetServer
 	return electionID, nil
 }
 
+// checkBallotRules checks that the choices and the ballot type of a
+// single-contest election make sense together
+func checkBallotRules(election *types.Election) error {
+	choiceCnt := len(election.Base.Choices)
+	if choiceCnt == 0 {
+		return errors.New("an election needs at least one choice")
+	}
+
+	switch election.Base.BallotType {
+	case types.BallotSingle:
+	case types.BallotRanked:
+		// ranked ballots are decrypted one by one, only the mixnet keeps
+		// them anonymous
+		if election.Base.TallyMode != types.TallyMixnet {
+			return xerrors.Errorf("%s ballots need the %s tally mode", types.BallotRanked, types.TallyMixnet)
+		}
+	case types.BallotApproval:
+		maxSelections := election.Base.MaxSelections
+		if maxSelections < 1 || maxSelections > choiceCnt {
+			return xerrors.Errorf("an approval ballot can't select up to %d of %d choices", maxSelections, choiceCnt)
+		}
+	default:
+		return xerrors.Errorf("unknown ballot type %s", election.Base.BallotType)
+	}
+
+	return nil
+}
+
+// GetElections implements peer.Voting. The elections are snapshots, the
+// handlers keep changing the stored ones.
 func (n *node) GetElections() []*types.Election {
 	elections := n.electionStore.GetAll()
 
 	return elections
 }
 
-func (n *node) Vote(electionID string, choiceID int) error {
+// Vote implements peer.Voting. It waits for the election to open, then sends
+// the ballot to the mixnet server until it acknowledges it with a signed
+// receipt. It returns early if the election closes or the context is done.
+func (n *node) Vote(ctx context.Context, electionID string, choiceID int) (types.BallotReceiptMessage, error) {
 	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	if len(election.Base.Contests) > 0 {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("election %s has several contests", electionID)
+	}
 
-	// encrypt choiceID
-	plaintext := big.NewInt(int64(choiceID))
-	publicKey := election.GetPublicKey()
-	rScalar := GenerateRandomBigInt(elliptic.P256().Params().N)
-	encryptedVote := ElGamalEncryption(elliptic.P256(), &publicKey, &rScalar, plaintext)
+	if election.Base.BallotType != types.BallotSingle {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("election %s expects %s ballots", electionID, election.Base.BallotType)
+	}
 
-	secretValue, err := rand.Int(rand.Reader, new(big.Int).SetUint64(2))
+	maker, err := n.singleBallotMaker(election, choiceID)
 	if err != nil {
-		return err
+		return types.BallotReceiptMessage{}, err
 	}
 
-	secretValueInt := int(secretValue.Int64())
+	return n.castBallot(ctx, election, maker)
+}
 
-	secretBit := secretValueInt == 0
+// VoteRanking implements peer.Voting
+func (n *node) VoteRanking(ctx context.Context, electionID string, ranking []int) (types.BallotReceiptMessage, error) {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("unknown election %s", electionID)
+	}
 
-	// rPkPoint will act as a decryption share on the part of the voters
-	// In Decryption, the final mixnet server will have to access it in the ballot proof in order to do correct
-	rPkX, rPkY := elliptic.P256().ScalarMult(&publicKey.X, &publicKey.Y, rScalar.Bytes())
-	rPkPoint := NewPoint(rPkX, rPkY)
-	correctEncProof, err := ProveDlogEq(rScalar.Bytes(), encryptedVote.Ct1, publicKey, rPkPoint, elliptic.P256())
+	if election.Base.BallotType != types.BallotRanked {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("election %s expects %s ballots", electionID, election.Base.BallotType)
+	}
 
+	maker, err := rankedBallotMaker(election, ranking)
 	if err != nil {
-		return err
+		return types.BallotReceiptMessage{}, err
+	}
+
+	return n.castBallot(ctx, election, maker)
+}
+
+// VoteApproval implements peer.Voting
+func (n *node) VoteApproval(ctx context.Context, electionID string, selection []int) (types.BallotReceiptMessage, error) {
+	election := n.electionStore.Get(electionID)
+	if election == nil {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("unknown election %s", electionID)
+	}
+
+	if election.Base.BallotType != types.BallotApproval {
+		return types.BallotReceiptMessage{}, xerrors.Errorf("election %s expects %s ballots", electionID, election.Base.BallotType)
 	}
 
-	// Prove that ballot is either the encryption of 0 or of 1
-	proofBallot, err := ProveDlogEqOr(rScalar.Bytes(), encryptedVote.Ct1, publicKey, rPkPoint, elliptic.P256(), secretBit)
+	maker, err := approvalBallotMaker(election, selection)
 	if err != nil {
-		return err
+		return types.BallotReceiptMessage{}, err
+	}
+
+	return n.castBallot(ctx, election, maker)
+}
+
+// ballotMaker makes the ballot of the selections of this peer, one per
+// contest. makeBallot encrypts them for the election key and also returns the
+// randomness of each ciphertext, record sets them in the election once the
+// ballot is cast.
+type ballotMaker struct {
+	selections [][]int
+	record     func(*types.Election)
+	makeBallot func(publicKey types.Point) (types.VoteMessage, []big.Int, error)
+}
+
+// singleBallotMaker checks the choice and returns the maker of its ballot
+func (n *node) singleBallotMaker(election *types.Election, choiceID int) (ballotMaker, error) {
+	if !contains(election.GetChoiceIDs(), choiceID) {
+		return ballotMaker{}, xerrors.Errorf("unknown choice %d for election %s", choiceID, election.Base.ElectionID)
+	}
+
+	return ballotMaker{
+		selections: [][]int{{choiceID}},
+		record: func(election *types.Election) {
+			election.MyVote = choiceID
+		},
+		makeBallot: func(publicKey types.Point) (types.VoteMessage, []big.Int, error) {
+			return n.makeBallot(election, publicKey, choiceID)
+		},
+	}, nil
+}
+
+// rankedBallotMaker checks the ranking and returns the maker of its ballot
+func rankedBallotMaker(election *types.Election, ranking []int) (ballotMaker, error) {
+	if !isRanking(election.Base.Choices, ranking) {
+		return ballotMaker{}, xerrors.Errorf("%v is not a ranking of the choices of election %s",
+			ranking, election.Base.ElectionID)
 	}
 
-	// broadcast as private message
-	voteMessage := types.VoteMessage{
-		ElectionID:       electionID,
-		EncryptedVote:    *encryptedVote,
-		CorrectVoteProof: *proofBallot,
-		CorectEncProof:   *correctEncProof,
+	return ballotMaker{
+		selections: [][]int{ranking},
+		record: func(election *types.Election) {
+			election.MyVote = ranking[0]
+			election.MyRanking = ranking
+		},
+		makeBallot: func(publicKey types.Point) (types.VoteMessage, []big.Int, error) {
+			return makeRankedBallot(election, publicKey, ranking)
+		},
+	}, nil
+}
+
+// approvalBallotMaker checks the selection and returns the maker of its ballot
+func approvalBallotMaker(election *types.Election, selection []int) (ballotMaker, error) {
+	if !isSelection(election, selection) {
+		return ballotMaker{}, xerrors.Errorf("%v is not a selection of 1 to %d choices of election %s",
+			selection, election.Base.MaxSelections, election.Base.ElectionID)
+	}
+
+	return ballotMaker{
+		selections: [][]int{selection},
+		record: func(election *types.Election) {
+			election.MyVote = selection[0]
+			election.MySelections = selection
+		},
+		makeBallot: func(publicKey types.Point) (types.VoteMessage, []big.Int, error) {
+			return makeApprovalBallot(election, publicKey, selection)
+		},
+	}, nil
+}
+
+// preparedBallot is a ballot made by PrepareBallot, with the randomness of its
+// ciphertexts and the maker of its selections
+type preparedBallot struct {
+	types.PreparedBallot
+	maker      ballotMaker
+	randomness []big.Int
+}
+
+// castBallot prepares the ballot (see prepareBallot) and casts it right away
+func (n *node) castBallot(ctx context.Context, election *types.Election, maker ballotMaker) (types.BallotReceiptMessage, error) {
+	prepared, err := n.prepareBallot(ctx, election, maker)
+	if err != nil {
+		return types.BallotReceiptMessage{}, err
 	}
 
-	if election.MyVote != -1 {
-		return errors.New("this peer has already voted")
+	return n.castPreparedBallot(ctx, election, prepared)
+}
+
+// prepareBallot waits for the election to open, then encrypts the ballot for
+// the election key and signs it. In a re-voting election, the ballot carries
+// the encrypted credential of this peer instead of its signature.
+func (n *node) prepareBallot(ctx context.Context, election *types.Election, maker ballotMaker) (*preparedBallot, error) {
+	electionID := election.Base.ElectionID
+
+	if election.MyVote != -1 && !election.Base.Revoting {
+		return nil, errors.New("this peer has already voted")
 	}
 
-	// n.dkgMutex.Lock()
-	// if !election.IsElectionStarted() {
-	// n.dkgMutex.Unlock()
-	// return errors.New("election hasn't started yet")
-	// }
+	if election.Base.AnonymousCredentials && election.MyCredential == nil {
+		return nil, xerrors.Errorf("this peer must register for election %s before voting", electionID)
+	}
 
-	// election.MyVote = choiceID
-	// n.electionStore.Set(voteMessage.ElectionID, election)
 	// wait for the election to start
-	election.VoteWG.Wait()
+	phase, err := n.waitForPhase(ctx, electionID, types.PhaseOpen)
+	if err != nil {
+		return nil, err
+	}
+	if phase != types.PhaseOpen {
+		return nil, xerrors.Errorf("election %s is %s, votes are not accepted", electionID, phase)
+	}
 
-	n.dkgMutex.Lock()
-	//if !election.IsElectionStarted() {
-	//	n.dkgMutex.Unlock()
-	//	return errors.New("election hasn't started yet")
-	//}
+	var publicKey types.Point
+	n.electionStore.Update(electionID, func(election *types.Election) {
+		publicKey = election.GetPublicKey()
+		if election.Base.Revoting {
+			// a new ballot replaces the previous ones
+			election.MyBallotCounter++
+		}
+	})
 
-	election.MyVote = choiceID
-	mixnetServer := election.GetFirstQualifiedInitiator()
-	n.dkgMutex.Unlock()
+	voteMessage, randomness, err := maker.makeBallot(publicKey)
+	if err != nil {
+		return nil, err
+	}
 
-	log.Info().Str("peerAddr", n.myAddr).Msgf("sending  VoteMessage to mixnetSever %s", mixnetServer)
-	err = n.sendVoteMessage(mixnetServer, voteMessage)
+	if election.Base.Revoting {
+		err = n.attachCredential(ctx, election, publicKey, &voteMessage)
+	} else {
+		err = n.signBallot(election, &voteMessage)
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &preparedBallot{
+		PreparedBallot: types.PreparedBallot{
+			ElectionID: electionID,
+			Ballot:     voteMessage,
+			BallotHash: BallotHash(&voteMessage),
+		},
+		maker:      maker,
+		randomness: randomness,
+	}, nil
 }
 
-func (n *node) Mix(electionID string, hop int, shuffleProofs []types.ShuffleProof, reEncProofs []types.Proof) error {
-	election := n.electionStore.Get(electionID)
-	votes := election.Votes
-	curve := elliptic.P256()
+// castPreparedBallot sends the prepared ballot (see sendBallot). Once the
+// mixnet server acknowledges it with a valid receipt, the selections of this
+// peer are set in the election, MyVote is then set, and only a re-voting
+// election accepts another ballot. A peer casts one ballot at a time.
+func (n *node) castPreparedBallot(ctx context.Context, election *types.Election,
+	prepared *preparedBallot) (types.BallotReceiptMessage, error) {
 
-	// do the actual mixing
-	voteCnt := len(votes)
-	election.Base.VotesPermutation = MakeRandomPermutation(voteCnt)
+	electionID := election.Base.ElectionID
 
-	permutedVotes := make([]types.VoteMessage, voteCnt)
-	for i := 0; i < voteCnt; i++ {
-		permutedVotes[i] = votes[election.Base.VotesPermutation[i]]
+	_, casting := n.castingBallots.LoadOrStore(electionID, struct{}{})
+	if casting {
+		return types.BallotReceiptMessage{}, errors.New("this peer is already casting a ballot")
+	}
+	defer n.castingBallots.Delete(electionID)
+
+	var mixnetServer string
+	var err error
+	n.electionStore.View(electionID, func(election *types.Election) {
+		if election.MyVote != -1 && !election.Base.Revoting {
+			err = errors.New("this peer has already voted")
+			return
+		}
+		mixnetServer = election.GetFirstQualifiedInitiator()
+	})
+	if err != nil {
+		return types.BallotReceiptMessage{}, err
 	}
 
-	publicKey := election.GetPublicKey()
-	reencryptedVotes := make([]types.VoteMessage, voteCnt)
+	log.Info().Str("peerAddr", n.myAddr).Msgf("sending  VoteMessage to mixnetSever %s", mixnetServer)
+	receipt, err := n.sendBallot(ctx, election, mixnetServer, &prepared.Ballot)
+	if err != nil {
+		return types.BallotReceiptMessage{}, err
+	}
 
-	// Generates a list of scalars for reencryption
-	rScalars := GenerateRandomPolynomial(len(votes)-1, curve.Params().N)
+	// the receipt is signed by the mixnet server (see HandleBallotReceiptMessage)
+	n.electionStore.Update(electionID, func(election *types.Election) {
+		prepared.maker.record(election)
+		election.MyBallotReceipt = &receipt
+	})
 
-	for i, permutedVote := range permutedVotes {
-		// Vote instead of Ciphetext
-		reencryptedVote := ElGamalVoteReEncryption(curve, &publicKey, &rScalars[i], permutedVote)
+	return receipt, nil
+}
+
+// sendBallot sends the ballot to the mixnet server, and resends it with
+// backoff (see peer.Configuration.BackoffVote) until it gets a receipt. The
+// mixnet server stores a ballot only once.
+func (n *node) sendBallot(ctx context.Context, election *types.Election, mixnetServer string,
+	ballot *types.VoteMessage) (types.BallotReceiptMessage, error) {
+
+	electionID := election.Base.ElectionID
+
+	// filled by HandleBallotReceiptMessage
+	receipts := make(chan types.BallotReceiptMessage, 1)
+	ballotKey := hex.EncodeToString(BallotHash(ballot))
+	n.ballotReceipts.Store(ballotKey, receipts)
+	defer n.ballotReceipts.Delete(ballotKey)
+
+	phases, unsubscribe, err := n.SubscribePhase(electionID)
+	if err != nil {
+		return types.BallotReceiptMessage{}, err
+	}
+	defer unsubscribe()
 
-		// This is the original vote on which reEncryption is done
-		encVoteBefore := votes[election.Base.VotesPermutation[i]].EncryptedVote
+	backoff := n.conf.BackoffVote
+	if backoff.Initial <= 0 {
+		backoff.Initial = defaultVoteBackoff
+	}
+	waitTime := backoff.Initial
+
+	resend := time.NewTimer(0)
+	defer resend.Stop()
+
+	for attempt := uint(0); ; {
+		select {
+		case receipt := <-receipts:
+			return receipt, nil
+
+		case phase, ok := <-phases:
+			if !ok {
+				return types.BallotReceiptMessage{}, xerrors.Errorf("stopped waiting for the receipt of election %s", electionID)
+			}
+			if phase == types.PhaseOpen {
+				continue
+			}
+
+			// the receipt may be processed right after the closing
+			select {
+			case receipt := <-receipts:
+				return receipt, nil
+			default:
+			}
+			return types.BallotReceiptMessage{}, xerrors.Errorf("election %s is %s, the ballot was not acknowledged", electionID, phase)
+
+		case <-ctx.Done():
+			return types.BallotReceiptMessage{}, ctx.Err()
+
+		case <-resend.C:
+			if attempt > 0 {
+				log.Warn().Str("peerAddr", n.myAddr).Msgf("no receipt from mixnet server %s, resending the ballot", mixnetServer)
+			}
+
+			err := n.sendVoteMessage(mixnetServer, *ballot)
+			if err != nil {
+				return types.BallotReceiptMessage{}, err
+			}
+
+			resend.Reset(waitTime)
+			if attempt < backoff.Retry {
+				waitTime *= time.Duration(backoff.Factor)
+				attempt++
+			}
+		}
+	}
+}
 
-		minusOne := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+// makeBallot one-hot encrypts choiceID: the ballot holds one ciphertext per
+// election choice, the one of choiceID encrypts 1 and all the others encrypt 0.
+// Each ciphertext comes with a proof that it encrypts either 0 or 1, and the
+// ballot with a proof that the ciphertexts add up to exactly one. It also
+// returns the randomness of each ciphertext.
+func (n *node) makeBallot(election *types.Election, publicKey types.Point, choiceID int) (types.VoteMessage, []big.Int, error) {
+	g, err := electionGroup(election)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
+	}
+	choices := election.Base.Choices
 
-		// Negate the before ciphertexts
-		negEncVoteBeforeCt1X, negEncVoteBeforeCt1Y := curve.ScalarMult(&encVoteBefore.Ct1.X, &encVoteBefore.Ct1.Y, minusOne.Bytes())
-		negEncVoteBeforeCt2X, negEncVoteBeforeCt2Y := curve.ScalarMult(&encVoteBefore.Ct2.X, &encVoteBefore.Ct2.Y, minusOne.Bytes())
+	encryptedVotes := make([]types.ElGamalCipherText, len(choices))
+	correctVoteProofs := make([]types.Proof, len(choices))
+	rScalars := make([]big.Int, len(choices))
+	rSum := new(big.Int)
 
-		DiffCt1X, DiffCt1Y := curve.Add(&reencryptedVote.EncryptedVote.Ct1.X, &reencryptedVote.EncryptedVote.Ct1.Y, negEncVoteBeforeCt1X, negEncVoteBeforeCt1Y)
-		DiffCt1Point := NewPoint(DiffCt1X, DiffCt1Y)
+	for i, choice := range choices {
+		bit := choice.ChoiceID == choiceID
+		plaintext := big.NewInt(0)
+		if bit {
+			plaintext = big.NewInt(1)
+		}
 
-		DiffCt2X, DiffCt2Y := curve.Add(&reencryptedVote.EncryptedVote.Ct2.X, &reencryptedVote.EncryptedVote.Ct2.Y, negEncVoteBeforeCt2X, negEncVoteBeforeCt2Y)
-		DiffCt2Point := NewPoint(DiffCt2X, DiffCt2Y)
+		rScalars[i] = GenerateRandomBigInt(g.Order())
+		rSum.Add(rSum, &rScalars[i])
 
-		// Mixnet needs to prove that reenecryption is done properly. This is also a proof of the correct decryption share.
-		reEncProof, err := ProveDlogEq(rScalars[i].Bytes(), DiffCt1Point, election.GetPublicKey(), DiffCt2Point, curve)
+		encryptedVote, err := ElGamalEncryption(g, &publicKey, &rScalars[i], plaintext)
 		if err != nil {
-			return xerrors.Errorf("Error in Mix function, when generating reEncryption Proof, %v", err)
+			return types.VoteMessage{}, nil, err
 		}
+		encryptedVotes[i] = *encryptedVote
 
-		reEncProofs = append(reEncProofs, *reEncProof)
-		reencryptedVotes = append(reencryptedVotes, reencryptedVote)
+		// Prove that the ciphertext is either the encryption of 0 or of 1
+		proofBallot, err := ProveEncryptedBit(&rScalars[i], publicKey, *encryptedVote, bit, g)
+		if err != nil {
+			return types.VoteMessage{}, nil, err
+		}
+		correctVoteProofs[i] = *proofBallot
+	}
+
+	// Prove that exactly one of the ciphertexts encrypts 1
+	rSum.Mod(rSum, g.Order())
+	encryptedSum, err := ElGamalAddCipherTexts(g, encryptedVotes)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
 	}
 
-	// Do Shuffle proof on the code
+	sumProof, err := ProveEncryptedOne(rSum, publicKey, encryptedSum, g)
+	if err != nil {
+		return types.VoteMessage{}, nil, err
+	}
 
-	// Extract ciphertexts from the received vote list
-	ctBeforeList := make([]types.ElGamalCipherText, 0)
-	for _, vote := range votes {
-		ctBeforeList = append(ctBeforeList, vote.EncryptedVote)
+	return types.VoteMessage{
+		ElectionID:        election.Base.ElectionID,
+		EncryptedVotes:    encryptedVotes,
+		CorrectVoteProofs: correctVoteProofs,
+		SumProof:          *sumProof,
+	}, rScalars, nil
+}
+
+// VerifyBallot checks that the ballot holds one ciphertext per election choice,
+// that each of them encrypts either 0 or 1 and that they add up to exactly one.
+// Ranked, approval and multi-contest ballots are checked with
+// VerifyRankedBallot, VerifyApprovalBallot and VerifyContestsBallot.
+func VerifyBallot(election *types.Election, publicKey types.Point, vote *types.VoteMessage) bool {
+	if len(election.Base.Contests) > 0 {
+		return VerifyContestsBallot(election, publicKey, vote)
+	}
+
+	switch election.Base.BallotType {
+	case types.BallotRanked:
+		return VerifyRankedBallot(election, publicKey, vote)
+	case types.BallotApproval:
+		return VerifyApprovalBallot(election, publicKey, vote)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return false
+	}
+	choiceCnt := len(election.Base.Choices)
+
+	if len(vote.EncryptedVotes) != choiceCnt || len(vote.CorrectVoteProofs) != choiceCnt {
+		return false
 	}
 
-	// Extract ciphertexts from reencrypted vote list
-	ctAfterList := make([]types.ElGamalCipherText, 0)
-	for _, vote := range reencryptedVotes {
-		ctBeforeList = append(ctAfterList, vote.EncryptedVote)
+	for i, encryptedVote := range vote.EncryptedVotes {
+		if !VerifyEncryptedBit(&vote.CorrectVoteProofs[i], g, publicKey, encryptedVote) {
+			return false
+		}
 	}
 
-	shuffleInstance := NewShuffleInstance(curve, publicKey, ctBeforeList, ctAfterList)
-	shuffleWitness := NewShuffleWitness(election.Base.VotesPermutation, rScalars)
-	shuffleProof, err := ProveShuffle(shuffleInstance, shuffleWitness)
+	encryptedSum, err := ElGamalAddCipherTexts(g, vote.EncryptedVotes)
+	if err != nil {
+		return false
+	}
 
-	shuffleProofs = append(shuffleProofs, *shuffleProof)
+	return VerifyEncryptedOne(&vote.SumProof, g, publicKey, encryptedSum)
+}
 
+func (n *node) Mix(electionID string, hop int, shuffleProofs, ballotShuffleProofs []types.ShuffleProof, reEncProofs []types.Proof) error {
+	election := n.electionStore.Get(electionID)
+	g, err := electionGroup(election)
 	if err != nil {
 		return err
 	}
 
+	var publicKey types.Point
+	var votes []types.VoteMessage
+	var secretShare big.Int
+	n.electionStore.View(electionID, func(election *types.Election) {
+		publicKey = election.GetPublicKey()
+		votes = election.Votes
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	if len(shuffleProofs) == 0 {
+		// first hop: the ballots are still signed by their voters
+		votes = stripVoterSignatures(votes)
+	}
+
+	// do the actual mixing
+	permutation, reencryptedVotes, hopShuffleProofs, hopBallotShuffleProof, hopReEncProofs, err :=
+		ShuffleVotes(g, publicKey, votes, election.GetBallotSize())
+	if err != nil {
+		return err
+	}
+	shuffleProofs = append(shuffleProofs, hopShuffleProofs...)
+	if hopBallotShuffleProof != nil {
+		ballotShuffleProofs = append(ballotShuffleProofs, *hopBallotShuffleProof)
+	}
+	reEncProofs = append(reEncProofs, hopReEncProofs...)
+
 	// get address for next hop
-	nextHop := election.GetNextMixHop(hop)
+	var nextHop int
+	n.electionStore.Update(electionID, func(election *types.Election) {
+		election.Base.VotesPermutation = permutation
+		nextHop = election.GetNextMixHop(hop)
+	})
 
 	// otherwise continue forwarding to the next mixnet server
 	mixMessage := types.MixMessage{
-		ElectionID:         electionID,
-		Votes:              reencryptedVotes,
-		NextHop:            nextHop,
-		ShuffleProofs:      shuffleProofs,
-		ReEncryptionProofs: reEncProofs,
+		ElectionID:          electionID,
+		MixnetServerID:      election.GetMyMixnetServerID(n.myAddr),
+		Votes:               reencryptedVotes,
+		NextHop:             nextHop,
+		ShuffleProofs:       shuffleProofs,
+		BallotShuffleProofs: ballotShuffleProofs,
+		ReEncryptionProofs:  reEncProofs,
 	}
 
-	if nextHop != -1 {
+	mixMessage.Signature, err = SignMixMessage(g, &mixMessage, &secretShare)
+	if err != nil {
+		return err
+	}
+
+	// mix batches are sent privately to the next hop, publish them
+	err = n.postOnBulletinBoard(electionID, &mixMessage)
+	if err != nil {
+		return err
+	}
+
+	if nextHop == -1 {
 		// done with mixing -> tally
 		log.Info().Str("peerAddr", n.myAddr).Msgf("Last mixnet node reached: Start Tallying")
-		n.Tally(electionID, mixMessage)
+		n.Tally(electionID, mixMessage.Votes)
 		return nil
 	}
 
@@ -245,7 +690,7 @@ func (n *node) Mix(electionID string, hop int, shuffleProofs []types.ShuffleProo
 	recipients := make(map[string]struct{})
 	recipients[mixnetPeer] = struct{}{}
 
-	err = n.sendPrivateMessage(recipients, mixMessage)
+	err = n.sendPrivateMessage(recipients, &mixMessage)
 	if err != nil {
 		return err
 	}
@@ -253,76 +698,729 @@ func (n *node) Mix(electionID string, hop int, shuffleProofs []types.ShuffleProo
 	return nil
 }
 
-func (n *node) Tally(electionID string, mixMessage types.MixMessage) {
-	election := n.electionStore.Get(electionID)
-	curve := elliptic.P256()
-	votes := mixMessage.Votes
-	// Step 1: add all ct2's together; this aggregates all encrypted vote into "encrypted result"
-	electionResultX, electionResultY := votes[0].EncryptedVote.Ct2.X, votes[0].EncryptedVote.Ct2.Y
-	encResult := NewPoint(&electionResultX, &electionResultY)
-	if len(votes) > 1 {
-		for i := 1; i < len(votes); i++ {
-			encResultX, encResultY := curve.Add(&encResult.X, &encResult.Y, &votes[i].EncryptedVote.Ct2.X, &votes[i].EncryptedVote.Ct2.X)
+// ShuffleVotes permutes and re-encrypts the votes, this is the work of one mix
+// hop. It returns the permutation, the mixed votes, one shuffle proof per
+// ciphertext of a ballot (each position in the ballot is a separate list of
+// ciphertexts), the proof that the whole ballots are shuffled (see
+// ProveBallotShuffle), which is nil if there are no votes, and one
+// re-encryption proof per ciphertext of each mixed ballot.
+func ShuffleVotes(g group.Group, publicKey types.Point, votes []types.VoteMessage, ballotSize int) ([]uint32,
+	[]types.VoteMessage, []types.ShuffleProof, *types.ShuffleProof, []types.Proof, error) {
+
+	voteCnt := len(votes)
+	permutation := MakeRandomPermutation(voteCnt)
+
+	permutedVotes := make([]types.VoteMessage, voteCnt)
+	for i := 0; i < voteCnt; i++ {
+		permutedVotes[i] = votes[permutation[i]]
+	}
+
+	reencryptedVotes := make([]types.VoteMessage, 0, voteCnt)
+	reEncProofs := make([]types.Proof, 0, voteCnt*ballotSize)
+
+	// Generates a list of scalars for reencryption, one per ciphertext of each ballot
+	rScalars := make([][]big.Int, voteCnt)
+	for i := range rScalars {
+		rScalars[i] = GenerateRandomPolynomial(ballotSize-1, g.Order())
+	}
+
+	for i, permutedVote := range permutedVotes {
+		// Vote instead of Ciphetext
+		reencryptedVote, err := ElGamalVoteReEncryption(g, &publicKey, rScalars[i], permutedVote)
+		if err != nil {
+			return nil, nil, nil, nil, nil, xerrors.Errorf("Error in Mix function, when re-encrypting a vote, %v", err)
+		}
+
+		for j := 0; j < ballotSize; j++ {
+			// This is the original vote on which reEncryption is done
+			diff, err := ElGamalSubtractCipherTexts(g, reencryptedVote.EncryptedVotes[j], permutedVote.EncryptedVotes[j])
+			if err != nil {
+				return nil, nil, nil, nil, nil, xerrors.Errorf("Error in Mix function, when re-encrypting a vote, %v", err)
+			}
+
+			// Mixnet needs to prove that reenecryption is done properly
+			reEncProof, err := ProveDlogEq(rScalars[i][j].Bytes(), diff.Ct1, publicKey, diff.Ct2, g)
+			if err != nil {
+				return nil, nil, nil, nil, nil, xerrors.Errorf("Error in Mix function, when generating reEncryption Proof, %v", err)
+			}
+
+			reEncProofs = append(reEncProofs, *reEncProof)
+		}
+
+		reencryptedVotes = append(reencryptedVotes, reencryptedVote)
+	}
+
+	// Do Shuffle proof on the code, one per position as each position is a separate list of ciphertexts
+	shuffleProofs := make([]types.ShuffleProof, 0, ballotSize)
+	for j := 0; voteCnt > 0 && j < ballotSize; j++ {
+		rScalarList := make([]big.Int, 0, voteCnt)
+		for i := range reencryptedVotes {
+			rScalarList = append(rScalarList, rScalars[i][j])
+		}
+
+		shuffleInstance := NewShuffleInstance(g, publicKey, getChoiceCipherTexts(votes, j), getChoiceCipherTexts(reencryptedVotes, j))
+		shuffleWitness := NewShuffleWitness(permutation, rScalarList)
+		shuffleProof, err := ProveShuffle(shuffleInstance, shuffleWitness)
+		if err != nil {
+			return nil, nil, nil, nil, nil, err
+		}
+
+		shuffleProofs = append(shuffleProofs, *shuffleProof)
+	}
+
+	if voteCnt == 0 {
+		return permutation, reencryptedVotes, shuffleProofs, nil, reEncProofs, nil
+	}
 
-			encResult.X, encResult.Y = *encResultX, *encResultY
+	// the positions are shuffled separately above, prove that they are all
+	// shuffled with the same permutation
+	ballotShuffleProof, err := ProveBallotShuffle(g, publicKey, getColumns(votes, ballotSize),
+		getColumns(reencryptedVotes, ballotSize), permutation, rScalars)
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
+	return permutation, reencryptedVotes, shuffleProofs, ballotShuffleProof, reEncProofs, nil
+}
+
+// VerifyMixProofs verifies the proofs accumulated in the mixMessage, hop by
+// hop, and returns the number of hops whose output can be trusted. Each hop
+// must prove the shuffle of its input (the output of the previous hop), that
+// the same permutation shuffles every position of the ballots, and the
+// re-encryption of each of its ciphertexts. The votes of the message are valid
+// only if all the hops are, that is, if validHops*ballotSize == len(ShuffleProofs)
+// and the votes match the output of the last hop.
+func VerifyMixProofs(g group.Group, publicKey types.Point, ballotSize int, mixMessage *types.MixMessage) (validHops int, ok bool) {
+	shuffleProofs := mixMessage.ShuffleProofs
+	if len(shuffleProofs) == 0 {
+		// nothing was mixed, there must be no votes
+		return 0, len(mixMessage.Votes) == 0 && len(mixMessage.BallotShuffleProofs) == 0 &&
+			len(mixMessage.ReEncryptionProofs) == 0
+	}
+
+	voteCnt := len(shuffleProofs[0].Instance.CtBefore)
+	hopCnt := len(shuffleProofs) / ballotSize
+
+	for h := 0; h < hopCnt; h++ {
+		if !verifyMixHop(g, publicKey, ballotSize, voteCnt, mixMessage, h) {
+			return h, false
+		}
+	}
+
+	if hopCnt*ballotSize != len(shuffleProofs) || hopCnt != len(mixMessage.BallotShuffleProofs) ||
+		hopCnt*ballotSize*voteCnt != len(mixMessage.ReEncryptionProofs) {
+		return hopCnt, false
+	}
+
+	// the votes must be the output of the last hop
+	if len(mixMessage.Votes) != voteCnt {
+		return hopCnt, false
+	}
+	for j := 0; j < ballotSize; j++ {
+		for i, vote := range mixMessage.Votes {
+			if len(vote.EncryptedVotes) != ballotSize ||
+				!equalCipherTexts(vote.EncryptedVotes[j], shuffleProofs[(hopCnt-1)*ballotSize+j].Instance.CtAfter[i]) {
+				return hopCnt, false
+			}
 		}
 	}
 
-	// Step 2: Access all decryption shares of the mixnets
-	minusOne := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
-	for _, proof := range mixMessage.ReEncryptionProofs {
-		decryptShareCompressed := proof.PPointOther
-		decryptShareX, decryptShareY := elliptic.UnmarshalCompressed(curve, decryptShareCompressed)
-		negDecryptShareX, negDecryptShareY := curve.ScalarMult(decryptShareX, decryptShareY, minusOne.Bytes())
+	return hopCnt, true
+}
+
+// verifyMixHop verifies the shuffle and re-encryption proofs of hop h
+func verifyMixHop(g group.Group, publicKey types.Point, ballotSize, voteCnt int, mixMessage *types.MixMessage, h int) bool {
+	pkCompressed, err := compressPoint(g, publicKey)
+	if err != nil {
+		return false
+	}
+
+	if h >= len(mixMessage.BallotShuffleProofs) {
+		return false
+	}
+
+	before := make([][]types.ElGamalCipherText, ballotSize)
+	after := make([][]types.ElGamalCipherText, ballotSize)
+
+	for j := 0; j < ballotSize; j++ {
+		shuffleProof := mixMessage.ShuffleProofs[h*ballotSize+j]
+		instance := shuffleProof.Instance
+
+		if !equalPoints(instance.PPoint, publicKey) || len(instance.CtBefore) != voteCnt || len(instance.CtAfter) != voteCnt {
+			return false
+		}
+		before[j] = instance.CtBefore
+		after[j] = instance.CtAfter
+
+		// the input of the hop is the output of the previous one
+		if h > 0 {
+			previous := mixMessage.ShuffleProofs[(h-1)*ballotSize+j].Instance.CtAfter
+			for i := range previous {
+				if !equalCipherTexts(previous[i], instance.CtBefore[i]) {
+					return false
+				}
+			}
+		}
+
+		shuffleProof.Instance.Suite = g.Suite()
+		if !VerifyShuffle(&shuffleProof) {
+			return false
+		}
 
-		encResultX, encResultY := curve.Add(&encResult.X, &encResult.Y, negDecryptShareX, negDecryptShareY)
-		encResult.X, encResult.Y = *encResultX, *encResultY
+		// each output ciphertext is a re-encryption of one of the input ciphertexts
+		for i := 0; i < voteCnt; i++ {
+			k := (h*voteCnt+i)*ballotSize + j
+			if k >= len(mixMessage.ReEncryptionProofs) {
+				return false
+			}
+
+			reEncProof := mixMessage.ReEncryptionProofs[k]
+			if !checkChallBytes(pkCompressed, reEncProof.BPointOther) {
+				return false
+			}
+
+			reEncProof.Suite = g.Suite()
+			isValid, err := VerifyDlogEq(&reEncProof)
+			if err != nil || !isValid {
+				return false
+			}
+
+			diffPoints, err := decodeElements(g, reEncProof.PPoint, reEncProof.PPointOther)
+			if err != nil {
+				return false
+			}
+			diff := types.ElGamalCipherText{
+				Ct1: diffPoints[0].Point(),
+				Ct2: diffPoints[1].Point(),
+			}
+
+			before, err := ElGamalSubtractCipherTexts(g, instance.CtAfter[i], diff)
+			if err != nil || !containsCipherText(instance.CtBefore, before) {
+				return false
+			}
+		}
+	}
+
+	// each position is shuffled, with the same permutation
+	return VerifyBallotShuffle(g, publicKey, before, after, &mixMessage.BallotShuffleProofs[h])
+}
+
+// SignMixComplaint signs the complaint with the secret key share of the
+// complaining mixnet server (see signWithShare, the public key is its public
+// key share)
+func SignMixComplaint(g group.Group, complaint *types.MixComplaintMessage, secretShare *big.Int) ([]byte, error) {
+	return signWithShare(g, mixComplaintDigest(complaint), secretShare)
+}
+
+// VerifyMixComplaint checks the signature of the complaint against the public
+// key share of the complaining mixnet server
+func VerifyMixComplaint(g group.Group, complaint *types.MixComplaintMessage, publicShare *types.Point) bool {
+	return verifyWithShare(g, mixComplaintDigest(complaint), complaint.Signature, publicShare)
+}
+
+// SignMixMessage signs the batch with the secret key share of the mixnet
+// server which mixed it
+func SignMixMessage(g group.Group, mixMessage *types.MixMessage, secretShare *big.Int) ([]byte, error) {
+	digest, err := mixMessageDigest(mixMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	return signWithShare(g, digest, secretShare)
+}
+
+// VerifyMixMessage checks the signature of the batch against the public key
+// share of the mixnet server which mixed it
+func VerifyMixMessage(g group.Group, mixMessage *types.MixMessage, publicShare *types.Point) bool {
+	digest, err := mixMessageDigest(mixMessage)
+	if err != nil {
+		return false
+	}
+
+	return verifyWithShare(g, digest, mixMessage.Signature, publicShare)
+}
+
+// SignDecryptionRequest signs the request with the secret key share of the
+// mixnet server which tallied the ballots
+func SignDecryptionRequest(g group.Group, request *types.DecryptionRequestMessage, secretShare *big.Int) ([]byte, error) {
+	digest, err := decryptionRequestDigest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return signWithShare(g, digest, secretShare)
+}
+
+// VerifyDecryptionRequest checks the signature of the request against the
+// public key share of the mixnet server which tallied the ballots
+func VerifyDecryptionRequest(g group.Group, request *types.DecryptionRequestMessage, publicShare *types.Point) bool {
+	digest, err := decryptionRequestDigest(request)
+	if err != nil {
+		return false
+	}
+
+	return verifyWithShare(g, digest, request.Signature, publicShare)
+}
+
+// SignBallotReceipt signs the receipt with the secret key share of the mixnet
+// server which stored the ballot
+func SignBallotReceipt(g group.Group, receipt *types.BallotReceiptMessage, secretShare *big.Int) ([]byte, error) {
+	return signWithShare(g, ballotReceiptDigest(receipt), secretShare)
+}
+
+// VerifyBallotReceipt checks the signature of the receipt against the public
+// key share of the mixnet server which stored the ballot
+func VerifyBallotReceipt(g group.Group, receipt *types.BallotReceiptMessage, publicShare *types.Point) bool {
+	return verifyWithShare(g, ballotReceiptDigest(receipt), receipt.Signature, publicShare)
+}
+
+// BallotHash returns the hash of the ciphertexts of the ballot, which
+// identifies it
+func BallotHash(ballot *types.VoteMessage) []byte {
+	h := sha256.New()
+	h.Write([]byte(ballot.ElectionID))
+	for _, ct := range ballot.EncryptedVotes {
+		for _, point := range []types.Point{ct.Ct1, ct.Ct2} {
+			h.Write(point.X.Bytes())
+			h.Write(point.Y.Bytes())
+		}
+	}
+	return h.Sum(nil)
+}
+
+// signWithShare makes a Schnorr signature of the digest in the group of the
+// election, the secret key is a share of the election key. The signature is
+// the compressed commitment R followed by the response s = k + c*x, where
+// c = H(R, x*G, digest).
+func signWithShare(g group.Group, digest []byte, secretShare *big.Int) ([]byte, error) {
+	k, err := g.RandomScalar()
+	if err != nil {
+		return nil, err
+	}
+
+	x := g.ScalarFromInt(secretShare)
+	rPoint := g.BaseMul(k)
+	c := shareSignatureChallenge(g, rPoint, g.BaseMul(x), digest)
+
+	return append(rPoint.Bytes(), k.Add(c.Mul(x)).Bytes()...), nil
+}
+
+// verifyWithShare checks a signature of signWithShare against the public key
+// share: s*G = R + c*(x*G)
+func verifyWithShare(g group.Group, digest []byte, signature []byte, publicShare *types.Point) bool {
+	pointLen := len(g.Generator().Bytes())
+	if len(signature) != pointLen+g.ScalarLen() {
+		return false
+	}
+
+	publicKey, err := g.ElementFromPoint(*publicShare)
+	if err != nil {
+		return false
+	}
+
+	rPoint, err := g.ElementFromBytes(signature[:pointLen])
+	if err != nil {
+		return false
+	}
+
+	c := shareSignatureChallenge(g, rPoint, publicKey, digest)
+	sPoint := g.BaseMul(g.Scalar(signature[pointLen:]))
+
+	return sPoint.Equal(rPoint.Add(publicKey.Mul(c)))
+}
+
+func shareSignatureChallenge(g group.Group, rPoint, publicKey group.Element, digest []byte) group.Scalar {
+	h := sha256.New()
+	h.Write(rPoint.Bytes())
+	h.Write(publicKey.Bytes())
+	h.Write(digest)
+	return g.Scalar(h.Sum(nil))
+}
+
+func ballotReceiptDigest(receipt *types.BallotReceiptMessage) []byte {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%x", receipt.ElectionID, receipt.MixnetServerID, receipt.BallotHash)))
+	return digest[:]
+}
+
+func mixComplaintDigest(complaint *types.MixComplaintMessage) []byte {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", complaint.ElectionID, complaint.MixnetServerID, complaint.AccusedID)))
+	return digest[:]
+}
+
+// mixMessageDigest hashes the whole batch but its signature
+func mixMessageDigest(mixMessage *types.MixMessage) ([]byte, error) {
+	unsigned := *mixMessage
+	unsigned.Signature = nil
+
+	buf, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(append([]byte("mix|"), buf...))
+	return digest[:], nil
+}
+
+// decryptionRequestDigest hashes the whole request but its signature
+func decryptionRequestDigest(request *types.DecryptionRequestMessage) ([]byte, error) {
+	unsigned := *request
+	unsigned.Signature = nil
+
+	buf, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := sha256.Sum256(append([]byte("decryption-request|"), buf...))
+	return digest[:], nil
+}
+
+// getLastValidBatch returns the votes output by the last valid hop, or the
+// votes input to the first hop if none is valid. It fails if the proofs don't
+// hold a batch of ballots of ballotSize ciphertexts for that hop.
+func getLastValidBatch(ballotSize int, shuffleProofs []types.ShuffleProof, validHops int) ([]types.VoteMessage, error) {
+	hop := validHops
+	if hop == 0 {
+		hop = 1
+	}
+	if ballotSize <= 0 || validHops < 0 || hop*ballotSize > len(shuffleProofs) {
+		return nil, xerrors.Errorf("no batch of hop %d in %d shuffle proofs", validHops, len(shuffleProofs))
+	}
+
+	columns := make([][]types.ElGamalCipherText, ballotSize)
+	for j := range columns {
+		if validHops == 0 {
+			columns[j] = shuffleProofs[j].Instance.CtBefore
+		} else {
+			columns[j] = shuffleProofs[(validHops-1)*ballotSize+j].Instance.CtAfter
+		}
+
+		if len(columns[j]) != len(columns[0]) {
+			return nil, xerrors.Errorf("the batch of hop %d has %d ciphertexts at position %d, %d at position 0",
+				validHops, len(columns[j]), j, len(columns[0]))
+		}
 	}
 
-	// Step 3: Access all decryption shares of the voters
+	votes := make([]types.VoteMessage, len(columns[0]))
+	for i := range votes {
+		votes[i].EncryptedVotes = make([]types.ElGamalCipherText, ballotSize)
+		for j := range columns {
+			votes[i].EncryptedVotes[j] = columns[j][i]
+		}
+	}
+
+	return votes, nil
+}
+
+// getColumns returns the ciphertexts of the votes position by position (see
+// getChoiceCipherTexts)
+func getColumns(votes []types.VoteMessage, ballotSize int) [][]types.ElGamalCipherText {
+	columns := make([][]types.ElGamalCipherText, ballotSize)
+	for j := range columns {
+		columns[j] = getChoiceCipherTexts(votes, j)
+	}
+	return columns
+}
+
+// getChoiceCipherTexts returns the j-th ciphertext of all the votes, that is,
+// the ones of choice j for single choice ballots
+func getChoiceCipherTexts(votes []types.VoteMessage, j int) []types.ElGamalCipherText {
+	ctList := make([]types.ElGamalCipherText, 0, len(votes))
 	for _, vote := range votes {
-		proof := vote.CorectEncProof
-		decryptShareCompressed := proof.PPointOther
-		decryptShareX, decryptShareY := elliptic.UnmarshalCompressed(curve, decryptShareCompressed)
-		negDecryptShareX, negDecryptShareY := curve.ScalarMult(decryptShareX, decryptShareY, minusOne.Bytes())
+		ctList = append(ctList, vote.EncryptedVotes[j])
+	}
+	return ctList
+}
+
+func equalPoints(p, q types.Point) bool {
+	return p.X.Cmp(&q.X) == 0 && p.Y.Cmp(&q.Y) == 0
+}
+
+func equalCipherTexts(ct, other types.ElGamalCipherText) bool {
+	return equalPoints(ct.Ct1, other.Ct1) && equalPoints(ct.Ct2, other.Ct2)
+}
 
-		encResultX, encResultY := curve.Add(&encResult.X, &encResult.Y, negDecryptShareX, negDecryptShareY)
-		encResult.X, encResult.Y = *encResultX, *encResultY
+// containsBallot returns true if one of the ballots has the given hash
+func containsBallot(ballots []types.VoteMessage, ballotHash []byte) bool {
+	for i := range ballots {
+		if bytes.Equal(BallotHash(&ballots[i]), ballotHash) {
+			return true
+		}
 	}
-	// The actual vote count (the number of 1 votes) is the discrete log of the encResult
-	participantNum := len(votes)
-	resultOnes, _ := BsgsFunction(&encResult, curve, participantNum)
+	return false
+}
 
-	resultOnesUint := uint(resultOnes.Uint64())
+func containsCipherText(ctList []types.ElGamalCipherText, ct types.ElGamalCipherText) bool {
+	for _, other := range ctList {
+		if equalCipherTexts(ct, other) {
+			return true
+		}
+	}
+	return false
+}
 
-	// Do partial decryption, then append proofs to the result message
+// getVotes returns the ballots stored in the election
+func (n *node) getVotes(election *types.Election) []types.VoteMessage {
+	var votes []types.VoteMessage
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		votes = election.Votes
+	})
+	return votes
+}
 
-	// we want 0 to show up as a count as well
-	// inefficient, but doesn't matter
-	results := map[int]uint{}
-	results[1] = resultOnesUint
-	results[0] = uint(participantNum) - resultOnesUint
-	for _, choice := range election.Base.Choices {
-		count := uint(0)
-		//for _, vote := range votes {
-		//if vote == choice.ChoiceID {
-		count++
-		//}
-		//}
+// Tally is run by the last mixnet server, or by the mixnet server which stored
+// the ballots in homomorphic mode. It homomorphically adds the ballots into one
+// ciphertext per choice and asks the qualified mixnet servers to decrypt them
+// (see HandleDecryptionRequestMessage). No single mixnet server can decrypt the
+// tally on its own, Threshold decryption shares are needed.
+func (n *node) Tally(electionID string, votes []types.VoteMessage) {
+	election := n.electionStore.Get(electionID)
 
-		results[choice.ChoiceID] = count
+	// aggregates all encrypted votes into "encrypted result"
+	cipherTexts, err := TallyCipherTexts(election, votes)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to add up the ballots")
+		return
 	}
 
-	// Put proof into Result Messages at the end
-	resultMessage := types.ResultMessage{
-		ElectionID: electionID,
-		Results:    results,
+	g, err := electionGroup(election)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to add up the ballots")
+		return
+	}
+
+	var secretShare big.Int
+	n.electionStore.Update(electionID, func(election *types.Election) {
+		election.TallyCipherTexts = cipherTexts
+		election.TallyVoteCnt = len(votes)
+		election.DecryptShares = make(map[int][]types.Point)
+		n.setPhase(election, types.PhaseTallying)
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	decryptionRequestMessage := types.DecryptionRequestMessage{
+		ElectionID:     electionID,
+		MixnetServerID: election.GetMyMixnetServerID(n.myAddr),
+		CipherTexts:    cipherTexts,
+		VoteCnt:        len(votes),
+	}
+
+	decryptionRequestMessage.Signature, err = SignDecryptionRequest(g, &decryptionRequestMessage, &secretShare)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to sign the decryption request")
+		return
+	}
+
+	err = n.sendDecryptionRequestMessage(decryptionRequestMessage)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("error broadcasting decryption request")
+	}
+}
+
+// TallyCipherTexts returns the ciphertexts to decrypt to count the votes: the
+// sum of the ciphertexts of each choice, or, for ranked ballots, all the
+// ciphertexts of all the ballots as they are decrypted one by one. The
+// ciphertexts of a multi-contest election are the ones of each contest, one
+// contest after the other.
+func TallyCipherTexts(election *types.Election, votes []types.VoteMessage) ([]types.ElGamalCipherText, error) {
+	if len(election.Base.Contests) > 0 {
+		cipherTexts := []types.ElGamalCipherText{}
+		for i := range election.Base.Contests {
+			contestCipherTexts, err := TallyCipherTexts(election.GetContestElection(i), contestVotes(election, votes, i))
+			if err != nil {
+				return nil, err
+			}
+			cipherTexts = append(cipherTexts, contestCipherTexts...)
+		}
+		return cipherTexts, nil
+	}
+
+	if election.Base.BallotType == types.BallotRanked {
+		cipherTexts := make([]types.ElGamalCipherText, 0, len(votes)*election.GetBallotSize())
+		for _, vote := range votes {
+			cipherTexts = append(cipherTexts, vote.EncryptedVotes...)
+		}
+		return cipherTexts, nil
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	// add all ciphertexts of each choice together
+	cipherTexts := make([]types.ElGamalCipherText, len(election.Base.Choices))
+	for j := range election.Base.Choices {
+		cipherTexts[j], err = ElGamalAddCipherTexts(g, getChoiceCipherTexts(votes, j))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cipherTexts, nil
+}
+
+// CombineDecryptShares recovers the count of each choice from the decryption
+// shares collected in the election and broadcasts the results. It expects at
+// least Threshold verified shares.
+func (n *node) CombineDecryptShares(election *types.Election) {
+	shareIDs := make([]int, 0, len(election.DecryptShares))
+	for id := range election.DecryptShares {
+		shareIDs = append(shareIDs, id)
+	}
+	sort.Ints(shareIDs)
+	shareIDs = shareIDs[:election.Base.Threshold]
+
+	plaintexts, err := RecoverPlaintexts(election, election.TallyCipherTexts, shareIDs, election.DecryptShares, election.TallyVoteCnt)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to decrypt the tally")
+		return
 	}
 
-	err := n.sendResultsMessage(resultMessage)
+	resultMessage, err := CountVotes(election, plaintexts, election.TallyVoteCnt)
+	if err != nil {
+		log.Err(err).Str("peerAddr", n.myAddr).Msgf("failed to count the votes")
+		return
+	}
+
+	err = n.sendResultsMessage(resultMessage)
 	if err != nil {
 		log.Err(err).Str("peerAddr", n.myAddr).Msgf("error broadcasting election results")
 	}
 }
 
+// notABit stands for a ciphertext of a ranked ballot which doesn't decrypt to
+// 0 or 1
+const notABit = 2
+
+// RecoverPlaintexts combines the decryption shares of the tally ciphertexts
+// (see TallyCipherTexts) of the given mixnet servers and returns the
+// plaintexts. A plaintext is at most voteCnt, or 1 for ranked ballots (or
+// notABit, if the ciphertext decrypts to something else).
+func RecoverPlaintexts(election *types.Election, cipherTexts []types.ElGamalCipherText, shareIDs []int,
+	decryptShares map[int][]types.Point, voteCnt int) ([]uint, error) {
+
+	if len(election.Base.Contests) > 0 {
+		if len(cipherTexts) != tallySize(election, voteCnt) {
+			return nil, xerrors.Errorf("%d tally ciphertexts for %d ballots", len(cipherTexts), voteCnt)
+		}
+
+		plaintexts := make([]uint, 0, len(cipherTexts))
+		offset := 0
+
+		for i := range election.Base.Contests {
+			contestElection := election.GetContestElection(i)
+			size := tallySize(contestElection, voteCnt)
+
+			contestShares := make(map[int][]types.Point, len(shareIDs))
+			for _, id := range shareIDs {
+				contestShares[id] = decryptShares[id][offset : offset+size]
+			}
+
+			contestPlaintexts, err := RecoverPlaintexts(contestElection, cipherTexts[offset:offset+size], shareIDs,
+				contestShares, voteCnt)
+			if err != nil {
+				return nil, xerrors.Errorf("contest %d: %v", i, err)
+			}
+
+			plaintexts = append(plaintexts, contestPlaintexts...)
+			offset += size
+		}
+
+		return plaintexts, nil
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPlaintext := voteCnt
+	if election.Base.BallotType == types.BallotRanked {
+		maxPlaintext = 1
+	}
+
+	plaintexts := make([]uint, len(cipherTexts))
+
+	for j := range cipherTexts {
+		shares := make([]types.Point, len(shareIDs))
+		for i, id := range shareIDs {
+			shares[i] = decryptShares[id][j]
+		}
+
+		// The plaintext is the discrete log of the decrypted ciphertext
+		plaintext, ok := RecoverVoteCount(g, &cipherTexts[j], shareIDs, shares, maxPlaintext)
+		if !ok && election.Base.BallotType == types.BallotRanked {
+			// the ranked ballot is not a permutation matrix, it is rejected
+			// when the rankings are decoded (see DecodeRankings)
+			plaintexts[j] = notABit
+			continue
+		}
+		if !ok {
+			return nil, xerrors.Errorf("failed to decrypt ciphertext %d of the tally", j)
+		}
+
+		plaintexts[j] = uint(plaintext.Uint64())
+	}
+
+	return plaintexts, nil
+}
+
+// CountVotes computes the results of the election from the decrypted tally:
+// the count of each choice (for approval ballots, the number of ballots which
+// selected it), or, for ranked ballots, the instant-runoff rounds
+// of the decrypted rankings. The results of a multi-contest election are
+// counted per contest, voteCnt is the number of tallied ballots.
+func CountVotes(election *types.Election, plaintexts []uint, voteCnt int) (types.ResultMessage, error) {
+	if len(election.Base.Contests) > 0 {
+		if len(plaintexts) != tallySize(election, voteCnt) {
+			return types.ResultMessage{}, xerrors.Errorf("%d plaintexts for %d ballots", len(plaintexts), voteCnt)
+		}
+
+		contestResults := make([]types.ResultMessage, len(election.Base.Contests))
+		offset := 0
+
+		for i := range election.Base.Contests {
+			contestElection := election.GetContestElection(i)
+			size := tallySize(contestElection, voteCnt)
+
+			result, err := CountVotes(contestElection, plaintexts[offset:offset+size], voteCnt)
+			if err != nil {
+				return types.ResultMessage{}, xerrors.Errorf("contest %d: %v", i, err)
+			}
+
+			contestResults[i] = result
+			offset += size
+		}
+
+		return resultsOfContests(election, contestResults), nil
+	}
+	resultMessage := types.ResultMessage{
+		ElectionID: election.Base.ElectionID,
+		Results:    map[int]uint{},
+	}
+
+	if election.Base.BallotType != types.BallotRanked {
+		if len(plaintexts) != len(election.Base.Choices) {
+			return types.ResultMessage{}, xerrors.Errorf("%d counts for %d choices", len(plaintexts), len(election.Base.Choices))
+		}
+
+		for j, choice := range election.Base.Choices {
+			resultMessage.Results[choice.ChoiceID] = plaintexts[j]
+		}
+		return resultMessage, nil
+	}
+
+	rankings, rejected, err := DecodeRankings(election.Base.Choices, plaintexts)
+	if err != nil {
+		return types.ResultMessage{}, err
+	}
+
+	resultMessage.Rankings = rankings
+	resultMessage.RejectedBallots = rejected
+	resultMessage.Rounds = InstantRunoff(election.Base.Choices, rankings)
+	resultMessage.Results = runoffResults(election.Base.Choices, resultMessage.Rounds)
+
+	return resultMessage, nil
+}
+
 // When I handle result message, save proofs into election, when you want to do display, then verify
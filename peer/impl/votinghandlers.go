@@ -1,12 +1,15 @@
 package impl
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"sync"
+	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/rs/zerolog/log"
+	"go.dedis.ch/cs438/peer/impl/group"
 	"go.dedis.ch/cs438/transport"
 	"go.dedis.ch/cs438/types"
 )
@@ -21,32 +24,71 @@ func (n *node) HandleAnnounceElectionMessage(t types.Message, pkt transport.Pack
 		return err
 	}
 
-	voteWG := sync.WaitGroup{}
-	voteWG.Add(1)
+	_, err = group.ForSuite(announceElectionMessage.Base.Suite)
+	if err != nil {
+		return fmt.Errorf("election %s: %v", announceElectionMessage.Base.ElectionID, err)
+	}
+
+	// the announcer is identified by its key, which signs the cancellations
+	// and extensions of the election
+	err = n.setSigningKey(announceElectionMessage.Base.Announcer, announceElectionMessage.Base.AnnouncerKey)
+	if err != nil {
+		return fmt.Errorf("invalid key of the announcer: %v", err)
+	}
+
+	// the mixnet servers are identified by the keys pinned by the announcer. A
+	// mixnet server without key can't take part.
+	if len(announceElectionMessage.Base.MixnetServerKeys) != len(announceElectionMessage.Base.MixnetServers) {
+		return fmt.Errorf("election %s: %d keys for %d mixnet servers", announceElectionMessage.Base.ElectionID,
+			len(announceElectionMessage.Base.MixnetServerKeys), len(announceElectionMessage.Base.MixnetServers))
+	}
+
+	for i, signingKey := range announceElectionMessage.Base.MixnetServerKeys {
+		if signingKey == nil {
+			continue
+		}
+
+		err = n.setSigningKey(announceElectionMessage.Base.MixnetServers[i], signingKey)
+		if err != nil {
+			return fmt.Errorf("invalid key of mixnet server %d: %v", i, err)
+		}
+	}
+
 	election := types.Election{
 		Base:                     announceElectionMessage.Base,
 		MyVote:                   -1,
-		VoteWG:                   &voteWG,
+		Phase:                    types.PhaseAnnounced,
 		ElectionStartedTimestamp: time.Now(),
 	}
-	n.dkgMutex.Lock()
 
-	if n.electionStore.Exists(election.Base.ElectionID) {
+	isMixnetServer := contains(election.Base.MixnetServers, n.myAddr)
+	if isMixnetServer {
+		// if node is one of the mixnet servers, it needs to store the data about other mixnet servers
+		election.Base.MixnetServerInfos = make([]*types.MixnetServerInfo, len(election.Base.MixnetServers))
+	}
+
+	if !n.electionStore.Add(election.Base.ElectionID, &election) {
 		return errors.New("election already exists")
 	}
 
-	n.electionStore.Set(election.Base.ElectionID, &election)
+	err = n.recordOnBulletinBoard(election.Base.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
 
-	n.notfify.Notify(election.Base.ElectionID)
+	n.scheduleReadyDeadline(&election)
 
-	n.dkgMutex.Unlock()
+	// every peer receives private messages: DKG shares, ballots and mixed
+	// votes for the mixnet servers, credentials for the voters
+	n.publishNodeKey()
 
-	if contains(election.Base.MixnetServers, n.myAddr) {
-		// if node is one of the mixnet servers, it needs to store the data about other mixnet servers
-		election.Base.MixnetServerInfos = make([]*types.MixnetServerInfo, len(election.Base.MixnetServers))
+	if isMixnetServer {
 		n.PedersenDkg(&election)
 	}
 
+	// the messages which arrived before the announcement
+	go n.replayPendingMessages(election.Base.ElectionID)
+
 	return nil
 }
 
@@ -59,17 +101,160 @@ func (n *node) HandleVoteMessage(t types.Message, pkt transport.Packet) error {
 	}
 
 	election := n.electionStore.Get(voteMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(voteMessage.ElectionID, pkt)
+	}
+
+	var publicKey types.Point
+	var phase types.Phase
+	var expiration time.Time
+	n.electionStore.View(voteMessage.ElectionID, func(election *types.Election) {
+		publicKey = election.GetPublicKey()
+		phase = election.Phase
+		expiration = election.Base.Expiration
+	})
+
+	// accept if opened and not expired
+	if time.Now().Before(election.Base.OpeningTime) {
+		return errors.New("this election is not open yet - vote won't be accepted")
+	}
 
-	// accept if not expired
-	if !time.Now().Before(election.Base.Expiration) {
+	if !time.Now().Before(expiration) {
 		return errors.New("this election expired - vote won't be accepted")
 	}
 
-	n.electionStore.StoreVote(election.Base.ElectionID, voteMessage)
+	if phase == types.PhaseAborted {
+		return errors.New("this election was aborted - vote won't be accepted")
+	}
+
+	if !VerifyBallot(election, publicKey, &voteMessage) {
+		return errors.New("ballot proofs are not valid - vote won't be accepted")
+	}
+
+	if election.Base.Revoting {
+		var roll []types.RegisterCredentialMessage
+		roll, err = n.getCredentialRoll(voteMessage.ElectionID)
+		if err == nil {
+			err = VerifyBallotCredential(election, roll, &voteMessage)
+		}
+	} else {
+		err = VerifyBallotSignature(election, &voteMessage)
+	}
+	if err != nil {
+		return fmt.Errorf("%v - vote won't be accepted", err)
+	}
+
+	ballotHash := BallotHash(&voteMessage)
+
+	// the voter resends its ballot until it gets a receipt, store it only
+	// once. A voter has one ballot, the vote policy tells which one. In a
+	// re-voting election, every ballot is stored: the equivalence tests keep
+	// the last one of each credential before the mixing.
+	isNew := false
+	n.electionStore.Update(voteMessage.ElectionID, func(election *types.Election) {
+		isNew = !containsBallot(election.Votes, ballotHash)
+		if !isNew {
+			return
+		}
+
+		if election.Base.Revoting {
+			if reusesCredentialCipherText(election.Votes, &voteMessage) {
+				err = errors.New("the encrypted credential of another ballot is reused - vote won't be accepted")
+				return
+			}
+			election.Votes = append(election.Votes, voteMessage)
+			return
+		}
+
+		i := voterBallotIndex(election.Votes, voteMessage.VoterKey)
+		switch {
+		case i < 0:
+			election.Votes = append(election.Votes, voteMessage)
+		case election.Base.VotePolicy == types.LastVoteCounts:
+			log.Info().Str("peerAddr", n.myAddr).Msgf("replacing the previous ballot of voter %x", voteMessage.VoterKey)
+			election.Votes[i] = voteMessage
+		default:
+			err = errors.New("this voter already voted - vote won't be accepted")
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if isNew {
+		// ballots are sent privately to the mixnet server, publish them
+		err = n.postOnBulletinBoard(election.Base.ElectionID, &voteMessage)
+		if err != nil {
+			return err
+		}
+	}
+
+	return n.sendBallotReceiptMessage(election, ballotHash)
+}
+
+// HandleBallotReceiptMessage processes types.BallotReceiptMessage. If this peer
+// waits for the receipt of the ballot, it checks the signature of the mixnet
+// server and hands the receipt over to Vote.
+func (n *node) HandleBallotReceiptMessage(t types.Message, pkt transport.Packet) error {
+	ballotReceiptMessage := types.BallotReceiptMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &ballotReceiptMessage)
+	if err != nil {
+		return err
+	}
+
+	value, ok := n.ballotReceipts.Load(hex.EncodeToString(ballotReceiptMessage.BallotHash))
+	if !ok {
+		// not one of my ballots, or already acknowledged
+		return nil
+	}
+	receipts := value.(chan types.BallotReceiptMessage)
+
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling BallotReceiptMessage from %v", pkt.Header.Source)
+
+	election := n.electionStore.Get(ballotReceiptMessage.ElectionID)
+	if election == nil {
+		return fmt.Errorf("received BallotReceiptMessage for unknown election %s", ballotReceiptMessage.ElectionID)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	var mixnetServerID int
+	n.electionStore.View(ballotReceiptMessage.ElectionID, func(election *types.Election) {
+		mixnetServerID = election.GetMyMixnetServerID(election.GetFirstQualifiedInitiator())
+	})
+
+	if ballotReceiptMessage.MixnetServerID != mixnetServerID {
+		return fmt.Errorf("receipt from mixnet server %d, the ballot was sent to %d", ballotReceiptMessage.MixnetServerID, mixnetServerID)
+	}
+
+	publicShare, err := n.getPublicShareFromBoard(election, mixnetServerID)
+	if err != nil {
+		return err
+	}
+
+	if !VerifyBallotReceipt(g, &ballotReceiptMessage, &publicShare) {
+		return errors.New("ballot receipt signature is not valid")
+	}
+
+	select {
+	case receipts <- ballotReceiptMessage:
+	default:
+		// duplicate receipt of a resent ballot
+	}
 
 	return nil
 }
 
+// HandleMixMessage verifies all the proofs accumulated in the types.MixMessage
+// before mixing the votes. The batch must be signed by a mixnet server which
+// mixes before this one, and the input of the first hop must be the ballots
+// counted on the bulletin board. If a hop cheated, the mixnet server which
+// signed the batch (which either cheated itself or forwarded the cheating
+// without complaining) is ejected, and the votes are mixed again from the last
+// valid batch.
 func (n *node) HandleMixMessage(t types.Message, pkt transport.Packet) error {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling MixMessage from %v", pkt.Header.Source)
 	mixMessage := types.MixMessage{}
@@ -79,28 +264,185 @@ func (n *node) HandleMixMessage(t types.Message, pkt transport.Packet) error {
 	}
 
 	election := n.electionStore.Get(mixMessage.ElectionID)
-	election.Votes = mixMessage.Votes
-	n.electionStore.Set(mixMessage.ElectionID, election)
+	if election == nil {
+		return n.parkMessage(mixMessage.ElectionID, pkt)
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
 
-	/*
-		isValid := true
-		for _, shuffleProof := range mixMessage.ShuffleProofs {
-			isValid = isValid && VerifyShuffle(&shuffleProof)
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+	if myMixnetServerID == -1 || mixMessage.NextHop != myMixnetServerID {
+		return fmt.Errorf("mix batch for hop %d received by %s", mixMessage.NextHop, n.myAddr)
+	}
+
+	// an unsigned batch is dropped: only the mixnet server which answers for
+	// the batch can be blamed
+	var publicKey types.Point
+	n.electionStore.View(mixMessage.ElectionID, func(election *types.Election) {
+		publicKey = election.GetPublicKey()
+
+		signerID := mixMessage.MixnetServerID
+		if signerID < 0 || signerID >= myMixnetServerID ||
+			election.Base.MixnetServersPoints[signerID] < election.Base.Threshold {
+			err = fmt.Errorf("mix batch from mixnet server %d, which doesn't mix before %d", signerID, myMixnetServerID)
+			return
+		}
+
+		var publicShare types.Point
+		publicShare, err = n.GetPublicShare(g, election, signerID)
+		if err == nil && !VerifyMixMessage(g, &mixMessage, &publicShare) {
+			err = fmt.Errorf("mix batch not signed by mixnet server %d", signerID)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	ballotSize := election.GetBallotSize()
+	validHops, ok := VerifyMixProofs(g, publicKey, ballotSize, &mixMessage)
+
+	// the first hop must mix the counted ballots, which may not all be on the
+	// local bulletin board yet
+	var countedBallots []types.VoteMessage
+	checkCountedBallots := func() error {
+		var err error
+		countedBallots, err = n.getCountedBallotsFromBoard(mixMessage.ElectionID)
+		if err != nil {
+			return fmt.Errorf("failed to count the ballots of the bulletin board: %v", err)
+		}
+		if !isFirstBatchOf(ballotSize, &mixMessage, countedBallots) {
+			return errors.New("the first hop didn't mix the ballots counted on the bulletin board")
+		}
+		return nil
+	}
+
+	n.waitForBulletinBoard(mixMessage.ElectionID, "MixMessage", checkCountedBallots, func() error {
+		return n.mixBatch(election, &mixMessage, countedBallots, validHops, ok)
+	}, func(error) error {
+		countedBallots, err := n.getCountedBallotsFromBoard(mixMessage.ElectionID)
+		if err != nil {
+			return fmt.Errorf("failed to count the ballots of the bulletin board: %v", err)
 		}
+		return n.mixBatch(election, &mixMessage, countedBallots, 0, false)
+	})
+
+	return nil
+}
+
+// mixBatch mixes the votes of the batch once its hops are verified: validHops
+// is the number of valid hops, and ok tells if all of them are. If a hop
+// cheated, this mixnet server complains about the mixnet server which signed
+// the batch, and mixes again from the last valid batch, or from the counted
+// ballots if no hop is valid.
+func (n *node) mixBatch(election *types.Election, mixMessage *types.MixMessage, countedBallots []types.VoteMessage,
+	validHops int, ok bool) error {
+
+	ballotSize := election.GetBallotSize()
 
-		if !isValid {
-			return errors.New("shuffle proof is not valid")
+	if !ok {
+		log.Warn().Str("peerAddr", n.myAddr).Msgf("invalid mix batch, only %d valid hops: ejecting mixnet server %d",
+			validHops, mixMessage.MixnetServerID)
+
+		err := n.sendMixComplaintMessage(election, mixMessage.MixnetServerID)
+		if err != nil {
+			return err
+		}
+
+		if validHops == 0 {
+			mixMessage.Votes = stripVoterSignatures(countedBallots)
+			mixMessage.ShuffleProofs = nil
+			mixMessage.BallotShuffleProofs = nil
+			mixMessage.ReEncryptionProofs = nil
+		} else {
+			mixMessage.Votes, err = getLastValidBatch(ballotSize, mixMessage.ShuffleProofs, validHops)
+			if err != nil {
+				return err
+			}
+			mixMessage.ShuffleProofs = mixMessage.ShuffleProofs[:validHops*ballotSize]
+			mixMessage.BallotShuffleProofs = mixMessage.BallotShuffleProofs[:validHops]
+			mixMessage.ReEncryptionProofs = mixMessage.ReEncryptionProofs[:validHops*ballotSize*len(mixMessage.Votes)]
 		}
+	}
+
+	for i := range mixMessage.Votes {
+		mixMessage.Votes[i].ElectionID = mixMessage.ElectionID
+	}
 
-	*/
+	n.electionStore.Update(mixMessage.ElectionID, func(election *types.Election) {
+		n.setPhase(election, types.PhaseMixing)
+		election.Votes = mixMessage.Votes
+	})
 
-	err = n.Mix(mixMessage.ElectionID, mixMessage.NextHop, mixMessage.ShuffleProofs, mixMessage.ReEncryptionProofs)
+	return n.Mix(mixMessage.ElectionID, mixMessage.NextHop, mixMessage.ShuffleProofs, mixMessage.BallotShuffleProofs,
+		mixMessage.ReEncryptionProofs)
+}
+
+// HandleMixComplaintMessage processes types.MixComplaintMessage. A mixnet
+// server ejects the accused mixnet server from the mixing if the complaint is
+// signed by a qualified mixnet server.
+func (n *node) HandleMixComplaintMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling MixComplaintMessage from %v", pkt.Header.Source)
+	mixComplaintMessage := types.MixComplaintMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &mixComplaintMessage)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	election := n.electionStore.Get(mixComplaintMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(mixComplaintMessage.ElectionID, pkt)
+	}
+
+	err = n.recordOnBulletinBoard(mixComplaintMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	n.electionStore.Update(mixComplaintMessage.ElectionID, func(election *types.Election) {
+		// only mixnet servers know the public key shares, and only they mix
+		if election.Base.MixnetServerInfos == nil {
+			return
+		}
+
+		mixnetServerCnt := len(election.Base.MixnetServers)
+		if mixComplaintMessage.MixnetServerID < 0 || mixComplaintMessage.MixnetServerID >= mixnetServerCnt ||
+			mixComplaintMessage.AccusedID < 0 || mixComplaintMessage.AccusedID >= mixnetServerCnt ||
+			election.Base.MixnetServersPoints[mixComplaintMessage.MixnetServerID] < election.Base.Threshold {
+			err = errors.New("mix complaint from or against an unknown mixnet server")
+			return
+		}
+
+		var publicShare types.Point
+		publicShare, err = n.GetPublicShare(g, election, mixComplaintMessage.MixnetServerID)
+		if err != nil {
+			return
+		}
+		if !VerifyMixComplaint(g, &mixComplaintMessage, &publicShare) {
+			err = errors.New("mix complaint signature is not valid")
+			return
+		}
+
+		if election.EjectedMixnetServers == nil {
+			election.EjectedMixnetServers = make(map[int]struct{})
+		}
+		election.EjectedMixnetServers[mixComplaintMessage.AccusedID] = struct{}{}
+	})
+
+	return err
 }
 
+// HandleResultMessage processes types.ResultMessage. The results finish the
+// election only if they are the decryption of the verified tally on the
+// bulletin board (see checkResults), or if the first mixnet server signed
+// that the quorum is not reached.
 func (n *node) HandleResultMessage(t types.Message, pkt transport.Packet) error {
 	log.Info().Str("peerAddr", n.myAddr).Msgf("handling ResultsMessage from %v", pkt.Header.Source)
 	resultMessage := types.ResultMessage{}
@@ -111,9 +453,206 @@ func (n *node) HandleResultMessage(t types.Message, pkt transport.Packet) error
 
 	// update election record
 	election := n.electionStore.Get(resultMessage.ElectionID)
-	n.dkgMutex.Lock()
-	election.Results = resultMessage.Results
-	election.ReceivedResultsTimestamp = time.Now()
-	n.dkgMutex.Unlock()
+	if election == nil {
+		return n.parkMessage(resultMessage.ElectionID, pkt)
+	}
+
+	if resultMessage.QuorumNotReached {
+		return n.finishWithoutQuorum(election, &resultMessage, pkt.Msg)
+	}
+
+	// the decryption shares and the results are broadcast separately
+	n.waitForBulletinBoard(resultMessage.ElectionID, "ResultMessage", func() error {
+		return n.checkResultsOnBoard(&resultMessage)
+	}, func() error {
+		return n.finishWithResults(&resultMessage, pkt.Msg)
+	}, func(err error) error {
+		return fmt.Errorf("refusing results: %v", err)
+	})
+
+	return nil
+}
+
+// finishWithResults finishes the election with the results, once they are
+// checked against the bulletin board
+func (n *node) finishWithResults(resultMessage *types.ResultMessage, msg *transport.Message) error {
+	err := n.recordOnBulletinBoard(resultMessage.ElectionID, msg)
+	if err != nil {
+		return err
+	}
+
+	n.electionStore.Update(resultMessage.ElectionID, func(election *types.Election) {
+		election.Results = resultMessage.Results
+		election.ContestResults = resultMessage.Contests
+		election.ReceivedResultsTimestamp = time.Now()
+		n.setPhase(election, types.PhaseFinished)
+	})
+	return nil
+}
+
+// HandleDecryptionRequestMessage processes types.DecryptionRequestMessage. A
+// qualified mixnet server answers with its decryption shares of the tally. The
+// request must be signed by the mixnet server which tallies the ballots, and
+// hold exactly their tally, as recomputed from the bulletin board (see
+// checkDecryptionRequest): the mixnet servers decrypt nothing else.
+func (n *node) HandleDecryptionRequestMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling DecryptionRequestMessage from %v", pkt.Header.Source)
+	decryptionRequestMessage := types.DecryptionRequestMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &decryptionRequestMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(decryptionRequestMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(decryptionRequestMessage.ElectionID, pkt)
+	}
+
+	n.waitForBulletinBoard(decryptionRequestMessage.ElectionID, "DecryptionRequestMessage", func() error {
+		return n.checkDecryptionRequestOnBoard(&decryptionRequestMessage)
+	}, func() error {
+		return n.answerDecryptionRequest(election, &decryptionRequestMessage, pkt.Msg)
+	}, func(err error) error {
+		return fmt.Errorf("refusing decryption request: %v", err)
+	})
+
+	return nil
+}
+
+// answerDecryptionRequest records the decryption request once it is checked
+// against the bulletin board, and sends the decryption shares of this mixnet
+// server if it is qualified
+func (n *node) answerDecryptionRequest(election *types.Election, decryptionRequestMessage *types.DecryptionRequestMessage,
+	msg *transport.Message) error {
+
+	err := n.recordOnBulletinBoard(decryptionRequestMessage.ElectionID, msg)
+	if err != nil {
+		return err
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	myMixnetServerID := election.GetMyMixnetServerID(n.myAddr)
+	qualified := false
+	var secretShare big.Int
+	var publicShare types.Point
+
+	n.electionStore.Update(decryptionRequestMessage.ElectionID, func(election *types.Election) {
+		n.setPhase(election, types.PhaseTallying)
+
+		// only qualified mixnet servers hold a valid share of the secret key
+		qualified = myMixnetServerID != -1 && election.Base.MixnetServersPoints[myMixnetServerID] >= election.Base.Threshold
+		if !qualified {
+			return
+		}
+		secretShare = n.GetSecretShare(g, election)
+		publicShare, err = n.GetPublicShare(g, election, myMixnetServerID)
+	})
+	if !qualified {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	decryptShares := make([]types.Point, len(decryptionRequestMessage.CipherTexts))
+	decryptProofs := make([]types.Proof, len(decryptionRequestMessage.CipherTexts))
+	for i, cipherText := range decryptionRequestMessage.CipherTexts {
+		cipherText := cipherText
+		decryptShare, proof, err := MakeDecryptShare(g, &cipherText, &publicShare, secretShare.Bytes())
+		if err != nil {
+			return err
+		}
+		decryptShares[i] = *decryptShare
+		decryptProofs[i] = *proof
+	}
+
+	decryptShareMessage := types.DecryptShareMessage{
+		ElectionID:     decryptionRequestMessage.ElectionID,
+		MixnetServerID: myMixnetServerID,
+		DecryptShares:  decryptShares,
+		DecryptProofs:  decryptProofs,
+	}
+
+	return n.sendDecryptShareMessage(decryptShareMessage)
+}
+
+// HandleDecryptShareMessage processes types.DecryptShareMessage. The mixnet
+// server which requested the decryption verifies the shares, and once it holds
+// Threshold valid ones, it recovers and broadcasts the election results.
+func (n *node) HandleDecryptShareMessage(t types.Message, pkt transport.Packet) error {
+	log.Info().Str("peerAddr", n.myAddr).Msgf("handling DecryptShareMessage from %v", pkt.Header.Source)
+	decryptShareMessage := types.DecryptShareMessage{}
+	err := json.Unmarshal(pkt.Msg.Payload, &decryptShareMessage)
+	if err != nil {
+		return err
+	}
+
+	election := n.electionStore.Get(decryptShareMessage.ElectionID)
+	if election == nil {
+		return n.parkMessage(decryptShareMessage.ElectionID, pkt)
+	}
+
+	err = n.recordOnBulletinBoard(decryptShareMessage.ElectionID, pkt.Msg)
+	if err != nil {
+		return err
+	}
+
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	isComplete := false
+
+	n.electionStore.Update(decryptShareMessage.ElectionID, func(election *types.Election) {
+		// only the node which requested the decryption combines the shares
+		if election.TallyCipherTexts == nil || len(election.DecryptShares) >= election.Base.Threshold {
+			return
+		}
+
+		mixnetServerID := decryptShareMessage.MixnetServerID
+		if mixnetServerID < 0 || mixnetServerID >= len(election.Base.MixnetServers) ||
+			election.Base.MixnetServersPoints[mixnetServerID] < election.Base.Threshold {
+			err = fmt.Errorf("mixnet server %d is not qualified to decrypt", mixnetServerID)
+			return
+		}
+
+		if _, ok := election.DecryptShares[mixnetServerID]; ok {
+			return
+		}
+
+		cipherTexts := election.TallyCipherTexts
+		if len(decryptShareMessage.DecryptShares) != len(cipherTexts) || len(decryptShareMessage.DecryptProofs) != len(cipherTexts) {
+			err = fmt.Errorf("mixnet server %d sent a wrong number of decryption shares", mixnetServerID)
+			return
+		}
+
+		var publicShare types.Point
+		publicShare, err = n.GetPublicShare(g, election, mixnetServerID)
+		if err != nil {
+			return
+		}
+		for i := range cipherTexts {
+			if !VerifyDecryptShare(g, &cipherTexts[i], &publicShare, &decryptShareMessage.DecryptShares[i], &decryptShareMessage.DecryptProofs[i]) {
+				err = fmt.Errorf("decryption share of mixnet server %d is not valid", mixnetServerID)
+				return
+			}
+		}
+
+		election.DecryptShares[mixnetServerID] = decryptShareMessage.DecryptShares
+		isComplete = len(election.DecryptShares) == election.Base.Threshold
+	})
+	if err != nil {
+		return err
+	}
+
+	if isComplete {
+		n.CombineDecryptShares(n.electionStore.Get(decryptShareMessage.ElectionID))
+	}
+
 	return nil
 }
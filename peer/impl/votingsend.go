@@ -1,6 +1,10 @@
 package impl
 
-import "go.dedis.ch/cs438/types"
+import (
+	"math/big"
+
+	"go.dedis.ch/cs438/types"
+)
 
 func (n *node) sendAnnounceElectionMessage(electionMessage types.AnnounceElectionMessage) error {
 	msg, err := marshalMessage(electionMessage)
@@ -20,9 +24,41 @@ func (n *node) sendVoteMessage(mixnetPeer string, voteMessage types.VoteMessage)
 	recipients := make(map[string]struct{})
 	recipients[mixnetPeer] = struct{}{}
 
-	n.sendPrivateMessage(recipients, voteMessage)
+	return n.sendPrivateMessage(recipients, &voteMessage)
+}
 
-	return nil
+// sendBallotReceiptMessage broadcasts a types.BallotReceiptMessage for the
+// stored ballot, signed with the secret key share of this node. It is broadcast
+// as the mixnet server doesn't know the address of the voter.
+func (n *node) sendBallotReceiptMessage(election *types.Election, ballotHash []byte) error {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	var secretShare big.Int
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	ballotReceiptMessage := types.BallotReceiptMessage{
+		ElectionID:     election.Base.ElectionID,
+		MixnetServerID: election.GetMyMixnetServerID(n.myAddr),
+		BallotHash:     ballotHash,
+	}
+
+	signature, err := SignBallotReceipt(g, &ballotReceiptMessage, &secretShare)
+	if err != nil {
+		return err
+	}
+	ballotReceiptMessage.Signature = signature
+
+	msg, err := marshalMessage(&ballotReceiptMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
 }
 
 func (n *node) sendResultsMessage(resultMessage types.ResultMessage) error {
@@ -38,3 +74,54 @@ func (n *node) sendResultsMessage(resultMessage types.ResultMessage) error {
 
 	return nil
 }
+
+func (n *node) sendDecryptionRequestMessage(decryptionRequestMessage types.DecryptionRequestMessage) error {
+	msg, err := marshalMessage(&decryptionRequestMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
+
+func (n *node) sendDecryptShareMessage(decryptShareMessage types.DecryptShareMessage) error {
+	msg, err := marshalMessage(&decryptShareMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
+
+// sendMixComplaintMessage broadcasts a types.MixComplaintMessage against the
+// accused mixnet server, signed with the secret key share of this node
+func (n *node) sendMixComplaintMessage(election *types.Election, accusedID int) error {
+	g, err := electionGroup(election)
+	if err != nil {
+		return err
+	}
+
+	var secretShare big.Int
+	n.electionStore.View(election.Base.ElectionID, func(election *types.Election) {
+		secretShare = n.GetSecretShare(g, election)
+	})
+
+	mixComplaintMessage := types.MixComplaintMessage{
+		ElectionID:     election.Base.ElectionID,
+		MixnetServerID: election.GetMyMixnetServerID(n.myAddr),
+		AccusedID:      accusedID,
+	}
+
+	signature, err := SignMixComplaint(g, &mixComplaintMessage, &secretShare)
+	if err != nil {
+		return err
+	}
+	mixComplaintMessage.Signature = signature
+
+	msg, err := marshalMessage(&mixComplaintMessage)
+	if err != nil {
+		return err
+	}
+
+	return n.Broadcast(msg)
+}
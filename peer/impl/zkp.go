@@ -10,21 +10,21 @@ package impl
 // 1. Generate new g-base, given an old one
 // 2. Verification needs to check challenge bytes
 import (
-	"crypto/elliptic"
-	cryptorand "crypto/rand"
 	"math/big"
 
+	"go.dedis.ch/cs438/peer/impl/group"
 	"go.dedis.ch/cs438/types"
 	"golang.org/x/xerrors"
 )
 
 const (
-	DLOG_LABEL       = "dlog_LABEL"
-	DLOG_EQ_LABEL    = "dlog_EQ_LABEL"
-	DLOG_OR_EQ_LABEL = "dlog_EQ_LABEL"
-	DLOG_OR_LABEL    = "dlog_OR_LABEL"
-	SHUFFLE_LABEL    = "shuffle_LABEL"
-	SCALAR_SIZE      = 32
+	DLOG_LABEL           = "dlog_LABEL"
+	DLOG_EQ_LABEL        = "dlog_EQ_LABEL"
+	DLOG_OR_EQ_LABEL     = "dlog_EQ_LABEL"
+	DLOG_OR_LABEL        = "dlog_OR_LABEL"
+	SHUFFLE_LABEL        = "shuffle_LABEL"
+	BALLOT_SHUFFLE_LABEL = "ballot_shuffle_LABEL"
+	RANGE_LABEL          = "range_LABEL"
 )
 
 type Value []byte
@@ -42,10 +42,10 @@ func NewProof(proofType string) types.Proof {
 	}
 }
 
-func NewProofExtended(proofType string, curve elliptic.Curve, pPoint, cPoint, challBytes []byte, result *big.Int) *types.Proof {
+func NewProofExtended(proofType string, g group.Group, pPoint, cPoint, challBytes []byte, result *big.Int) *types.Proof {
 	return &types.Proof{
 		ProofType:     proofType,
-		Curve:         curve,
+		Suite:         g.Suite(),
 		PPoint:        pPoint,
 		CPoint:        cPoint,
 		VerifierChall: challBytes,
@@ -53,9 +53,9 @@ func NewProofExtended(proofType string, curve elliptic.Curve, pPoint, cPoint, ch
 	}
 }
 
-func NewShuffleInstance(curve elliptic.Curve, pPoint types.Point, ctBefore, ctAfter []types.ElGamalCipherText) *types.ShuffleInstance {
+func NewShuffleInstance(g group.Group, pPoint types.Point, ctBefore, ctAfter []types.ElGamalCipherText) *types.ShuffleInstance {
 	return &types.ShuffleInstance{
-		Curve:    curve,
+		Suite:    g.Suite(),
 		PPoint:   pPoint,
 		CtBefore: ctBefore,
 		CtAfter:  ctAfter,
@@ -70,13 +70,13 @@ func NewShuffleWitness(permList []uint32, RscalarList []big.Int) *types.ShuffleW
 }
 
 // The function computes the non-interactive proof of knowledge of the DLOG (a.k.a Schnorr's proof)
-func ProveDlog(secret Value, pPoint types.Point, curve elliptic.Curve) (*types.Proof, error) {
-
-	// Derive parameters of the elliptic curve
-	curveParams := curve.Params()
+func ProveDlog(secret Value, pPoint types.Point, g group.Group) (*types.Proof, error) {
 
 	// Marshal the instance point in order to place it into the protocol transcript
-	pPointCompressed := elliptic.MarshalCompressed(curve, &pPoint.X, &pPoint.Y)
+	pPointCompressed, err := compressPoint(g, pPoint)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveDlog: %v", err)
+	}
 
 	// Appends public info about the proof instance into the transcript
 	proofTypeBytes := []byte(DLOG_LABEL)
@@ -87,7 +87,7 @@ func ProveDlog(secret Value, pPoint types.Point, curve elliptic.Curve) (*types.P
 	// Begin derving commitment scalar
 
 	// Generate random commitment seed using order of the group
-	commitRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	commitRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlog: %v", err)
 	}
@@ -100,51 +100,42 @@ func ProveDlog(secret Value, pPoint types.Point, curve elliptic.Curve) (*types.P
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlog: %v\n", err)
 	}
-	commitScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-	//fmt.Printf("In ProveDlog, commitScalarBytes length: %v\n", len(commitScalarBytes))
+	commitScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
 
-	// Create commitment Point (cPoint=c*B, where B is the base point of the curve)
-	cPointX, cPointY := curve.ScalarBaseMult(commitScalarBytes)
-
-	// cPoint := NewPoint(cPointX, cPointY)
-
-	cPointCompressed := elliptic.MarshalCompressed(curve, cPointX, cPointY)
+	// Create commitment Point (cPoint=c*B, where B is the base point of the group)
+	cPointCompressed := g.BaseMul(commitScalar).Bytes()
 
 	// Append derived cPoint to the protocol transcript
 	transcript.AppendMessage(proofTypeBytes, cPointCompressed)
 
 	// Derive public coins (challenge bytes) from the verifier based on the current
 	// Trancript state
-	challBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
-
-	//fmt.Printf("In VerifyDlog, challBytes: %v\n", challBytes)
-
-	// Cast scalars as big.Ints
-	secretScalar := new(big.Int).SetBytes(secret)
-	challScalar := new(big.Int).SetBytes(challBytes)
-	commitScalar := new(big.Int).SetBytes(commitScalarBytes)
+	challBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 
-	// Computes chall*x (as scalars mod N, where N is the order of the base point)
-	blindedScalar := new(big.Int).Mod(new(big.Int).Mul(secretScalar, challScalar), curveParams.N)
+	// Cast scalars as scalars of the group
+	secretScalar := g.Scalar(secret)
+	challScalar := g.Scalar(challBytes)
 
 	// Computes z=chall*x+c (as scalars mod N, where N is the order of the base point)
-	result := new(big.Int).Mod(new(big.Int).Add(commitScalar, blindedScalar), curveParams.N)
+	result := commitScalar.Add(secretScalar.Mul(challScalar))
 
 	// Store into the proof
-	proof.Curve = curve
+	proof.Suite = g.Suite()
 	proof.PPoint = pPointCompressed
 	proof.CPoint = cPointCompressed
 	proof.VerifierChall = challBytes
-	proof.Result = *result
+	proof.Result = *result.BigInt()
 
-	//fmt.Printf("In ProveDlog, pPoint: %v\n", proof.PPoint)
-	//fmt.Printf("In ProveDlog, cPoint: %v\n", proof.CPoint)
-	//fmt.Printf("In ProveDlog, result: %v\n", proof.Result)
 	return &proof, nil
 }
 
 // Verifies the Schnorr's non-interactive proof of the knowledge of DLOG
 func VerifyDlog(proof *types.Proof) (bool, error) {
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return false, xerrors.Errorf("Error in VerifyDlog: %v", err)
+	}
+
 	// Recreate the state of the transcript to get challenge scalar
 	transcript := NewTranscript(proof.ProofType)
 	proofTypeBytes := []byte(proof.ProofType)
@@ -156,51 +147,28 @@ func VerifyDlog(proof *types.Proof) (bool, error) {
 	transcript.AppendMessage(proofTypeBytes, proof.CPoint)
 
 	// Derives verifier's challenge based on the current transcript state
-	challBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
+	challBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 
 	checkBytes := checkChallBytes(challBytes, proof.VerifierChall)
 
-	//fmt.Printf("In VerifyDlog, challBytes: %v\n", challBytes)
-
-	// Create placeholder for the l.h.S and the r.h.S of the final check
-	// point01 := types.Point{}
-	// point02 := types.Point{}
-
-	// Create placeholder for the instance Pand the commitment Point in the proof
-	// pPoint := types.Point{}
-	// cPoint := types.Point{}
-
-	// Computes z*G, where G is the base point, and z (a.k.a result) is z=chall*x+c
-	// This is what is "received" by the verifier from the prover
-	point01X, point01Y := proof.Curve.ScalarBaseMult(proof.Result.Bytes())
-
-	// Unmarshalls both pPoint = x*G and cPoint = c*G
-	pPointX, pPointY := elliptic.UnmarshalCompressed(proof.Curve, proof.PPoint)
-	cPointX, cPointY := elliptic.UnmarshalCompressed(proof.Curve, proof.CPoint)
-
-	// Computes chall*x*G
-	point02X, point02Y := proof.Curve.ScalarMult(pPointX, pPointY, challBytes)
-
-	// Computes c*G+(chall*x)*G
-	point02X, point02Y = proof.Curve.Add(point02X, point02Y, cPointX, cPointY)
-
-	// Finally compare if the claimed value (point01) is equal to the actual value (point02)
-	return checkBytes && point01X.Cmp(point02X) == 0 && point01Y.Cmp(point02Y) == 0, nil
+	// Finally check that z*G = c*G+(chall*x)*G, where z (a.k.a result) is z=chall*x+c
+	return checkBytes && VerifyDlogRelation(proof), nil
 }
 
 // Proves that the two values P = x*G and P' = x*G' have the same DLOG x (a.k.a the Chaum-Pedersen proof)
 // Essentially, this corresponds to running two Schnorr proofs in parallel.
 // For details, see e.x: https://crypto.Stackexchange.com/questions/99262/chaum-pedersen-protocol
 func ProveDlogEq(secret Value, pPoint types.Point, bPointOther types.Point, pPointOther types.Point,
-	curve elliptic.Curve) (*types.Proof, error) {
-
-	// Derive parameters of the elliptic curve
-	curveParams := curve.Params()
+	g group.Group) (*types.Proof, error) {
 
 	// Marshall proof instance to bytes
-	pPointCompressed := elliptic.MarshalCompressed(curve, &pPoint.X, &pPoint.Y)
-	bPointOtherCompressed := elliptic.MarshalCompressed(curve, &bPointOther.X, &bPointOther.Y)
-	pPointOtherCompressed := elliptic.MarshalCompressed(curve, &pPointOther.X, &pPointOther.Y)
+	instance, err := decodePoints(g, pPoint, bPointOther, pPointOther)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveDlogEq: %v", err)
+	}
+	pPointCompressed := instance[0].Bytes()
+	bPointOtherCompressed := instance[1].Bytes()
+	pPointOtherCompressed := instance[2].Bytes()
 
 	proofTypeBytes := []byte(DLOG_EQ_LABEL)
 
@@ -218,7 +186,7 @@ func ProveDlogEq(secret Value, pPoint types.Point, bPointOther types.Point, pPoi
 	// Begin deriving commitment scalar
 
 	// Derive seed based on which the commitment scalar is derived
-	commitRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	commitRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlogEq: %v", err)
 	}
@@ -229,54 +197,50 @@ func ProveDlogEq(secret Value, pPoint types.Point, bPointOther types.Point, pPoi
 	trPRGbuilder.RekeyWitnessBytes([]byte(proof.ProofType), commitRandSeed.Bytes())
 	trPrg, err := trPRGbuilder.Finalize(proofTypeBytes)
 	if err != nil {
-
+		return nil, xerrors.Errorf("Error in ProveDlogEq: %v", err)
 	}
 
-	commitScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-
-	// Create placeholders for the two commitment points
+	commitScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
 
-	// Compute cPoint = c*G where G is the base point of the curve
-	cPointX, cPointY := curve.ScalarBaseMult(commitScalarBytes)
-	cPointCompressed := elliptic.MarshalCompressed(curve, cPointX, cPointY)
+	// Compute cPoint = c*G where G is the base point of the group
+	cPointCompressed := g.BaseMul(commitScalar).Bytes()
 
-	// Compute cPoint = c*G' where G' is the base point of the curve (and G != G')
-	cPointOtherX, cPointOtherY := curve.ScalarMult(&bPointOther.X, &bPointOther.Y, commitScalarBytes)
-	cPointOtherCompressed := elliptic.MarshalCompressed(curve, cPointOtherX, cPointOtherY)
+	// Compute cPoint = c*G' where G' is the base point of the group (and G != G')
+	cPointOtherCompressed := instance[1].Mul(commitScalar).Bytes()
 
 	// Append derived commitment points to the transcript
 	transcript.AppendMessage(proofTypeBytes, cPointCompressed)
 	transcript.AppendMessage(proofTypeBytes, cPointOtherCompressed)
 
 	// Derive a public coin challenge based on the current state of the transcript
-	challBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
+	challBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 
-	// Cast scalars as big.Ints
-	secretScalar := new(big.Int).SetBytes(secret)
-	commitScalar := new(big.Int).SetBytes(commitScalarBytes)
-	challScalar := new(big.Int).SetBytes(challBytes)
-
-	// Computes chall*x (mod N, where N is the order of the base point)
-	blindedScalar := new(big.Int).Mod(new(big.Int).Mul(secretScalar, challScalar), curveParams.N)
+	// Cast scalars as scalars of the group
+	secretScalar := g.Scalar(secret)
+	challScalar := g.Scalar(challBytes)
 
 	// Computes z=c-chall*x (mod N, where N is the order of the base point)
-	result := new(big.Int).Mod(new(big.Int).Sub(commitScalar, blindedScalar), curveParams.N)
+	result := commitScalar.Sub(secretScalar.Mul(challScalar))
 
 	// Store the results into the proof structure
-	proof.Curve = curve
+	proof.Suite = g.Suite()
 	proof.BPointOther = bPointOtherCompressed
 	proof.PPoint = pPointCompressed
 	proof.PPointOther = pPointOtherCompressed
 	proof.CPoint = cPointCompressed
 	proof.CPointOther = cPointOtherCompressed
 	proof.VerifierChall = challBytes
-	proof.Result = *result
+	proof.Result = *result.BigInt()
 
 	return &proof, nil
 }
 
 // Verifies that the two values P = x*G and P' = x*G' have the same DLOG x (a.k.a the Chaum-Pedersen proof)
 func VerifyDlogEq(proof *types.Proof) (bool, error) {
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return false, xerrors.Errorf("Error in VerifyDlogEq: %v", err)
+	}
 
 	// Recreate the state of the transcript to get challenge scalar
 	transcript := NewTranscript(proof.ProofType)
@@ -292,81 +256,40 @@ func VerifyDlogEq(proof *types.Proof) (bool, error) {
 	transcript.AppendMessage(proofTypeBytes, proof.CPointOther)
 
 	// Generate public challege from the current state of the transcript
-	challBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
+	challBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 	checkBytes := checkChallBytes(challBytes, proof.VerifierChall)
 
-	// Begin deriving components for checking if P = x*G
-
-	// Derive P from the proof
-	// pPoint := types.Point{}
-	pPointX, pPointY := elliptic.UnmarshalCompressed(proof.Curve, proof.PPoint)
-
-	// Compute chall*P
-	// gAddend01 := types.Point{}
-	gAddend01X, gAddend01Y := proof.Curve.ScalarMult(pPointX, pPointY, challBytes)
-
-	// Compute z*G (where z=c-chall*x)
-	// gAddend02 := types.Point{}
-	gAddend02X, gAddend02Y := proof.Curve.ScalarBaseMult(proof.Result.Bytes())
-
-	// Compute chall*P + z*G
-	// resultFirst := types.Point{}
-	resultFirstX, resultFirstY := proof.Curve.Add(gAddend01X, gAddend01Y, gAddend02X, gAddend02Y)
-
-	// Begin deriving components for checking if P=x*G
-
-	// gPrimeAddend01 := types.Point{}
-	// pPointOther := types.Point{}
-	pPointOtherX, pPointOtherY := elliptic.UnmarshalCompressed(proof.Curve, proof.PPointOther)
-	gPrimeAddend01X, gPrimeAddend01Y := proof.Curve.ScalarMult(pPointOtherX, pPointOtherY, challBytes)
-
-	// Checks for the other pair (corresponds to checks for G')
-	// bPointOther := types.Point{}
-	// gPrimeAddend02 := types.Point{}
-	bPointOtherX, bPointOtherY := elliptic.UnmarshalCompressed(proof.Curve, proof.BPointOther)
-	gPrimeAddend02X, gPrimeAddend02Y := proof.Curve.ScalarMult(bPointOtherX, bPointOtherY, proof.Result.Bytes())
-
-	// resultSecond := types.Point{}
-	resultSecondX, resultSecondY := proof.Curve.Add(gPrimeAddend01X, gPrimeAddend01Y, gPrimeAddend02X, gPrimeAddend02Y)
-
-	// cPoint := types.Point{}
-	cPointX, cPointY := elliptic.UnmarshalCompressed(proof.Curve, proof.CPoint)
-
-	// cPointOther := types.Point{}
-	cPointOtherX, cPointOtherY := elliptic.UnmarshalCompressed(proof.Curve, proof.CPointOther)
-	return checkBytes &&
-		cPointX.Cmp(resultFirstX) == 0 && cPointY.Cmp(resultFirstY) == 0 &&
-		cPointOtherX.Cmp(resultSecondX) == 0 && cPointOtherY.Cmp(resultSecondY) == 0, nil
-
+	// Check that c*G = chall*P + z*G and c*G' = chall*P' + z*G' (where z=c-chall*x)
+	return checkBytes && VerifyDlogEqRelation(proof), nil
 }
 
 // Computes the non-interactive zero-knowledge proof that secret x is the DLOG of either P or P'
 // The proof generation can be parsed into the two cases:
 // 1. For the true case: Run the regular Schnorr protocol
 // 2. For the fake case: Use simulator to create an accepting transcript
-func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOther types.Point, secretBit bool, curve elliptic.Curve) (*types.Proof, error) {
+func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOther types.Point, secretBit bool, g group.Group) (*types.Proof, error) {
+
+	// Marshall the information about the proof instance
+	instance, err := decodePoints(g, pPoint, pPointOther)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveDlogOR: %v", err)
+	}
 
-	curveParams := curve.Params()
 	var trueSecret Value
-	var truepPoint types.Point
-	var fakepPoint types.Point
+	var truepPoint group.Element
+	var fakepPoint group.Element
 	if secretBit {
 		trueSecret = secret
-		truepPoint = pPoint
-		fakepPoint = pPointOther
+		truepPoint = instance[0]
+		fakepPoint = instance[1]
 	} else {
 		trueSecret = secretOther
-		truepPoint = pPointOther
-		fakepPoint = pPoint
+		truepPoint = instance[1]
+		fakepPoint = instance[0]
 	}
 
-	// //fmt.Printf("In ProveDlogOr, trueSecret is: %v \n ", trueSecret)
-	// //fmt.Printf("In ProveDlogOr, truepPoint is: (%v, %v) \n ", pPoint.X, pPoint.Y)
-	// //fmt.Printf("In ProveDlogOr, fakepPoint is: (%v, %v) \n ", pPointOther.X, pPointOther.Y)
-
-	// Marshall the information about the proof instance
-	pPointCompressed := elliptic.MarshalCompressed(curve, &pPoint.X, &pPoint.Y)
-	pPointOtherCompressed := elliptic.MarshalCompressed(curve, &pPointOther.X, &pPointOther.Y)
+	pPointCompressed := instance[0].Bytes()
+	pPointOtherCompressed := instance[1].Bytes()
 
 	// Create the placeholder for the proof
 	proofTypeBytes := []byte(DLOG_OR_LABEL)
@@ -380,7 +303,7 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 	transcript.AppendMessage(proofTypeBytes, pPointOtherCompressed)
 
 	// For the true case: Derive randomness for the commitment scalar generation
-	trueRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	trueRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlogOR: %v", err)
 	}
@@ -395,17 +318,13 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 	}
 
 	// Derive commitment scalar based on the current state of the transcript
-	commitScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
+	trueCommitScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
 
 	// For the true case: Generate cPoint =  c*G, where G is the base point
-	cPoint := types.Point{}
-	cPointX, cPointY := curve.ScalarBaseMult(commitScalarBytes)
-	cPoint.X = *cPointX
-	cPoint.Y = *cPointY
-	cPointCompressed := elliptic.MarshalCompressed(curve, &cPoint.X, &cPoint.Y)
+	cPointCompressed := g.BaseMul(trueCommitScalar).Bytes()
 
 	// For the fake case: Derive random challenge for the simulation using the transcript
-	simRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	simRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlogOR: %v", err)
 	}
@@ -418,11 +337,10 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 	}
 
 	// For the fake case: Generate fake challenge bytes (As if they came from the verifier)
-	fakeChallBytes := trPRG.GetRandomness(SCALAR_SIZE)
-	//fmt.Printf("In ProveDlogOr, fakeChallBytes: %v\n", fakeChallBytes)
+	fakeChallBytes := trPRG.GetRandomness(g.ScalarLen())
 
 	// Use simulator for the proof ot the DLOG to create the fake (but accepting transcirpt)
-	fakeCPointCompressed, fakeChallBytes, fakeResult := SimulatorDlog(fakeChallBytes, trPRG, fakepPoint, curve)
+	fakeCPointCompressed, fakeChallBytes, fakeResult := SimulatorDlog(fakeChallBytes, trPRG, fakepPoint, g)
 
 	// Append commitment points for both cases to the transcript
 	if secretBit {
@@ -434,7 +352,7 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 	}
 
 	// Generate verifier's challenge bytes from the current transcript state
-	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 
 	// Derive challenge bytes for the true case  by xoring the chall bytes received
 	// from the verifier with the fake challenge bytes
@@ -442,29 +360,20 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 	for i, val := range verifierChallBytes {
 		trueChallBytes[i] = val ^ fakeChallBytes[i]
 	}
-	//fmt.Printf("In ProveDlogOr, trueChallBytes: %v\n", trueChallBytes)
-
-	// Cast the secret x as a scalar
-	trueSecretScalar := new(big.Int).SetBytes(trueSecret)
-
-	// Cast the commitment c as a scalar
-	trueCommitScalar := new(big.Int).SetBytes(commitScalarBytes)
-
-	// Cast chall as scalar (verifier's public coins)
-	trueChallScalar := new(big.Int).SetBytes(trueChallBytes)
 
-	// Compute x*chall (mod N, where N is the order of base point)
-	trueBlindedScalar := new(big.Int).Mod(new(big.Int).Mul(trueSecretScalar, trueChallScalar), curveParams.N)
+	// Cast the secret x and chall (verifier's public coins) as scalars
+	trueSecretScalar := g.Scalar(trueSecret)
+	trueChallScalar := g.Scalar(trueChallBytes)
 
-	// Finish blinded scalar
-	trueResult := new(big.Int).Mod(new(big.Int).Add(trueCommitScalar, trueBlindedScalar), curveParams.N)
+	// Compute z=c+x*chall (mod N, where N is the order of base point)
+	trueResult := trueCommitScalar.Add(trueSecretScalar.Mul(trueChallScalar))
 
-	truepPointCompressed := elliptic.MarshalCompressed(curve, &truepPoint.X, &truepPoint.Y)
-	fakepPointCompressed := elliptic.MarshalCompressed(curve, &fakepPoint.X, &fakepPoint.Y)
+	truepPointCompressed := truepPoint.Bytes()
+	fakepPointCompressed := fakepPoint.Bytes()
 
 	if secretBit {
-		proof.Curve = curve
-		proof.Result = *trueResult
+		proof.Suite = g.Suite()
+		proof.Result = *trueResult.BigInt()
 		proof.ResultOther = *fakeResult
 		proof.CPoint = cPointCompressed
 		proof.CPointOther = fakeCPointCompressed
@@ -475,9 +384,9 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 		proof.ProverChallOther = fakeChallBytes
 
 	} else {
-		proof.Curve = curve
+		proof.Suite = g.Suite()
 		proof.Result = *fakeResult
-		proof.ResultOther = *trueResult
+		proof.ResultOther = *trueResult.BigInt()
 		proof.CPoint = fakeCPointCompressed
 		proof.CPointOther = cPointCompressed
 		proof.PPoint = fakepPointCompressed
@@ -493,6 +402,10 @@ func ProveDlogOr(secret Value, pPoint types.Point, secretOther Value, pPointOthe
 // Verifies that one of the two proof instances satisfies the DLOG relation
 // (a.k.a the OR-proof for the DLOG relation)
 func VerifyDlogOr(proof *types.Proof) (bool, error) {
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return false, xerrors.Errorf("Error in VerifyDlogOr: %v", err)
+	}
 
 	proofTypeBytes := []byte(DLOG_OR_LABEL)
 
@@ -507,13 +420,12 @@ func VerifyDlogOr(proof *types.Proof) (bool, error) {
 	transcript.AppendMessage(proofTypeBytes, proof.CPoint)
 	transcript.AppendMessage(proofTypeBytes, proof.CPointOther)
 
-	//fmt.Printf("In VerifyDlog, proverChall: %v\n", proof.ProverChall)
-	//fmt.Printf("In VerifyDlog, proverChallOther: %v\n", proof.ProverChallOther)
-	//fmt.Printf("In VerifyDlog, PROOF verifierChall: %v\n", proof.VerifierChall)
-
 	// Derive challenge bytes based on the state of the current transcript
-	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
-	//fmt.Printf("In VerifyDlog, DERIVED verifierChall: %v\n", verifierChallBytes)
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
+
+	if !checkChallBytesLen(g, proof.VerifierChall, proof.ProverChall, proof.ProverChallOther) {
+		return false, nil
+	}
 
 	// Begin checking if the verifier challenge is correctly derived
 	checkBytesXor := true
@@ -528,14 +440,14 @@ func VerifyDlogOr(proof *types.Proof) (bool, error) {
 	// Create two proof instances which need to be checked
 
 	proof01 := types.Proof{}
-	proof01.Curve = proof.Curve
+	proof01.Suite = proof.Suite
 	proof01.PPoint = proof.PPoint
 	proof01.CPoint = proof.CPoint
 	proof01.VerifierChall = proof.ProverChall
 	proof01.Result = proof.Result
 
 	proof02 := types.Proof{}
-	proof02.Curve = proof.Curve
+	proof02.Suite = proof.Suite
 	proof02.PPoint = proof.PPointOther
 	proof02.CPoint = proof.CPointOther
 	proof02.VerifierChall = proof.ProverChallOther
@@ -545,96 +457,51 @@ func VerifyDlogOr(proof *types.Proof) (bool, error) {
 	res01 := VerifyDlogRelation(&proof01)
 	res02 := VerifyDlogRelation(&proof02)
 
-	//fmt.Printf("In VerifyDlogOr, checkBytesXor result %v\n", checkBytesXor)
-	//fmt.Printf("In VerifyDlogOr, VerifyDlogRelation for proof01 result: %v\n", res01)
-	//fmt.Printf("In VerifyDlogOr, VerifyDlogRelation for proof02 result %v\n", res02)
-
 	// Return the AND of all the checks as a result
 	return checkBytesXor && res01 && res02, nil
 }
 
 // Based on the choise of the chall, derives the fake simulated (a.k.a) fake transcript for the Schnorr's proof
 // Utilizes the c-simultability property of the Sigma protocols
-func SimulatorDlog(challBytes []byte, trPRG *TranscriptRng, pPoint types.Point, curve elliptic.Curve) ([]byte, []byte, *big.Int) {
-
-	curveParams := curve.Params()
-
+func SimulatorDlog(challBytes []byte, trPRG *TranscriptRng, pPoint group.Element, g group.Group) ([]byte, []byte, *big.Int) {
 	// Derive scalar z
-	blindBytes := trPRG.GetRandomness(SCALAR_SIZE)
-	blindScalar := new(big.Int).SetBytes(blindBytes)
-	blindScalar = blindScalar.Mod(blindScalar, curveParams.N)
+	blindScalar := g.Scalar(trPRG.GetRandomness(g.ScalarLen()))
 
 	// Compute z*G
-	blindPoint := types.Point{}
-	//fmt.Printf("In SimulatorDlog, multiplying base point with value %v\n", blindScalar.Bytes())
-	blindPointX, blindPointY := curve.ScalarBaseMult(blindScalar.Bytes())
-
-	blindPoint.X, blindPoint.Y = *blindPointX, *blindPointY
-
-	// Compute -chall*P, where P is the point from the proof intance
-	minusOneScalar := new(big.Int).Sub(curveParams.N, big.NewInt(1))
-	challScalar := new(big.Int).SetBytes(challBytes)
-
-	// Create placeholder for the -chall*P point
-	// challpPoint := types.Point{}
+	blindPoint := g.BaseMul(blindScalar)
 
-	// First compute -P given P
-	challpPointX, challpPointY := curve.ScalarMult(&pPoint.X, &pPoint.Y, minusOneScalar.Bytes())
-
-	// challpPoint.X, challpPoint.Y = *challpPointX, *challpPointY
-
-	// Then computer chall*(-P)
-	challpPointX, challpPointY = curve.ScalarMult(challpPointX, challpPointY, challScalar.Bytes())
+	// Compute chall*P, where P is the point from the proof intance
+	challpPoint := pPoint.Mul(g.Scalar(challBytes))
 
 	// Finally compute the the first message (in the original proof c*G)
 	// As: c*G = z*G - chall*P
-	// cPoint := types.Point{}
-	cPointX, cPointY := curve.Add(&blindPoint.X, &blindPoint.Y, challpPointX, challpPointY)
-	cPointCompressed := elliptic.MarshalCompressed(curve, cPointX, cPointY)
-
-	//fmt.Printf("In SimulatorDlog, BlindScalar value %v\n", blindScalar.Bytes())
-	//fmt.Printf("In SimulatorDlog, Is blindPoint on curve: %v\n", curve.IsOnCurve(blindPoint.X, blindPoint.Y))
-	//fmt.Printf("In SimulatorDlog, Is challpPoint on curve: %v\n", curve.IsOnCurve(challpPoint.X, challpPoint.Y))
-	//fmt.Printf("In SimulatorDlog, Is cPoint on curve: %v\n", curve.IsOnCurve(cPoint.X, cPoint.Y))
-
-	//fmt.Printf("In SimulatorDlog, blindPoint coordinates: (%v, %v) \n", blindPoint.X, blindPoint.Y)
-	//fmt.Printf("In SimulatorDlog, challpPoint coordinates: (%v, %v) \n", challpPoint.X, challpPoint.Y)
-	//fmt.Printf("In SimulatorDlog, cPoint coordinates: (%v, %v) \n", cPoint.X, cPoint.Y)
-
-	//fmt.Printf("In SimulatorDlog, cPointCompressed %v\n", cPointCompressed)
-	//fmt.Printf("In SimulatorDlog, challBytes %v\n", challBytes)
-	//fmt.Printf("In SimulatorDlog, blindScalar %v\n", blindScalar.Bytes())
-	return cPointCompressed, challBytes, blindScalar
+	cPointCompressed := blindPoint.Sub(challpPoint).Bytes()
+
+	return cPointCompressed, challBytes, blindScalar.BigInt()
 }
 
 // Verifies that the values stored in the proof satisfies the DLOG (Schnorr's) relation
 func VerifyDlogRelation(proof *types.Proof) bool {
-	curve := proof.Curve
-
-	//fmt.Printf("In VerifyDlogRelation, cPointCompressed %v\n", proof.CPoint)
-	//fmt.Printf("In VerifyDlogRelation, challBytes %v\n", proof.VerifierChall)
-	//fmt.Printf("In VerifyDlogRelation, blindScalar %v\n", proof.Result.Bytes())
-
-	//fmt.Printf("In VerifyDlogRelation result scalar: %v\n", proof.Result.Bytes())
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return false
+	}
 
-	//fmt.Printf("In VerifyDlogRelation, multiplying base point with value %v\n", proof.Result.Bytes())
+	// Cast the proof instance and the commitment point as elements
+	points, err := decodeElements(g, proof.PPoint, proof.CPoint)
+	if err != nil {
+		return false
+	}
+	pPoint, cPoint := points[0], points[1]
 
 	// Computes z*G, which acts as the l.h.S of the check
-	pointlhsX, pointlhsY := curve.ScalarBaseMult(proof.Result.Bytes())
-
-	// Cast the proof instance and the commitment point as Point structs
-	pPointX, pPointY := elliptic.UnmarshalCompressed(curve, proof.PPoint)
-	cPointX, cPointY := elliptic.UnmarshalCompressed(curve, proof.CPoint)
+	pointlhs := g.BaseMul(g.ScalarFromInt(&proof.Result))
 
 	//Compute the r.h.S as a chall*P+cPoint
-	pointrhsX, pointrhsY := proof.Curve.ScalarMult(pPointX, pPointY, proof.VerifierChall)
-	pointrhsX, pointrhsY = proof.Curve.Add(pointrhsX, pointrhsY, cPointX, cPointY)
-
-	//fmt.Printf("In VerifyDlogRelation, Comparing X points %v\n", pointlhs.X.Cmp(pointrhs.X) == 0)
-	//fmt.Printf("In VerifyDlogRelation, Comparing Y points %v\n", pointlhs.Y.Cmp(pointrhs.Y) == 0)
+	pointrhs := pPoint.Mul(g.Scalar(proof.VerifierChall)).Add(cPoint)
 
 	//Finally, check if the l.h.S and the r.h.S match
-	return pointlhsX.Cmp(pointrhsX) == 0 && pointlhsY.Cmp(pointrhsY) == 0
+	return pointlhs.Equal(pointrhs)
 }
 
 func Verify(proof *types.Proof) (bool, error) {
@@ -660,38 +527,53 @@ func Verify(proof *types.Proof) (bool, error) {
 // Effectively, it acts as  4 Sigma proofs run in parallel, which are
 // 1.
 func ProveShuffle(instance *types.ShuffleInstance, witness *types.ShuffleWitness) (*types.ShuffleProof, error) {
-	curveParams := instance.Curve.Params()
+	g, err := group.ForSuite(instance.Suite)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
+	}
+
 	proofTypeBytes := []byte(SHUFFLE_LABEL)
 
 	transcript := NewTranscript(SHUFFLE_LABEL)
 
 	// Initialize transcript with the proof instance (which consists)
-	pPointCompressed := elliptic.MarshalCompressed(instance.Curve, &instance.PPoint.X, &instance.PPoint.Y)
+	pPoint, err := g.ElementFromPoint(instance.PPoint)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
+	}
+	pPointCompressed := pPoint.Bytes()
 
 	// If a ciphertext consists of a pair ct=(ct_1, ct_2), then these are
-	// The lists of ct_2,s for each ciphertext
-	reEncBeforeList := MakeReencList(instance.CtBefore)
-	reEncAfterList := MakeReencList(instance.CtAfter)
+	// The lists of ct_1,s for each ciphertext
+	reEncBeforeList, err := decodePoints(g, MakeReencList(instance.CtBefore)...)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
+	}
+	reEncAfterList, err := decodePoints(g, MakeReencList(instance.CtAfter)...)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
+	}
 
 	// If a ciphertext consists of a pair ct=(ct_1, ct_2), then these are
 	// The lists of ct_2,s for each ciphertext
-	ctMsgBeforeList := MakeCtMsgList(instance.CtBefore)
-	ctMsgAfterList := MakeCtMsgList(instance.CtAfter)
-
-	reEncBeforeByteList := MarshalPointList(reEncBeforeList, instance.Curve)
-	reEncAfterByteList := MarshalPointList(reEncAfterList, instance.Curve)
-	ctMsgBeforeByteList := MarshalPointList(ctMsgBeforeList, instance.Curve)
-	ctMsgAfterByteList := MarshalPointList(ctMsgAfterList, instance.Curve)
+	ctMsgBeforeList, err := decodePoints(g, MakeCtMsgList(instance.CtBefore)...)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
+	}
+	ctMsgAfterList, err := decodePoints(g, MakeCtMsgList(instance.CtAfter)...)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
+	}
 
 	// Append proof instance parameters to the transcript
-	transcript.BatchAppendMessages(proofTypeBytes, reEncBeforeByteList)
-	transcript.BatchAppendMessages(proofTypeBytes, reEncAfterByteList)
-	transcript.BatchAppendMessages(proofTypeBytes, ctMsgBeforeByteList)
-	transcript.BatchAppendMessages(proofTypeBytes, ctMsgAfterByteList)
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(reEncBeforeList))
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(reEncAfterList))
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(ctMsgBeforeList))
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(ctMsgAfterList))
 	transcript.AppendMessage(proofTypeBytes, pPointCompressed)
 
 	// Prover STEP 01: Derive randomness for the prover's masking scalars
-	commitRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	commitRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveReEncListShuffle: %v\n", err)
 	}
@@ -713,352 +595,155 @@ func ProveShuffle(instance *types.ShuffleInstance, witness *types.ShuffleWitness
 	}
 
 	// Prover STEP 01: Derive prover's masking scalars
-	tauScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-	thetaScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-	sigmaScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-	lambdaScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-	phiScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-
-	//fmt.Printf("In ProveShuffle, public point is: (%v,%v)\n", instance.PPoint.X, instance.PPoint.Y)
-	phiScalar := new(big.Int).SetBytes(phiScalarBytes)
-	phiList := [][]byte{}
+	tauScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
+	thetaScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
+	sigmaScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
+	lambdaScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
+	phiScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
 
+	phiList := [][]byte{}
 	for i := 0; i < len(witness.PermList); i++ {
-		phiList = append(phiList, trPrg.GetRandomness(SCALAR_SIZE))
+		phiList = append(phiList, trPrg.GetRandomness(g.ScalarLen()))
 	}
 
 	lambdaList := [][]byte{}
 	for i := 0; i < len(witness.PermList); i++ {
-		lambdaList = append(phiList, trPrg.GetRandomness(SCALAR_SIZE))
+		lambdaList = append(phiList, trPrg.GetRandomness(g.ScalarLen()))
 	}
 
+	twoScalar := g.ScalarFromInt(big.NewInt(2))
+	threeScalar := g.ScalarFromInt(big.NewInt(3))
+
 	// Prover STEP 01: do the following
 	//Compute T=\tau*G
 	//Compute v=\theta*G
 	//Compute w=\sigma*G
 	//Compute u=\lambda*G
 
-	tPointX, tPointY := instance.Curve.ScalarBaseMult(tauScalarBytes)
-	vPointX, vPointY := instance.Curve.ScalarBaseMult(thetaScalarBytes)
-	wPointX, wPointY := instance.Curve.ScalarBaseMult(sigmaScalarBytes)
-	uPointX, uPointY := instance.Curve.ScalarBaseMult(lambdaScalarBytes)
-
-	//fmt.Printf("In ProveShuffle, tauScalar is: %v\n", new(big.Int).SetBytes(tauScalarBytes))
-	//fmt.Printf("In ProveShuffle, thetaScalar is: %v\n", new(big.Int).SetBytes(thetaScalarBytes))
-	//fmt.Printf("In ProveShuffle, sigmaScalar is: %v\n", new(big.Int).SetBytes(sigmaScalarBytes))
-	//fmt.Printf("In ProveShuffle, lambdaScalar is: %v\n", new(big.Int).SetBytes(lambdaScalarBytes))
-
-	//fmt.Printf("In ProveShuffle, tPoint result is (%v,%v)\n", tPoint.X, tPoint.Y)
-	//fmt.Printf("In ProveShuffle, vPoint result is (%v,%v)\n", vPoint.X, vPoint.Y)
-	//fmt.Printf("In ProveShuffle, wPoint result is (%v,%v)\n", wPoint.X, wPoint.Y)
-	//fmt.Printf("In ProveShuffle, uPoint result is (%v,%v)\n", uPoint.X, uPoint.Y)
+	tPoint := g.BaseMul(tauScalar)
+	vPoint := g.BaseMul(thetaScalar)
+	wPoint := g.BaseMul(sigmaScalar)
+	uPoint := g.BaseMul(lambdaScalar)
 
 	// Prover STEP 01: Compute U_i = \lambda_i * G, where G is the base point
-	uPointList := make([]types.Point, 0)
+	uPointList := make([]group.Element, 0)
 	for i := 0; i < len(witness.PermList); i++ {
-		uP := types.Point{}
-		//fmt.Printf("In ProveShuffle, for index %d, lambdaScalar[i] is: %v\n", i, new(big.Int).SetBytes(lambdaList[i]))
-		uPX, uPY := instance.Curve.ScalarBaseMult(lambdaList[i])
-		//fmt.Printf("In ProveShuffle, for index %d uPoint result is (%v,%v)\n", i, uP.X, uP.Y)
-
-		uP.X = *uPX
-		uP.Y = *uPY
-		uPointList = append(uPointList, uP)
+		uPointList = append(uPointList, g.BaseMul(g.Scalar(lambdaList[i])))
 	}
 
 	// Prover STEP 01: Compute G'=\phi*G + \sum_i \phi_i*ct_{i,1}
 
 	//First compute the \phi*G
-
-	gPrimePointX, gPrimePointY := instance.Curve.ScalarBaseMult(phiScalarBytes)
-	//fmt.Printf("In ProveShuffle, PhiScalar is: %v\n", new(big.Int).SetBytes(phiScalarBytes))
-	//fmt.Printf("In ProveShuffle, gPrimePoint, initial result is (%v,%v)\n", gPrimePoint.X, gPrimePoint.Y)
+	gPrimePoint := g.BaseMul(phiScalar)
 
 	// Then compute the sum by calcluating addends and add them at each step to the running value of G'
 	for i := 0; i < len(witness.PermList); i++ {
-		// addend := types.Point{}
-		//fmt.Printf("In ProveShuffle, for i: %d, PhiScalar[i] is: %v\n", i, new(big.Int).SetBytes(phiList[i]))
-		//fmt.Printf("In ProveShuffle, for i: %d, reEncBeforeList[i] is (%v,%v)\n", i, reEncBeforeList[i].X, reEncBeforeList[i].Y)
-
-		addendX, addendY := instance.Curve.ScalarMult(&reEncBeforeList[i].X, &reEncBeforeList[i].Y, phiList[i])
-
-		//fmt.Printf("In ProveShuffle, for i: %d, addend for gPrimePoint is (%v,%v)\n", i, addend.X, addend.Y)
-
-		gPrimePointX, gPrimePointY = instance.Curve.Add(gPrimePointX, gPrimePointY, addendX, addendY)
-
-		//fmt.Printf("In ProveShuffle, gPrimePoint, partial result is (%v,%v)\n", gPrimePoint.X, gPrimePoint.Y)
+		gPrimePoint = gPrimePoint.Add(reEncBeforeList[i].Mul(g.Scalar(phiList[i])))
 	}
-	//fmt.Printf("In ProveShuffle, gPrimePoint final result is (%v,%v)\n", gPrimePoint.X, gPrimePoint.Y)
 
 	//Compute M'=\phi*P + \sum_i \phi_i*ct_{i,2}
 
 	//First compute  \phi*P
-	// mPrimePoint := types.Point{}
-	mPrimePointX, mPrimePointY := instance.Curve.ScalarMult(&instance.PPoint.X, &instance.PPoint.Y, phiScalarBytes)
-	//fmt.Printf("In ProveShuffle, mPrimePoint initial result is (%v,%v)\n", mPrimePoint.X, mPrimePoint.Y)
+	mPrimePoint := pPoint.Mul(phiScalar)
 
 	// Then compute the sum by calcluating addends and add them at each step to the running value of M'
 	for i := 0; i < len(instance.CtBefore); i++ {
 		// Computes addend = phi[i] * ct_{i,2}
-		// addend := types.Point{}
-		addendX, addendY := instance.Curve.ScalarMult(&ctMsgBeforeList[i].X, &ctMsgBeforeList[i].Y, phiList[i])
-		//fmt.Printf("In ProveShuffle, reEncBlindedPoint result is (%v,%v)\n", addend.X, addend.Y)
-		mPrimePointX, mPrimePointY = instance.Curve.Add(mPrimePointX, mPrimePointY, addendX, addendY)
-		//fmt.Printf("In ProveShuffle, mPrimePoint partial result is (%v,%v)\n", mPrimePoint.X, mPrimePoint.Y)
+		mPrimePoint = mPrimePoint.Add(ctMsgBeforeList[i].Mul(g.Scalar(phiList[i])))
 	}
-	//fmt.Printf("In ProveShuffle, mPrimePoint final result is (%v,%v)\n", mPrimePoint.X, mPrimePoint.Y)
 
 	// Compute \cap{T_i}
-	tauScalar := new(big.Int).SetBytes(tauScalarBytes)
-	//fmt.Printf("In ProveShuffle, tauScalar is: %v\n", tauScalar)
-
-	tCapPointList := make([]types.Point, 0)
+	tCapPointList := make([]group.Element, 0)
 	for i := 0; i < len(witness.PermList); i++ {
-		tCapBlindPoint := types.Point{}
-
-		// Derive \lambda_i
-		lambdaListScalar := new(big.Int).SetBytes(lambdaList[i])
-
-		//fmt.Printf("In ProveShuffle, for i: %d, lambdaList[i] is: %v\n", i, lambdaListScalar)
 		// Compute product of tau*lambda_i
-		tauLambdaScalar := new(big.Int).SetUint64(0)
-		tauLambdaScalar.Mul(tauScalar, lambdaListScalar)
-		tauLambdaScalar.Mod(tauLambdaScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for i: %d, tauLambdaScalar is: %v\n", i, tauLambdaScalar)
+		tauLambdaScalar := tauScalar.Mul(g.Scalar(lambdaList[i]))
 
 		// Find the image of i under the secret permutation \pi
 		permImg := witness.PermList[i]
 
-		// Derive phi_{\pi(i)} as a scalar
-		phiListScalar := new(big.Int).SetBytes(phiList[permImg])
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, chosen phiScalar is: %v\n", i, permImg, phiListScalar)
-
-		//Compute resultScalar =  3*phi_{\pi(i)}
-		threeScalar := new(big.Int).SetUint64(3)
-		resultScalar := new(big.Int).SetUint64(0)
-		resultScalar.Mul(threeScalar, phiListScalar)
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, resultScalar (after *3) is: %v\n", i, permImg, resultScalar)
-
-		//Set resultScalar=resultScalar+tau*Lambda_i
-		resultScalar.Add(resultScalar, tauLambdaScalar)
-		resultScalar.Mod(resultScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, resultScalar (after adding tauLambda) final is: %v\n", i, permImg, resultScalar)
+		//Compute resultScalar =  3*phi_{\pi(i)} + tau*Lambda_i
+		resultScalar := threeScalar.Mul(g.Scalar(phiList[permImg])).Add(tauLambdaScalar)
 
 		//Compute result \cap{T_i} = result*G
-		tCapBlindPointX, tCapBlindPointY := instance.Curve.ScalarBaseMult(resultScalar.Bytes())
-
-		tCapBlindPoint.X = *tCapBlindPointX
-		tCapBlindPoint.Y = *tCapBlindPointY
-		//fmt.Printf("In ProveShuffle, for i: %d, tCapBlindedPoint[i] is (%v,%v)\n", i, tCapBlindPoint.X, tCapBlindPoint.Y)
-		tCapPointList = append(tCapPointList, tCapBlindPoint)
+		tCapPointList = append(tCapPointList, g.BaseMul(resultScalar))
 	}
 
 	//Compute \cap{V_i}
-
-	//First derive \theta as a scalar
-	thetaScalar := new(big.Int).SetBytes(thetaScalarBytes)
-	vCapPointList := make([]types.Point, 0)
-	//fmt.Printf("In ProveShuffle, thetaScalar is: %v\n", thetaScalar)
-
+	vCapPointList := make([]group.Element, 0)
 	for i := 0; i < len(witness.PermList); i++ {
-		vCapBlindedPoint := types.Point{}
-		// Set the placeholder for \theta * r_i
-		thetaRScalar := new(big.Int).SetUint64(0)
-
-		//Derive r_i as a scalar
-		rScalar := witness.RscalarList[i]
-
-		//fmt.Printf("In ProveShuffle, for i: %d, rScalar is: %v\n", i, rScalar)
-
 		//Compute  \theta * r_i
-		thetaRScalar = thetaRScalar.Mul(thetaScalar, &rScalar)
-		thetaRScalar = thetaRScalar.Mod(thetaRScalar, curveParams.N)
-
-		//fmt.Printf("In ProveShuffle, for i: %d, thetaRsalar is: %v\n", i, thetaRScalar)
+		thetaRScalar := thetaScalar.Mul(g.ScalarFromInt(&witness.RscalarList[i]))
 
 		// Find the image of i under the secret permutation \pi
 		permImg := witness.PermList[i]
 
 		// Derive phi_{\pi(i)} as a scalar
-		phiListScalar := new(big.Int).SetBytes(phiList[permImg])
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, chosen phiScalar is: %v\n", i, permImg, phiListScalar)
-
-		// Set 3 as a scalar
-		threeScalar := new(big.Int).SetUint64(3)
-
-		// Compute the exponent of the vCapPointList
-		resultScalar := new(big.Int).SetUint64(0)
-		// Compute result = phi_{\pi(i)} * phi_{\pi(i)}
-		resultScalar.Mul(phiListScalar, phiListScalar)
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, resultScalar (phi*phi) is: %v\n", i, permImg, resultScalar)
+		phiListScalar := g.Scalar(phiList[permImg])
 
-		// Compute result = 3 * phi_{\pi(i)} * phi_{\pi(i)}
-		resultScalar.Mul(threeScalar, resultScalar)
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, resultScalar (3*phi*phi) is: %v\n", i, permImg, resultScalar)
-
-		// Compute result = result + (r_i * theta)
-		resultScalar.Add(resultScalar, thetaRScalar)
-		resultScalar.Mod(resultScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for i: %d, chosen permImg %d, resultScalar *final(3*phi*phi+r_i * theta) is: %v\n", i, permImg, resultScalar)
+		// Compute result = 3 * phi_{\pi(i)} * phi_{\pi(i)} + (r_i * theta)
+		resultScalar := threeScalar.Mul(phiListScalar.Mul(phiListScalar)).Add(thetaRScalar)
 
 		// Compute the point and add it to the vCapPointList
-		vCapBlindedPointX, vCapBlindedPointY := instance.Curve.ScalarBaseMult(resultScalar.Bytes())
-		//fmt.Printf("In ProveShuffle, for i: %d, vCapBlindedPoint[i] is (%v,%v)\n", i, vCapBlindedPoint.X, vCapBlindedPoint.Y)
-
-		vCapBlindedPoint.X = *vCapBlindedPointX
-		vCapBlindedPoint.Y = *vCapBlindedPointY
-
-		vCapPointList = append(vCapPointList, vCapBlindedPoint)
+		vCapPointList = append(vCapPointList, g.BaseMul(resultScalar))
 	}
 
 	//Compute \cap{V}
-	// vCapPoint := types.Point{}
-
-	// Compute the placeholders for \theta *\phi and \tau * \lambda
-	thetaPhiScalar := new(big.Int).SetUint64(0)
-	tauLambdaScalar := new(big.Int).SetUint64(0)
-
-	// Set \lambda as a scalar
-	lambdaScalar := new(big.Int).SetBytes(lambdaScalarBytes)
-
-	//fmt.Printf("In ProveShuffle, computing vCapPoint, lambdaScalar is: %v\n", lambdaScalar)
-
-	// Compute the product \tau*\lambda
-	tauLambdaScalar.Mul(tauScalar, lambdaScalar)
-	tauLambdaScalar.Mod(tauLambdaScalar, curveParams.N)
-	//fmt.Printf("In ProveShuffle, computing vCapPoint, tauLambdaScalar is: %v\n", tauLambdaScalar)
-
-	// Compute the product \theta *\phi
-	thetaPhiScalar.Mul(thetaScalar, phiScalar)
-	thetaPhiScalar.Mod(thetaPhiScalar, curveParams.N)
-	//fmt.Printf("In ProveShuffle, computing vCapPoint, thetaPhiScalar is: %v\n", thetaPhiScalar)
 
-	// Initialize the result for the dlog of \cap{V}
-	vCapScalarResult := new(big.Int).SetUint64(0)
-	vCapScalarResult.Add(tauLambdaScalar, thetaPhiScalar)
-	vCapScalarResult.Mod(vCapScalarResult, curveParams.N)
-	//fmt.Printf("In ProveShuffle, computing vCapPoint, vCapScalarResult initial (theta*phi + tau*lambda) is: %v\n", vCapScalarResult)
+	// Initialize the result for the dlog of \cap{V} as theta*phi + tau*lambda
+	vCapScalarResult := tauScalar.Mul(lambdaScalar).Add(thetaScalar.Mul(phiScalar))
 
 	for i := 0; i < len(witness.PermList); i++ {
-		// Create the placeholders for \phi_i^2 and \phi_i^3
-		phiSquared := new(big.Int).SetUint64(0)
-		phiCubed := new(big.Int).SetUint64(0)
-
-		phiListScalar := new(big.Int).SetBytes(phiList[i])
-		//fmt.Printf("In ProveShuffle, for index %d, computing vCapPoint, phiListScalar is: %v\n", i, phiListScalar)
-
-		// Compute the \phi_i^2
-		phiSquared.Mul(phiListScalar, phiListScalar)
-		phiSquared.Mod(phiSquared, curveParams.N)
-
-		// Compute the \phi_i^3 as \phi_i^2 * \phi_i
-		phiCubed.Mul(phiSquared, phiListScalar)
-		phiCubed.Mod(phiCubed, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for index %d, computing vCapPoint, phiCubed is: %v\n", i, phiCubed)
+		phiListScalar := g.Scalar(phiList[i])
 
 		// Add the phi_i^3 to the running store for the exponent of \cap{V}
-		vCapScalarResult.Add(phiCubed, vCapScalarResult)
-		vCapScalarResult.Mod(vCapScalarResult, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for index %d, computing vCapPoint, vCapScalarResult partial is: %v\n", i, phiCubed)
+		vCapScalarResult = vCapScalarResult.Add(phiListScalar.Mul(phiListScalar).Mul(phiListScalar))
 	}
-	//fmt.Printf("In ProveShuffle, computing vCapPoint, vCapScalarResult final is: %v\n", vCapScalarResult)
 
 	// Compute the \cap{V} point based on the computed scalar as
-	vCapPointX, vCapPointY := instance.Curve.ScalarBaseMult(vCapScalarResult.Bytes())
-	//fmt.Printf("In ProveShuffle, vCapPoint final is (%v,%v)\n", vCapPoint.X, vCapPoint.Y)
+	vCapPoint := g.BaseMul(vCapScalarResult)
 
 	// Compute \cap{W_i}
-	wCapPointList := make([]types.Point, 0)
-	sigmaScalar := new(big.Int).SetBytes(sigmaScalarBytes)
-	//fmt.Printf("In ProveShuffle, computing wCapPointList, value of sigmaScalar is: %v\n", sigmaScalar)
-
+	wCapPointList := make([]group.Element, 0)
 	for i := 0; i < len(witness.PermList); i++ {
-
-		// Choose r_i
-		rScalar := witness.RscalarList[i]
-		//fmt.Printf("In ProveShuffle, loop for ^w_i, index %d, witness.RscalarList[i] is: %v\n", i, witness.RscalarList[i])
-
 		//Compute \sigma * r_i
-		sigmarScalar := new(big.Int).Mul(sigmaScalar, &rScalar)
-		sigmarScalar.Mod(sigmarScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, loop for ^w_i, index %d, sigmarScalar is: %v\n", i, sigmarScalar)
+		sigmarScalar := sigmaScalar.Mul(g.ScalarFromInt(&witness.RscalarList[i]))
 
 		// Find the image of i under the secret permutation \pi
 		ind := witness.PermList[i]
-		//fmt.Printf("In ProveShuffle, loop for ^w_i, index %d, witness.PermList is: %v\n", i, ind)
-
-		// Derive phi_{\pi(i)}
-		phiListScalar := new(big.Int).SetBytes(phiList[ind])
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint[i], index %d, chosen perm index %d, chosen phiListScalar is: %v\n", i, ind, phiListScalar)
-
-		// Initialize 2 as a scalar
-		twoScalar := new(big.Int).SetUint64(2)
-
-		// Compute the product of 2*phi_{\pi(i)}
-		resultScalar := new(big.Int).Mul(twoScalar, phiListScalar)
-		resultScalar.Mod(resultScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint[i], index %d, chosen perm index %d, 2*phiListScalar is: %v\n", i, ind, resultScalar)
 
 		// Compute the 2*phi_{\pi(i)} + \sigma * r_i
-		resultScalar.Add(resultScalar, sigmarScalar)
-		resultScalar.Mod(resultScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint[i], index %d, chosen perm index %d, resultScalar is: %v\n", i, ind, resultScalar)
+		resultScalar := twoScalar.Mul(g.Scalar(phiList[ind])).Add(sigmarScalar)
 
 		// Compute \cap{W_i} as result*G
-		wCapBlindPoint := types.Point{}
-		wCapBlindPointX, wCapBlindPointY := instance.Curve.ScalarBaseMult(resultScalar.Bytes())
-
-		wCapBlindPoint.X = *wCapBlindPointX
-		wCapBlindPoint.Y = *wCapBlindPointY
-
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint[i], index %d, chosen perm index %d, wCapBlindPoint is (%v,%v)\n", i, ind, wCapBlindPoint.X, wCapBlindPoint.Y)
-		wCapPointList = append(wCapPointList, wCapBlindPoint)
+		wCapPointList = append(wCapPointList, g.BaseMul(resultScalar))
 	}
 
 	//Compute  \cap{W}
-	// wCapPoint := types.Point{}
-
-	// \cap{W} =
-	wCapScalar := new(big.Int).SetUint64(0)
-	wCapScalar.Mul(phiScalar, sigmaScalar)
-	wCapScalar.Mod(wCapScalar, curveParams.N)
-	//fmt.Printf("In ProveShuffle, loop for wCapPoint, inital result for exponent is %v\n", wCapScalar)
 
+	// \cap{W} = (phi*sigma + \sum_i phi_i^2)*G
+	wCapScalar := phiScalar.Mul(sigmaScalar)
 	for i := 0; i < len(witness.PermList); i++ {
-		phiSquared := new(big.Int).SetUint64(0)
-		phiListScalar := new(big.Int).SetBytes(phiList[i])
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint, phiListScalar is %v\n", phiListScalar)
-
-		phiSquared.Mul(phiListScalar, phiListScalar)
-		phiSquared.Mod(phiSquared, curveParams.N)
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint, phiListScalar is %v\n", phiSquared)
-
-		wCapScalar.Add(wCapScalar, phiSquared)
-		wCapScalar.Mod(wCapScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, loop for wCapPoint, partial result for exponent is %v\n", wCapScalar)
-
+		phiListScalar := g.Scalar(phiList[i])
+		wCapScalar = wCapScalar.Add(phiListScalar.Mul(phiListScalar))
 	}
-	//fmt.Printf("In ProveShuffle, loop for wCapPoint, final result for exponent is %v\n", wCapScalar)
-	wCapScalar.Mod(wCapScalar, curveParams.N)
-	wCapPointX, wCapPointY := instance.Curve.ScalarBaseMult(wCapScalar.Bytes())
-	//fmt.Printf("In ProveShuffle, loop for wCapPoint, final result is (%v,%v)\n", wCapPoint.X, wCapPoint.Y)
+	wCapPoint := g.BaseMul(wCapScalar)
 
 	//Begin preparing derived points for appending to the transcript
 
-	tPointCompressed := elliptic.MarshalCompressed(instance.Curve, tPointX, tPointY)
-	vPointCompressed := elliptic.MarshalCompressed(instance.Curve, vPointX, vPointY)
-	wPointCompressed := elliptic.MarshalCompressed(instance.Curve, wPointX, wPointY)
-	uPointCompressed := elliptic.MarshalCompressed(instance.Curve, uPointX, uPointY)
-	uPointListCompressed := MarshalPointList(uPointList, instance.Curve)
+	tPointCompressed := tPoint.Bytes()
+	vPointCompressed := vPoint.Bytes()
+	wPointCompressed := wPoint.Bytes()
+	uPointCompressed := uPoint.Bytes()
+	uPointListCompressed := encodeElements(uPointList)
 	//TODO: missing is ^g'-list and ^g' point
 
-	gPrimePointCompressed := elliptic.MarshalCompressed(instance.Curve, gPrimePointX, gPrimePointY)
-	mPrimePointCompressed := elliptic.MarshalCompressed(instance.Curve, mPrimePointX, mPrimePointY)
-	tCapPointListCompressed := MarshalPointList(tCapPointList, instance.Curve)
-	vCapPointListCompressed := MarshalPointList(vCapPointList, instance.Curve)
-	vCapPointCompressed := elliptic.MarshalCompressed(instance.Curve, vCapPointX, vCapPointY)
-	wCapPointListCompressed := MarshalPointList(wCapPointList, instance.Curve)
-	wCapPointCompressed := elliptic.MarshalCompressed(instance.Curve, wCapPointX, wCapPointY)
+	gPrimePointCompressed := gPrimePoint.Bytes()
+	mPrimePointCompressed := mPrimePoint.Bytes()
+	tCapPointListCompressed := encodeElements(tCapPointList)
+	vCapPointListCompressed := encodeElements(vCapPointList)
+	vCapPointCompressed := vCapPoint.Bytes()
+	wCapPointListCompressed := encodeElements(wCapPointList)
+	wCapPointCompressed := wCapPoint.Bytes()
 
 	//Putting commitment messages into the transcript
 
@@ -1080,84 +765,42 @@ func ProveShuffle(instance *types.ShuffleInstance, witness *types.ShuffleWitness
 	//Verifier's step: derive public coins based on the
 	challScalarList := make([][]byte, 0)
 	for i := 0; i < len(witness.PermList); i++ {
-		challScalarList = append(challScalarList, transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE))
+		challScalarList = append(challScalarList, transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen()))
 	}
 
-	//fmt.Printf("In ProveShuffle, challScalarList is %v\n", challScalarList)
-
 	//Compute s_0 scalar
-	sZeroScalar := new(big.Int).Set(phiScalar)
-	//fmt.Printf("In ProveShuffle, sZeroScalar initial: %v\n", sZeroScalar)
-
+	sZeroScalar := phiScalar
 	for i := 0; i < len(witness.PermList); i++ {
-		//Corresponds to \beta_j
-		challScalar := new(big.Int).SetBytes(challScalarList[i])
-		//fmt.Printf("In ProveShuffle, challScalar is: %v\n", challScalar)
-		//multiplied by r_j
-		//fmt.Printf("In ProveShuffle, loop for s_0, witness.RscalarList[i] is: %v\n", witness.RscalarList[i])
-		rBeta := challScalar.Mul(challScalar, &witness.RscalarList[i])
-		rBeta = rBeta.Mod(rBeta, curveParams.N)
-
-		//fmt.Printf("In ProveShuffle, for sZeroScalar addend is : %v\n", rBeta)
+		//Corresponds to \beta_j multiplied by r_j
+		rBeta := g.Scalar(challScalarList[i]).Mul(g.ScalarFromInt(&witness.RscalarList[i]))
 
 		//Add result to the sum
-		sZeroScalar = sZeroScalar.Add(sZeroScalar, rBeta)
-		sZeroScalar = sZeroScalar.Mod(sZeroScalar, curveParams.N)
-
-		//fmt.Printf("In ProveShuffle, sZeroScalar partial: %v\n", sZeroScalar)
-
+		sZeroScalar = sZeroScalar.Add(rBeta)
 	}
-	//fmt.Printf("In ProveShuffle, sZeroScalar final: %v\n", sZeroScalar)
 
 	sList := make([]big.Int, 0)
-	//fmt.Printf("In ProveShuffle, PermList is: %v\n", witness.PermList)
 	permMatrix := MakePermutationMatrix(witness.PermList)
-	//fmt.Printf("In ProveShuffle, PermMatrix is:\n")
 	printPermutationMatrix(permMatrix)
 
 	// Compute s_i scalars
 	for i := 0; i < len(witness.PermList); i++ {
-		sScalar := new(big.Int).SetBytes(phiList[i])
-		//fmt.Printf("In ProveShuffle, for index %d, sScalar initial %v\n", i, sScalar)
-
 		ind := findNonZeroIndex(permMatrix[i])
-		//fmt.Printf("In ProveShuffle, for index %d, chosen index in permutation matrix %d\n", i, ind)
-
-		//fmt.Printf("In ProveShuffle, Beta value chosen %v\n", challScalarList[ind])
-		challScalar := new(big.Int).SetBytes(challScalarList[ind])
-		//fmt.Printf("In ProveShuffle, for index %d, challScalar is %v\n", i, challScalar)
 
-		sScalar = sScalar.Add(sScalar, challScalar)
-		sScalar = sScalar.Mod(sScalar, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for index %d, sScalar final %v\n", i, sScalar)
-		sList = append(sList, *sScalar)
+		sScalar := g.Scalar(phiList[i]).Add(g.Scalar(challScalarList[ind]))
+		sList = append(sList, *sScalar.BigInt())
 	}
 
 	// Compute d scalar
 
 	//Set it to initially be lambda
-
-	dScalar := new(big.Int).SetBytes(lambdaScalarBytes)
+	dScalar := lambdaScalar
 	for i := 0; i < len(witness.PermList); i++ {
-		lambda := new(big.Int).SetBytes(lambdaList[i])
-		chall := new(big.Int).SetBytes(challScalarList[i])
-
-		//compute chall_i*chall_i
-		challSquared := chall.Mul(chall, chall)
-		challSquared = challSquared.Mod(challSquared, curveParams.N)
-		//fmt.Printf("In ProveShuffle, for dScalar, for index %d, partial value is %v\n", i, challSquared)
+		chall := g.Scalar(challScalarList[i])
 
 		//compute lambda_i*chall_i*chall_i
-		prod := challSquared.Mul(challSquared, lambda)
-		dScalar = dScalar.Add(dScalar, prod)
-		dScalar = dScalar.Mod(dScalar, curveParams.N)
-
-		//fmt.Printf("In ProveShuffle, for dScalar,for index %d, dScalar partial %v\n", i, dScalar)
-
+		dScalar = dScalar.Add(chall.Mul(chall).Mul(g.Scalar(lambdaList[i])))
 	}
 
-	//fmt.Printf("In ProveShuffle, dScalar final %v\n", dScalar)
-
 	shuffleProof := types.ShuffleProof{
 		ProofType:          SHUFFLE_LABEL,
 		Instance:           *instance,
@@ -1174,42 +817,56 @@ func ProveShuffle(instance *types.ShuffleInstance, witness *types.ShuffleWitness
 		VCapPoint:          vCapPointCompressed,
 		WCapPointListBytes: wCapPointListCompressed,
 		WCapPoint:          wCapPointCompressed,
-		SZeroScalar:        *sZeroScalar,
+		SZeroScalar:        *sZeroScalar.BigInt(),
 		SList:              sList,
-		DScalar:            *dScalar,
+		DScalar:            *dScalar.BigInt(),
 	}
 	return &shuffleProof, nil
 }
 
 func VerifyShuffle(proof *types.ShuffleProof) bool {
-	curve := proof.Instance.Curve
-	curveParams := proof.Instance.Curve.Params()
+	g, err := group.ForSuite(proof.Instance.Suite)
+	if err != nil {
+		return false
+	}
+
+	n := len(proof.Instance.CtBefore)
+	if len(proof.Instance.CtAfter) != n || len(proof.SList) != n || len(proof.UPointListBytes) != n ||
+		len(proof.TCapPointListBytes) != n || len(proof.VCapPointListBytes) != n || len(proof.WCapPointListBytes) != n {
+		return false
+	}
 
 	transcript := NewTranscript(proof.ProofType)
 	proofTypeBytes := []byte(proof.ProofType)
-	reEncBeforeList := MakeReencList(proof.Instance.CtBefore)
-	reEncAfterList := MakeReencList(proof.Instance.CtAfter)
-	ctMsgBeforeList := MakeCtMsgList(proof.Instance.CtBefore)
-	ctMsgAfterList := MakeCtMsgList(proof.Instance.CtAfter)
-
-	reEncBeforeByteList := MarshalPointList(reEncBeforeList, curve)
-	reEncAfterByteList := MarshalPointList(reEncAfterList, curve)
-	ctMsgBeforeByteList := MarshalPointList(ctMsgBeforeList, curve)
-	ctMsgAfterByteList := MarshalPointList(ctMsgAfterList, curve)
 
-	pPoint := types.Point{
-		X: proof.Instance.PPoint.X,
-		Y: proof.Instance.PPoint.Y,
+	reEncBeforeList, err := decodePoints(g, MakeReencList(proof.Instance.CtBefore)...)
+	if err != nil {
+		return false
+	}
+	reEncAfterList, err := decodePoints(g, MakeReencList(proof.Instance.CtAfter)...)
+	if err != nil {
+		return false
+	}
+	ctMsgBeforeList, err := decodePoints(g, MakeCtMsgList(proof.Instance.CtBefore)...)
+	if err != nil {
+		return false
+	}
+	ctMsgAfterList, err := decodePoints(g, MakeCtMsgList(proof.Instance.CtAfter)...)
+	if err != nil {
+		return false
 	}
 
-	pPointCompressed := elliptic.MarshalCompressed(curve, &pPoint.X, &pPoint.Y)
+	pPoint, err := g.ElementFromPoint(proof.Instance.PPoint)
+	if err != nil {
+		return false
+	}
 
 	//Putting public parameters into the transcript
-	transcript.BatchAppendMessages(proofTypeBytes, reEncBeforeByteList)
-	transcript.BatchAppendMessages(proofTypeBytes, reEncAfterByteList)
-	transcript.BatchAppendMessages(proofTypeBytes, ctMsgBeforeByteList)
-	transcript.BatchAppendMessages(proofTypeBytes, ctMsgAfterByteList)
-	transcript.AppendMessage(proofTypeBytes, pPointCompressed)
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(reEncBeforeList))
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(reEncAfterList))
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(ctMsgBeforeList))
+	transcript.BatchAppendMessages(proofTypeBytes, encodeElements(ctMsgAfterList))
+	transcript.AppendMessage(proofTypeBytes, pPoint.Bytes())
 
 	//Putting commitment messages into the transcript
 
@@ -1230,317 +887,298 @@ func VerifyShuffle(proof *types.ShuffleProof) bool {
 
 	//Verifier's step: derive public coins based on the
 	challBytesList := make([][]byte, 0)
-	for i := 0; i < len(proof.Instance.CtBefore); i++ {
-		challBytesList = append(challBytesList, transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE))
+	for i := 0; i < n; i++ {
+		challBytesList = append(challBytesList, transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen()))
 	}
 
-	//fmt.Printf("In VerifyShuffle, challBytesList is %v\n", challBytesList)
-
 	checkBytes := checkChallBytesLists(challBytesList, proof.VerifierChallList)
 
 	//Parse all the points for the final verifier's check
 
-	tPointX, tPointY := elliptic.UnmarshalCompressed(curve, proof.TPoint)
-	wPointX, wPointY := elliptic.UnmarshalCompressed(curve, proof.WPoint)
-	vPointX, vPointY := elliptic.UnmarshalCompressed(curve, proof.VPoint)
-	uPointX, uPointY := elliptic.UnmarshalCompressed(curve, proof.UPoint)
+	points, err := decodeElements(g, proof.TPoint, proof.WPoint, proof.VPoint, proof.UPoint,
+		proof.GPrimePoint, proof.MPrimePoint, proof.VCapPoint, proof.WCapPoint)
+	if err != nil {
+		return false
+	}
+	tPoint, wPoint, vPoint, uPoint := points[0], points[1], points[2], points[3]
+	gPrimePoint, mPrimePoint, vCapPoint, wCapPoint := points[4], points[5], points[6], points[7]
 
-	gPrimePointX, gPrimePointY := elliptic.UnmarshalCompressed(curve, proof.GPrimePoint)
-	mPrimePointX, mPrimePointY := elliptic.UnmarshalCompressed(curve, proof.MPrimePoint)
-	vCapPointX, vCapPointY := elliptic.UnmarshalCompressed(curve, proof.VCapPoint)
-	wCapPointX, wCapPointY := elliptic.UnmarshalCompressed(curve, proof.WCapPoint)
+	uPointList, err := decodeElements(g, proof.UPointListBytes...)
+	if err != nil {
+		return false
+	}
+	vCapPointList, err := decodeElements(g, proof.VCapPointListBytes...)
+	if err != nil {
+		return false
+	}
+	wCapPointList, err := decodeElements(g, proof.WCapPointListBytes...)
+	if err != nil {
+		return false
+	}
+	tCapPointList, err := decodeElements(g, proof.TCapPointListBytes...)
+	if err != nil {
+		return false
+	}
 
-	uPointList := UnmarshalPointList(proof.UPointListBytes, curve)
-	vCapPointList := UnmarshalPointList(proof.VCapPointListBytes, curve)
-	wCapPointList := UnmarshalPointList(proof.WCapPointListBytes, curve)
-	tCapPointList := UnmarshalPointList(proof.TCapPointListBytes, curve)
-	// //fmt.Printf("wCapPointList, %v\n", wCapPointList)
+	sZeroScalar := g.ScalarFromInt(&proof.SZeroScalar)
+	sList := make([]group.Scalar, n)
+	challList := make([]group.Scalar, n)
+	for i := 0; i < n; i++ {
+		sList[i] = g.ScalarFromInt(&proof.SList[i])
+		challList[i] = g.Scalar(challBytesList[i])
+	}
 
 	//Corresponds to check (5.20) in notes
-	// sGpoint := types.Point{}
-	sGpointX, sGpointY := curve.ScalarBaseMult(proof.SZeroScalar.Bytes())
-
-	//fmt.Printf("In VerifyShuffle, sZeroScalar is %v\n", proof.SZeroScalar)
-	//fmt.Printf("In VerifyShuffle, sGpoint, initial value is (%v,%v)\n", sGpoint.X, sGpointY)
-
-	for i, sScalar := range proof.SList {
-		// addend := types.Point{}
-		//fmt.Printf("In VerifyShuffle, reEncBeforeList[i] is (%v,%v)\n", reEncBeforeList[i].X, reEncBeforeList[i].Y)
-		//fmt.Printf("In VerifyShuffle, sScalar is %v\n", sScalar)
-
-		addendX, addendY := curve.ScalarMult(&reEncBeforeList[i].X, &reEncBeforeList[i].Y, sScalar.Bytes())
-		sGpointX, sGpointY = curve.Add(addendX, addendY, sGpointX, sGpointY)
-		//fmt.Printf("In VerifyShuffle, sGpoint, partial result is (%v,%v)\n", sGpoint.X, sGpoint.Y)
-
+	sGpoint := g.BaseMul(sZeroScalar)
+	for i, sScalar := range sList {
+		sGpoint = sGpoint.Add(reEncBeforeList[i].Mul(sScalar))
 	}
-	//fmt.Printf("In VerifyShuffle, sGpoint,final is (%v,%v)\n", sGpoint.X, sGpoint.Y)
-
-	betaGprimePointX := new(big.Int).Set(gPrimePointX)
-	betaGprimePointY := new(big.Int).Set(gPrimePointY)
-	//fmt.Printf("In VerifyShuffle, betaGprimePoint initial is (%v,%v)\n", betaGprimePoint.X, betaGprimePoint.Y)
-
-	for i, challBytes := range challBytesList {
-		// addend := types.Point{}
-		//fmt.Printf("In VerifyShuffle, for index %d, reEncAfterList[i] is (%v,%v)\n", i, reEncAfterList[i].X, reEncAfterList[i].Y)
-		addendX, addendY := curve.ScalarMult(&reEncAfterList[i].X, &reEncAfterList[i].Y, challBytes)
-		//fmt.Printf("In VerifyShuffle, for index %d, addend is (%v,%v)\n", i, reEncAfterList[i].X, reEncAfterList[i].Y)
-		betaGprimePointX, betaGprimePointY = curve.Add(addendX, addendY, betaGprimePointX, betaGprimePointY)
-		// betaGprimePoint.X, betaGprimePoint.Y = *bpX, *bpY
-
-		//fmt.Printf("In VerifyShuffle, betaGprimePoint partial result is (%v,%v)\n", betaGprimePoint.X, betaGprimePoint.Y)
 
+	betaGprimePoint := gPrimePoint
+	for i, chall := range challList {
+		betaGprimePoint = betaGprimePoint.Add(reEncAfterList[i].Mul(chall))
 	}
-	//fmt.Printf("In VerifyShuffle, betaGprimePoint final is (%v,%v)\n", betaGprimePoint.X, betaGprimePoint.Y)
 
-	checkO2 := sGpointX.Cmp(betaGprimePointX) == 0 && sGpointY.Cmp(betaGprimePointY) == 0
+	checkO2 := sGpoint.Equal(betaGprimePoint)
 
 	//Corresponds to check (5.21) in notes
-
-	// sMpoint := types.Point{}
-	sMpointX, sMpointY := curve.ScalarMult(&pPoint.X, &pPoint.Y, proof.SZeroScalar.Bytes())
-	//fmt.Printf("In VerifyShuffle, sMpoint initial is (%v,%v)\n", sMpoint.X, sMpoint.Y)
-
-	for i, s := range proof.SList {
-		// addend := types.Point{}
-		//fmt.Printf("In VerifyShuffle, for %d, ctMsgBefore[i] is (%v,%v)\n", i, ctMsgBeforeList[i].X, ctMsgBeforeList[i].Y)
-
-		addendX, addendY := curve.ScalarMult(&ctMsgBeforeList[i].X, &ctMsgBeforeList[i].Y, s.Bytes())
-		//fmt.Printf("In VerifyShuffle, sMpoint addend is (%v,%v)\n", addend.X, addend.Y)
-		sMpointX, sMpointY = curve.Add(addendX, addendY, sMpointX, sMpointY)
-		//fmt.Printf("In VerifyShuffle, sMpoint partial is (%v,%v)\n", sMpoint.X, sMpoint.Y)
+	sMpoint := pPoint.Mul(sZeroScalar)
+	for i, s := range sList {
+		sMpoint = sMpoint.Add(ctMsgBeforeList[i].Mul(s))
 	}
-	//fmt.Printf("In VerifyShuffle, sMpoint final is (%v,%v)\n", sMpoint.X, sMpoint.Y)
-
-	challMprimePointX := new(big.Int).Set(mPrimePointX)
-	challMprimePointY := new(big.Int).Set(mPrimePointY)
-
-	//fmt.Printf("In VerifyShuffle, challMprimePoint initial is (%v,%v)\n", challMprimePoint.X, challMprimePoint.Y)
-
-	for i, challBytes := range challBytesList {
-		// addend := types.Point{}
-		//fmt.Printf("In VerifyShuffle, for index %d, ctMsgAfterList is (%v,%v)\n", i, ctMsgAfterList[i].X, ctMsgAfterList[i].Y)
-		addendX, addendY := curve.ScalarMult(&ctMsgAfterList[i].X, &ctMsgAfterList[i].Y, challBytes)
-		//fmt.Printf("In VerifyShuffle,for index %d, challMprimePoint addend is (%v,%v)\n", i, addend.X, addend.Y)
 
-		challMprimePointX, challMprimePointY = curve.Add(addendX, addendY, challMprimePointX, challMprimePointY)
-
-		//fmt.Printf("In VerifyShuffle,for index %d, challMprimePoint partial is (%v,%v)\n", i, challMprimePoint.X, challMprimePoint.Y)
+	challMprimePoint := mPrimePoint
+	for i, chall := range challList {
+		challMprimePoint = challMprimePoint.Add(ctMsgAfterList[i].Mul(chall))
 	}
-	//fmt.Printf("In VerifyShuffle, challMprimePoint final is (%v,%v)\n", challMprimePoint.X, challMprimePoint.Y)
 
-	checkO3 := sMpointX.Cmp(challMprimePointX) == 0 && sMpointY.Cmp(challMprimePointY) == 0
+	checkO3 := sMpoint.Equal(challMprimePoint)
 
 	//Corresponds to check (5.22) in notes
-	// sBetaSqWGPoint := types.Point{}
 
 	//Initialize point to be s0*W
-	sBetaSqWGPointX, sBetaSqWGPointY := curve.ScalarMult(wPointX, wPointY, proof.SZeroScalar.Bytes())
-
-	//fmt.Printf("In VerifyShuffle, challMprimePoint initial is (%v,%v)\n", sBetaSqWGPoint.X, sBetaSqWGPoint.Y)
-
-	sqDiff := new(big.Int).SetUint64(0)
-	for i := 0; i < len(challBytesList); i++ {
-		//Compute s_i^2
-		siSq := new(big.Int).Set(&proof.SList[i])
+	sBetaSqWGPoint := wPoint.Mul(sZeroScalar)
 
-		siSq = siSq.Mul(siSq, siSq)
-		siSq = siSq.Mod(siSq, curveParams.N)
-
-		//fmt.Printf("In VerifyShuffle, for index %d, siSq is %v\n", i, siSq)
-
-		//compute chall^2
-		challScalar := new(big.Int).SetBytes(challBytesList[i])
-		challSq := challScalar.Mul(challScalar, challScalar)
-		challSq = challSq.Mod(challSq, curveParams.N)
-		challSq = challSq.Neg(challSq)
-
-		//fmt.Printf("In VerifyShuffle, for index %d, challSq is %v\n", i, challSq)
-
-		//Compute addend = s_i^2-chall^2
-		addend := siSq.Add(siSq, challSq)
-		addend = addend.Mod(addend, curveParams.N)
-
-		//fmt.Printf("In VerifyShuffle, for index %d, addend is %v\n", i, addend)
-
-		//Add addend to the whole sum
-		sqDiff = sqDiff.Add(sqDiff, addend)
-		sqDiff = sqDiff.Mod(sqDiff, curveParams.N)
-
-		//fmt.Printf("In VerifyShuffle, for index %d, sqDiff partial is %v\n", i, sqDiff)
+	//Compute the sum of s_i^2-chall^2
+	sqDiff := g.ScalarFromInt(new(big.Int))
+	for i := 0; i < n; i++ {
+		siSq := sList[i].Mul(sList[i])
+		challSq := challList[i].Mul(challList[i])
+		sqDiff = sqDiff.Add(siSq.Sub(challSq))
 	}
-	//fmt.Printf("In VerifyShuffle, sqDiff final is %v\n", sqDiff)
-
-	// sqDiffGPoint := types.Point{}
-	sqDiffGPointX, sqDiffGPointY := curve.ScalarBaseMult(sqDiff.Bytes())
-	//fmt.Printf("In VerifyShuffle, sqDiffGPoint is (%v,%v)\n", sqDiffGPoint.X, sqDiffGPoint.Y)
 
-	sBetaSqWGPointX, sBetaSqWGPointY = curve.Add(sBetaSqWGPointX, sBetaSqWGPointY, sqDiffGPointX, sqDiffGPointY)
-	//fmt.Printf("In VerifyShuffle, sBetaSqWGPoint final is (%v,%v)\n", sBetaSqWGPoint.X, sBetaSqWGPoint.Y)
+	sBetaSqWGPoint = sBetaSqWGPoint.Add(g.BaseMul(sqDiff))
 
-	betawCapPointX := new(big.Int).Set(wCapPointX)
-	betawCapPointY := new(big.Int).Set(wCapPointY)
-	//fmt.Printf("In VerifyShuffle, betawCapPoint initial is (%v,%v)\n", betawCapPoint.X, betawCapPoint.Y)
-
-	for i, challBytes := range challBytesList {
-		// addend := types.Point{}
-		addendX, addendY := curve.ScalarMult(&wCapPointList[i].X, &wCapPointList[i].Y, challBytes)
-		//fmt.Printf("In VerifyShuffle, addend for betawCapPoint is (%v,%v)\n", addend.X, addend.Y)
-
-		betawCapPointX, betawCapPointY = curve.Add(addendX, addendY, betawCapPointX, betawCapPointY)
-		//fmt.Printf("In VerifyShuffle,  betawCapPoint partial is (%v,%v)\n", betawCapPoint.X, betawCapPoint.Y)
+	betawCapPoint := wCapPoint
+	for i, chall := range challList {
+		betawCapPoint = betawCapPoint.Add(wCapPointList[i].Mul(chall))
 	}
-	//fmt.Printf("In VerifyShuffle,  betawCapPoint final is (%v,%v)\n", betawCapPoint.X, betawCapPoint.Y)
 
-	check04 := sBetaSqWGPointX.Cmp(betawCapPointX) == 0 && sBetaSqWGPointY.Cmp(betawCapPointY) == 0
+	check04 := sBetaSqWGPoint.Equal(betawCapPoint)
 
 	//Corresponds to check (5.23) in notes
+	dScalar := g.ScalarFromInt(&proof.DScalar)
+	dGpoint := g.BaseMul(dScalar)
 
-	// dGpoint := types.Point{}
-	dGpointX, dGpointY := curve.ScalarBaseMult(proof.DScalar.Bytes())
-
-	//fmt.Printf("In VerifyShuffle, dGPoint final is (%v,%v)\n", dGpoint.X, dGpoint.Y)
-
-	challSqUPointX := new(big.Int).Set(uPointX)
-	challSqUPointY := new(big.Int).Set(uPointY)
-
-	//fmt.Printf("In VerifyShuffle, challSqUPoint initial is (%v,%v)\n", challSqUPoint.X, challSqUPoint.Y)
-
-	for i, challBytes := range challBytesList {
-		// addend := types.Point{}
-		challScalar := new(big.Int).SetBytes(challBytes)
-		challSqScalar := challScalar.Mod(challScalar.Mul(challScalar, challScalar), curveParams.N)
-		//fmt.Printf("In VerifyShuffle, for index %d challSq is %v\n", i, challSqScalar)
-
-		addendX, addendY := curve.ScalarMult(&uPointList[i].X, &uPointList[i].Y, challSqScalar.Bytes())
-		//fmt.Printf("In VerifyShuffle, for index %d addend is (%v,%v)\n", i, addend.X, addend.Y)
-
-		challSqUPointX, challSqUPointY = curve.Add(addendX, addendY, challSqUPointX, challSqUPointY)
-		//fmt.Printf("In VerifyShuffle, challSqUPoint partial is (%v,%v)\n", challSqUPoint.X, challSqUPoint.Y)
+	challSqUPoint := uPoint
+	for i, chall := range challList {
+		challSqUPoint = challSqUPoint.Add(uPointList[i].Mul(chall.Mul(chall)))
 	}
 
-	//fmt.Printf("In VerifyShuffle, challSqUPoint final is (%v,%v)\n", challSqUPoint.X, challSqUPoint.Y)
-
-	check05 := dGpointX.Cmp(challSqUPointX) == 0 && dGpointY.Cmp(challSqUPointY) == 0
+	check05 := dGpoint.Equal(challSqUPoint)
 
 	//Corresponds to check (5.24) in notes
 
-	//Compute lhs of the (5.24) check
-	// dTPoint := types.Point{}
-	dTPointX, dTPointY := curve.ScalarMult(tPointX, tPointY, proof.DScalar.Bytes())
-	//fmt.Printf("In VerifyShuffle, value of dTPoint is (%v,%v)\n", dTPoint.X, dTPoint.Y)
+	//Compute lhs of the (5.24) check, d*T + s0*V + (\sum_i s_i^3-chall^3)*G
+	cubeDiff := g.ScalarFromInt(new(big.Int))
+	for i := 0; i < n; i++ {
+		siCubed := sList[i].Mul(sList[i]).Mul(sList[i])
+		challCubed := challList[i].Mul(challList[i]).Mul(challList[i])
+		cubeDiff = cubeDiff.Add(siCubed.Sub(challCubed))
+	}
 
-	// sZeroVPoint := types.Point{}
-	sZeroVPointX, sZeroVPointY := curve.ScalarMult(vPointX, vPointY, proof.SZeroScalar.Bytes())
-	//fmt.Printf("In VerifyShuffle, value of sZeroVPoint is (%v,%v)\n", sZeroVPoint.X, sZeroVPoint.Y)
+	lhs := tPoint.Mul(dScalar).Add(vPoint.Mul(sZeroScalar)).Add(g.BaseMul(cubeDiff))
 
-	cubeDiff := new(big.Int).SetUint64(0)
-	for i := 0; i < len(challBytesList); i++ {
-		//Compute s_i^3
-		siScalar := new(big.Int).Set(&proof.SList[i])
-		siCubed := new(big.Int).SetUint64(0)
-		siCubed = siCubed.Mul(siScalar, siScalar)
-		siCubed = siCubed.Mul(siCubed, siScalar)
-		siCubed = siCubed.Mod(siCubed, curveParams.N)
+	//Compute rhs of (5.24) check
+	rhs := vCapPoint
+	for i, chall := range challList {
+		rhs = rhs.Add(vCapPointList[i].Mul(chall))
+		rhs = rhs.Add(tCapPointList[i].Mul(chall.Mul(chall)))
+	}
 
-		//fmt.Printf("In VerifyShuffle, for index %d, siCubed is %v\n", i, siCubed)
+	check06 := lhs.Equal(rhs)
 
-		//compute -chall^3
-		challSc := new(big.Int).SetBytes(challBytesList[i])
+	return checkBytes && checkO2 && checkO3 && check04 && check05 && check06
+}
 
-		challCubed := new(big.Int).SetUint64(0)
-		challCubed = challCubed.Mul(challSc, challSc)
-		challCubed = challCubed.Mul(challCubed, challSc)
-		challCubed = challCubed.Mod(challCubed, curveParams.N)
-		//fmt.Printf("In VerifyShuffle, for index %d, challCubed is %v\n", i, challCubed)
+// ProveBallotShuffle proves that a mix hop shuffled whole ballots, that is,
+// that the same permutation applies to every position of the ballots, where
+// the shuffle proofs of each position only prove a permutation of their own
+// ciphertexts. before and after hold the ciphertexts of each position of the
+// ballots, input and output of the hop. The output ballot i is the
+// re-encryption of the input ballot permutation[i], with rScalars[i] (one
+// scalar per position). The ciphertexts of each ballot are combined into one
+// with random coefficients (see combineBallots), and the proof is the shuffle
+// proof of the combined ciphertexts.
+func ProveBallotShuffle(g group.Group, pPoint types.Point, before, after [][]types.ElGamalCipherText,
+	permutation []uint32, rScalars [][]big.Int) (*types.ShuffleProof, error) {
+
+	coefficients, err := ballotShuffleCoefficients(g, pPoint, before, after)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveBallotShuffle: %v", err)
+	}
 
-		challCubed = challCubed.Neg(challCubed)
+	combinedBefore, err := combineBallots(g, before, coefficients)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveBallotShuffle: %v", err)
+	}
+	combinedAfter, err := combineBallots(g, after, coefficients)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveBallotShuffle: %v", err)
+	}
 
-		//fmt.Printf("In VerifyShuffle, for index %d, -challCubed is %v\n", i, challCubed)
+	// the combined ciphertext is re-encrypted with the same combination of the
+	// re-encryption scalars
+	combinedScalars := make([]big.Int, len(rScalars))
+	for i := range rScalars {
+		if len(rScalars[i]) != len(coefficients) {
+			return nil, xerrors.Errorf("Error in ProveBallotShuffle: %d re-encryption scalars for %d positions",
+				len(rScalars[i]), len(coefficients))
+		}
 
-		//Compute addend = s_i^3-chall^3
-		addend := new(big.Int).SetUint64(0)
-		addend = addend.Add(siCubed, challCubed)
-		addend = addend.Mod(addend, curveParams.N)
+		sum := g.ScalarFromInt(big.NewInt(0))
+		for j := range coefficients {
+			sum = sum.Add(coefficients[j].Mul(g.ScalarFromInt(&rScalars[i][j])))
+		}
+		combinedScalars[i] = *sum.BigInt()
+	}
 
-		//fmt.Printf("In VerifyShuffle, for index %d, value of addend (s_i^3 - chall^3) is %v\n", i, addend)
+	instance := NewShuffleInstance(g, pPoint, combinedBefore, combinedAfter)
+	return ProveShuffle(instance, NewShuffleWitness(permutation, combinedScalars))
+}
 
-		// //fmt.Printf("In VerifyShuffle, for index %d, addend is %v\n", i, addend)
+// VerifyBallotShuffle verifies the proof that the ballots of before are
+// shuffled into the ballots of after (see ProveBallotShuffle)
+func VerifyBallotShuffle(g group.Group, pPoint types.Point, before, after [][]types.ElGamalCipherText,
+	proof *types.ShuffleProof) bool {
 
-		//Add addend to the whole sum
-		cubeDiff = cubeDiff.Add(cubeDiff, addend)
-		cubeDiff = cubeDiff.Mod(cubeDiff, curveParams.N)
-		//fmt.Printf("In VerifyShuffle, for index %d, cubeDiff partial is %v\n", i, cubeDiff)
+	coefficients, err := ballotShuffleCoefficients(g, pPoint, before, after)
+	if err != nil {
+		return false
 	}
-	//fmt.Printf("In VerifyShuffle, cubeDiff final is %v\n", cubeDiff)
 
-	// cubeDiffGPoint := types.Point{}
-	cubeDiffGPointX, cubeDiffGPointY := curve.ScalarBaseMult(cubeDiff.Bytes())
+	combinedBefore, err := combineBallots(g, before, coefficients)
+	if err != nil {
+		return false
+	}
+	combinedAfter, err := combineBallots(g, after, coefficients)
+	if err != nil {
+		return false
+	}
 
-	//fmt.Printf("In VerifyShuffle, value of cubeDiffGPoint is (%v,%v)\n", cubeDiffGPoint.X, cubeDiffGPoint.Y)
+	instance := proof.Instance
+	if !equalPoints(instance.PPoint, pPoint) ||
+		len(instance.CtBefore) != len(combinedBefore) || len(instance.CtAfter) != len(combinedAfter) {
+		return false
+	}
+	for i := range combinedBefore {
+		if !equalCipherTexts(instance.CtBefore[i], combinedBefore[i]) ||
+			!equalCipherTexts(instance.CtAfter[i], combinedAfter[i]) {
+			return false
+		}
+	}
 
-	// lhs := types.Point{}
-	lhsX, lhsY := curve.Add(dTPointX, dTPointY, sZeroVPointX, sZeroVPointY)
-	//fmt.Printf("In VerifyShuffle, value of sume dT + sZeroV is (%v,%v)\n", lhs.X, lhs.Y)
+	verified := *proof
+	verified.Instance.Suite = g.Suite()
+	return VerifyShuffle(&verified)
+}
 
-	lhsX, lhsY = curve.Add(lhsX, lhsY, cubeDiffGPointX, cubeDiffGPointY)
+// ballotShuffleCoefficients derives the coefficients which combine the
+// ciphertexts of each ballot, one per position, from the input and the output
+// of the hop: the mixnet server can't choose them
+func ballotShuffleCoefficients(g group.Group, pPoint types.Point, before, after [][]types.ElGamalCipherText) ([]group.Scalar, error) {
+	if len(before) == 0 || len(before) != len(after) {
+		return nil, xerrors.Errorf("%d positions before the shuffle, %d after", len(before), len(after))
+	}
 
-	//fmt.Printf("In VerifyShuffle, final value of lhs is (%v,%v)\n", lhs.X, lhs.Y)
+	proofTypeBytes := []byte(BALLOT_SHUFFLE_LABEL)
+	transcript := NewTranscript(BALLOT_SHUFFLE_LABEL)
 
-	//Compute rhs of (5.24) check
-	rhsX := new(big.Int).Set(vCapPointX)
-	rhsY := new(big.Int).Set(vCapPointY)
+	pPointCompressed, err := compressPoint(g, pPoint)
+	if err != nil {
+		return nil, err
+	}
+	transcript.AppendMessage(proofTypeBytes, pPointCompressed)
 
-	//fmt.Printf("In VerifyShuffle, initial value of rhs is (%v,%v)\n", rhs.X, rhs.Y)
+	for _, columns := range [][][]types.ElGamalCipherText{before, after} {
+		for _, column := range columns {
+			if len(column) != len(before[0]) {
+				return nil, xerrors.Errorf("%d ciphertexts at a position, %d at the first one", len(column), len(before[0]))
+			}
 
-	for i := 0; i < len(challBytesList); i++ {
-		// challVcapPoint := types.Point{}
-		// challSqTCapPoint := types.Point{}
+			points, err := decodePoints(g, MakeReencList(column)...)
+			if err != nil {
+				return nil, err
+			}
+			transcript.BatchAppendMessages(proofTypeBytes, encodeElements(points))
 
-		chall := new(big.Int).SetBytes(challBytesList[i])
+			points, err = decodePoints(g, MakeCtMsgList(column)...)
+			if err != nil {
+				return nil, err
+			}
+			transcript.BatchAppendMessages(proofTypeBytes, encodeElements(points))
+		}
+	}
 
-		//fmt.Printf("In VerifyShuffle, for index %d, value of vCapPoint (%v,%v)\n", i, vCapPointList[i].X, vCapPointList[i].Y)
+	coefficients := make([]group.Scalar, len(before))
+	for j := range coefficients {
+		coefficients[j] = g.Scalar(transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen()))
+	}
 
-		challVcapPointX, challVcapPointY := curve.ScalarMult(&vCapPointList[i].X, &vCapPointList[i].Y, challBytesList[i])
-		//fmt.Printf("In VerifyShuffle, for index %d, value of challVcapPoint (%v,%v)\n", i, challVcapPoint.X, challVcapPoint.Y)
+	return coefficients, nil
+}
 
-		challSq := new(big.Int).SetUint64(0)
-		challSq = challSq.Mul(chall, chall)
-		challSq = challSq.Mod(challSq, curveParams.N)
+// combineBallots combines the ciphertexts of each ballot into one, the sum of
+// the ciphertexts of each position j multiplied by coefficients[j]. The
+// combination of a re-encrypted ballot is a re-encryption of the combination
+// of the ballot.
+func combineBallots(g group.Group, columns [][]types.ElGamalCipherText, coefficients []group.Scalar) ([]types.ElGamalCipherText, error) {
+	combined := make([]types.ElGamalCipherText, len(columns[0]))
 
-		//fmt.Printf("In VerifyShuffle, challSq final is %v\n", challSq)
+	for i := range combined {
+		sumCt1, sumCt2 := g.Identity(), g.Identity()
 
-		//fmt.Printf("In VerifyShuffle, for index %d, value of tCapPoint (%v,%v)\n", i, tCapPointList[i].X, tCapPointList[i].Y)
-		challSqTCapPointX, challSqTCapPointY := curve.ScalarMult(&tCapPointList[i].X, &tCapPointList[i].Y, challSq.Bytes())
-		//fmt.Printf("In VerifyShuffle, for index %d, value of chall^2*tCapPoint (%v,%v)\n", i, challSqTCapPoint.X, challSqTCapPoint.Y)
+		for j, column := range columns {
+			points, err := decodePoints(g, column[i].Ct1, column[i].Ct2)
+			if err != nil {
+				return nil, err
+			}
+			sumCt1 = sumCt1.Add(points[0].Mul(coefficients[j]))
+			sumCt2 = sumCt2.Add(points[1].Mul(coefficients[j]))
+		}
 
-		rhsX, rhsY = curve.Add(rhsX, rhsY, challVcapPointX, challVcapPointY)
-		rhsX, rhsY = curve.Add(rhsX, rhsY, challSqTCapPointX, challSqTCapPointY)
+		combined[i] = types.ElGamalCipherText{
+			Ct1: sumCt1.Point(),
+			Ct2: sumCt2.Point(),
+		}
 	}
 
-	check06 := lhsX.Cmp(rhsX) == 0 && lhsY.Cmp(rhsY) == 0
-
-	//fmt.Printf("Check02: %v, Check03: %v, Check04: %v, Check05: %v, Check06: %v\n", checkO2, checkO3, check04, check05, check06)
-
-	return checkBytes && checkO2 && checkO3 && check04 && check05 && check06 //check04 && check05 //TODO: Missing checks for 5.22 (check04), 5.24 (check06)
+	return combined, nil
 }
 
 /********************************************** New additions *******************************************************/
 
 // Proves that one of the two statements satisfies the Chaum-Pedersen relation using the OR-Sigma proof composition
-func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, curve elliptic.Curve, secretBit bool) (*types.Proof, error) {
-
-	// Derive parameters of the elliptic curve
-	curveParams := curve.Params()
-
-	//fmt.Printf("Secret scalar is %v\n", new(big.Int).SetBytes(secret).Uint64())
-	//fmt.Printf("pPoint is (%v,%v)\n", pPoint.X, pPoint.Y)
-	//fmt.Printf("bPointOther is (%v,%v)\n", pPoint.X, pPoint.Y)
-	//fmt.Printf("pPointOther is (%v,%v)\n", pPoint.X, pPoint.Y)
+func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, g group.Group, secretBit bool) (*types.Proof, error) {
 
 	// Marshall proof instance to bytes
-	pPointCompressed := elliptic.MarshalCompressed(curve, &pPoint.X, &pPoint.Y)
-	bPointOtherCompressed := elliptic.MarshalCompressed(curve, &bPointOther.X, &bPointOther.Y)
-	pPointOtherCompressed := elliptic.MarshalCompressed(curve, &pPointOther.X, &pPointOther.Y)
+	instance, err := decodePoints(g, pPoint, bPointOther, pPointOther)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveDlogEqOr: %v", err)
+	}
+	pPointCompressed := instance[0].Bytes()
+	bPointOtherCompressed := instance[1].Bytes()
+	pPointOtherCompressed := instance[2].Bytes()
 
 	proofTypeBytes := []byte(DLOG_OR_EQ_LABEL)
 
@@ -1551,19 +1189,13 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 	transcript := NewTranscript(DLOG_OR_EQ_LABEL)
 
 	// Generate the triple for the fake case
-	fakepPointScalar := GenerateRandomBigInt(curveParams.N)
-	fakepPointOtherScalar := GenerateRandomBigInt(curveParams.N)
-	fakepPointX, fakepPointY := curve.ScalarBaseMult(fakepPointScalar.Bytes())
-	fakePOtherX, fakePOtherY := curve.ScalarBaseMult(fakepPointOtherScalar.Bytes())
-
-	fakepPoint := NewPoint(fakepPointX, fakepPointY)
-	fakepPointOther := NewPoint(fakePOtherX, fakePOtherY)
+	fakepPointScalar := GenerateRandomBigInt(g.Order())
+	fakepPointOtherScalar := GenerateRandomBigInt(g.Order())
+	fakepPoint := g.BaseMul(g.ScalarFromInt(&fakepPointScalar))
+	fakepPointOther := g.BaseMul(g.ScalarFromInt(&fakepPointOtherScalar))
 
-	//fmt.Printf("fakepPoint is (%v,%v)\n", pPoint.X, pPoint.Y)
-	//fmt.Printf("fakepPointOther is (%v,%v)\n", pPoint.X, pPoint.Y)
-
-	fakepPointCompressed := elliptic.MarshalCompressed(curve, &fakepPoint.X, &fakepPoint.Y)
-	fakepPointOtherCompressed := elliptic.MarshalCompressed(curve, &fakepPointOther.X, &fakepPointOther.Y)
+	fakepPointCompressed := fakepPoint.Bytes()
+	fakepPointOtherCompressed := fakepPointOther.Bytes()
 
 	// Append proof instance values to the transcript
 	if secretBit {
@@ -1591,7 +1223,7 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 	// Begin deriving commitment scalar
 
 	// Derive seed based on which the commitment scalar is derived
-	commitRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	commitRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlogEqOr: %v", err)
 	}
@@ -1602,35 +1234,19 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 	trPRGbuilder.RekeyWitnessBytes([]byte(proof.ProofType), commitRandSeed.Bytes())
 	trPrg, err := trPRGbuilder.Finalize(proofTypeBytes)
 	if err != nil {
-
+		return nil, xerrors.Errorf("Error in ProveDlogEqOr: %v", err)
 	}
 
-	commitScalarBytes := trPrg.GetRandomness(SCALAR_SIZE)
-	trueCommitScalar := new(big.Int).SetBytes(commitScalarBytes)
-	trueCommitScalar.Mod(trueCommitScalar, curveParams.N)
+	trueCommitScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
 
-	//fmt.Printf("trueCommitScalar scalar is %v\n", trueCommitScalar.Uint64())
+	// Compute cPoint = c*G where G is the base point of the group
+	cPointCompressed := g.BaseMul(trueCommitScalar).Bytes()
 
-	// Create placeholders for the two commitment points
-	cPoint := types.Point{}
-	cPointOther := types.Point{}
-
-	// Compute cPoint = c*G where G is the base point of the curve
-	cPointX, cPointY := curve.ScalarBaseMult(trueCommitScalar.Bytes())
-	cPoint.X, cPoint.Y = *cPointX, *cPointY
-	cPointCompressed := elliptic.MarshalCompressed(curve, &cPoint.X, &cPoint.Y)
-
-	//fmt.Printf("cPoint is (%v,%v)\n", cPoint.X, cPoint.Y)
-
-	// Compute cPoint = c*G' where G' is the base point of the curve (and G != G')
-	cPointOtherX, cPointOtherY := curve.ScalarMult(&bPointOther.X, &bPointOther.Y, trueCommitScalar.Bytes())
-	cPointOther.X, cPointOther.Y = *cPointOtherX, *cPointOtherY
-	cPointOtherCompressed := elliptic.MarshalCompressed(curve, &cPointOther.X, &cPointOther.Y)
-
-	//fmt.Printf("cPointOther is (%v,%v)\n", cPointOther.X, cPointOther.Y)
+	// Compute cPoint = c*G' where G' is the base point of the group (and G != G')
+	cPointOtherCompressed := instance[1].Mul(trueCommitScalar).Bytes()
 
 	// For the fake case: Derive random challenge for the simulation using the transcript
-	simRandSeed, err := cryptorand.Int(cryptorand.Reader, curveParams.N)
+	simRandSeed, err := g.RandomScalar()
 	if err != nil {
 		return nil, xerrors.Errorf("Error in ProveDlogEqOr: %v", err)
 	}
@@ -1643,14 +1259,13 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 	}
 
 	// For the fake case: Generate fake challenge bytes (As if they came from the verifier)
-	fakeChallBytes := trPRG.GetRandomness(SCALAR_SIZE)
-	//fmt.Printf("In ProveDlogEqOr, fakeChallBytes: %v\n", fakeChallBytes)
+	fakeChallBytes := trPRG.GetRandomness(g.ScalarLen())
 
 	// Use simulator for the proof ot the DLOG to create the fake (but accepting transcirpt)
 
 	// We will use the same bPoint and bPoint other as in the function arguments
 	//  (these will correspond to the base point, and public point when they are actually used, respectively)
-	fakeCPointCompressed, fakeCPointOtherCompressed, fakeChallBytes, fakeResult := SimulatorDlogEq(fakeChallBytes, trPRG, curve, fakepPoint, bPointOther, fakepPointOther)
+	fakeCPointCompressed, fakeCPointOtherCompressed, fakeChallBytes, fakeResult := SimulatorDlogEq(fakeChallBytes, trPRG, g, fakepPoint, instance[1], fakepPointOther)
 
 	if secretBit {
 		transcript.AppendMessage(proofTypeBytes, cPointCompressed)
@@ -1668,9 +1283,7 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 	}
 
 	// Generate verifier's challenge bytes from the current transcript state
-	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
-
-	//fmt.Printf("In ProveDlogEqOr, VerifierChallBytes: %v\n", verifierChallBytes)
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 
 	// Derive challenge bytes for the true case  by xoring the chall bytes received
 	// from the verifier with the fake challenge bytes
@@ -1679,35 +1292,17 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 		trueChallBytes[i] = val ^ fakeChallBytes[i]
 	}
 
-	//fmt.Printf("In ProveDlogEqOr, trueChallBytes: %v\n", trueChallBytes)
-
-	// Cast the secret x as a scalar
-	trueSecretScalar := new(big.Int).SetBytes(secret)
-
-	//fmt.Printf("In ProveDlogEqOr, trueSecretScalar (redundant, but helpful): %v\n", trueSecretScalar.Uint64())
-
-	// Cast the commitment c as a scalar
-	// trueCommitScalar := new(big.Int).SetBytes(commitScalarBytes)
-
-	// Cast chall as scalar (verifier's public coins)
-	trueChallScalar := new(big.Int).SetBytes(trueChallBytes)
-
-	//fmt.Printf("In ProveDlogEqOr, trueChallScalar is: %v\n", trueChallScalar.Uint64())
-
-	// Compute x*chall (mod N, where N is the order of base point)
-	trueBlindedScalar := new(big.Int).Mod(new(big.Int).Mul(trueSecretScalar, trueChallScalar), curveParams.N)
-
-	//fmt.Printf("In ProveDlogEqOr, trueBlindedScalar is: %v\n", trueChallScalar.Uint64())
+	// Cast the secret x and chall (verifier's public coins) as scalars
+	trueSecretScalar := g.Scalar(secret)
+	trueChallScalar := g.Scalar(trueChallBytes)
 
 	// Computes z=c-chall*x (mod N, where N is the order of the base point)
-	trueResult := new(big.Int).Mod(new(big.Int).Sub(trueCommitScalar, trueBlindedScalar), curveParams.N)
-
-	//fmt.Printf("In ProveDlogEqOr, trueResult is: %v\n", trueChallScalar.Uint64())
+	trueResult := trueCommitScalar.Sub(trueSecretScalar.Mul(trueChallScalar))
 
 	// Store the results into the proof structure
 	if secretBit { //if secret bit is true we put honest first
-		proof.Curve = curve
-		proof.Result = *trueResult
+		proof.Suite = g.Suite()
+		proof.Result = *trueResult.BigInt()
 
 		proof.BPointOther = bPointOtherCompressed
 		proof.CPoint = cPointCompressed
@@ -1727,9 +1322,9 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 		proof.ProverChallOther = fakeChallBytes
 
 	} else { //Otherwise, fakes go first
-		proof.Curve = curve
+		proof.Suite = g.Suite()
 		proof.Result = *fakeResult
-		proof.ResultOther = *trueResult
+		proof.ResultOther = *trueResult.BigInt()
 
 		proof.BPointOther = bPointOtherCompressed
 		proof.CPoint = fakeCPointCompressed
@@ -1751,46 +1346,26 @@ func ProveDlogEqOr(secret Value, pPoint, bPointOther, pPointOther types.Point, c
 	return &proof, nil
 }
 
-func SimulatorDlogEq(challBytes []byte, trPRG *TranscriptRng, curve elliptic.Curve, pPoint, bPointOther, pPointOther types.Point) ([]byte, []byte, []byte, *big.Int) {
+func SimulatorDlogEq(challBytes []byte, trPRG *TranscriptRng, g group.Group, pPoint, bPointOther, pPointOther group.Element) ([]byte, []byte, []byte, *big.Int) {
 
 	//Generate the 3rd message of the protocol first (this is the blinded scalar z)
-	result := GenerateRandomBigInt(curve.Params().N)
-
-	//fmt.Printf("In Simulator pPoint is:(%v, %v) \n", pPoint.X, pPoint.Y)
-	//fmt.Printf("In Simulator bPointOther is:(%v, %v) \n", bPointOther.X, bPointOther.Y)
-	//fmt.Printf("In Simulator pPointOther is:(%v, %v) \n", pPointOther.X, pPointOther.Y)
-	//fmt.Printf("In Simulator chall is:%v \n", new(big.Int).SetBytes(challBytes))
-
-	//fmt.Printf("In simulator, result (z) is %v\n", new(big.Int).Set(&result))
+	resultInt := GenerateRandomBigInt(g.Order())
+	result := g.ScalarFromInt(&resultInt)
+	challScalar := g.Scalar(challBytes)
 
-	// Generate the z*G point, where G is the generator
-	resultbPointX, resultbPointY := curve.ScalarBaseMult(result.Bytes())
+	// Generate the first message pair as a*G = z*G + chall*P and a*G' = z*G' + chall*P'
+	cPoint := g.BaseMul(result).Add(pPoint.Mul(challScalar))
+	cPointOther := bPointOther.Mul(result).Add(pPointOther.Mul(challScalar))
 
-	//fmt.Printf("In Simulator z*G is:(%v, %v) \n", resultbPointX, resultbPointY)
-
-	resultbPointOtherX, resultbPointOtherY := curve.ScalarMult(&bPointOther.X, &bPointOther.Y, result.Bytes())
-	//fmt.Printf("In Simulator, z*G' is:(%v, %v) \n", resultbPointOtherX, resultbPointOtherY)
-
-	challPpointX, challPpointY := curve.ScalarMult(&pPoint.X, &pPoint.Y, challBytes)
-	challPpointOtherX, challPpointOtherY := curve.ScalarMult(&pPointOther.X, &pPointOther.Y, challBytes)
-
-	//fmt.Printf("In Simulator, chall*P is:(%v, %v) \n", challPpointX, challPpointY)
-	//fmt.Printf("In Simulator, chall*P' is:(%v, %v) \n", challPpointOtherX, challPpointOtherY)
-
-	cPointX, cPointY := curve.Add(resultbPointX, resultbPointY, challPpointX, challPpointY)
-	//fmt.Printf("In Simulator, a*G is:(%v, %v) \n", cPointX, cPointY)
-
-	cPointOtherX, cPointOtherY := curve.Add(resultbPointOtherX, resultbPointOtherY, challPpointOtherX, challPpointOtherY)
-	//fmt.Printf("In Simulator, a*G' is:(%v, %v) \n", cPointOtherX, cPointOtherY)
-
-	cPointCompressed := elliptic.MarshalCompressed(curve, cPointX, cPointY)
-	cPointOtherCompressed := elliptic.MarshalCompressed(curve, cPointOtherX, cPointOtherY)
-
-	return cPointCompressed, cPointOtherCompressed, challBytes, &result
+	return cPoint.Bytes(), cPointOther.Bytes(), challBytes, result.BigInt()
 
 }
 
 func VerifyDlogEqOr(proof *types.Proof) bool {
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return false
+	}
 
 	proofTypeBytes := []byte(DLOG_OR_EQ_LABEL)
 
@@ -1813,8 +1388,11 @@ func VerifyDlogEqOr(proof *types.Proof) bool {
 	transcript.AppendMessage(proofTypeBytes, proof.OtherCPointOther)
 
 	// Derive challenge bytes based on the state of the current transcript
-	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, SCALAR_SIZE)
-	//fmt.Printf("In VerifyDlogEqOr, DERIVED verifierChall: %v\n", verifierChallBytes)
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
+
+	if !checkChallBytesLen(g, proof.VerifierChall, proof.ProverChall, proof.ProverChallOther) {
+		return false
+	}
 
 	// Begin checking if the verifier challenge is correctly derived
 	checkBytesXor := true
@@ -1828,7 +1406,7 @@ func VerifyDlogEqOr(proof *types.Proof) bool {
 
 	proof01 := types.Proof{
 		ProofType:     DLOG_EQ_LABEL,
-		Curve:         proof.Curve,
+		Suite:         proof.Suite,
 		VerifierChall: proof.ProverChall,
 		BPointOther:   proof.BPointOther,
 		PPoint:        proof.PPoint,
@@ -1840,7 +1418,7 @@ func VerifyDlogEqOr(proof *types.Proof) bool {
 
 	proof02 := types.Proof{
 		ProofType:     DLOG_EQ_LABEL,
-		Curve:         proof.Curve,
+		Suite:         proof.Suite,
 		VerifierChall: proof.ProverChallOther,
 		BPointOther:   proof.OtherBPointOther,
 		PPoint:        proof.OtherPPoint,
@@ -1852,78 +1430,429 @@ func VerifyDlogEqOr(proof *types.Proof) bool {
 
 	res01 := VerifyDlogEqRelation(&proof01)
 	res02 := VerifyDlogEqRelation(&proof02)
-	//fmt.Printf("CheckBytesXor %v, Res01 %v, Res02 %v,", checkBytesXor, res01, res02)
 
 	return checkBytesXor && res01 && res02
 
 }
 
 func VerifyDlogEqRelation(proof *types.Proof) bool {
-	// Derive P from the proof
-	// pPoint := types.Point{}
-	pPointX, pPointY := elliptic.UnmarshalCompressed(proof.Curve, proof.PPoint)
-	//fmt.Printf("In VerifyDlogEqRelation, pPoint is: (%v,%v)\n", pPoint.X, pPoint.Y)
+	g, err := group.ForSuite(proof.Suite)
+	if err != nil {
+		return false
+	}
+
+	// Derive P, P', G' and the first message pair (commitment messages) from the proof
+	points, err := decodeElements(g, proof.PPoint, proof.PPointOther, proof.BPointOther, proof.CPoint, proof.CPointOther)
+	if err != nil {
+		return false
+	}
+	pPoint, pPointOther, bPointOther, cPoint, cPointOther := points[0], points[1], points[2], points[3], points[4]
+
+	challScalar := g.Scalar(proof.VerifierChall)
+	resultScalar := g.ScalarFromInt(&proof.Result)
+
+	// Compute chall*P + z*G (where z=c-chall*x)
+	resultFirst := pPoint.Mul(challScalar).Add(g.BaseMul(resultScalar))
+
+	// Compute chall*P' + z*G', where G' is the other base point
+	resultSecond := pPointOther.Mul(challScalar).Add(bPointOther.Mul(resultScalar))
+
+	return cPoint.Equal(resultFirst) && cPointOther.Equal(resultSecond)
+
+}
+
+/********************************************** End new additions *******************************************************************************/
+
+/********************************************** Ballot proofs *******************************************************************************/
+
+// Proves that the ciphertext ct = (r*G, r*P + b*G) encrypts a bit b, without revealing which one.
+// This is the OR-composition of two Chaum-Pedersen statements which are always ordered as
+// (ct encrypts 0, ct encrypts 1), so that the position of the honest statement does not leak the bit:
+// 1. log_G(ct_1) = log_P(ct_2)
+// 2. log_G(ct_1) = log_P(ct_2 - G)
+func ProveEncryptedBit(rScalar *big.Int, pk types.Point, ct types.ElGamalCipherText, bit bool, g group.Group) (*types.Proof, error) {
+	proofTypeBytes := []byte(DLOG_OR_EQ_LABEL)
+	proof := NewProof(DLOG_OR_EQ_LABEL)
+
+	instance, err := decodePoints(g, pk, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedBit: %v", err)
+	}
+	pkPoint, ct1, ct2 := instance[0], instance[1], instance[2]
 
-	// Compute chall*P
-	// gAddend01 := types.Point{}
-	gAddend01X, gAddend01Y := proof.Curve.ScalarMult(pPointX, pPointY, proof.VerifierChall)
-	//fmt.Printf("In VerifyDlogEqRelation, chall*P is: (%v,%v)\n", gAddend01.X, gAddend01.Y)
+	// Derive both statements from the ciphertext
+	statements := [2][2]group.Element{
+		{ct1, ct2},
+		{ct1, ct2.Sub(g.Generator())},
+	}
 
-	// Compute z*G (where z=c-chall*x)
-	// gAddend02 := types.Point{}
-	gAddend02X, gAddend02Y := proof.Curve.ScalarBaseMult(proof.Result.Bytes())
+	trueIndex := 0
+	if bit {
+		trueIndex = 1
+	}
+	fakeIndex := 1 - trueIndex
 
-	//fmt.Printf("In VerifyDlogEqRelation, result is: %v\n", proof.Result)
-	//fmt.Printf("In VerifyDlogEqRelation, z*G is: (%v,%v)\n", gAddend02.X, gAddend02.Y)
+	bPointOtherCompressed := pkPoint.Bytes()
+	statementsCompressed := [2][2][]byte{}
+	for i, statement := range statements {
+		statementsCompressed[i][0] = statement[0].Bytes()
+		statementsCompressed[i][1] = statement[1].Bytes()
+	}
+
+	// Initialize protocol's transcript and append both statements in their fixed order
+	transcript := NewTranscript(DLOG_OR_EQ_LABEL)
+	for i := range statements {
+		transcript.AppendMessage(proofTypeBytes, bPointOtherCompressed)
+		transcript.AppendMessage(proofTypeBytes, statementsCompressed[i][0])
+		transcript.AppendMessage(proofTypeBytes, statementsCompressed[i][1])
+	}
+
+	// For the true case: Derive the commitment scalar
+	commitRandSeed, err := g.RandomScalar()
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedBit: %v", err)
+	}
 
-	// Compute chall*P + z*G
-	// resultFirst := types.Point{}
-	resultFirstX, resultFirstY := proof.Curve.Add(gAddend01X, gAddend01Y, gAddend02X, gAddend02Y)
-	//fmt.Printf("In VerifyDlogEqRelation, chall*P + z*G is: (%v,%v)\n", resultFirst.X, resultFirst.Y)
+	trPRGbuilder := transcript.BuildRng()
+	trPRGbuilder.RekeyWitnessBytes(proofTypeBytes, rScalar.Bytes())
+	trPRGbuilder.RekeyWitnessBytes(proofTypeBytes, commitRandSeed.Bytes())
+	trPrg, err := trPRGbuilder.Finalize(proofTypeBytes)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedBit: %v", err)
+	}
 
-	// Begin deriving components for the check
+	trueCommitScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
 
-	// gPrimeAddend01 := types.Point{}
-	// pPointOther := types.Point{}
-	pPointOtherX, pPointOtherY := elliptic.UnmarshalCompressed(proof.Curve, proof.PPointOther)
-	//fmt.Printf("In VerifyDlogEqRelation, pPointOther is: (%v,%v)\n", pPointOther.X, pPointOther.Y)
+	// Compute c*G and c*P
+	cPoints := [2][2][]byte{}
+	cPoints[trueIndex][0] = g.BaseMul(trueCommitScalar).Bytes()
+	cPoints[trueIndex][1] = pkPoint.Mul(trueCommitScalar).Bytes()
 
-	// Compute chall*P'
-	gPrimeAddend01X, gPrimeAddend01Y := proof.Curve.ScalarMult(pPointOtherX, pPointOtherY, proof.VerifierChall)
-	//fmt.Printf("In VerifyDlogEqRelation, chall*P' is: (%v,%v)\n", gPrimeAddend01.X, gPrimeAddend01.Y)
+	// For the fake case: Derive random challenge and use the simulator to create an accepting transcript
+	simRandSeed, err := g.RandomScalar()
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedBit: %v", err)
+	}
 
-	// bPointOther := types.Point{}
+	trPRGbuilder = transcript.BuildRng()
+	trPRGbuilder.RekeyWitnessBytes(proofTypeBytes, simRandSeed.Bytes())
+	trPRG, err := trPRGbuilder.Finalize(proofTypeBytes)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedBit: %v", err)
+	}
 
-	// gPrimeAddend02 := types.Point{}
-	bPointOtherX, bPointOtherY := elliptic.UnmarshalCompressed(proof.Curve, proof.BPointOther)
-	//fmt.Printf("In VerifyDlogEqRelation, bPointOther is: (%v,%v)\n", bPointOther.X, bPointOther.Y)
+	fakeChallBytes := trPRG.GetRandomness(g.ScalarLen())
+	fakeStatement := statements[fakeIndex]
+	fakeCPoint, fakeCPointOther, fakeChallBytes, fakeResult := SimulatorDlogEq(fakeChallBytes, trPRG, g, fakeStatement[0], pkPoint, fakeStatement[1])
+	cPoints[fakeIndex][0] = fakeCPoint
+	cPoints[fakeIndex][1] = fakeCPointOther
 
-	// Compute z*G', where G' is the other base point
-	gPrimeAddend02X, gPrimeAddend02Y := proof.Curve.ScalarMult(bPointOtherX, bPointOtherY, proof.Result.Bytes())
-	//fmt.Printf("In VerifyDlogEqRelation, z*G' is: (%v,%v)\n", gPrimeAddend02.X, gPrimeAddend02.Y)
+	// Append commitment points in the fixed order
+	for i := range cPoints {
+		transcript.AppendMessage(proofTypeBytes, cPoints[i][0])
+		transcript.AppendMessage(proofTypeBytes, cPoints[i][1])
+	}
 
-	// Compute chall*P' + z*G'
-	// resultSecond := types.Point{}
-	resultSecondX, resultSecondY := proof.Curve.Add(gPrimeAddend01X, gPrimeAddend01Y, gPrimeAddend02X, gPrimeAddend02Y)
-	//fmt.Printf("In VerifyDlogEqRelation, chall*P' + z*G' is: (%v,%v)\n", resultSecond.X, resultSecond.Y)
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
 
-	//Unmarshall the first message pair (first commitment message)
+	// The true challenge is the xor of the verifier's challenge and the fake challenge
+	trueChallBytes := make([]byte, len(verifierChallBytes))
+	for i, val := range verifierChallBytes {
+		trueChallBytes[i] = val ^ fakeChallBytes[i]
+	}
 
-	cPointX, cPointY := elliptic.UnmarshalCompressed(proof.Curve, proof.CPoint)
-	//fmt.Printf("In VerifyDlogEqRelation, cPoint is: (%v,%v)\n", cPoint.X, cPoint.Y)
+	// Computes z=c-chall*r (mod N, where N is the order of the base point)
+	trueResult := trueCommitScalar.Sub(g.ScalarFromInt(rScalar).Mul(g.Scalar(trueChallBytes)))
 
-	//Unmarshall the first message pair (second commitment message)
-	// cPointOther := types.Point{}
-	cPointOtherX, cPointOtherY := elliptic.UnmarshalCompressed(proof.Curve, proof.CPointOther)
-	//fmt.Printf("In VerifyDlogEqRelation, cPointOther is: (%v,%v)\n", cPointOther.X, cPointOther.Y)
+	challs := [2][]byte{}
+	results := [2]big.Int{}
+	challs[trueIndex], results[trueIndex] = trueChallBytes, *trueResult.BigInt()
+	challs[fakeIndex], results[fakeIndex] = fakeChallBytes, *fakeResult
 
-	//fmt.Printf("In VerifyDlogEqRelation %v, %v, %v, %v\n", cPoint.X.Cmp(resultFirst.X) == 0, cPoint.Y.Cmp(resultFirst.Y) == 0, cPointOther.X.Cmp(resultSecond.X) == 0, cPointOther.Y.Cmp(resultSecond.Y) == 0)
+	proof.Suite = g.Suite()
+	proof.VerifierChall = verifierChallBytes
 
-	return cPointX.Cmp(resultFirstX) == 0 && cPointY.Cmp(resultFirstY) == 0 && cPointOtherX.Cmp(resultSecondX) == 0 && cPointOtherY.Cmp(resultSecondY) == 0
+	proof.BPointOther = bPointOtherCompressed
+	proof.PPoint = statementsCompressed[0][0]
+	proof.PPointOther = statementsCompressed[0][1]
+	proof.CPoint = cPoints[0][0]
+	proof.CPointOther = cPoints[0][1]
+	proof.ProverChall = challs[0]
+	proof.Result = results[0]
+
+	proof.OtherBPointOther = bPointOtherCompressed
+	proof.OtherPPoint = statementsCompressed[1][0]
+	proof.OtherPPointOther = statementsCompressed[1][1]
+	proof.OtherCPoint = cPoints[1][0]
+	proof.OtherCPointOther = cPoints[1][1]
+	proof.ProverChallOther = challs[1]
+	proof.ResultOther = results[1]
 
+	return &proof, nil
 }
 
-/********************************************** End new additions *******************************************************************************/
+// Verifies that the proof is a valid OR-proof and that its two statements are the ones derived
+// from the ciphertext ct, that is, ct encrypts either 0 or 1 under pk
+func VerifyEncryptedBit(proof *types.Proof, g group.Group, pk types.Point, ct types.ElGamalCipherText) bool {
+	if !checkChallBytesLen(g, proof.VerifierChall, proof.ProverChall, proof.ProverChallOther) {
+		return false
+	}
+
+	instance, err := decodePoints(g, pk, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return false
+	}
+
+	pkCompressed := instance[0].Bytes()
+	ct1Compressed := instance[1].Bytes()
+	ct2Compressed := instance[2].Bytes()
+	ctMinusGCompressed := instance[2].Sub(g.Generator()).Bytes()
+
+	checkInstance := checkChallBytes(pkCompressed, proof.BPointOther) &&
+		checkChallBytes(pkCompressed, proof.OtherBPointOther) &&
+		checkChallBytes(ct1Compressed, proof.PPoint) &&
+		checkChallBytes(ct2Compressed, proof.PPointOther) &&
+		checkChallBytes(ct1Compressed, proof.OtherPPoint) &&
+		checkChallBytes(ctMinusGCompressed, proof.OtherPPointOther)
+
+	if !checkInstance {
+		return false
+	}
+
+	withSuite := *proof
+	withSuite.Suite = g.Suite()
+
+	return VerifyDlogEqOr(&withSuite)
+}
+
+// Proves that the ciphertext ct = (r*G, r*P + G) encrypts exactly 1, that is,
+// log_G(ct_1) = log_P(ct_2 - G). It is used on the homomorphic sum of a one-hot ballot.
+func ProveEncryptedOne(rScalar *big.Int, pk types.Point, ct types.ElGamalCipherText, g group.Group) (*types.Proof, error) {
+	ct2, err := g.ElementFromPoint(ct.Ct2)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedOne: %v", err)
+	}
+
+	return ProveDlogEq(rScalar.Bytes(), ct.Ct1, pk, ct2.Sub(g.Generator()).Point(), g)
+}
+
+// Verifies that the proof is a valid Chaum-Pedersen proof for the statement that ct encrypts 1 under pk
+func VerifyEncryptedOne(proof *types.Proof, g group.Group, pk types.Point, ct types.ElGamalCipherText) bool {
+	instance, err := decodePoints(g, pk, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return false
+	}
+
+	checkInstance := checkChallBytes(instance[0].Bytes(), proof.BPointOther) &&
+		checkChallBytes(instance[1].Bytes(), proof.PPoint) &&
+		checkChallBytes(instance[2].Sub(g.Generator()).Bytes(), proof.PPointOther)
+
+	if !checkInstance {
+		return false
+	}
+
+	withSuite := *proof
+	withSuite.Suite = g.Suite()
+
+	isValid, err := VerifyDlogEq(&withSuite)
+
+	return err == nil && isValid
+}
+
+// Proves that the ciphertext ct = (r*G, r*P + m*G) encrypts an integer m of the range [min, max],
+// without revealing which one. This is the OR-composition of one Chaum-Pedersen statement per
+// integer i of the range, in increasing order, which is honest for i = m only:
+// log_G(ct_1) = log_P(ct_2 - i*G)
+func ProveEncryptedRange(rScalar *big.Int, pk types.Point, ct types.ElGamalCipherText, m, min, max int,
+	g group.Group) (*types.RangeProof, error) {
+
+	if min > max || m < min || m > max {
+		return nil, xerrors.Errorf("Error in ProveEncryptedRange: %d is not in [%d, %d]", m, min, max)
+	}
+
+	instance, err := decodePoints(g, pk, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedRange: %v", err)
+	}
+	pkPoint, ct1, ct2 := instance[0], instance[1], instance[2]
+
+	proofTypeBytes := []byte(RANGE_LABEL)
+	trueIndex := m - min
+
+	bPointOtherCompressed := pkPoint.Bytes()
+	pPointCompressed := ct1.Bytes()
+
+	// Derive the statements from the ciphertext and append them to the transcript in their fixed order
+	statements := make([]group.Element, max-min+1)
+	statementsCompressed := make([][]byte, len(statements))
+
+	transcript := NewTranscript(RANGE_LABEL)
+	for i := range statements {
+		statements[i] = subtractMultipleOfBasePoint(g, ct2, min+i)
+		statementsCompressed[i] = statements[i].Bytes()
+
+		transcript.AppendMessage(proofTypeBytes, bPointOtherCompressed)
+		transcript.AppendMessage(proofTypeBytes, pPointCompressed)
+		transcript.AppendMessage(proofTypeBytes, statementsCompressed[i])
+	}
+
+	// For the true case: Derive the commitment scalar
+	commitRandSeed, err := g.RandomScalar()
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedRange: %v", err)
+	}
+
+	trPRGbuilder := transcript.BuildRng()
+	trPRGbuilder.RekeyWitnessBytes(proofTypeBytes, rScalar.Bytes())
+	trPRGbuilder.RekeyWitnessBytes(proofTypeBytes, commitRandSeed.Bytes())
+	trPrg, err := trPRGbuilder.Finalize(proofTypeBytes)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedRange: %v", err)
+	}
+
+	trueCommitScalar := g.Scalar(trPrg.GetRandomness(g.ScalarLen()))
+
+	branches := make([]types.Proof, len(statements))
+
+	// Compute c*G and c*P
+	branches[trueIndex].CPoint = g.BaseMul(trueCommitScalar).Bytes()
+	branches[trueIndex].CPointOther = pkPoint.Mul(trueCommitScalar).Bytes()
+
+	// For the fake cases: Derive random challenges and use the simulator to create accepting transcripts
+	simRandSeed, err := g.RandomScalar()
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedRange: %v", err)
+	}
+
+	trPRGbuilder = transcript.BuildRng()
+	trPRGbuilder.RekeyWitnessBytes(proofTypeBytes, simRandSeed.Bytes())
+	trPRG, err := trPRGbuilder.Finalize(proofTypeBytes)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ProveEncryptedRange: %v", err)
+	}
+
+	for i := range branches {
+		if i == trueIndex {
+			continue
+		}
+
+		fakeChallBytes := trPRG.GetRandomness(g.ScalarLen())
+		fakeCPoint, fakeCPointOther, fakeChallBytes, fakeResult := SimulatorDlogEq(fakeChallBytes, trPRG, g, ct1, pkPoint, statements[i])
+		branches[i].CPoint = fakeCPoint
+		branches[i].CPointOther = fakeCPointOther
+		branches[i].VerifierChall = fakeChallBytes
+		branches[i].Result = *fakeResult
+	}
+
+	// Append commitment points in the fixed order
+	for i := range branches {
+		transcript.AppendMessage(proofTypeBytes, branches[i].CPoint)
+		transcript.AppendMessage(proofTypeBytes, branches[i].CPointOther)
+	}
+
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
+
+	// The true challenge is the xor of the verifier's challenge and all the fake challenges
+	trueChallBytes := make([]byte, len(verifierChallBytes))
+	copy(trueChallBytes, verifierChallBytes)
+	for i := range branches {
+		if i == trueIndex {
+			continue
+		}
+		for j, val := range branches[i].VerifierChall {
+			trueChallBytes[j] ^= val
+		}
+	}
+
+	// Computes z=c-chall*r (mod N, where N is the order of the base point)
+	trueResult := trueCommitScalar.Sub(g.ScalarFromInt(rScalar).Mul(g.Scalar(trueChallBytes)))
+
+	branches[trueIndex].VerifierChall = trueChallBytes
+	branches[trueIndex].Result = *trueResult.BigInt()
+
+	for i := range branches {
+		branches[i].ProofType = DLOG_EQ_LABEL
+		branches[i].BPointOther = bPointOtherCompressed
+		branches[i].PPoint = pPointCompressed
+		branches[i].PPointOther = statementsCompressed[i]
+	}
+
+	return &types.RangeProof{
+		ProofType:     RANGE_LABEL,
+		Suite:         g.Suite(),
+		VerifierChall: verifierChallBytes,
+		Branches:      branches,
+	}, nil
+}
+
+// Verifies that the proof is a valid OR-proof and that its statements are the ones derived
+// from the ciphertext ct, that is, ct encrypts an integer of the range [min, max] under pk
+func VerifyEncryptedRange(proof *types.RangeProof, g group.Group, pk types.Point, ct types.ElGamalCipherText,
+	min, max int) bool {
+
+	if min > max || len(proof.Branches) != max-min+1 || len(proof.VerifierChall) != g.ScalarLen() {
+		return false
+	}
+
+	instance, err := decodePoints(g, pk, ct.Ct1, ct.Ct2)
+	if err != nil {
+		return false
+	}
+
+	proofTypeBytes := []byte(RANGE_LABEL)
+	pkCompressed := instance[0].Bytes()
+	ct1Compressed := instance[1].Bytes()
+
+	transcript := NewTranscript(RANGE_LABEL)
+	challXor := make([]byte, g.ScalarLen())
+
+	for i, branch := range proof.Branches {
+		statementCompressed := subtractMultipleOfBasePoint(g, instance[2], min+i).Bytes()
+
+		checkInstance := len(branch.VerifierChall) == g.ScalarLen() &&
+			checkChallBytes(pkCompressed, branch.BPointOther) &&
+			checkChallBytes(ct1Compressed, branch.PPoint) &&
+			checkChallBytes(statementCompressed, branch.PPointOther)
+
+		if !checkInstance {
+			return false
+		}
+
+		transcript.AppendMessage(proofTypeBytes, pkCompressed)
+		transcript.AppendMessage(proofTypeBytes, ct1Compressed)
+		transcript.AppendMessage(proofTypeBytes, statementCompressed)
+
+		for j, val := range branch.VerifierChall {
+			challXor[j] ^= val
+		}
+	}
+
+	for _, branch := range proof.Branches {
+		transcript.AppendMessage(proofTypeBytes, branch.CPoint)
+		transcript.AppendMessage(proofTypeBytes, branch.CPointOther)
+	}
+
+	// The challenges of the branches must xor to the challenge derived from the transcript
+	verifierChallBytes := transcript.GetChallengeBytes(proofTypeBytes, g.ScalarLen())
+	if !checkChallBytes(verifierChallBytes, proof.VerifierChall) || !checkChallBytes(challXor, proof.VerifierChall) {
+		return false
+	}
+
+	for _, branch := range proof.Branches {
+		withSuite := branch
+		withSuite.Suite = g.Suite()
+
+		// the commitment points are decoded, and rejected if they are not
+		// in the group, by VerifyDlogEqRelation
+		if !VerifyDlogEqRelation(&withSuite) {
+			return false
+		}
+	}
+
+	return true
+}
+
+/********************************************** End ballot proofs *******************************************************************************/
 
 func checkChallBytes(derived, actual []byte) bool {
 	if len(derived) != len(actual) {
@@ -1950,58 +1879,151 @@ func checkChallBytesLists(derived, actual [][]byte) bool {
 	return check
 }
 
+// checkChallBytesLen tells whether all the challenges have the length of the
+// challenges derived in the group
+func checkChallBytesLen(g group.Group, challs ...[]byte) bool {
+	for _, chall := range challs {
+		if len(chall) != g.ScalarLen() {
+			return false
+		}
+	}
+	return true
+}
+
 //Helper functions for this class
 /*********************************************************************/
 
-func ElGamalEncryption(curve elliptic.Curve, pPoint *types.Point, rScalar *big.Int, msg *big.Int) *types.ElGamalCipherText {
-	// msgPoint := types.Point{}
-	// rpPoint := types.Point{}
-
-	msgPointX, msgPointY := curve.ScalarBaseMult(msg.Bytes())
-	rBytes := rScalar.Bytes()
-	ct1X, ct1Y := curve.ScalarBaseMult(rBytes)
-	ct1 := NewPoint(ct1X, ct1Y)
+// decodePoints converts the points to elements of the group, it fails if one of
+// them is not in the group
+func decodePoints(g group.Group, points ...types.Point) ([]group.Element, error) {
+	elements := make([]group.Element, len(points))
+	for i, p := range points {
+		e, err := g.ElementFromPoint(p)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = e
+	}
+	return elements, nil
+}
 
-	rpPointX, rpPointY := curve.ScalarMult(&pPoint.X, &pPoint.Y, rBytes)
+// decodeElements decodes compressed elements of the group, it fails if one of
+// them is not in the group
+func decodeElements(g group.Group, encodings ...[]byte) ([]group.Element, error) {
+	elements := make([]group.Element, len(encodings))
+	for i, b := range encodings {
+		e, err := g.ElementFromBytes(b)
+		if err != nil {
+			return nil, err
+		}
+		elements[i] = e
+	}
+	return elements, nil
+}
 
-	// //fmt.Printf("ElGamalEncryption: rpPoint is: (%v,%v)\n", rpPoint.X, rpPoint.Y)
+// encodeElements returns the compressed encodings of the elements
+func encodeElements(elements []group.Element) [][]byte {
+	encodings := make([][]byte, len(elements))
+	for i, e := range elements {
+		encodings[i] = e.Bytes()
+	}
+	return encodings
+}
 
-	ct2X, ct2Y := curve.Add(rpPointX, rpPointY, msgPointX, msgPointY)
-	ct2 := NewPoint(ct2X, ct2Y)
-	return &types.ElGamalCipherText{
-		Ct1: ct1,
-		Ct2: ct2,
+// compressPoint returns the compressed encoding of the point, it fails if the
+// point is not in the group
+func compressPoint(g group.Group, p types.Point) ([]byte, error) {
+	e, err := g.ElementFromPoint(p)
+	if err != nil {
+		return nil, err
 	}
+	return e.Bytes(), nil
 }
 
-func ElGamalReEncryption(curve elliptic.Curve, pPoint *types.Point, rScalar *big.Int, cipherText *types.ElGamalCipherText) *types.ElGamalCipherText {
-	rBytes := rScalar.Bytes()
-	ct1X, ct1Y := curve.ScalarBaseMult(rBytes)
+func ElGamalEncryption(g group.Group, pPoint *types.Point, rScalar *big.Int, msg *big.Int) (*types.ElGamalCipherText, error) {
+	pk, err := g.ElementFromPoint(*pPoint)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ElGamalEncryption: %v", err)
+	}
 
-	ct1X, ct1Y = curve.Add(ct1X, ct1Y, &cipherText.Ct1.X, &cipherText.Ct1.Y)
+	r := g.ScalarFromInt(rScalar)
+	msgPoint := g.BaseMul(g.ScalarFromInt(msg))
 
-	ct1 := NewPoint(ct1X, ct1Y)
+	// ct = (r*G, r*P + m*G)
+	return &types.ElGamalCipherText{
+		Ct1: g.BaseMul(r).Point(),
+		Ct2: pk.Mul(r).Add(msgPoint).Point(),
+	}, nil
+}
 
-	rpPointX, rpPointY := curve.ScalarMult(&pPoint.X, &pPoint.Y, rBytes)
+func ElGamalReEncryption(g group.Group, pPoint *types.Point, rScalar *big.Int, cipherText *types.ElGamalCipherText) (*types.ElGamalCipherText, error) {
+	points, err := decodePoints(g, *pPoint, cipherText.Ct1, cipherText.Ct2)
+	if err != nil {
+		return nil, xerrors.Errorf("Error in ElGamalReEncryption: %v", err)
+	}
+	pk, ct1, ct2 := points[0], points[1], points[2]
 
-	// //fmt.Printf("ElGamalReEncryption: rpPoint is: (%v,%v)\n", rpPoint.X, rpPoint.Y)
+	r := g.ScalarFromInt(rScalar)
 
-	ct2X, ct2Y := curve.Add(rpPointX, rpPointY, &cipherText.Ct2.X, &cipherText.Ct2.Y)
-	ct2 := NewPoint(ct2X, ct2Y)
+	// ct' = (ct_1 + r*G, ct_2 + r*P)
 	return &types.ElGamalCipherText{
-		Ct1: ct1,
-		Ct2: ct2,
+		Ct1: g.BaseMul(r).Add(ct1).Point(),
+		Ct2: pk.Mul(r).Add(ct2).Point(),
+	}, nil
+}
+
+// Re-encrypts every ciphertext of the ballot, rScalars holds one scalar per ciphertext
+func ElGamalVoteReEncryption(g group.Group, pPoint *types.Point, rScalars []big.Int, vote types.VoteMessage) (types.VoteMessage, error) {
+	encryptedVotes := make([]types.ElGamalCipherText, len(vote.EncryptedVotes))
+	for i := range vote.EncryptedVotes {
+		ct, err := ElGamalReEncryption(g, pPoint, &rScalars[i], &vote.EncryptedVotes[i])
+		if err != nil {
+			return types.VoteMessage{}, err
+		}
+		encryptedVotes[i] = *ct
 	}
+
+	return types.VoteMessage{
+		ElectionID:        vote.ElectionID,
+		EncryptedVotes:    encryptedVotes,
+		CorrectVoteProofs: vote.CorrectVoteProofs,
+		SumProof:          vote.SumProof,
+	}, nil
 }
 
-func ElGamalVoteReEncryption(curve elliptic.Curve, pPoint *types.Point, rScalar *big.Int, vote types.VoteMessage) types.VoteMessage {
+// Homomorphically adds the ciphertexts, the result encrypts the sum of the plaintexts
+func ElGamalAddCipherTexts(g group.Group, ctList []types.ElGamalCipherText) (types.ElGamalCipherText, error) {
+	sumCt1, sumCt2 := g.Identity(), g.Identity()
 
-	ct := ElGamalReEncryption(curve, pPoint, rScalar, &vote.EncryptedVote)
+	for _, ct := range ctList {
+		points, err := decodePoints(g, ct.Ct1, ct.Ct2)
+		if err != nil {
+			return types.ElGamalCipherText{}, xerrors.Errorf("Error in ElGamalAddCipherTexts: %v", err)
+		}
+		sumCt1 = sumCt1.Add(points[0])
+		sumCt2 = sumCt2.Add(points[1])
+	}
 
-	return types.VoteMessage{
-		ElectionID:       vote.ElectionID,
-		EncryptedVote:    *ct,
-		CorrectVoteProof: vote.CorrectVoteProof,
-		CorectEncProof:   vote.CorectEncProof,
+	return types.ElGamalCipherText{
+		Ct1: sumCt1.Point(),
+		Ct2: sumCt2.Point(),
+	}, nil
+}
+
+// Homomorphically subtracts other from ct, the result encrypts the difference of the plaintexts
+func ElGamalSubtractCipherTexts(g group.Group, ct, other types.ElGamalCipherText) (types.ElGamalCipherText, error) {
+	points, err := decodePoints(g, ct.Ct1, ct.Ct2, other.Ct1, other.Ct2)
+	if err != nil {
+		return types.ElGamalCipherText{}, xerrors.Errorf("Error in ElGamalSubtractCipherTexts: %v", err)
 	}
+
+	return types.ElGamalCipherText{
+		Ct1: points[0].Sub(points[2]).Point(),
+		Ct2: points[1].Sub(points[3]).Point(),
+	}, nil
+}
+
+// Computes P - m*G, where G is the base point of the group
+func subtractMultipleOfBasePoint(g group.Group, pPoint group.Element, m int) group.Element {
+	return pPoint.Sub(g.BaseMul(g.ScalarFromInt(big.NewInt(int64(m)))))
 }
@@ -6,6 +6,7 @@ import (
 	"go.dedis.ch/cs438/registry"
 	"go.dedis.ch/cs438/storage"
 	"go.dedis.ch/cs438/transport"
+	"go.dedis.ch/cs438/types"
 )
 
 // Peer defines the interface of a peer in the Peerster system. It embeds all
@@ -61,6 +62,19 @@ type Configuration struct {
 	// Default: {2s 2 5}
 	BackoffDataRequest Backoff
 
+	// Backoff parameters used to resend a ballot until the mixnet server
+	// acknowledges it with a receipt. The ballot is resent until the election
+	// closes, Retry only bounds the growth of the waiting time.
+	// Default: {1s 2 3}
+	BackoffVote Backoff
+
+	// DKGTimeout is the deadline of each phase of the key generation of an
+	// election. A mixnet server which didn't send its shares by then is
+	// disqualified, and the election goes on without it if enough mixnet
+	// servers qualify.
+	// Default: 10s
+	DKGTimeout time.Duration
+
 	Storage storage.Storage
 
 	// TotalPeers is the total number of peers in Peerster. If it is <= 1 then
@@ -82,8 +96,17 @@ type Configuration struct {
 	// Default: 5s.
 	PaxosProposerRetry time.Duration
 
-	// PedersenSuite is the set of parameters used in Pedersen DKG protocol.
-	//PedersenSuite types.PedersenSuite
+	// PedersenSuite is the group in which the elections announced by the peer
+	// run the Pedersen DKG, the encryption of the ballots and the proofs.
+	// Default: P-256
+	PedersenSuite types.PedersenSuite
+
+	// AllowUnsigned accepts the packets and rumors which are not signed by
+	// their creator, from peers which don't sign such as the tests of the
+	// early homeworks. The messages of the elections must be signed all the
+	// same.
+	// Default: false
+	AllowUnsigned bool
 }
 
 // Backoff describes parameters for a backoff algorithm. The initial time must
@@ -38,6 +38,7 @@ func Test_HW2_Integration_Scenario(t *testing.T) {
 
 	t.Parallel()
 	for i := 1; i < len(stages); i++ {
+		i := i
 		t.Run(fmt.Sprintf("stage %d", i), func(t *testing.T) {
 			t.Parallel()
 
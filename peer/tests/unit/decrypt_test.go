@@ -1,27 +1,24 @@
 package unit
 
 import (
-	"crypto/elliptic"
 	"fmt"
 	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/cs438/peer/impl"
+	"go.dedis.ch/cs438/peer/impl/group"
+	"go.dedis.ch/cs438/types"
 )
 
 func Test_BSGS(t *testing.T) {
-	curve := elliptic.P256()
+	g := group.P256()
 
 	secret := new(big.Int).SetUint64(8)
 
-	tpX, tpY := curve.ScalarBaseMult(secret.Bytes())
+	targetPoint := g.BaseMul(g.ScalarFromInt(secret)).Point()
 
-	targetPoint := impl.NewPoint(tpX, tpY)
-
-	// targetPoint.X, targetPoint.Y =
-
-	result, isFound := impl.BsgsFunction(&targetPoint, curve, 8)
+	result, isFound := impl.BsgsFunction(&targetPoint, g, 8)
 
 	fmt.Printf("Result is %d\n", result.Uint64())
 
@@ -29,3 +26,61 @@ func Test_BSGS(t *testing.T) {
 	require.Equal(t, 0, secret.Cmp(result))
 
 }
+
+// Secret key shared among 3 mixnet servers with a threshold of 2: any 2 of the
+// decryption shares recover the plaintext, a single one doesn't.
+func Test_ThresholdDecryption(t *testing.T) {
+	for _, g := range []group.Group{group.P256(), group.P384()} {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			thresholdDecryption(t, g)
+		})
+	}
+}
+
+func thresholdDecryption(t *testing.T, g group.Group) {
+	order := g.Order()
+
+	// f(z) = a0 + a1*z, the secret key is a0
+	a := impl.GenerateRandomPolynomial(1, order)
+	publicKey := g.BaseMul(g.ScalarFromInt(&a[0])).Point()
+
+	secretShares := make([]big.Int, 3)
+	publicShares := make([]types.Point, 3)
+	for id := range secretShares {
+		share := new(big.Int).Mul(&a[1], big.NewInt(int64(id+1)))
+		share.Add(share, &a[0])
+		secretShares[id] = *share.Mod(share, order)
+
+		publicShares[id] = g.BaseMul(g.ScalarFromInt(&secretShares[id])).Point()
+	}
+
+	rScalar := impl.GenerateRandomBigInt(order)
+	cipherText, err := impl.ElGamalEncryption(g, &publicKey, &rScalar, big.NewInt(5))
+	require.NoError(t, err)
+
+	decryptShares := make([]types.Point, 3)
+	for id := range secretShares {
+		decryptShare, proof, err := impl.MakeDecryptShare(g, cipherText, &publicShares[id], secretShares[id].Bytes())
+		require.NoError(t, err)
+		require.True(t, impl.VerifyDecryptShare(g, cipherText, &publicShares[id], decryptShare, proof))
+
+		// the proof is bound to the public share of the mixnet server
+		require.False(t, impl.VerifyDecryptShare(g, cipherText, &publicShares[(id+1)%3], decryptShare, proof))
+
+		decryptShares[id] = *decryptShare
+	}
+
+	for _, shareIDs := range [][]int{{0, 1}, {0, 2}, {1, 2}, {0, 1, 2}} {
+		shares := make([]types.Point, len(shareIDs))
+		for i, id := range shareIDs {
+			shares[i] = decryptShares[id]
+		}
+
+		count, ok := impl.RecoverVoteCount(g, cipherText, shareIDs, shares, 10)
+		require.True(t, ok)
+		require.Equal(t, int64(5), count.Int64())
+	}
+
+	count, ok := impl.RecoverVoteCount(g, cipherText, []int{1}, decryptShares[1:2], 10)
+	require.False(t, ok && count.Int64() == 5)
+}
@@ -1,7 +1,11 @@
 package unit
 
 import (
+	"context"
+	"crypto/ecdsa"
 	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
 	"math/big"
 	"sync"
 	"testing"
@@ -11,6 +15,10 @@ import (
 
 	"github.com/stretchr/testify/require"
 	z "go.dedis.ch/cs438/internal/testing"
+	"go.dedis.ch/cs438/peer"
+	"go.dedis.ch/cs438/peer/impl"
+	"go.dedis.ch/cs438/peer/impl/group"
+	"go.dedis.ch/cs438/storage/inmemory"
 	"go.dedis.ch/cs438/transport"
 	"go.dedis.ch/cs438/transport/channel"
 )
@@ -29,6 +37,54 @@ func GetWinner(results map[int]uint) int {
 	return winner
 }
 
+// waitForPhase fails the test if the election does not reach the phase on the
+// node before the timeout.
+func waitForPhase(t *testing.T, node z.TestNode, electionID string, phase types.Phase, timeout time.Duration) {
+	var phases <-chan types.Phase
+	unsubscribe := func() {}
+
+	// the announcement may not have reached the node yet
+	require.Eventually(t, func() bool {
+		var err error
+		phases, unsubscribe, err = node.SubscribePhase(electionID)
+		return err == nil
+	}, timeout, time.Millisecond*10)
+	defer unsubscribe()
+
+	timer := time.After(timeout)
+
+	for {
+		select {
+		case current, ok := <-phases:
+			require.True(t, ok, "subscription closed before the election is %s", phase)
+			if current >= phase {
+				require.Equal(t, phase, current)
+				return
+			}
+		case <-timer:
+			t.Fatalf("election %s is not %s after %s", electionID, phase, timeout)
+		}
+	}
+}
+
+// collectPhases returns the phases of the election on the node until it is
+// finished or aborted.
+func collectPhases(t *testing.T, node z.TestNode, electionID string) <-chan []types.Phase {
+	phases, _, err := node.SubscribePhase(electionID)
+	require.NoError(t, err)
+
+	res := make(chan []types.Phase, 1)
+	go func() {
+		collected := []types.Phase{}
+		for phase := range phases {
+			collected = append(collected, phase)
+		}
+		res <- collected
+	}()
+
+	return res
+}
+
 func Test_SimpleElection(t *testing.T) {
 	transp := channel.NewTransport()
 
@@ -64,10 +120,10 @@ func Test_SimpleElection(t *testing.T) {
 
 	choiceID := election.Base.Choices[0].ChoiceID
 
-	err = node1.Vote(elections[0].Base.ElectionID, choiceID)
+	_, err = node1.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 	require.NoError(t, err)
 
-	err = node2.Vote(elections[0].Base.ElectionID, choiceID)
+	_, err = node2.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 	require.NoError(t, err)
 
 	time.Sleep(time.Second)
@@ -76,7 +132,7 @@ func Test_SimpleElection(t *testing.T) {
 	votes := node2.GetElections()[0].Votes
 	require.Len(t, votes, 2)
 
-	time.Sleep(time.Second * 5)
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*10)
 
 	elections = node1.GetElections()
 	election = elections[0]
@@ -120,10 +176,10 @@ func Test_ElectionTwoMixnetNodes(t *testing.T) {
 
 	choiceID := election.Base.Choices[0].ChoiceID
 
-	err = node1.Vote(elections[0].Base.ElectionID, choiceID)
+	_, err = node1.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 	require.NoError(t, err)
 
-	err = node2.Vote(elections[0].Base.ElectionID, choiceID)
+	_, err = node2.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 	require.NoError(t, err)
 
 	time.Sleep(time.Second)
@@ -132,7 +188,7 @@ func Test_ElectionTwoMixnetNodes(t *testing.T) {
 	votes := node1.GetElections()[0].Votes
 	require.Len(t, votes, 2)
 
-	time.Sleep(time.Second * 5)
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*10)
 
 	elections = node1.GetElections()
 	election = elections[0]
@@ -187,22 +243,22 @@ func Test_ElectionThreeMixnetNodes(t *testing.T) {
 
 	go func() {
 		defer wait.Done()
-		err = node1.Vote(elections[0].Base.ElectionID, choiceID)
+		_, err = node1.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 		require.NoError(t, err)
 	}()
 	go func() {
 		defer wait.Done()
-		err = node2.Vote(elections[0].Base.ElectionID, choiceID)
+		_, err = node2.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 		require.NoError(t, err)
 	}()
 	go func() {
 		defer wait.Done()
-		err = node3.Vote(elections[0].Base.ElectionID, choiceID)
+		_, err = node3.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 		require.NoError(t, err)
 	}()
 
 	wait.Wait()
-	time.Sleep(5 * time.Second)
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*10)
 
 	// first mixnet node accepts the votes
 	votes := node1.GetElections()[0].Votes
@@ -250,9 +306,9 @@ func Test_ElectionExpired(t *testing.T) {
 
 	choiceID := election.Base.Choices[0].ChoiceID
 
-	err = node1.Vote(elections[0].Base.ElectionID, choiceID)
+	_, err = node1.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 
-	err = node2.Vote(elections[0].Base.ElectionID, choiceID)
+	_, err = node2.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 
 	time.Sleep(time.Second)
 
@@ -330,15 +386,15 @@ func Test_Mixing(t *testing.T) {
 	choice2 := election.Base.Choices[1].ChoiceID
 
 	go func() {
-		err = node1.Vote(elections[0].Base.ElectionID, choice1)
+		_, err = node1.Vote(context.Background(), elections[0].Base.ElectionID, choice1)
 		require.NoError(t, err)
 	}()
 	go func() {
-		err = node2.Vote(elections[0].Base.ElectionID, choice2)
+		_, err = node2.Vote(context.Background(), elections[0].Base.ElectionID, choice2)
 		require.NoError(t, err)
 	}()
 	go func() {
-		err = node3.Vote(elections[0].Base.ElectionID, choice2)
+		_, err = node3.Vote(context.Background(), elections[0].Base.ElectionID, choice2)
 		require.NoError(t, err)
 	}()
 
@@ -361,6 +417,371 @@ func Test_Mixing(t *testing.T) {
 	require.Equal(t, winner3, choice2)
 }
 
+func Test_MultiCandidateElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node1.AddPeer(node3.GetAddr())
+
+	node2.AddPeer(node1.GetAddr())
+	node2.AddPeer(node3.GetAddr())
+
+	node3.AddPeer(node1.GetAddr())
+	node3.AddPeer(node2.GetAddr())
+
+	choices := []string{"Alice", "Bob", "Carol"}
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	_, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*3)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+
+	election := node1.GetElections()[0]
+	require.Len(t, election.Base.Choices, 3)
+
+	alice := election.Base.Choices[0].ChoiceID
+	carol := election.Base.Choices[2].ChoiceID
+
+	// a choice which does not exist is rejected
+	_, err = node1.Vote(context.Background(), election.Base.ElectionID, 42)
+	require.Error(t, err)
+
+	_, err = node1.Vote(context.Background(), election.Base.ElectionID, alice)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), election.Base.ElectionID, carol)
+	require.NoError(t, err)
+
+	_, err = node3.Vote(context.Background(), election.Base.ElectionID, carol)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second * 5)
+
+	expected := map[int]uint{
+		alice:                             1,
+		election.Base.Choices[1].ChoiceID: 0,
+		carol:                             2,
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		elections := node.GetElections()
+		require.Len(t, elections, 1)
+		require.Equal(t, expected, elections[0].Results)
+	}
+}
+
+func Test_BulletinBoard(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node1.AddPeer(node3.GetAddr())
+
+	node2.AddPeer(node1.GetAddr())
+	node2.AddPeer(node3.GetAddr())
+
+	node3.AddPeer(node1.GetAddr())
+	node3.AddPeer(node2.GetAddr())
+
+	choices := []string{"Alice", "Bob"}
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*3)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+
+	election := node1.GetElections()[0]
+	bob := election.Base.Choices[1].ChoiceID
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		_, err = node.Vote(context.Background(), electionID, bob)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(time.Second * 5)
+
+	// > the board of a peer which is not a mixnet server is enough to verify
+	// the whole election
+	board := node3.GetBulletinBoard(electionID)
+	require.Equal(t, electionID, board.ElectionID)
+	require.NoError(t, impl.VerifyBulletinBoard(board))
+
+	// > the board survives an export
+	buf, err := json.Marshal(board)
+	require.NoError(t, err)
+
+	exported := types.BulletinBoard{}
+	err = json.Unmarshal(buf, &exported)
+	require.NoError(t, err)
+	require.NoError(t, impl.VerifyBulletinBoard(exported))
+
+	// > announcing other results is detected
+	for i, entry := range exported.Entries {
+		if entry.Type == (types.ResultMessage{}).Name() {
+			forged, err := json.Marshal(&types.ResultMessage{
+				ElectionID: electionID,
+				Results:    map[int]uint{election.Base.Choices[0].ChoiceID: 3, bob: 0},
+			})
+			require.NoError(t, err)
+			exported.Entries[i].Payload = forged
+		}
+	}
+	require.Error(t, impl.VerifyBulletinBoard(exported))
+
+	// > dropping a ballot is detected
+	board = node3.GetBulletinBoard(electionID)
+	for i, entry := range board.Entries {
+		if entry.Type == (types.VoteMessage{}).Name() {
+			board.Entries = append(board.Entries[:i], board.Entries[i+1:]...)
+			break
+		}
+	}
+	require.Error(t, impl.VerifyBulletinBoard(board))
+
+	// > a copy of an entry is skipped, a ready message counts once
+	board = node3.GetBulletinBoard(electionID)
+	for _, entry := range board.Entries {
+		if entry.Type == (types.ElectionReadyMessage{}).Name() {
+			board.Entries = append(board.Entries, entry)
+			break
+		}
+	}
+	require.NoError(t, impl.VerifyBulletinBoard(board))
+
+	// > a second, conflicting result is detected
+	board = node3.GetBulletinBoard(electionID)
+	forged, err := json.Marshal(&types.ResultMessage{
+		ElectionID: electionID,
+		Results:    map[int]uint{election.Base.Choices[0].ChoiceID: 3, bob: 0},
+	})
+	require.NoError(t, err)
+	board.Entries = append(board.Entries, types.BulletinBoardEntry{
+		Type:    (types.ResultMessage{}).Name(),
+		Payload: forged,
+	})
+	require.Error(t, impl.VerifyBulletinBoard(board))
+
+	// > a posted entry must be signed by the mixnet server which posted it
+	board = node3.GetBulletinBoard(electionID)
+	for i, entry := range board.Entries {
+		if entry.Type == (types.VoteMessage{}).Name() {
+			require.Equal(t, node1.GetAddr(), entry.Sender)
+			board.Entries[i].Sender = node2.GetAddr()
+			break
+		}
+	}
+	require.Error(t, impl.VerifyBulletinBoard(board))
+}
+
+// A mixnet server only mixes the batches signed by a mixnet server which mixes
+// before it: a forged batch gets nobody ejected.
+func Test_ForgedMixBatch(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	rogue, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices,
+		mixnetServers, time.Second*2)
+	require.NoError(t, err)
+
+	waitForPhase(t, node2, electionID, types.PhaseOpen, time.Second*5)
+
+	for i, node := range []z.TestNode{node1, node2, node3} {
+		_, err = node.Vote(context.Background(), electionID, i%2)
+		require.NoError(t, err)
+	}
+
+	// > a batch for the second hop, which claims to be mixed by the first one
+	mixMessage := types.MixMessage{
+		ElectionID:     electionID,
+		MixnetServerID: 0,
+		Votes:          []types.VoteMessage{{ElectionID: electionID}},
+		NextHop:        1,
+	}
+	transpMsg, err := node2.GetRegistry().MarshalMessage(&mixMessage)
+	require.NoError(t, err)
+
+	header := transport.NewHeader(rogue.GetAddress(), rogue.GetAddress(), node2.GetAddr(), 0)
+	err = rogue.Send(node2.GetAddr(), transport.Packet{Header: &header, Msg: &transpMsg}, 0)
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+
+		election := node.GetElections()[0]
+		require.Equal(t, map[int]uint{0: 2, 1: 1}, election.Results)
+		require.Empty(t, election.EjectedMixnetServers)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// A peer asks a mixnet server to decrypt a single ballot while the election is
+// open: the request isn't signed by the mixnet server which tallies the
+// ballots, and isn't their tally, so it is refused.
+func Test_ForgedDecryptionRequest(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	rogue, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices,
+		mixnetServers, time.Second*5)
+	require.NoError(t, err)
+
+	waitForPhase(t, node2, electionID, types.PhaseOpen, time.Second*5)
+
+	for i, node := range []z.TestNode{node1, node2, node3} {
+		_, err = node.Vote(context.Background(), electionID, i%2)
+		require.NoError(t, err)
+	}
+
+	// > the ciphertexts of the first ballot, which the first mixnet server
+	// stored
+	ballot := node1.GetElections()[0].Votes[0]
+	decryptionRequest := types.DecryptionRequestMessage{
+		ElectionID:     electionID,
+		MixnetServerID: 1,
+		CipherTexts:    ballot.EncryptedVotes,
+		VoteCnt:        1,
+	}
+	transpMsg, err := node2.GetRegistry().MarshalMessage(&decryptionRequest)
+	require.NoError(t, err)
+
+	header := transport.NewHeader(rogue.GetAddress(), rogue.GetAddress(), node2.GetAddr(), 0)
+	err = rogue.Send(node2.GetAddr(), transport.Packet{Header: &header, Msg: &transpMsg}, 0)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+
+	require.Equal(t, types.PhaseOpen, node2.GetElections()[0].Phase)
+	for _, entry := range node2.GetBulletinBoard(electionID).Entries {
+		require.NotEqual(t, types.DecryptionRequestMessage{}.Name(), entry.Type)
+		require.NotEqual(t, types.DecryptShareMessage{}.Name(), entry.Type)
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{0: 2, 1: 1}, node.GetElections()[0].Results)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// A peer broadcasts made-up results, while the election is open and once it
+// finished: they are not the decryption of the tally, so they neither finish
+// the election nor replace its results.
+func Test_ForgedResults(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	rogue, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr()}
+
+	electionID, err := node2.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices,
+		mixnetServers, time.Second*3)
+	require.NoError(t, err)
+
+	waitForPhase(t, node2, electionID, types.PhaseOpen, time.Second*5)
+
+	sendForgedResults := func() {
+		resultMessage := types.ResultMessage{
+			ElectionID: electionID,
+			Results:    map[int]uint{0: 0, 1: 10},
+		}
+		transpMsg, err := node2.GetRegistry().MarshalMessage(&resultMessage)
+		require.NoError(t, err)
+
+		header := transport.NewHeader(rogue.GetAddress(), rogue.GetAddress(), node2.GetAddr(), 0)
+		err = rogue.Send(node2.GetAddr(), transport.Packet{Header: &header, Msg: &transpMsg}, 0)
+		require.NoError(t, err)
+
+		time.Sleep(time.Second)
+	}
+
+	sendForgedResults()
+	require.Equal(t, types.PhaseOpen, node2.GetElections()[0].Phase)
+	require.Empty(t, node2.GetElections()[0].Results)
+
+	for _, node := range []z.TestNode{node1, node2} {
+		_, err = node.Vote(context.Background(), electionID, 0)
+		require.NoError(t, err)
+	}
+
+	waitForPhase(t, node2, electionID, types.PhaseFinished, time.Second*20)
+	require.Equal(t, map[int]uint{0: 2, 1: 0}, node2.GetElections()[0].Results)
+
+	sendForgedResults()
+	require.Equal(t, map[int]uint{0: 2, 1: 0}, node2.GetElections()[0].Results)
+
+	require.NoError(t, impl.VerifyBulletinBoard(node2.GetBulletinBoard(electionID)))
+}
+
 func Test_DishonestMixnetNode(t *testing.T) {
 	transp := channel.NewTransport()
 
@@ -374,6 +795,36 @@ func Test_DishonestMixnetNode(t *testing.T) {
 	node1.AddPeer(node1.GetAddr())
 	node1.AddPeer(dishonestNode.GetAddress())
 
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	// publish the keys of the mixnet server in a signed rumor: the announcer
+	// pins its signing key, and the encryption key is needed to send it
+	// private messages
+	nodeKeyMessage := CreateNodeKeyMessage(t, dishonestNode.GetAddress(), signingKey)
+	transpMsg, err := node1.GetRegistry().MarshalMessage(&nodeKeyMessage)
+	require.NoError(t, err)
+
+	rumor := types.Rumor{Origin: dishonestNode.GetAddress(), Sequence: 1, Msg: &transpMsg}
+	require.NoError(t, impl.SignRumor(signingKey, &rumor))
+
+	transpMsg, err = node1.GetRegistry().MarshalMessage(&types.RumorsMessage{Rumors: []types.Rumor{rumor}})
+	require.NoError(t, err)
+
+	header := transport.NewHeader(dishonestNode.GetAddress(), dishonestNode.GetAddress(), node1.GetAddr(), 0)
+
+	packet := transport.Packet{
+		Header: &header,
+		Msg:    &transpMsg,
+	}
+
+	err = dishonestNode.Send(node1.GetAddr(), packet, 0)
+	require.NoError(t, err)
+
+	// > the socket must receive the ack of its rumor
+	_, err = dishonestNode.Recv(time.Second)
+	require.NoError(t, err)
+
 	choices := []string{"One choice", "a better choice"}
 
 	mixnetServers := []string{node1.GetAddr(), dishonestNode.GetAddress()}
@@ -382,22 +833,23 @@ func Test_DishonestMixnetNode(t *testing.T) {
 	require.NoError(t, err)
 
 	// > the socket must receive a AnnounceElectionMessage
-	packet, err := dishonestNode.Recv(time.Second)
+	_, err = dishonestNode.Recv(time.Second)
 	require.NoError(t, err)
 
 	// send invalid types.DKGShareMessage
 
-	dkgMessage := CreateInvalidDKGShareMessage(electionID, 1, 1)
-	transpMsg, err := node1.GetRegistry().MarshalMessage(&dkgMessage)
+	dkgMessage := CreateInvalidDKGShareMessage(t, electionID, 1, 1, signingKey)
+	transpMsg, err = node1.GetRegistry().MarshalMessage(&dkgMessage)
 	require.NoError(t, err)
 
-	header := transport.NewHeader(dishonestNode.GetAddress(), dishonestNode.GetAddress(), node1.GetAddr(), 0)
+	header = transport.NewHeader(dishonestNode.GetAddress(), dishonestNode.GetAddress(), node1.GetAddr(), 0)
 
 	packet = transport.Packet{
 		Header: &header,
 		Msg:    &transpMsg,
 	}
 
+	require.NoError(t, packet.Sign(signingKey))
 	err = dishonestNode.Send(node1.GetAddr(), packet, 0)
 	require.NoError(t, err)
 
@@ -413,7 +865,7 @@ func Test_DishonestMixnetNode(t *testing.T) {
 
 	// Send 2 DKGShareValidation messages
 	for i := 0; i <= 1; i++ {
-		dkgShareValidationMessage := CreateDKGShareValidationMessage(electionID, i)
+		dkgShareValidationMessage := CreateDKGShareValidationMessage(t, electionID, i, 1, signingKey)
 		transpMsg, err = node1.GetRegistry().MarshalMessage(&dkgShareValidationMessage)
 		require.NoError(t, err)
 		header = transport.NewHeader(dishonestNode.GetAddress(), dishonestNode.GetAddress(), node1.GetAddr(), 0)
@@ -421,13 +873,14 @@ func Test_DishonestMixnetNode(t *testing.T) {
 			Header: &header,
 			Msg:    &transpMsg,
 		}
+		require.NoError(t, packet.Sign(signingKey))
 		err = dishonestNode.Send(node1.GetAddr(), packet, 0)
 		require.NoError(t, err)
 	}
 
 	// Send DKGShareReveal Message
 	time.Sleep(time.Second)
-	dkgRevealShareMessage := CreateDKGShareRevealMessage(electionID, 1, 0)
+	dkgRevealShareMessage := CreateDKGShareRevealMessage(t, electionID, 1, 0, signingKey)
 	transpMsg, err = node1.GetRegistry().MarshalMessage(&dkgRevealShareMessage)
 	require.NoError(t, err)
 	header = transport.NewHeader(dishonestNode.GetAddress(), dishonestNode.GetAddress(), node1.GetAddr(), 0)
@@ -435,6 +888,7 @@ func Test_DishonestMixnetNode(t *testing.T) {
 		Header: &header,
 		Msg:    &transpMsg,
 	}
+	require.NoError(t, packet.Sign(signingKey))
 	err = dishonestNode.Send(node1.GetAddr(), packet, 0)
 	require.NoError(t, err)
 
@@ -444,7 +898,7 @@ func Test_DishonestMixnetNode(t *testing.T) {
 	qualifiedServers = append(qualifiedServers, 1)
 
 	time.Sleep(1 * time.Second)
-	electionReadyMessage := CreateElectionReadyMessage(electionID, qualifiedServers)
+	electionReadyMessage := CreateElectionReadyMessage(t, electionID, 1, qualifiedServers, signingKey)
 	transpMsg, err = node1.GetRegistry().MarshalMessage(&electionReadyMessage)
 	require.NoError(t, err)
 	header = transport.NewHeader(dishonestNode.GetAddress(), dishonestNode.GetAddress(), node1.GetAddr(), 0)
@@ -452,6 +906,7 @@ func Test_DishonestMixnetNode(t *testing.T) {
 		Header: &header,
 		Msg:    &transpMsg,
 	}
+	require.NoError(t, packet.Sign(signingKey))
 	err = dishonestNode.Send(node1.GetAddr(), packet, 0)
 	require.NoError(t, err)
 
@@ -494,31 +949,66 @@ func Test_DishonestMixnetNode(t *testing.T) {
 	//require.Equal(t, winner2, choiceID)
 }
 
-func CreateElectionReadyMessage(electionID string, qualifiedServers []int) types.ElectionReadyMessage {
-	return types.ElectionReadyMessage{
+func CreateElectionReadyMessage(t *testing.T, electionID string, mixnetServerID int, qualifiedServers []int,
+	signingKey *ecdsa.PrivateKey) types.ElectionReadyMessage {
+
+	electionReadyMessage := types.ElectionReadyMessage{
 		ElectionID:       electionID,
+		MixnetServerID:   mixnetServerID,
 		QualifiedServers: qualifiedServers,
 	}
+	require.NoError(t, impl.SignElectionReady(signingKey, &electionReadyMessage))
+
+	return electionReadyMessage
 }
 
-func CreateDKGShareRevealMessage(electionID string, mixnetServerID int, complainingServerID int) types.DKGRevealShareMessage {
-	return types.DKGRevealShareMessage{
+func CreateDKGShareRevealMessage(t *testing.T, electionID string, mixnetServerID int, complainingServerID int,
+	signingKey *ecdsa.PrivateKey) types.DKGRevealShareMessage {
+
+	dkgRevealShareMessage := types.DKGRevealShareMessage{
 		ElectionID:          electionID,
 		Share:               *big.NewInt(1),
 		MixnetServerID:      mixnetServerID,
 		ComplainingServerID: complainingServerID,
 	}
+	require.NoError(t, impl.SignDKGRevealShare(signingKey, &dkgRevealShareMessage))
+
+	return dkgRevealShareMessage
 }
 
-func CreateDKGShareValidationMessage(electionID string, mixnetServerID int) types.DKGShareValidationMessage {
-	return types.DKGShareValidationMessage{
+func CreateDKGShareValidationMessage(t *testing.T, electionID string, mixnetServerID int, validatorID int,
+	signingKey *ecdsa.PrivateKey) types.DKGShareValidationMessage {
+
+	dkgShareValidationMessage := types.DKGShareValidationMessage{
 		ElectionID:     electionID,
 		MixnetServerID: mixnetServerID,
+		ValidatorID:    validatorID,
 		IsShareValid:   true,
 	}
+	require.NoError(t, impl.SignDKGShareValidation(signingKey, &dkgShareValidationMessage))
+
+	return dkgShareValidationMessage
+}
+
+func CreateNodeKeyMessage(t *testing.T, addr string, signingKey *ecdsa.PrivateKey) types.NodeKeyMessage {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	nodeKeyMessage := types.NodeKeyMessage{
+		Address: addr,
+		PublicKey: types.Point{
+			X: *privateKey.X,
+			Y: *privateKey.Y,
+		},
+	}
+	require.NoError(t, impl.SignNodeKey(signingKey, &nodeKeyMessage))
+
+	return nodeKeyMessage
 }
 
-func CreateInvalidDKGShareMessage(electionID string, threshold int, mixnetServerID int) types.DKGShareMessage {
+func CreateInvalidDKGShareMessage(t *testing.T, electionID string, threshold int, mixnetServerID int,
+	signingKey *ecdsa.PrivateKey) types.DKGShareMessage {
+
 	X := make([]types.Point, threshold+1)
 	tmp := make([]byte, 32)
 	for i := 0; i < threshold+1; i++ {
@@ -526,12 +1016,15 @@ func CreateInvalidDKGShareMessage(electionID string, threshold int, mixnetServer
 		X[i].X, X[i].Y = *xx, *xy
 	}
 
-	return types.DKGShareMessage{
+	dkgShareMessage := types.DKGShareMessage{
 		ElectionID:     electionID,
 		MixnetServerID: mixnetServerID,
 		Share:          *big.NewInt(1),
 		X:              X,
 	}
+	require.NoError(t, impl.SignDKGShare(signingKey, &dkgShareMessage))
+
+	return dkgShareMessage
 }
 
 // func Test_StartNode(t *testing.T) {
@@ -562,3 +1055,2392 @@ func getRumorByOrigin(t *testing.T, pkts []transport.Packet, initiator string, s
 	}
 	return nil, nil
 }
+
+// An election must survive a restart of its initiator: the election, its DKG
+// shares and the accepted votes are loaded from the storage and the mixing
+// timer is re-armed.
+func Test_ElectionRestart(t *testing.T) {
+	transp := channel.NewTransport()
+
+	storage := inmemory.NewPersistency()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithStorage(storage))
+	node1Addr := node1.GetAddr()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithAntiEntropy(time.Millisecond*100))
+	defer node2.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1Addr)
+
+	choices := []string{"One choice", "a better choice"}
+
+	mixnetServers := []string{node1Addr}
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*4)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+
+	election := node1.GetElections()[0]
+	choiceID := election.Base.Choices[1].ChoiceID
+
+	_, err = node1.Vote(context.Background(), electionID, choiceID)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), electionID, choiceID)
+	require.NoError(t, err)
+
+	time.Sleep(time.Second)
+
+	require.Len(t, node1.GetElections()[0].Votes, 2)
+
+	board := node1.GetBulletinBoard(electionID)
+
+	node1.StopAll()
+
+	// > restart the node with the same storage
+
+	node1 = z.NewTestNode(t, peerFac, transp, node1Addr, z.WithStorage(storage))
+	defer node1.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+
+	elections := node1.GetElections()
+	require.Len(t, elections, 1)
+	require.Equal(t, electionID, elections[0].Base.ElectionID)
+	require.Len(t, elections[0].Votes, 2)
+	require.True(t, elections[0].IsElectionStarted())
+
+	// > the bulletin board is restored too
+	require.Equal(t, board, node1.GetBulletinBoard(electionID))
+
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*10)
+	waitForPhase(t, node2, electionID, types.PhaseFinished, time.Second*10)
+
+	election = node1.GetElections()[0]
+	require.Equal(t, choiceID, GetWinner(election.Results))
+	require.NoError(t, impl.VerifyBulletinBoard(node1.GetBulletinBoard(electionID)))
+
+	election = node2.GetElections()[0]
+	require.Equal(t, choiceID, GetWinner(election.Results))
+}
+
+// Peers observe the phases of an election in order. A voter does not witness
+// the mixing, and the mixnet server may start mixing before closing.
+func Test_ElectionPhases(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr())
+
+	choices := []string{"One choice", "a better choice"}
+
+	mixnetServers := []string{node2.GetAddr()}
+
+	_, _, err := node1.SubscribePhase("unknown")
+	require.Error(t, err)
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*2)
+	require.NoError(t, err)
+
+	voterPhases := collectPhases(t, node1, electionID)
+
+	waitForPhase(t, node2, electionID, types.PhaseOpen, time.Second*5)
+	mixnetPhases := collectPhases(t, node2, electionID)
+
+	_, err = node1.Vote(context.Background(), electionID, 1)
+	require.NoError(t, err)
+
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*10)
+
+	// voting is not possible anymore
+	_, err = node2.Vote(context.Background(), electionID, 1)
+	require.Error(t, err)
+
+	phases := <-voterPhases
+	require.Equal(t, []types.Phase{types.PhaseAnnounced, types.PhaseDKG, types.PhaseOpen,
+		types.PhaseClosed, types.PhaseTallying, types.PhaseFinished}, phases)
+
+	phases = <-mixnetPhases
+	require.Equal(t, types.PhaseOpen, phases[0])
+	require.Equal(t, []types.Phase{types.PhaseMixing, types.PhaseTallying, types.PhaseFinished}, phases[len(phases)-3:])
+
+	// a late subscriber only gets the final phase
+	phases = <-collectPhases(t, node2, electionID)
+	require.Equal(t, []types.Phase{types.PhaseFinished}, phases)
+
+	election := node1.GetElections()[0]
+	require.Equal(t, types.PhaseFinished, election.Phase)
+	require.Equal(t, 1, GetWinner(election.Results))
+}
+
+func Test_PhaseTransitions(t *testing.T) {
+	require.True(t, types.PhaseAnnounced.CanTransitionTo(types.PhaseDKG))
+	require.True(t, types.PhaseOpen.CanTransitionTo(types.PhaseTallying))
+	require.True(t, types.PhaseMixing.CanTransitionTo(types.PhaseAborted))
+
+	require.False(t, types.PhaseOpen.CanTransitionTo(types.PhaseOpen))
+	require.False(t, types.PhaseMixing.CanTransitionTo(types.PhaseClosed))
+	require.False(t, types.PhaseFinished.CanTransitionTo(types.PhaseAborted))
+	require.False(t, types.PhaseAborted.CanTransitionTo(types.PhaseFinished))
+
+	require.Equal(t, "Tallying", types.PhaseTallying.String())
+}
+
+// The mixnet server acknowledges each ballot with a signed receipt, which
+// holds the hash of the stored ballot.
+func Test_VoteReceipt(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr())
+
+	choices := []string{"One choice", "a better choice"}
+
+	mixnetServers := []string{node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*3)
+	require.NoError(t, err)
+
+	receipt, err := node1.Vote(context.Background(), electionID, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, electionID, receipt.ElectionID)
+	require.Equal(t, 0, receipt.MixnetServerID)
+
+	votes := node2.GetElections()[0].Votes
+	require.Len(t, votes, 1)
+	require.Equal(t, impl.BallotHash(&votes[0]), receipt.BallotHash)
+
+	election := node1.GetElections()[0]
+	require.NotNil(t, election.MyBallotReceipt)
+	require.Equal(t, receipt, *election.MyBallotReceipt)
+
+	// a forged receipt is not valid
+	publicShare := group.P256().Generator().Point()
+	require.False(t, impl.VerifyBallotReceipt(group.P256(), &receipt, &publicShare))
+}
+
+// If the mixnet server doesn't acknowledge the ballot, the voter resends it.
+// The mixnet server stores it only once.
+func Test_VoteRetry(t *testing.T) {
+	transp := channel.NewTransport()
+
+	type voteHandler interface {
+		HandleVoteMessage(types.Message, transport.Packet) error
+	}
+
+	var node2 z.TestNode
+	received := 0
+	mutex := sync.Mutex{}
+
+	// the first ballot is lost, the next ones are processed twice
+	unreliableHandler := func(msg types.Message, pkt transport.Packet) error {
+		mutex.Lock()
+		received++
+		cnt := received
+		mutex.Unlock()
+
+		if cnt == 1 {
+			return nil
+		}
+
+		handler := node2.Peer.(voteHandler)
+		err := handler.HandleVoteMessage(msg, pkt)
+		if err != nil {
+			return err
+		}
+		return handler.HandleVoteMessage(msg, pkt)
+	}
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithVoteBackoff(time.Millisecond*200, 2, 2))
+	defer node1.Stop()
+
+	node2 = z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithMessage(types.VoteMessage{}, unreliableHandler))
+	defer node2.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr())
+
+	choices := []string{"One choice", "a better choice"}
+
+	mixnetServers := []string{node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*4)
+	require.NoError(t, err)
+
+	receipt, err := node1.Vote(context.Background(), electionID, 0)
+	require.NoError(t, err)
+
+	mutex.Lock()
+	require.Equal(t, 2, received)
+	mutex.Unlock()
+
+	votes := node2.GetElections()[0].Votes
+	require.Len(t, votes, 1)
+	require.Equal(t, impl.BallotHash(&votes[0]), receipt.BallotHash)
+
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*10)
+	require.Equal(t, 0, GetWinner(node1.GetElections()[0].Results))
+}
+
+// A ballot which is never acknowledged is not recorded: the peer can still
+// vote.
+func Test_VoteUnacknowledged(t *testing.T) {
+	transp := channel.NewTransport()
+
+	type voteHandler interface {
+		HandleVoteMessage(types.Message, transport.Packet) error
+	}
+
+	var node2 z.TestNode
+	drop := true
+	mutex := sync.Mutex{}
+
+	// the ballots are lost until drop is unset
+	lossyHandler := func(msg types.Message, pkt transport.Packet) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if drop {
+			return nil
+		}
+		return node2.Peer.(voteHandler).HandleVoteMessage(msg, pkt)
+	}
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithVoteBackoff(time.Millisecond*100, 1, 100))
+	defer node1.Stop()
+
+	node2 = z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithMessage(types.VoteMessage{}, lossyHandler))
+	defer node2.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr())
+
+	choices := []string{"One choice", "a better choice"}
+
+	mixnetServers := []string{node2.GetAddr()}
+
+	// > the election stays open long after both votes, also under the race
+	// detector
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*15)
+	require.NoError(t, err)
+
+	waitForPhase(t, node1, electionID, types.PhaseOpen, time.Second*5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+
+	_, err = node1.Vote(ctx, electionID, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the vote was not cast
+	require.Equal(t, -1, node1.GetElections()[0].MyVote)
+	require.Nil(t, node1.GetElections()[0].MyBallotReceipt)
+
+	mutex.Lock()
+	drop = false
+	mutex.Unlock()
+
+	receipt, err := node1.Vote(context.Background(), electionID, 1)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, node1.GetElections()[0].MyVote)
+	require.Equal(t, receipt.BallotHash, node1.GetElections()[0].MyBallotReceipt.BallotHash)
+}
+
+// Vote returns when the context is done, even if the election never starts.
+func Test_VoteContext(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	choices := []string{"One choice", "a better choice"}
+
+	// the mixnet server doesn't exist, the key generation never ends
+	mixnetServers := []string{node1.GetAddr(), "127.0.0.1:999"}
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+
+	start := time.Now()
+	_, err = node1.Vote(ctx, electionID, 0)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second*2)
+
+	// the vote was not cast
+	require.Equal(t, -1, node1.GetElections()[0].MyVote)
+}
+
+// Private messages are only readable by their recipients: a peer relaying the
+// DKG shares and the ballots can't decrypt them.
+func Test_PrivateMessageEncrypted(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"One choice", "a better choice"}
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node3.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers, time.Second*3)
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	_, err = node3.Vote(context.Background(), electionID, 1)
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseFinished, time.Second*10)
+	require.Equal(t, 1, GetWinner(node3.GetElections()[0].Results))
+
+	countPrivate := func(node z.TestNode) (private, shares, votes int) {
+		for _, msg := range node.GetRegistry().GetMessages() {
+			switch m := msg.(type) {
+			case *types.PrivateMessage:
+				private++
+				require.NotNil(t, m.SenderKey)
+				require.Nil(t, m.Msg)
+				require.Empty(t, m.Recipients)
+			case *types.DKGShareMessage:
+				shares++
+			case *types.VoteMessage:
+				votes++
+			}
+		}
+		return private, shares, votes
+	}
+
+	// the mixnet servers received a share from each of them, and the first one
+	// received the ballot
+	_, shares, votes := countPrivate(node1)
+	require.Equal(t, 2, shares)
+	require.Equal(t, 1, votes)
+
+	_, shares, _ = countPrivate(node2)
+	require.Equal(t, 2, shares)
+
+	// the voter relayed private messages without reading them
+	private, shares, votes := countPrivate(node3)
+	require.Greater(t, private, 0)
+	require.Equal(t, 0, shares)
+	require.Equal(t, 0, votes)
+}
+
+// Packets and rumors are signed by their creator: a peer can't impersonate
+// another one, nor alter what it relays.
+func Test_SignedPackets(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	attacker, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	attackerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	node1.AddPeer(node2.GetAddr(), attacker.GetAddress())
+	node2.AddPeer(node1.GetAddr())
+
+	// > node1 learns the key of node2 from its signed rumor
+
+	chat := types.ChatMessage{Message: "genuine"}
+	chatMsg, err := node2.GetRegistry().MarshalMessage(&chat)
+	require.NoError(t, err)
+
+	err = node2.Broadcast(chatMsg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 500)
+
+	countChats := func() int {
+		count := 0
+		for _, msg := range node1.GetRegistry().GetMessages() {
+			if _, ok := msg.(*types.ChatMessage); ok {
+				count++
+			}
+		}
+		return count
+	}
+	require.Equal(t, 1, countChats())
+
+	forged := types.ChatMessage{Message: "forged"}
+	forgedMsg, err := node1.GetRegistry().MarshalMessage(&forged)
+	require.NoError(t, err)
+
+	send := func(source string, msg transport.Message, sign func(transport.Packet)) {
+		header := transport.NewHeader(source, attacker.GetAddress(), node1.GetAddr(), 0)
+		pkt := transport.Packet{Header: &header, Msg: &msg}
+		sign(pkt)
+
+		err := attacker.Send(node1.GetAddr(), pkt, 0)
+		require.NoError(t, err)
+	}
+
+	// > a packet from node2 signed with another key is dropped
+
+	send(node2.GetAddr(), forgedMsg, func(pkt transport.Packet) {
+		require.NoError(t, pkt.Sign(attackerKey))
+	})
+
+	// > a packet altered after its signature is dropped
+
+	send(attacker.GetAddress(), chatMsg, func(pkt transport.Packet) {
+		require.NoError(t, pkt.Sign(attackerKey))
+		pkt.Msg.Payload = forgedMsg.Payload
+	})
+
+	// > an unsigned rumor claiming to come from node2 is dropped
+
+	rumors := types.RumorsMessage{
+		Rumors: []types.Rumor{{Origin: node2.GetAddr(), Sequence: 2, Msg: &forgedMsg}},
+	}
+	rumorsMsg, err := node1.GetRegistry().MarshalMessage(&rumors)
+	require.NoError(t, err)
+
+	send(attacker.GetAddress(), rumorsMsg, func(pkt transport.Packet) {
+		require.NoError(t, pkt.Sign(attackerKey))
+	})
+
+	time.Sleep(time.Millisecond * 500)
+
+	require.Equal(t, 1, countChats())
+
+	// > the genuine rumor is still accepted
+	err = node2.Broadcast(chatMsg)
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 500)
+
+	require.Equal(t, 2, countChats())
+}
+
+// A peer which accepts unsigned traffic still drops the messages of the
+// elections which are not signed, in packets or in rumors.
+func Test_UnsignedElectionMessages(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	stranger, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	node1.AddPeer(stranger.GetAddress())
+
+	count := func() (chats, requests int) {
+		for _, msg := range node1.GetRegistry().GetMessages() {
+			switch msg.(type) {
+			case *types.ChatMessage:
+				chats++
+			case *types.NodeKeyRequestMessage:
+				requests++
+			}
+		}
+		return chats, requests
+	}
+
+	send := func(msg types.Message, sequence uint) {
+		transpMsg, err := node1.GetRegistry().MarshalMessage(msg)
+		require.NoError(t, err)
+
+		header := transport.NewHeader(stranger.GetAddress(), stranger.GetAddress(), node1.GetAddr(), 0)
+		pkt := transport.Packet{Header: &header, Msg: &transpMsg}
+		require.NoError(t, stranger.Send(node1.GetAddr(), pkt, 0))
+
+		rumors := types.RumorsMessage{
+			Rumors: []types.Rumor{{Origin: stranger.GetAddress(), Sequence: sequence, Msg: &transpMsg}},
+		}
+		rumorsMsg, err := node1.GetRegistry().MarshalMessage(&rumors)
+		require.NoError(t, err)
+
+		header = transport.NewHeader(stranger.GetAddress(), stranger.GetAddress(), node1.GetAddr(), 0)
+		pkt = transport.Packet{Header: &header, Msg: &rumorsMsg}
+		require.NoError(t, stranger.Send(node1.GetAddr(), pkt, 0))
+	}
+
+	// > an unsigned chat is accepted, directly and in a rumor
+	send(&types.ChatMessage{Message: "hello"}, 1)
+
+	// > an unsigned message of the elections is dropped in both
+	send(&types.NodeKeyRequestMessage{Addresses: []string{node1.GetAddr()}}, 2)
+
+	time.Sleep(time.Millisecond * 500)
+
+	chats, requests := count()
+	require.Equal(t, 2, chats)
+	require.Equal(t, 0, requests)
+}
+
+// A peer which requires signatures drops the unsigned packets and rumors, even
+// from peers it never heard of.
+func Test_RequireSignatures(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithRequireSignatures())
+	defer node1.Stop()
+
+	stranger, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	strangerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	node1.AddPeer(stranger.GetAddress())
+
+	countChats := func() int {
+		count := 0
+		for _, msg := range node1.GetRegistry().GetMessages() {
+			if _, ok := msg.(*types.ChatMessage); ok {
+				count++
+			}
+		}
+		return count
+	}
+
+	chat := types.ChatMessage{Message: "hello"}
+	chatMsg, err := node1.GetRegistry().MarshalMessage(&chat)
+	require.NoError(t, err)
+
+	send := func(msg transport.Message, sign bool) {
+		header := transport.NewHeader(stranger.GetAddress(), stranger.GetAddress(), node1.GetAddr(), 0)
+		pkt := transport.Packet{Header: &header, Msg: &msg}
+		if sign {
+			require.NoError(t, pkt.Sign(strangerKey))
+		}
+
+		err := stranger.Send(node1.GetAddr(), pkt, 0)
+		require.NoError(t, err)
+	}
+
+	// > an unsigned packet is dropped
+
+	send(chatMsg, false)
+
+	// > an unsigned rumor is dropped, even in a signed packet
+
+	unsigned := types.RumorsMessage{
+		Rumors: []types.Rumor{{Origin: stranger.GetAddress(), Sequence: 1, Msg: &chatMsg}},
+	}
+	unsignedMsg, err := node1.GetRegistry().MarshalMessage(&unsigned)
+	require.NoError(t, err)
+
+	send(unsignedMsg, true)
+
+	// > a cleartext private message is dropped, even in a signed packet
+
+	private := types.PrivateMessage{
+		Recipients: map[string]struct{}{node1.GetAddr(): {}},
+		Msg:        &chatMsg,
+	}
+	privateMsg, err := node1.GetRegistry().MarshalMessage(&private)
+	require.NoError(t, err)
+
+	send(privateMsg, true)
+
+	time.Sleep(time.Millisecond * 500)
+
+	require.Equal(t, 0, countChats())
+
+	// > signed packets and rumors are accepted
+
+	send(chatMsg, true)
+
+	rumor := types.Rumor{Origin: stranger.GetAddress(), Sequence: 1, Msg: &chatMsg}
+	require.NoError(t, impl.SignRumor(strangerKey, &rumor))
+
+	signedMsg, err := node1.GetRegistry().MarshalMessage(&types.RumorsMessage{Rumors: []types.Rumor{rumor}})
+	require.NoError(t, err)
+
+	send(signedMsg, true)
+
+	time.Sleep(time.Millisecond * 500)
+
+	require.Equal(t, 2, countChats())
+}
+
+// Only a peer can publish its own keys: the keys of another address are
+// ignored, and private messages still reach the genuine peer.
+func Test_NodeKeyForgery(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	attacker, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	attackerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	node1.AddPeer(node2.GetAddr(), attacker.GetAddress())
+	node2.AddPeer(node1.GetAddr())
+
+	// > the attacker publishes, in its own signed rumors, keys for node2:
+	// signed with its key, and with the signing key of node2
+
+	forged := CreateNodeKeyMessage(t, node2.GetAddr(), attackerKey)
+
+	stolen := CreateNodeKeyMessage(t, node2.GetAddr(), attackerKey)
+	stolen.SigningKey = node2.GetVoterKey()
+
+	for i, nodeKeyMessage := range []types.NodeKeyMessage{forged, stolen} {
+		transpMsg, err := node1.GetRegistry().MarshalMessage(&nodeKeyMessage)
+		require.NoError(t, err)
+
+		rumor := types.Rumor{Origin: attacker.GetAddress(), Sequence: uint(i + 1), Msg: &transpMsg}
+		require.NoError(t, impl.SignRumor(attackerKey, &rumor))
+
+		rumorsMsg, err := node1.GetRegistry().MarshalMessage(&types.RumorsMessage{Rumors: []types.Rumor{rumor}})
+		require.NoError(t, err)
+
+		header := transport.NewHeader(attacker.GetAddress(), attacker.GetAddress(), node1.GetAddr(), 0)
+		pkt := transport.Packet{Header: &header, Msg: &rumorsMsg}
+		require.NoError(t, pkt.Sign(attackerKey))
+
+		require.NoError(t, attacker.Send(node1.GetAddr(), pkt, 0))
+	}
+
+	time.Sleep(time.Millisecond * 500)
+
+	// > the ballot of node1 is encrypted for the genuine key of node2
+
+	mixnetServers := []string{node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", []string{"Yes", "No"},
+		mixnetServers, time.Second*2)
+	require.NoError(t, err)
+
+	waitForPhase(t, node1, electionID, types.PhaseOpen, time.Second*10)
+
+	_, err = node1.Vote(context.Background(), electionID, 1)
+	require.NoError(t, err)
+
+	waitForPhase(t, node1, electionID, types.PhaseFinished, time.Second*20)
+
+	for _, election := range node1.GetElections() {
+		require.Equal(t, map[int]uint{0: 0, 1: 1}, election.Results)
+	}
+}
+
+// A private message comes from the address whose published key it is
+// encrypted with: a peer can't send one in the name of another peer.
+func Test_PrivateMessageSender(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	attacker, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	attackerKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	node1.AddPeer(node2.GetAddr(), attacker.GetAddress())
+	node2.AddPeer(node1.GetAddr())
+
+	// > node1 and node2 publish their keys when the election is announced
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", []string{"Yes", "No"},
+		[]string{node2.GetAddr()}, time.Second*2)
+	require.NoError(t, err)
+
+	waitForPhase(t, node1, electionID, types.PhaseOpen, time.Second*10)
+
+	secret := new(big.Int).SetBytes(node1.GetStorage().GetKeyStore().Get("encryption"))
+	x, y := elliptic.P256().ScalarBaseMult(secret.Bytes())
+	recipientKey := types.Point{X: *x, Y: *y}
+
+	// > the attacker encrypts a chat for node1 with its own key, in the name
+	// of node2
+	chatMsg, err := node1.GetRegistry().MarshalMessage(&types.ChatMessage{Message: "hello from node2"})
+	require.NoError(t, err)
+
+	private, err := impl.SealPrivateMessage(attackerKey.D, node2.GetAddr(),
+		types.Point{X: *attackerKey.X, Y: *attackerKey.Y}, []types.Point{recipientKey}, &chatMsg)
+	require.NoError(t, err)
+
+	privateMsg, err := node1.GetRegistry().MarshalMessage(&private)
+	require.NoError(t, err)
+
+	rumor := types.Rumor{Origin: attacker.GetAddress(), Sequence: 1, Msg: &privateMsg}
+	require.NoError(t, impl.SignRumor(attackerKey, &rumor))
+
+	rumorsMsg, err := node1.GetRegistry().MarshalMessage(&types.RumorsMessage{Rumors: []types.Rumor{rumor}})
+	require.NoError(t, err)
+
+	header := transport.NewHeader(attacker.GetAddress(), attacker.GetAddress(), node1.GetAddr(), 0)
+	pkt := transport.Packet{Header: &header, Msg: &rumorsMsg}
+	require.NoError(t, pkt.Sign(attackerKey))
+
+	require.NoError(t, attacker.Send(node1.GetAddr(), pkt, 0))
+
+	time.Sleep(time.Millisecond * 500)
+
+	// > node1 drops it, the private message is processed but not the chat
+	private1, chats := 0, 0
+	for _, msg := range node1.GetRegistry().GetMessages() {
+		switch msg.(type) {
+		case *types.PrivateMessage:
+			private1++
+		case *types.ChatMessage:
+			chats++
+		}
+	}
+	require.Greater(t, private1, 0)
+	require.Equal(t, 0, chats)
+}
+
+// The announcer pins the keys of the mixnet servers in the announcement, also
+// of the ones it never heard of, and the other peers don't accept other keys.
+func Test_MixnetServerKeys(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	// node1 <-> node2 <-> node3
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node2.GetAddr())
+
+	mixnetServers := []string{node2.GetAddr(), node3.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", []string{"Yes", "No"},
+		mixnetServers, time.Second*3)
+	require.NoError(t, err)
+
+	expected := [][]byte{node2.GetVoterKey(), node3.GetVoterKey()}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseOpen, time.Second*10)
+
+		for _, election := range node.GetElections() {
+			require.Equal(t, expected, election.Base.MixnetServerKeys)
+		}
+	}
+}
+
+// In homomorphic mode, the ballots are tallied without going through the
+// mixnet, and the election can still be verified from the bulletin board.
+func Test_HomomorphicTally(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	// an unknown tally mode is rejected
+	_, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*3, peer.WithTallyMode(types.TallyMode(42)))
+	require.Error(t, err)
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*3, peer.WithTallyMode(types.TallyHomomorphic))
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	require.Equal(t, types.TallyHomomorphic, election.Base.TallyMode)
+
+	yes := election.Base.Choices[0].ChoiceID
+	no := election.Base.Choices[1].ChoiceID
+
+	for i, node := range []z.TestNode{node1, node2, node3} {
+		choice := yes
+		if i == 2 {
+			choice = no
+		}
+		_, err = node.Vote(context.Background(), electionID, choice)
+		require.NoError(t, err)
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*10)
+		require.Equal(t, map[int]uint{yes: 2, no: 1}, node.GetElections()[0].Results)
+	}
+
+	// > the ballots were not mixed
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		for _, msg := range node.GetRegistry().GetMessages() {
+			_, isMix := msg.(*types.MixMessage)
+			require.False(t, isMix)
+		}
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// Ranked ballots go through the mixnet, are decrypted one by one, and the
+// winner is elected by instant-runoff.
+func Test_RankedChoiceElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Alice", "Bob", "Carol"}
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	// ranked ballots can't be tallied homomorphically
+	_, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers,
+		time.Second*3, peer.WithBallotType(types.BallotRanked), peer.WithTallyMode(types.TallyHomomorphic))
+	require.Error(t, err)
+
+	electionID, err := node1.AnnounceElection("Election for Mayor", "El Cidad is looking for a new mayor", choices, mixnetServers,
+		time.Second*3, peer.WithBallotType(types.BallotRanked))
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	alice := election.Base.Choices[0].ChoiceID
+	bob := election.Base.Choices[1].ChoiceID
+	carol := election.Base.Choices[2].ChoiceID
+
+	// > a ranked election expects a full ranking
+	_, err = node3.Vote(context.Background(), electionID, alice)
+	require.Error(t, err)
+
+	_, err = node3.VoteRanking(context.Background(), electionID, []int{alice, bob})
+	require.Error(t, err)
+
+	_, err = node3.VoteRanking(context.Background(), electionID, []int{alice, alice, bob})
+	require.Error(t, err)
+
+	// > each choice is the first preference of one voter, Carol is eliminated
+	// first and her voter prefers Bob to Alice
+	_, err = node1.VoteRanking(context.Background(), electionID, []int{alice, bob, carol})
+	require.NoError(t, err)
+
+	_, err = node2.VoteRanking(context.Background(), electionID, []int{bob, carol, alice})
+	require.NoError(t, err)
+
+	_, err = node3.VoteRanking(context.Background(), electionID, []int{carol, bob, alice})
+	require.NoError(t, err)
+
+	require.Equal(t, carol, node3.GetElections()[0].MyVote)
+	require.Equal(t, []int{carol, bob, alice}, node3.GetElections()[0].MyRanking)
+
+	expectedRounds := []types.RunoffRound{
+		{Counts: map[int]uint{alice: 1, bob: 1, carol: 1}, Eliminated: carol},
+		{Counts: map[int]uint{alice: 1, bob: 2}, Eliminated: -1},
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{alice: 1, bob: 2, carol: 0}, node.GetElections()[0].Results)
+	}
+
+	// > the rankings and rounds are published with the results
+	var resultMessage *types.ResultMessage
+	for _, msg := range node3.GetRegistry().GetMessages() {
+		if result, ok := msg.(*types.ResultMessage); ok {
+			resultMessage = result
+		}
+	}
+	require.NotNil(t, resultMessage)
+	require.Equal(t, expectedRounds, resultMessage.Rounds)
+	require.ElementsMatch(t, [][]int{{alice, bob, carol}, {bob, carol, alice}, {carol, bob, alice}}, resultMessage.Rankings)
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+func Test_InstantRunoff(t *testing.T) {
+	choices := []types.Choice{{ChoiceID: 0, Name: "A"}, {ChoiceID: 1, Name: "B"}, {ChoiceID: 2, Name: "C"}}
+
+	// > a majority in the first round ends the count
+	rounds := impl.InstantRunoff(choices, [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}})
+	require.Equal(t, []types.RunoffRound{
+		{Counts: map[int]uint{0: 2, 1: 1, 2: 0}, Eliminated: -1},
+	}, rounds)
+
+	// > the choice with the fewest votes is eliminated, its votes go to the
+	// next preference
+	rounds = impl.InstantRunoff(choices, [][]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 1, 0}})
+	require.Equal(t, []types.RunoffRound{
+		{Counts: map[int]uint{0: 2, 1: 2, 2: 1}, Eliminated: 2},
+		{Counts: map[int]uint{0: 2, 1: 3}, Eliminated: -1},
+	}, rounds)
+
+	// > without ballots, there is a single round
+	rounds = impl.InstantRunoff(choices, nil)
+	require.Len(t, rounds, 1)
+	require.Equal(t, -1, rounds[0].Eliminated)
+}
+
+// The ranked ballots which don't decrypt to a ranking are rejected, the
+// others are still counted
+func Test_DecodeRankings(t *testing.T) {
+	choices := []types.Choice{{ChoiceID: 0, Name: "A"}, {ChoiceID: 1, Name: "B"}}
+
+	plaintexts := []uint{
+		// > B then A
+		0, 1,
+		1, 0,
+		// > A twice
+		1, 0,
+		1, 0,
+		// > not a bit
+		0, 2,
+		1, 0,
+		// > A then B
+		1, 0,
+		0, 1,
+	}
+
+	rankings, rejected, err := impl.DecodeRankings(choices, plaintexts)
+	require.NoError(t, err)
+	require.Equal(t, [][]int{{1, 0}, {0, 1}}, rankings)
+	require.Equal(t, []int{1, 2}, rejected)
+
+	// > the plaintexts must hold whole ballots
+	_, _, err = impl.DecodeRankings(choices, plaintexts[:3])
+	require.Error(t, err)
+}
+
+// Approval ballots select up to MaxSelections choices, each selected choice
+// gets one vote.
+func Test_ApprovalElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Park", "Library", "Pool"}
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	// > up to 4 selections out of 3 choices is rejected
+	_, err := node1.AnnounceElection("Budget", "What should El Cidad build next?", choices, mixnetServers,
+		time.Second*3, peer.WithMaxSelections(4))
+	require.Error(t, err)
+
+	electionID, err := node1.AnnounceElection("Budget", "What should El Cidad build next?", choices, mixnetServers,
+		time.Second*3, peer.WithMaxSelections(2))
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	require.Equal(t, types.BallotApproval, election.Base.BallotType)
+	require.Equal(t, 2, election.Base.MaxSelections)
+
+	park := election.Base.Choices[0].ChoiceID
+	library := election.Base.Choices[1].ChoiceID
+	pool := election.Base.Choices[2].ChoiceID
+
+	// > a selection holds between 1 and 2 distinct choices
+	_, err = node3.VoteApproval(context.Background(), electionID, []int{})
+	require.Error(t, err)
+
+	_, err = node3.VoteApproval(context.Background(), electionID, []int{park, library, pool})
+	require.Error(t, err)
+
+	_, err = node3.VoteApproval(context.Background(), electionID, []int{park, park})
+	require.Error(t, err)
+
+	_, err = node3.Vote(context.Background(), electionID, park)
+	require.Error(t, err)
+
+	// > a ballot selecting 3 choices is rejected by the mixnet server
+	publicKey := election.GetPublicKey()
+	g := group.P256()
+	ballot := types.VoteMessage{ElectionID: electionID}
+	rSum := new(big.Int)
+	for range choices {
+		rScalar := impl.GenerateRandomBigInt(g.Order())
+		rSum.Add(rSum, &rScalar)
+		ct, err := impl.ElGamalEncryption(g, &publicKey, &rScalar, big.NewInt(1))
+		require.NoError(t, err)
+		proof, err := impl.ProveEncryptedBit(&rScalar, publicKey, *ct, true, g)
+		require.NoError(t, err)
+		ballot.EncryptedVotes = append(ballot.EncryptedVotes, *ct)
+		ballot.CorrectVoteProofs = append(ballot.CorrectVoteProofs, *proof)
+	}
+	rSum.Mod(rSum, g.Order())
+	selectionsSum, err := impl.ElGamalAddCipherTexts(g, ballot.EncryptedVotes)
+	require.NoError(t, err)
+	selectionsProof, err := impl.ProveEncryptedRange(rSum, publicKey, selectionsSum, 2, 1, 2, g)
+	require.NoError(t, err)
+	ballot.SelectionsProof = *selectionsProof
+	require.False(t, impl.VerifyBallot(election, publicKey, &ballot))
+
+	_, err = node1.VoteApproval(context.Background(), electionID, []int{park, library})
+	require.NoError(t, err)
+
+	_, err = node2.VoteApproval(context.Background(), electionID, []int{library})
+	require.NoError(t, err)
+
+	_, err = node3.VoteApproval(context.Background(), electionID, []int{pool, library})
+	require.NoError(t, err)
+
+	require.Equal(t, pool, node3.GetElections()[0].MyVote)
+	require.Equal(t, []int{pool, library}, node3.GetElections()[0].MySelections)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{park: 1, library: 3, pool: 1}, node.GetElections()[0].Results)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// A multi-contest ballot holds one selection per contest, the mixnet shuffles
+// whole ballots and the results are reported per contest.
+func Test_MultiContestElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	boardSeat := peer.WithContest("Board seat", []string{"Alice", "Bob", "Carol"}, peer.WithBallotType(types.BallotRanked))
+	budget := peer.WithContest("Budget", []string{"Yes", "No"})
+	projects := peer.WithContest("Projects", []string{"Park", "Library", "Pool"}, peer.WithMaxSelections(2))
+
+	// > the choices are given per contest
+	_, err := node1.AnnounceElection("General election", "El Cidad votes", []string{"Yes", "No"}, mixnetServers,
+		time.Second*3, boardSeat, budget)
+	require.Error(t, err)
+
+	// > each contest follows the rules of its ballot type
+	_, err = node1.AnnounceElection("General election", "El Cidad votes", nil, mixnetServers,
+		time.Second*3, budget, peer.WithContest("Projects", []string{"Park"}, peer.WithMaxSelections(2)))
+	require.Error(t, err)
+
+	electionID, err := node1.AnnounceElection("General election", "El Cidad votes", nil, mixnetServers,
+		time.Second*3, boardSeat, budget, projects)
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	require.Len(t, election.Base.Contests, 3)
+	require.Equal(t, 9+2+3, election.GetBallotSize())
+
+	alice, bob, carol := 0, 1, 2
+	yes, no := 0, 1
+	park, library, pool := 0, 1, 2
+
+	// > one selection per contest, following the contest's ballot type
+	_, err = node3.Vote(context.Background(), electionID, yes)
+	require.Error(t, err)
+
+	_, err = node3.VoteContests(context.Background(), electionID, [][]int{{alice, bob, carol}, {yes}})
+	require.Error(t, err)
+
+	_, err = node3.VoteContests(context.Background(), electionID, [][]int{{alice, bob, carol}, {yes, no}, {park}})
+	require.Error(t, err)
+
+	_, err = node1.VoteContests(context.Background(), electionID, [][]int{{alice, bob, carol}, {yes}, {park, library}})
+	require.NoError(t, err)
+
+	_, err = node2.VoteContests(context.Background(), electionID, [][]int{{bob, carol, alice}, {no}, {library}})
+	require.NoError(t, err)
+
+	_, err = node3.VoteContests(context.Background(), electionID, [][]int{{carol, bob, alice}, {yes}, {pool, library}})
+	require.NoError(t, err)
+
+	require.Equal(t, [][]int{{carol, bob, alice}, {yes}, {pool, library}}, node3.GetElections()[0].MyContestSelections)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+
+		contestResults := node.GetElections()[0].ContestResults
+		require.Len(t, contestResults, 3)
+		require.Equal(t, map[int]uint{alice: 1, bob: 2, carol: 0}, contestResults[0].Results)
+		require.Equal(t, map[int]uint{yes: 2, no: 1}, contestResults[1].Results)
+		require.Equal(t, map[int]uint{park: 1, library: 3, pool: 1}, contestResults[2].Results)
+	}
+
+	// > the ranked contest publishes its rankings and rounds
+	contestResults := node3.GetElections()[0].ContestResults
+	require.ElementsMatch(t, [][]int{{alice, bob, carol}, {bob, carol, alice}, {carol, bob, alice}}, contestResults[0].Rankings)
+	require.Len(t, contestResults[0].Rounds, 2)
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// makeSignedBallot one-hot encrypts the choice at index choice and signs the
+// ballot with the voter key
+func makeSignedBallot(t *testing.T, election *types.Election, choice int, voterKey *ecdsa.PrivateKey) types.VoteMessage {
+	g, err := group.ForSuite(election.Base.Suite)
+	require.NoError(t, err)
+	publicKey := election.GetPublicKey()
+
+	ballot := types.VoteMessage{ElectionID: election.Base.ElectionID}
+	rSum := new(big.Int)
+	for i := range election.Base.Choices {
+		bit := i == choice
+		msg := big.NewInt(0)
+		if bit {
+			msg = big.NewInt(1)
+		}
+
+		rScalar := impl.GenerateRandomBigInt(g.Order())
+		rSum.Add(rSum, &rScalar)
+		ct, err := impl.ElGamalEncryption(g, &publicKey, &rScalar, msg)
+		require.NoError(t, err)
+		proof, err := impl.ProveEncryptedBit(&rScalar, publicKey, *ct, bit, g)
+		require.NoError(t, err)
+
+		ballot.EncryptedVotes = append(ballot.EncryptedVotes, *ct)
+		ballot.CorrectVoteProofs = append(ballot.CorrectVoteProofs, *proof)
+	}
+	rSum.Mod(rSum, g.Order())
+
+	sum, err := impl.ElGamalAddCipherTexts(g, ballot.EncryptedVotes)
+	require.NoError(t, err)
+	sumProof, err := impl.ProveEncryptedOne(rSum, publicKey, sum, g)
+	require.NoError(t, err)
+	ballot.SumProof = *sumProof
+
+	signature, err := transport.SignDigest(voterKey, impl.BallotHash(&ballot))
+	require.NoError(t, err)
+	ballot.VoterKey = transport.MarshalPublicKey(&voterKey.PublicKey)
+	ballot.VoterSignature = signature
+
+	return ballot
+}
+
+// Only the voters on the eligibility roll may vote, once each. The vote policy
+// tells which of the ballots of a voter counts.
+func Test_EligibilityRoll(t *testing.T) {
+	for _, policy := range []types.VotePolicy{types.FirstVoteCounts, types.LastVoteCounts} {
+		t.Run(policy.String(), func(t *testing.T) {
+			transp := channel.NewTransport()
+
+			node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+			defer node1.Stop()
+
+			node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+			defer node2.Stop()
+
+			node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+			defer node3.Stop()
+
+			node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+			node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+			node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+			voterKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+
+			choices := []string{"Yes", "No"}
+			mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+			// > node3 is not on the roll, the voter key is
+			roll := [][]byte{node1.GetVoterKey(), node2.GetVoterKey(), transport.MarshalPublicKey(&voterKey.PublicKey)}
+
+			_, err = node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+				time.Second*3, peer.WithEligibleVoters([]byte("not a key")))
+			require.Error(t, err)
+
+			electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+				time.Second*3, peer.WithEligibleVoters(roll...), peer.WithVotePolicy(policy))
+			require.NoError(t, err)
+
+			waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+			election := node3.GetElections()[0]
+			require.Equal(t, roll, election.Base.EligibleVoters)
+			yes := election.Base.Choices[0].ChoiceID
+			no := election.Base.Choices[1].ChoiceID
+
+			_, err = node1.Vote(context.Background(), electionID, yes)
+			require.NoError(t, err)
+
+			_, err = node2.Vote(context.Background(), electionID, no)
+			require.NoError(t, err)
+
+			// > the ballot of node3 is never acknowledged
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+			defer cancel()
+			_, err = node3.Vote(ctx, electionID, yes)
+			require.Error(t, err)
+
+			// > the voter sends a yes, then a no
+			for _, choice := range []int{0, 1} {
+				ballot := makeSignedBallot(t, election, choice, voterKey)
+				transpMsg, err := node3.GetRegistry().MarshalMessage(&ballot)
+				require.NoError(t, err)
+
+				err = node3.Unicast(node1.GetAddr(), transpMsg)
+				require.NoError(t, err)
+
+				time.Sleep(time.Millisecond * 300)
+			}
+
+			// > the first mixnet server keeps one ballot per voter
+			votes := node1.GetElections()[0].Votes
+			require.Len(t, votes, 3)
+			voterKeys := make([][]byte, len(votes))
+			for i, vote := range votes {
+				voterKeys[i] = vote.VoterKey
+			}
+			require.ElementsMatch(t, roll, voterKeys)
+
+			expected := map[int]uint{yes: 2, no: 1}
+			if policy == types.LastVoteCounts {
+				expected = map[int]uint{yes: 1, no: 2}
+			}
+
+			for _, node := range []z.TestNode{node1, node2, node3} {
+				waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+				require.Equal(t, expected, node.GetElections()[0].Results)
+			}
+
+			// > the mixed ballots are not signed
+			for _, msg := range node2.GetRegistry().GetMessages() {
+				mixMessage, ok := msg.(*types.MixMessage)
+				if !ok {
+					continue
+				}
+				for _, vote := range mixMessage.Votes {
+					require.Empty(t, vote.VoterKey)
+					require.Empty(t, vote.VoterSignature)
+				}
+			}
+
+			require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+		})
+	}
+}
+
+// With anonymous credentials, the voters on the roll register a pseudonym
+// blindly signed by the announcer, and sign their ballots with it.
+func Test_AnonymousCredentials(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+	roll := [][]byte{node1.GetVoterKey(), node2.GetVoterKey()}
+
+	// > the credentials are only issued to the voters on a roll
+	_, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4, peer.WithAnonymousCredentials())
+	require.Error(t, err)
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4, peer.WithAnonymousCredentials(), peer.WithEligibleVoters(roll...))
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	require.NotEmpty(t, election.Base.CredentialKey)
+	yes := election.Base.Choices[0].ChoiceID
+	no := election.Base.Choices[1].ChoiceID
+
+	// > a voter registers before voting
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.Error(t, err)
+
+	require.NoError(t, node1.Register(context.Background(), electionID))
+	require.NoError(t, node2.Register(context.Background(), electionID))
+
+	// > registering again keeps the same credential
+	credential := node1.GetElections()[0].MyCredential
+	require.NoError(t, node1.Register(context.Background(), electionID))
+	require.Equal(t, credential, node1.GetElections()[0].MyCredential)
+
+	// > node3 is not on the roll
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*500)
+	defer cancel()
+	require.Error(t, node3.Register(ctx, electionID))
+
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	// > a pseudonym without credential can't vote
+	pseudonym, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ballot := makeSignedBallot(t, election, 0, pseudonym)
+	transpMsg, err := node3.GetRegistry().MarshalMessage(&ballot)
+	require.NoError(t, err)
+	require.NoError(t, node3.Unicast(node1.GetAddr(), transpMsg))
+
+	time.Sleep(time.Millisecond * 300)
+
+	// > the ballots are signed by pseudonyms holding a credential, not by the
+	// keys on the roll
+	announcerElection := node1.GetElections()[0]
+	require.Len(t, announcerElection.IssuedCredentials, 2)
+	require.Len(t, announcerElection.Votes, 2)
+	for _, vote := range announcerElection.Votes {
+		require.NotContains(t, roll, vote.VoterKey)
+		require.True(t, impl.VerifyCredential(announcerElection, vote.VoterKey, vote.Credential))
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{yes: 1, no: 1}, node.GetElections()[0].Results)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// A voter which is not a mixnet server, and not a neighbor of the announcer,
+// gets its credential: the announcer answers the voter, not the peer which
+// relayed its request.
+func Test_AnonymousCredentials_MultiHop(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	// node1 <-> node2 <-> node3
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+	roll := [][]byte{node2.GetVoterKey(), node3.GetVoterKey()}
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4, peer.WithAnonymousCredentials(), peer.WithEligibleVoters(roll...))
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+	defer cancel()
+
+	require.NoError(t, node3.Register(ctx, electionID))
+	require.NoError(t, node2.Register(ctx, electionID))
+
+	_, err = node3.Vote(context.Background(), electionID, 0)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), electionID, 0)
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{0: 2, 1: 0}, node.GetElections()[0].Results)
+	}
+}
+
+// A voter prepares a ballot and audits it: the revealed randomness shows that
+// it encrypts the selected choice, and the audited ballot is never cast. The
+// voter then prepares and casts a new ballot.
+func Test_BallotAudit(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4)
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	yes := election.Base.Choices[0].ChoiceID
+	no := election.Base.Choices[1].ChoiceID
+
+	// > nothing to cast or audit before a ballot is prepared
+	_, err = node3.CastBallot(context.Background(), electionID)
+	require.Error(t, err)
+	_, err = node3.AuditBallot(electionID)
+	require.Error(t, err)
+
+	_, err = node3.PrepareBallot(context.Background(), electionID, [][]int{{yes, no}})
+	require.Error(t, err)
+
+	audited, err := node3.PrepareBallot(context.Background(), electionID, [][]int{{yes}})
+	require.NoError(t, err)
+
+	audit, err := node3.AuditBallot(electionID)
+	require.NoError(t, err)
+	require.Equal(t, audited.BallotHash, impl.BallotHash(&audit.Ballot))
+	require.Equal(t, [][]int{{yes}}, audit.Selections)
+	require.NoError(t, impl.VerifyBallotAudit(election, &audit))
+
+	// > the ballot doesn't encrypt the other choice
+	audit.Selections = [][]int{{no}}
+	require.Error(t, impl.VerifyBallotAudit(election, &audit))
+
+	// > the audited ballot is discarded
+	_, err = node3.CastBallot(context.Background(), electionID)
+	require.Error(t, err)
+
+	prepared, err := node3.PrepareBallot(context.Background(), electionID, [][]int{{yes}})
+	require.NoError(t, err)
+	require.NotEqual(t, audited.BallotHash, prepared.BallotHash)
+
+	receipt, err := node3.CastBallot(context.Background(), electionID)
+	require.NoError(t, err)
+	require.Equal(t, prepared.BallotHash, receipt.BallotHash)
+
+	_, err = node3.AuditBallot(electionID)
+	require.Error(t, err)
+
+	_, err = node1.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 300)
+
+	announcerElection := node1.GetElections()[0]
+	require.Len(t, announcerElection.Votes, 3)
+	for _, vote := range announcerElection.Votes {
+		require.NotEqual(t, audited.BallotHash, impl.BallotHash(&vote))
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{yes: 2, no: 1}, node.GetElections()[0].Results)
+	}
+	require.Equal(t, yes, node3.GetElections()[0].MyVote)
+}
+
+// Audits also check ranked and approval selections, contest by contest.
+func Test_BallotAudit_Contests(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node1.AddPeer(node2.GetAddr())
+	node2.AddPeer(node1.GetAddr())
+
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("General election", "", nil, mixnetServers, time.Second*4,
+		peer.WithContest("Mayor", []string{"Alice", "Bob", "Carol"}, peer.WithBallotType(types.BallotRanked)),
+		peer.WithContest("Council", []string{"Dan", "Eve", "Fay"}, peer.WithMaxSelections(2)))
+	require.NoError(t, err)
+
+	waitForPhase(t, node2, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node2.GetElections()[0]
+	selections := [][]int{{2, 0, 1}, {1, 2}}
+
+	_, err = node2.PrepareBallot(context.Background(), electionID, selections)
+	require.NoError(t, err)
+
+	audit, err := node2.AuditBallot(electionID)
+	require.NoError(t, err)
+	require.NoError(t, impl.VerifyBallotAudit(election, &audit))
+
+	audit.Selections = [][]int{{2, 1, 0}, {1, 2}}
+	require.Error(t, impl.VerifyBallotAudit(election, &audit))
+
+	audit.Selections = [][]int{{2, 0, 1}, {1}}
+	require.Error(t, impl.VerifyBallotAudit(election, &audit))
+}
+
+// A voter casts a second ballot which replaces the first one. The ballots carry
+// the encrypted credential the voter registered, and no voter key: the
+// plaintext equivalence tests keep the last ballot of each credential without
+// telling whose ballots they are.
+func Test_Revoting(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4, peer.WithRevoting())
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	yes := election.Base.Choices[0].ChoiceID
+	no := election.Base.Choices[1].ChoiceID
+
+	// > node3 is coerced into voting yes, then overrides its ballot
+	coerced, err := node3.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	last, err := node3.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+	require.NotEqual(t, coerced.BallotHash, last.BallotHash)
+	require.Equal(t, uint64(2), node3.GetElections()[0].MyBallotCounter)
+
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	time.Sleep(time.Millisecond * 300)
+
+	// > the first mixnet server stores every ballot, none of them identifies
+	// its voter, and the credential is encrypted anew in each of them
+	announcerElection := node1.GetElections()[0]
+	require.Len(t, announcerElection.Votes, 4)
+	for _, ballot := range announcerElection.Votes {
+		require.Empty(t, ballot.VoterKey)
+		require.Empty(t, ballot.VoterSignature)
+	}
+	require.Equal(t, coerced.BallotHash, impl.BallotHash(&announcerElection.Votes[0]))
+	require.Equal(t, last.BallotHash, impl.BallotHash(&announcerElection.Votes[1]))
+	require.NotEqual(t, announcerElection.Votes[0].EncryptedCredential, announcerElection.Votes[1].EncryptedCredential)
+
+	// > each voter registered one credential
+	var roll []types.RegisterCredentialMessage
+	for _, entry := range node3.GetBulletinBoard(electionID).Entries {
+		if entry.Type != (types.RegisterCredentialMessage{}).Name() {
+			continue
+		}
+		registration := types.RegisterCredentialMessage{}
+		require.NoError(t, json.Unmarshal(entry.Payload, &registration))
+		roll = append(roll, registration)
+	}
+	require.Len(t, roll, 3)
+
+	// > the encrypted credential can't be copied to another ballot
+	require.NoError(t, impl.VerifyBallotCredential(announcerElection, roll, &announcerElection.Votes[1]))
+	forged := announcerElection.Votes[3]
+	forged.EncryptedCredential = announcerElection.Votes[1].EncryptedCredential
+	forged.CredentialSignature = announcerElection.Votes[1].CredentialSignature
+	forged.RollSize = announcerElection.Votes[1].RollSize
+	forged.RollProof = announcerElection.Votes[1].RollProof
+	require.Error(t, impl.VerifyBallotCredential(announcerElection, roll, &forged))
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{yes: 1, no: 2}, node.GetElections()[0].Results)
+	}
+
+	// > the first mixnet server kept the last ballot of each credential
+	require.Len(t, node1.GetElections()[0].Votes, 3)
+
+	// > the equivalence tests are on the bulletin board, signed by the first
+	// mixnet server
+	board := node3.GetBulletinBoard(electionID)
+	petRequests := 0
+	for _, entry := range board.Entries {
+		if entry.Type != (types.PETRequestMessage{}).Name() {
+			continue
+		}
+		petRequests++
+
+		request := types.PETRequestMessage{}
+		require.NoError(t, json.Unmarshal(entry.Payload, &request))
+		require.Equal(t, 4, request.BallotCnt)
+		require.Equal(t, 0, request.MixnetServerID)
+	}
+	require.Equal(t, 2, petRequests)
+
+	require.NoError(t, impl.VerifyBulletinBoard(board))
+}
+
+// The announcer cancels an open election: every peer aborts it, no ballot is
+// accepted anymore and the initiator doesn't mix. Only the announcer can
+// cancel it.
+func Test_CancelElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node2.GetAddr(), node3.GetAddr()}
+
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*2)
+	require.NoError(t, err)
+
+	waitForPhase(t, node1, electionID, types.PhaseOpen, time.Second*5)
+
+	yes := node1.GetElections()[0].Base.Choices[0].ChoiceID
+
+	// > only the announcer may cancel the election
+	require.Error(t, node2.CancelElection(electionID, "wrong question"))
+
+	forged := types.CancelElectionMessage{
+		ElectionID: electionID,
+		Reason:     "wrong question",
+		Signature:  []byte("forged"),
+	}
+	transpMsg, err := node2.GetRegistry().MarshalMessage(&forged)
+	require.NoError(t, err)
+	require.NoError(t, node2.Unicast(node1.GetAddr(), transpMsg))
+
+	time.Sleep(time.Millisecond * 300)
+	require.Equal(t, types.PhaseOpen, node1.GetElections()[0].Phase)
+
+	require.NoError(t, node1.CancelElection(electionID, "wrong question"))
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseAborted, time.Second*2)
+		require.Equal(t, "wrong question", node.GetElections()[0].CancelReason)
+	}
+
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.Error(t, err)
+	require.Error(t, node1.CancelElection(electionID, "again"))
+
+	// > the initiator doesn't mix once the election expires
+	time.Sleep(time.Second * 3)
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		election := node.GetElections()[0]
+		require.Equal(t, types.PhaseAborted, election.Phase)
+		require.Empty(t, election.Results)
+	}
+
+	require.Error(t, impl.VerifyBulletinBoard(node1.GetBulletinBoard(electionID)))
+}
+
+// The announcer extends an open election: the peers close it and the initiator
+// mixes it at the new expiration only, ballots are accepted until then.
+func Test_ExtendElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	electionID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*2)
+	require.NoError(t, err)
+
+	waitForPhase(t, node3, electionID, types.PhaseOpen, time.Second*5)
+
+	election := node3.GetElections()[0]
+	yes := election.Base.Choices[0].ChoiceID
+	no := election.Base.Choices[1].ChoiceID
+	expiration := election.Base.Expiration
+
+	// > only the announcer may extend the election
+	require.Error(t, node1.ExtendElection(electionID, time.Second*2))
+	require.Error(t, node3.ExtendElection(electionID, 0))
+
+	require.NoError(t, node3.ExtendElection(electionID, time.Second*2))
+
+	time.Sleep(time.Millisecond * 300)
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		require.Equal(t, expiration.Add(time.Second*2).UnixNano(), node.GetElections()[0].Base.Expiration.UnixNano())
+	}
+
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	// > the election is still open after its first expiration
+	time.Sleep(time.Until(expiration) + time.Millisecond*500)
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		require.Equal(t, types.PhaseOpen, node.GetElections()[0].Phase)
+	}
+
+	_, err = node2.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	_, err = node3.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{yes: 1, no: 2}, node.GetElections()[0].Results)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// An election scheduled ahead of time generates its key right away, but only
+// accepts votes from its opening time until its closing time.
+func Test_ScheduledElection(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	// > the election must close after it opens, and in the future
+	now := time.Now()
+	_, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		0, peer.WithSchedule(now.Add(time.Second), now))
+	require.Error(t, err)
+	_, err = node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		0, peer.WithSchedule(now.Add(-time.Second*2), now.Add(-time.Second)))
+	require.Error(t, err)
+
+	opening := time.Now().Add(time.Second * 3)
+	closing := opening.Add(time.Second * 2)
+
+	electionID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		0, peer.WithSchedule(opening, closing))
+	require.NoError(t, err)
+
+	// > the key is generated ahead of time, but the election is not open yet
+	require.Eventually(t, func() bool {
+		return node3.GetElections()[0].IsElectionStarted()
+	}, time.Second*3, time.Millisecond*100)
+	require.True(t, time.Now().Before(opening))
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		election := node.GetElections()[0]
+		require.Equal(t, types.PhaseDKG, election.Phase)
+		require.Equal(t, opening.UnixNano(), election.Base.OpeningTime.UnixNano())
+		require.Equal(t, closing.UnixNano(), election.Base.Expiration.UnixNano())
+	}
+
+	yes := node3.GetElections()[0].Base.Choices[0].ChoiceID
+	no := node3.GetElections()[0].Base.Choices[1].ChoiceID
+
+	// > a vote waits for the opening
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+	require.False(t, time.Now().Before(opening))
+
+	_, err = node2.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	_, err = node3.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		require.Equal(t, map[int]uint{yes: 2, no: 1}, node.GetElections()[0].Results)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// An election which doesn't reach its quorum finishes without results: the
+// first mixnet server publishes the signed outcome instead of mixing.
+func Test_Quorum(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+	roll := [][]byte{node1.GetVoterKey(), node2.GetVoterKey(), node3.GetVoterKey()}
+
+	// > a quorum in percent needs an eligibility roll
+	_, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*2, peer.WithQuorumPercent(50))
+	require.Error(t, err)
+	_, err = node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*2, peer.WithEligibleVoters(roll...), peer.WithQuorumPercent(150))
+	require.Error(t, err)
+
+	// > 2 ballots out of 3 eligible voters, with a quorum of 100%
+	invalidID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4, peer.WithEligibleVoters(roll...), peer.WithQuorumPercent(100))
+	require.NoError(t, err)
+
+	// > 2 ballots, with a quorum of 2
+	validID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*4, peer.WithQuorum(2))
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2} {
+		waitForPhase(t, node, invalidID, types.PhaseOpen, time.Second*5)
+		waitForPhase(t, node, validID, types.PhaseOpen, time.Second*5)
+	}
+
+	// > both elections are open together, the ballots are cast in parallel
+	wg := sync.WaitGroup{}
+	for _, electionID := range []string{invalidID, validID} {
+		for choiceID, node := range []z.TestNode{node1, node2} {
+			wg.Add(1)
+			go func(node z.TestNode, electionID string, choiceID int) {
+				defer wg.Done()
+				_, err := node.Vote(context.Background(), electionID, choiceID)
+				require.NoError(t, err)
+			}(node, electionID, choiceID)
+		}
+	}
+	wg.Wait()
+
+	getElection := func(node z.TestNode, electionID string) *types.Election {
+		for _, election := range node.GetElections() {
+			if election.Base.ElectionID == electionID {
+				return election
+			}
+		}
+		return nil
+	}
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, invalidID, types.PhaseFinished, time.Second*20)
+		election := getElection(node, invalidID)
+		require.True(t, election.QuorumNotReached)
+		require.Empty(t, election.Results)
+
+		waitForPhase(t, node, validID, types.PhaseFinished, time.Second*20)
+		election = getElection(node, validID)
+		require.False(t, election.QuorumNotReached)
+		require.Equal(t, map[int]uint{0: 1, 1: 1}, election.Results)
+	}
+
+	// > the outcome is verified against the published ballots
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(invalidID)))
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(validID)))
+}
+
+// The messages of the key generation are signed by the mixnet server they
+// come from, with its pinned key, and name mixnet servers of the election.
+func Test_DKGMessageSignatures(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		var err error
+		keys[i], err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+	}
+
+	election := &types.Election{
+		Base: types.ElectionBase{
+			ElectionID:    "election",
+			MixnetServers: []string{"server0", "server1"},
+			MixnetServerKeys: [][]byte{transport.MarshalPublicKey(&keys[0].PublicKey),
+				transport.MarshalPublicKey(&keys[1].PublicKey)},
+			Threshold: 1,
+		},
+	}
+
+	// > a share is signed by its dealer
+	share := CreateInvalidDKGShareMessage(t, "election", 0, 1, keys[1])
+	require.NoError(t, impl.VerifyDKGShare(election, &share))
+
+	share = CreateInvalidDKGShareMessage(t, "election", 0, 1, keys[0])
+	require.Error(t, impl.VerifyDKGShare(election, &share))
+
+	share = CreateInvalidDKGShareMessage(t, "election", 0, 2, keys[1])
+	require.Error(t, impl.VerifyDKGShare(election, &share))
+
+	// > a validation is signed by its validator, about a mixnet server of the
+	// election
+	validation := CreateDKGShareValidationMessage(t, "election", 0, 1, keys[1])
+	require.NoError(t, impl.VerifyDKGShareValidation(election, &validation))
+
+	validation.ValidatorID = 0
+	require.Error(t, impl.VerifyDKGShareValidation(election, &validation))
+
+	validation = CreateDKGShareValidationMessage(t, "election", 5, 1, keys[1])
+	require.Error(t, impl.VerifyDKGShareValidation(election, &validation))
+
+	// > a share is revealed by its dealer, to a mixnet server of the election
+	reveal := CreateDKGShareRevealMessage(t, "election", 1, 0, keys[1])
+	require.NoError(t, impl.VerifyDKGRevealShare(election, &reveal))
+
+	reveal.Share = *big.NewInt(2)
+	require.Error(t, impl.VerifyDKGRevealShare(election, &reveal))
+
+	reveal = CreateDKGShareRevealMessage(t, "election", 1, -1, keys[1])
+	require.Error(t, impl.VerifyDKGRevealShare(election, &reveal))
+}
+
+// Mixnet servers which don't answer during the key generation are
+// disqualified at its deadlines: the election goes on if enough mixnet
+// servers qualify, it is aborted otherwise.
+func Test_DKGTimeout(t *testing.T) {
+	transp := channel.NewTransport()
+
+	// offline mixnet servers, which never send their shares
+	offline1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	offline1.Stop()
+
+	offline2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	offline2.Stop()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithDKGTimeout(time.Second))
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithDKGTimeout(time.Second))
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithDKGTimeout(time.Second))
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+
+	t.Run("enough mixnet servers qualify", func(t *testing.T) {
+		// > 2 out of 3 mixnet servers answer, 2 are needed
+		mixnetServers := []string{node1.GetAddr(), node2.GetAddr(), offline1.GetAddr()}
+
+		electionID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices,
+			mixnetServers, time.Second*2)
+		require.NoError(t, err)
+
+		for _, node := range []z.TestNode{node1, node2, node3} {
+			waitForPhase(t, node, electionID, types.PhaseOpen, time.Second*10)
+		}
+
+		_, err = node1.Vote(context.Background(), electionID, 0)
+		require.NoError(t, err)
+
+		_, err = node2.Vote(context.Background(), electionID, 1)
+		require.NoError(t, err)
+
+		_, err = node3.Vote(context.Background(), electionID, 0)
+		require.NoError(t, err)
+
+		for _, node := range []z.TestNode{node1, node2, node3} {
+			waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		}
+
+		var election *types.Election
+		for _, e := range node3.GetElections() {
+			if e.Base.ElectionID == electionID {
+				election = e
+			}
+		}
+		require.Equal(t, map[int]uint{0: 2, 1: 1}, election.Results)
+		require.Equal(t, []int{2, 2, 0}, election.Base.MixnetServersPoints)
+
+		require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+	})
+
+	t.Run("too few mixnet servers qualify", func(t *testing.T) {
+		// > 1 out of 3 mixnet servers answers, 2 are needed
+		mixnetServers := []string{node1.GetAddr(), offline1.GetAddr(), offline2.GetAddr()}
+
+		electionID, err := node3.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices,
+			mixnetServers, time.Second*2)
+		require.NoError(t, err)
+
+		for _, node := range []z.TestNode{node1, node2, node3} {
+			waitForPhase(t, node, electionID, types.PhaseAborted, time.Second*10)
+		}
+	})
+}
+
+// The messages of an election which arrive before its announcement are parked,
+// and replayed once the announcement is received.
+func Test_PendingMessages(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	mixnetServer, err := transp.CreateSocket("127.0.0.1:0")
+	require.NoError(t, err)
+
+	node1.AddPeer(mixnetServer.GetAddress())
+
+	signingKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	send := func(msg types.Message) {
+		transpMsg, err := node1.GetRegistry().MarshalMessage(msg)
+		require.NoError(t, err)
+
+		header := transport.NewHeader(mixnetServer.GetAddress(), mixnetServer.GetAddress(), node1.GetAddr(), 0)
+		packet := transport.Packet{Header: &header, Msg: &transpMsg}
+		require.NoError(t, packet.Sign(signingKey))
+
+		err = mixnetServer.Send(node1.GetAddr(), packet, 0)
+		require.NoError(t, err)
+	}
+
+	electionID := "early-election"
+
+	// > the election is ready and started before node1 knows about it
+	electionReadyMessage := CreateElectionReadyMessage(t, electionID, 0, []int{0}, signingKey)
+	send(&electionReadyMessage)
+
+	startElectionMessage := types.StartElectionMessage{
+		ElectionID: electionID,
+		Expiration: time.Now().Add(time.Minute),
+		Initiator:  mixnetServer.GetAddress(),
+	}
+	require.NoError(t, impl.SignStartElection(signingKey, &startElectionMessage))
+	send(&startElectionMessage)
+
+	time.Sleep(time.Millisecond * 100)
+	require.Empty(t, node1.GetElections())
+
+	announceElectionMessage := types.AnnounceElectionMessage{
+		Base: types.ElectionBase{
+			ElectionID:          electionID,
+			Announcer:           mixnetServer.GetAddress(),
+			AnnouncerKey:        transport.MarshalPublicKey(&signingKey.PublicKey),
+			Title:               "Referendum",
+			Description:         "Should El Cidad have a new mayor?",
+			Choices:             []types.Choice{{ChoiceID: 0, Name: "Yes"}, {ChoiceID: 1, Name: "No"}},
+			MixnetServers:       []string{mixnetServer.GetAddress()},
+			MixnetServerKeys:    [][]byte{transport.MarshalPublicKey(&signingKey.PublicKey)},
+			MixnetServersPoints: []int{0},
+			Threshold:           1,
+			Initiators:          map[string]types.Point{},
+			Duration:            time.Minute,
+			Expiration:          time.Now().Add(time.Minute),
+		},
+	}
+	send(&announceElectionMessage)
+
+	// > the parked messages open the election
+	waitForPhase(t, node1, electionID, types.PhaseOpen, time.Second*5)
+
+	elections := node1.GetElections()
+	require.Len(t, elections, 1)
+	require.Equal(t, []int{1}, elections[0].Base.MixnetServersPoints)
+	require.Equal(t, 1, elections[0].Base.ElectionReadyCnt)
+}
+
+// Elections don't share a lock: two elections run side by side on the same
+// peers.
+func Test_ConcurrentElections(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	nodes := []z.TestNode{node1, node2, node3}
+	choices := []string{"Yes", "No"}
+
+	electionIDs := make([]string, 2)
+	errs := make(chan error, 2)
+
+	// > both elections are announced at the same time, by different peers
+	go func() {
+		var err error
+		electionIDs[0], err = node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices,
+			[]string{node1.GetAddr(), node2.GetAddr()}, time.Second*8)
+		errs <- err
+	}()
+	go func() {
+		var err error
+		electionIDs[1], err = node3.AnnounceElection("Referendum", "Should El Cidad have a new park?", choices,
+			[]string{node3.GetAddr(), node2.GetAddr()}, time.Second*8)
+		errs <- err
+	}()
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+
+	for _, node := range nodes {
+		for _, electionID := range electionIDs {
+			waitForPhase(t, node, electionID, types.PhaseOpen, time.Second*5)
+		}
+	}
+
+	// > every peer votes "Yes" in the first election and "No" in the second
+	// one, in parallel
+	wg := sync.WaitGroup{}
+	for _, node := range nodes {
+		for i, electionID := range electionIDs {
+			wg.Add(1)
+			go func(node z.TestNode, electionID string, choiceID int) {
+				defer wg.Done()
+				_, err := node.Vote(context.Background(), electionID, choiceID)
+				require.NoError(t, err)
+			}(node, electionID, i)
+		}
+	}
+	wg.Wait()
+
+	// > the elections are snapshots, which can be read while the handlers
+	// change the stored elections
+	stop := make(chan struct{})
+	readers := sync.WaitGroup{}
+	for _, node := range nodes {
+		readers.Add(1)
+		go func(node z.TestNode) {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-time.After(time.Millisecond):
+				}
+				for _, election := range node.GetElections() {
+					_ = len(election.Votes) + len(election.Results)
+					election.Results = nil
+				}
+			}
+		}(node)
+	}
+
+	for _, node := range nodes {
+		for _, electionID := range electionIDs {
+			waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*20)
+		}
+	}
+
+	close(stop)
+	readers.Wait()
+
+	for _, election := range node2.GetElections() {
+		if election.Base.ElectionID == electionIDs[0] {
+			require.Equal(t, map[int]uint{0: 3, 1: 0}, election.Results)
+		} else {
+			require.Equal(t, map[int]uint{0: 0, 1: 3}, election.Results)
+		}
+	}
+}
+
+// The announcer runs its elections in P-384: the key generation, the ballots,
+// the mixing, the equivalence tests of the re-voting and the decryption all
+// happen in that group, and the bulletin board verifies.
+func Test_P384Election(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithPedersenSuite(types.P384Suite))
+	defer node1.Stop()
+
+	node2 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node2.Stop()
+
+	node3 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0")
+	defer node3.Stop()
+
+	node1.AddPeer(node2.GetAddr(), node3.GetAddr())
+	node2.AddPeer(node1.GetAddr(), node3.GetAddr())
+	node3.AddPeer(node1.GetAddr(), node2.GetAddr())
+
+	choices := []string{"Yes", "No"}
+	mixnetServers := []string{node1.GetAddr(), node2.GetAddr()}
+
+	// > P-384 is slow, the window leaves room for the four ballots
+	electionID, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", choices, mixnetServers,
+		time.Second*12, peer.WithRevoting())
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseOpen, time.Second*10)
+	}
+
+	election := node3.GetElections()[0]
+	require.Equal(t, types.P384Suite, election.Base.Suite)
+
+	// > the election key is a point of P-384
+	publicKey := election.GetPublicKey()
+	require.True(t, elliptic.P384().IsOnCurve(&publicKey.X, &publicKey.Y))
+
+	yes := election.Base.Choices[0].ChoiceID
+	no := election.Base.Choices[1].ChoiceID
+
+	_, err = node3.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	_, err = node3.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	_, err = node1.Vote(context.Background(), electionID, yes)
+	require.NoError(t, err)
+
+	_, err = node2.Vote(context.Background(), electionID, no)
+	require.NoError(t, err)
+
+	for _, node := range []z.TestNode{node1, node2, node3} {
+		waitForPhase(t, node, electionID, types.PhaseFinished, time.Second*40)
+		require.Equal(t, map[int]uint{yes: 1, no: 2}, node.GetElections()[0].Results)
+	}
+
+	require.NoError(t, impl.VerifyBulletinBoard(node3.GetBulletinBoard(electionID)))
+}
+
+// An election can't be announced in an unknown group
+func Test_UnknownPedersenSuite(t *testing.T) {
+	transp := channel.NewTransport()
+
+	node1 := z.NewTestNode(t, peerFac, transp, "127.0.0.1:0", z.WithPedersenSuite("P-224"))
+	defer node1.Stop()
+
+	_, err := node1.AnnounceElection("Referendum", "Should El Cidad have a new mayor?", []string{"Yes", "No"},
+		[]string{node1.GetAddr()}, time.Second*4)
+	require.Error(t, err)
+}
@@ -1,6 +1,7 @@
 package unit
 
 import (
+	"context"
 	"encoding/csv"
 	"github.com/stretchr/testify/require"
 	z "go.dedis.ch/cs438/internal/testing"
@@ -69,7 +70,7 @@ func GetPerformances(t *testing.T, numNodes, numMixnetNodes int, electionDuratio
 	for _, node := range nodes {
 		go func(n z.TestNode) {
 			defer wait.Done()
-			err = n.Vote(elections[0].Base.ElectionID, choiceID)
+			_, err = n.Vote(context.Background(), elections[0].Base.ElectionID, choiceID)
 			require.NoError(t, err)
 		}(node)
 	}
@@ -1,8 +1,11 @@
 package unit
 
 import (
+	"bytes"
 	"crypto/elliptic"
 	cryptorand "crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
@@ -11,10 +14,12 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/cs438/peer/impl"
+	"go.dedis.ch/cs438/peer/impl/group"
 )
 
 func Test_ZKP_Dlog_True(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	secret, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	if err != nil {
@@ -25,7 +30,7 @@ func Test_ZKP_Dlog_True(t *testing.T) {
 
 	pPoint := impl.NewPoint(px, py)
 
-	proof, err := impl.ProveDlog(secret, pPoint, curve)
+	proof, err := impl.ProveDlog(secret, pPoint, g)
 	if err != nil {
 		t.Errorf("Unexpected error, ProveDlog failed: %v", err)
 	}
@@ -42,6 +47,7 @@ func Test_ZKP_Dlog_True(t *testing.T) {
 
 func Test_ZKP_Dlog_False(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	secret, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	if err != nil {
@@ -55,7 +61,7 @@ func Test_ZKP_Dlog_False(t *testing.T) {
 
 	pPoint := impl.NewPoint(px, py)
 
-	proof, err := impl.ProveDlog(secret, pPoint, curve)
+	proof, err := impl.ProveDlog(secret, pPoint, g)
 	if err != nil {
 		t.Errorf("Unexpected error, Key Generation in test: %v", err)
 	}
@@ -70,6 +76,7 @@ func Test_ZKP_Dlog_False(t *testing.T) {
 
 func Test_ZKP_DlogEq_True(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	secret, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	if err != nil {
@@ -90,7 +97,7 @@ func Test_ZKP_DlogEq_True(t *testing.T) {
 
 	pPointOther := impl.NewPoint(pox, poy)
 
-	proof, err := impl.ProveDlogEq(secret, pPoint, bPointOther, pPointOther, curve)
+	proof, err := impl.ProveDlogEq(secret, pPoint, bPointOther, pPointOther, g)
 	if err != nil {
 		t.Errorf("Unexpected error, ProveDlogEq failed: %v", err)
 	}
@@ -105,6 +112,7 @@ func Test_ZKP_DlogEq_True(t *testing.T) {
 
 func Test_ZKP_DlogEq_False(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	secret, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	if err != nil {
@@ -127,7 +135,7 @@ func Test_ZKP_DlogEq_False(t *testing.T) {
 
 	pPointOther := impl.NewPoint(pox, poy)
 
-	proof, err := impl.ProveDlogEq(secret, pPoint, bPointOther, pPointOther, curve)
+	proof, err := impl.ProveDlogEq(secret, pPoint, bPointOther, pPointOther, g)
 
 	if err != nil {
 		t.Errorf("Unexpected error, ProveDlogEq failed: %v", err)
@@ -145,6 +153,7 @@ func Test_ZKP_DlogEq_False(t *testing.T) {
 func Test_ZKP_DlogSimulator(t *testing.T) {
 	curve := elliptic.P256()
 	curveParams := curve.Params()
+	g := group.P256()
 	_, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	if err != nil {
@@ -179,13 +188,16 @@ func Test_ZKP_DlogSimulator(t *testing.T) {
 	}
 
 	// Making sure the scalar is of order N
-	challBytes := trPrg.GetRandomness(impl.SCALAR_SIZE)
+	challBytes := trPrg.GetRandomness(g.ScalarLen())
 	challScalar := new(big.Int).SetBytes(challBytes)
 	challScalar = challScalar.Mod(challScalar, curveParams.N)
 
-	cPointCompressed, challBytes, result := impl.SimulatorDlog(challScalar.Bytes(), trPrg, pPoint, curve)
+	pElement, err := g.ElementFromPoint(pPoint)
+	require.NoError(t, err)
 
-	proof := impl.NewProofExtended(impl.DLOG_EQ_LABEL, curve, pPointCompressed, cPointCompressed, challBytes, result)
+	cPointCompressed, challBytes, result := impl.SimulatorDlog(challScalar.Bytes(), trPrg, pElement, g)
+
+	proof := impl.NewProofExtended(impl.DLOG_EQ_LABEL, g, pPointCompressed, cPointCompressed, challBytes, result)
 
 	isTrue := impl.VerifyDlogRelation(proof)
 
@@ -194,6 +206,7 @@ func Test_ZKP_DlogSimulator(t *testing.T) {
 
 func Test_ZKP_DlogEqOr_True(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	secret, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	fmt.Printf("In Test_ZKP_DlogEqOr_True, secret length: %v\n", len(secret))
@@ -220,9 +233,9 @@ func Test_ZKP_DlogEqOr_True(t *testing.T) {
 	fmt.Printf("In Test_ZKP_DlogEqOr_True, pPoint is: (%v, %v) \n", pPoint.X, pPoint.Y)
 	fmt.Printf("In Test_ZKP_DlogEqOr_True, bPointOther is: (%v, %v) \n", bPointOther.X, bPointOther.Y)
 
-	proof, err := impl.ProveDlogEqOr(secret, pPoint, bPointOther, pPointOther, curve, secretBit)
+	proof, err := impl.ProveDlogEqOr(secret, pPoint, bPointOther, pPointOther, g, secretBit)
 
-	// proof, err := impl.ProveDlogOr(secret, pPoint, bPointOther, secretBit, curve)
+	// proof, err := impl.ProveDlogOr(secret, pPoint, bPointOther, secretBit, g)
 
 	if err != nil {
 		t.Errorf("Unexpected error, ProveDlogOr failed: %v", err)
@@ -237,6 +250,7 @@ func Test_ZKP_DlogEqOr_True(t *testing.T) {
 
 func Test_ZKP_DlogOr_True(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	secret, px, py, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
 	fmt.Printf("In Test_ZKP_Dlog_True, secret length: %v\n", len(secret))
@@ -249,7 +263,7 @@ func Test_ZKP_DlogOr_True(t *testing.T) {
 
 	_, pox, poy, err := elliptic.GenerateKey(curve, cryptorand.Reader)
 
-	secretOther := make([]byte, impl.SCALAR_SIZE)
+	secretOther := make([]byte, g.ScalarLen())
 	secretOther[31] = 1 //set other secret scalar to 1
 
 	fmt.Printf("In Test_ZKP_DlogOr_True, length of secret is: %d \n", len(secret))
@@ -265,7 +279,7 @@ func Test_ZKP_DlogOr_True(t *testing.T) {
 	fmt.Printf("In Test_ZKP_DlogOr_True, pPoint is: (%v, %v) \n", pPoint.X, pPoint.Y)
 	fmt.Printf("In Test_ZKP_DlogOr_True, pPointOther is: (%v, %v) \n", pPointOther.X, pPointOther.Y)
 
-	proof, err := impl.ProveDlogOr(secret, pPoint, secretOther, pPointOther, secretBit, curve)
+	proof, err := impl.ProveDlogOr(secret, pPoint, secretOther, pPointOther, secretBit, g)
 
 	if err != nil {
 		t.Errorf("Unexpected error, ProveDlogOr failed: %v", err)
@@ -283,6 +297,7 @@ func Test_ZKP_DlogOr_True(t *testing.T) {
 
 func Test_ZKP_ElGamalReEncryption(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	curveParams := curve.Params()
 	pPoint := types.Point{}
 
@@ -319,12 +334,14 @@ func Test_ZKP_ElGamalReEncryption(t *testing.T) {
 
 	// fmt.Printf("Test_ZKP_ReEncryption: Check if msgPoint is on curve: %v\n", curve.IsOnCurve(msgPoint.X, msgPoint.Y))
 
-	ctBefore := impl.ElGamalEncryption(curve, &pPoint, encScalar, msgScalar)
+	ctBefore, err := impl.ElGamalEncryption(g, &pPoint, encScalar, msgScalar)
+	require.NoError(t, err)
 
 	// fmt.Printf("Test_ZKP_ReEncryption: ctBefore ct1 is: (%v,%v)\n", ctBeforeCt01.X, ctBeforeCt01.Y)
 	// fmt.Printf("Test_ZKP_ReEncryption: ctBefore ct2 is: (%v,%v)\n", ctBeforeCt02.X, ctBeforeCt02.Y)
 
-	ctAfter := impl.ElGamalReEncryption(curve, &pPoint, reEncScalar, ctBefore)
+	ctAfter, err := impl.ElGamalReEncryption(g, &pPoint, reEncScalar, ctBefore)
+	require.NoError(t, err)
 
 	checkScalar := new(big.Int).Add(encScalar, reEncScalar)
 	checkScalar = checkScalar.Mod(checkScalar, curveParams.N)
@@ -364,6 +381,7 @@ func Test_ZKP_ElGamalReEncryption(t *testing.T) {
 func Test_ZKP_Shuffle_Simple(t *testing.T) {
 	// Secret permutation for the proof
 	curve := elliptic.P256()
+	g := group.P256()
 	curveParams := curve.Params()
 
 	//var secret []byte
@@ -396,15 +414,15 @@ func Test_ZKP_Shuffle_Simple(t *testing.T) {
 	msg0 := new(big.Int).SetInt64(1)
 
 	ctListBefore := make([]types.ElGamalCipherText, 1)
-	ctListBefore[0] = *impl.ElGamalEncryption(curve, &pPoint, encRandomizerList[0], msg0)
+	ctListBefore[0] = *mustEncrypt(t, g, &pPoint, encRandomizerList[0], msg0)
 
 	ctListMed := make([]types.ElGamalCipherText, 1)
-	ctListMed[0] = *impl.ElGamalReEncryption(curve, &pPoint, &reEncRandomizerList[0], &ctListBefore[0])
+	ctListMed[0] = *mustReEncrypt(t, g, &pPoint, &reEncRandomizerList[0], &ctListBefore[0])
 
 	ctListAfter := make([]types.ElGamalCipherText, 1)
 	ctListAfter[permList[0]] = ctListMed[0]
 
-	shuffleInstance := impl.NewShuffleInstance(curve, pPoint, ctListBefore, ctListAfter)
+	shuffleInstance := impl.NewShuffleInstance(g, pPoint, ctListBefore, ctListAfter)
 	shuffleWitness := impl.NewShuffleWitness(permList, reEncRandomizerList)
 
 	shuffleProof, err := impl.ProveShuffle(shuffleInstance, shuffleWitness)
@@ -420,6 +438,7 @@ func Test_ZKP_Shuffle_Simple(t *testing.T) {
 func Test_ZKP_Shuffle(t *testing.T) {
 	// Secret permutation for the proof
 	curve := elliptic.P256()
+	g := group.P256()
 	curveParams := curve.Params()
 
 	//var secret []byte
@@ -498,9 +517,9 @@ func Test_ZKP_Shuffle(t *testing.T) {
 	msg2 := new(big.Int).SetInt64(1)
 
 	ctListBefore := make([]types.ElGamalCipherText, 3)
-	ctListBefore[0] = *impl.ElGamalEncryption(curve, &pPoint, &encRandomizerList[0], msg0)
-	ctListBefore[1] = *impl.ElGamalEncryption(curve, &pPoint, &encRandomizerList[1], msg1)
-	ctListBefore[2] = *impl.ElGamalEncryption(curve, &pPoint, &encRandomizerList[2], msg2)
+	ctListBefore[0] = *mustEncrypt(t, g, &pPoint, &encRandomizerList[0], msg0)
+	ctListBefore[1] = *mustEncrypt(t, g, &pPoint, &encRandomizerList[1], msg1)
+	ctListBefore[2] = *mustEncrypt(t, g, &pPoint, &encRandomizerList[2], msg2)
 
 	ctListMed := make([]types.ElGamalCipherText, 3)
 	ctListMed[0] = ctListBefore[permList[0]]
@@ -508,11 +527,11 @@ func Test_ZKP_Shuffle(t *testing.T) {
 	ctListMed[2] = ctListBefore[permList[2]]
 
 	ctListAfter := make([]types.ElGamalCipherText, 3)
-	ctListAfter[0] = *impl.ElGamalReEncryption(curve, &pPoint, &reEncRandomizerList[0], &ctListMed[0])
-	ctListAfter[1] = *impl.ElGamalReEncryption(curve, &pPoint, &reEncRandomizerList[1], &ctListMed[1])
-	ctListAfter[2] = *impl.ElGamalReEncryption(curve, &pPoint, &reEncRandomizerList[2], &ctListMed[2])
+	ctListAfter[0] = *mustReEncrypt(t, g, &pPoint, &reEncRandomizerList[0], &ctListMed[0])
+	ctListAfter[1] = *mustReEncrypt(t, g, &pPoint, &reEncRandomizerList[1], &ctListMed[1])
+	ctListAfter[2] = *mustReEncrypt(t, g, &pPoint, &reEncRandomizerList[2], &ctListMed[2])
 
-	shuffleInstance := impl.NewShuffleInstance(curve, pPoint, ctListBefore, ctListAfter)
+	shuffleInstance := impl.NewShuffleInstance(g, pPoint, ctListBefore, ctListAfter)
 	shuffleWitness := impl.NewShuffleWitness(permList, reEncRandomizerList)
 
 	shuffleProof, err := impl.ProveShuffle(shuffleInstance, shuffleWitness)
@@ -528,6 +547,7 @@ func Test_ZKP_Shuffle(t *testing.T) {
 
 func Test_MarshalPointList(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 
 	one := make([]byte, 32)
 	one[31] = 1 //Scalar is 1
@@ -555,7 +575,8 @@ func Test_MarshalPointList(t *testing.T) {
 	pointList[1] = bPoint
 	pointList[2] = cPoint
 
-	resultCompressed := impl.MarshalPointList(pointList, curve)
+	resultCompressed, err := impl.MarshalPointList(pointList, g)
+	require.NoError(t, err)
 
 	listExpected := make([][]byte, 3)
 	listExpected[0] = aCompressed
@@ -573,6 +594,7 @@ func Test_MarshalPointList(t *testing.T) {
 
 func Test_UnmarshalPointList(t *testing.T) {
 	curve := elliptic.P256()
+	g := group.P256()
 	// aPoint := types.Point{}
 	// bPoint := types.Point{}
 	// cPoint := types.Point{}
@@ -599,8 +621,10 @@ func Test_UnmarshalPointList(t *testing.T) {
 	pointList[1] = bPoint
 	pointList[2] = cPoint
 
-	resultCompressed := impl.MarshalPointList(pointList, curve)
-	resultReturned := impl.UnmarshalPointList(resultCompressed, curve)
+	resultCompressed, err := impl.MarshalPointList(pointList, g)
+	require.NoError(t, err)
+	resultReturned, err := impl.UnmarshalPointList(resultCompressed, g)
+	require.NoError(t, err)
 
 	for i := 0; i < 3; i++ {
 		// fmt.Printf("In Test_MarshalPointList, Checking for entry %d, expected: %v\n", i, listExpected[i])
@@ -638,3 +662,624 @@ func Test_makePermutationMatrix(t *testing.T) {
 	}
 
 }
+
+// zkpGroups are the groups the proofs of the ballots and of the mixing are
+// tested in
+var zkpGroups = []group.Group{group.P256(), group.P384()}
+
+// newPublicKey returns the point of a fresh secret key of the group
+func newPublicKey(t *testing.T, g group.Group) types.Point {
+	secret, err := g.RandomScalar()
+	require.NoError(t, err)
+
+	return g.BaseMul(secret).Point()
+}
+
+func mustEncrypt(t *testing.T, g group.Group, pPoint *types.Point, rScalar, msg *big.Int) *types.ElGamalCipherText {
+	ct, err := impl.ElGamalEncryption(g, pPoint, rScalar, msg)
+	require.NoError(t, err)
+
+	return ct
+}
+
+func mustReEncrypt(t *testing.T, g group.Group, pPoint *types.Point, rScalar *big.Int,
+	ct *types.ElGamalCipherText) *types.ElGamalCipherText {
+
+	reEncrypted, err := impl.ElGamalReEncryption(g, pPoint, rScalar, ct)
+	require.NoError(t, err)
+
+	return reEncrypted
+}
+
+func mustAdd(t *testing.T, g group.Group, cts []types.ElGamalCipherText) types.ElGamalCipherText {
+	sum, err := impl.ElGamalAddCipherTexts(g, cts)
+	require.NoError(t, err)
+
+	return sum
+}
+
+func Test_ZKP_EncryptedBit(t *testing.T) {
+	for _, g := range zkpGroups {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			pPoint := newPublicKey(t, g)
+
+			for _, bit := range []bool{false, true} {
+				msg := big.NewInt(0)
+				if bit {
+					msg = big.NewInt(1)
+				}
+
+				rScalar, err := cryptorand.Int(cryptorand.Reader, g.Order())
+				require.NoError(t, err)
+
+				ct := mustEncrypt(t, g, &pPoint, rScalar, msg)
+
+				proof, err := impl.ProveEncryptedBit(rScalar, pPoint, *ct, bit, g)
+				require.NoError(t, err)
+
+				require.True(t, impl.VerifyEncryptedBit(proof, g, pPoint, *ct))
+			}
+		})
+	}
+}
+
+func Test_ZKP_EncryptedBit_False(t *testing.T) {
+	g := group.P256()
+
+	pPoint := newPublicKey(t, g)
+
+	rScalar, err := cryptorand.Int(cryptorand.Reader, g.Order())
+	require.NoError(t, err)
+
+	// a ballot with a weight of 2 must be rejected
+	ct := mustEncrypt(t, g, &pPoint, rScalar, big.NewInt(2))
+
+	proof, err := impl.ProveEncryptedBit(rScalar, pPoint, *ct, true, g)
+	require.NoError(t, err)
+
+	require.False(t, impl.VerifyEncryptedBit(proof, g, pPoint, *ct))
+
+	// the proof must not be valid for another ciphertext
+	ctOne := mustEncrypt(t, g, &pPoint, rScalar, big.NewInt(1))
+	proof, err = impl.ProveEncryptedBit(rScalar, pPoint, *ctOne, true, g)
+	require.NoError(t, err)
+
+	require.False(t, impl.VerifyEncryptedBit(proof, g, pPoint, *ct))
+
+	// the proof must not be valid in another group
+	require.False(t, impl.VerifyEncryptedBit(proof, group.P384(), pPoint, *ctOne))
+}
+
+func Test_ZKP_EncryptedOne(t *testing.T) {
+	g := group.P256()
+
+	pPoint := newPublicKey(t, g)
+
+	// one-hot encoding of the second choice out of three
+	msgs := []int64{0, 1, 0}
+	cts := make([]types.ElGamalCipherText, len(msgs))
+	rSum := new(big.Int)
+
+	for i, msg := range msgs {
+		rScalar, err := cryptorand.Int(cryptorand.Reader, g.Order())
+		require.NoError(t, err)
+
+		rSum.Add(rSum, rScalar)
+		cts[i] = *mustEncrypt(t, g, &pPoint, rScalar, big.NewInt(msg))
+	}
+
+	rSum.Mod(rSum, g.Order())
+	sum := mustAdd(t, g, cts)
+
+	proof, err := impl.ProveEncryptedOne(rSum, pPoint, sum, g)
+	require.NoError(t, err)
+
+	require.True(t, impl.VerifyEncryptedOne(proof, g, pPoint, sum))
+
+	// the proof must not be valid for a ballot which sums up to two
+	cts = append(cts, cts[1])
+	require.False(t, impl.VerifyEncryptedOne(proof, g, pPoint, mustAdd(t, g, cts)))
+}
+
+func Test_ZKP_EncryptedRange(t *testing.T) {
+	for _, g := range zkpGroups {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			encryptedRange(t, g)
+		})
+	}
+}
+
+func encryptedRange(t *testing.T, g group.Group) {
+	pPoint := newPublicKey(t, g)
+
+	for m := 1; m <= 3; m++ {
+		rScalar, err := cryptorand.Int(cryptorand.Reader, g.Order())
+		require.NoError(t, err)
+
+		ct := mustEncrypt(t, g, &pPoint, rScalar, big.NewInt(int64(m)))
+
+		proof, err := impl.ProveEncryptedRange(rScalar, pPoint, *ct, m, 1, 3, g)
+		require.NoError(t, err)
+
+		require.True(t, impl.VerifyEncryptedRange(proof, g, pPoint, *ct, 1, 3))
+
+		// the proof is only valid for its range
+		require.False(t, impl.VerifyEncryptedRange(proof, g, pPoint, *ct, 1, 2))
+		require.False(t, impl.VerifyEncryptedRange(proof, g, pPoint, *ct, 0, 2))
+	}
+
+	rScalar, err := cryptorand.Int(cryptorand.Reader, g.Order())
+	require.NoError(t, err)
+
+	// a ciphertext out of the range must be rejected
+	ct := mustEncrypt(t, g, &pPoint, rScalar, big.NewInt(4))
+
+	_, err = impl.ProveEncryptedRange(rScalar, pPoint, *ct, 4, 1, 3, g)
+	require.Error(t, err)
+
+	proof, err := impl.ProveEncryptedRange(rScalar, pPoint, *ct, 3, 1, 3, g)
+	require.NoError(t, err)
+
+	require.False(t, impl.VerifyEncryptedRange(proof, g, pPoint, *ct, 1, 3))
+
+	// the proof must not be valid for another ciphertext
+	ctThree := mustEncrypt(t, g, &pPoint, rScalar, big.NewInt(3))
+	proof, err = impl.ProveEncryptedRange(rScalar, pPoint, *ctThree, 3, 1, 3, g)
+	require.NoError(t, err)
+
+	require.True(t, impl.VerifyEncryptedRange(proof, g, pPoint, *ctThree, 1, 3))
+	require.False(t, impl.VerifyEncryptedRange(proof, g, pPoint, *ct, 1, 3))
+}
+
+// mixTwoHops encrypts 3 two-choice ballots and mixes them twice
+func mixTwoHops(t *testing.T, g group.Group, pPoint types.Point) types.MixMessage {
+	votes := make([]types.VoteMessage, 3)
+	for i := range votes {
+		votes[i].EncryptedVotes = make([]types.ElGamalCipherText, 2)
+		for j := range votes[i].EncryptedVotes {
+			rScalar := impl.GenerateRandomBigInt(g.Order())
+			votes[i].EncryptedVotes[j] = *mustEncrypt(t, g, &pPoint, &rScalar, big.NewInt(int64((i+j)%2)))
+		}
+	}
+
+	mixMessage := types.MixMessage{}
+	for hop := 0; hop < 2; hop++ {
+		_, mixedVotes, shuffleProofs, ballotShuffleProof, reEncProofs, err := impl.ShuffleVotes(g, pPoint, votes, 2)
+		require.NoError(t, err)
+		require.Len(t, shuffleProofs, 2)
+		require.NotNil(t, ballotShuffleProof)
+		require.Len(t, reEncProofs, 6)
+
+		votes = mixedVotes
+		mixMessage.Votes = mixedVotes
+		mixMessage.ShuffleProofs = append(mixMessage.ShuffleProofs, shuffleProofs...)
+		mixMessage.BallotShuffleProofs = append(mixMessage.BallotShuffleProofs, *ballotShuffleProof)
+		mixMessage.ReEncryptionProofs = append(mixMessage.ReEncryptionProofs, reEncProofs...)
+	}
+
+	return mixMessage
+}
+
+func Test_ZKP_MixProofs(t *testing.T) {
+	for _, g := range zkpGroups {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			pPoint := newPublicKey(t, g)
+
+			mixMessage := mixTwoHops(t, g, pPoint)
+
+			validHops, ok := impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+			require.True(t, ok)
+			require.Equal(t, 2, validHops)
+		})
+	}
+}
+
+func Test_ZKP_MixProofs_False(t *testing.T) {
+	g := group.P256()
+
+	pPoint := newPublicKey(t, g)
+
+	// > the last hop replaces a ballot by a fresh one
+	mixMessage := mixTwoHops(t, g, pPoint)
+	rScalar := impl.GenerateRandomBigInt(g.Order())
+	mixMessage.Votes[0].EncryptedVotes[0] = *mustEncrypt(t, g, &pPoint, &rScalar, big.NewInt(1))
+
+	validHops, ok := impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+	require.False(t, ok)
+	require.Equal(t, 2, validHops)
+
+	// > the last hop shuffles something else than the output of the first hop
+	mixMessage = mixTwoHops(t, g, pPoint)
+	mixMessage.ShuffleProofs[2].Instance.CtBefore[0] = mixMessage.ShuffleProofs[3].Instance.CtBefore[0]
+
+	validHops, ok = impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+	require.False(t, ok)
+	require.Equal(t, 1, validHops)
+
+	// > the first hop sends a re-encryption proof of another hop
+	mixMessage = mixTwoHops(t, g, pPoint)
+	mixMessage.ReEncryptionProofs[0] = mixMessage.ReEncryptionProofs[6]
+
+	validHops, ok = impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+	require.False(t, ok)
+	require.Equal(t, 0, validHops)
+}
+
+// mixColumnsSeparately mixes each position of the ballots with its own
+// permutation, with valid shuffle and re-encryption proofs for each position:
+// the ballots are taken apart. The proof that the whole ballots are shuffled
+// is made with the permutation of the first position.
+func mixColumnsSeparately(t *testing.T, g group.Group, pPoint types.Point, votes []types.VoteMessage,
+	permutations [][]uint32) types.MixMessage {
+
+	ballotSize := len(permutations)
+
+	rScalars := make([][]big.Int, len(votes))
+	mixedVotes := make([]types.VoteMessage, len(votes))
+	for i := range votes {
+		rScalars[i] = make([]big.Int, ballotSize)
+		mixedVotes[i].EncryptedVotes = make([]types.ElGamalCipherText, ballotSize)
+	}
+
+	mixMessage := types.MixMessage{
+		ReEncryptionProofs: make([]types.Proof, len(votes)*ballotSize),
+	}
+
+	before := make([][]types.ElGamalCipherText, ballotSize)
+	after := make([][]types.ElGamalCipherText, ballotSize)
+
+	for j, permutation := range permutations {
+		rColumn := make([]big.Int, len(votes))
+
+		for i := range votes {
+			rColumn[i] = impl.GenerateRandomBigInt(g.Order())
+			rScalars[i][j] = rColumn[i]
+
+			ct := votes[permutation[i]].EncryptedVotes[j]
+			reEncrypted := mustReEncrypt(t, g, &pPoint, &rColumn[i], &ct)
+			mixedVotes[i].EncryptedVotes[j] = *reEncrypted
+
+			diff, err := impl.ElGamalSubtractCipherTexts(g, *reEncrypted, ct)
+			require.NoError(t, err)
+			reEncProof, err := impl.ProveDlogEq(rColumn[i].Bytes(), diff.Ct1, pPoint, diff.Ct2, g)
+			require.NoError(t, err)
+			mixMessage.ReEncryptionProofs[i*ballotSize+j] = *reEncProof
+		}
+
+		before[j] = make([]types.ElGamalCipherText, len(votes))
+		after[j] = make([]types.ElGamalCipherText, len(votes))
+		for i := range votes {
+			before[j][i] = votes[i].EncryptedVotes[j]
+			after[j][i] = mixedVotes[i].EncryptedVotes[j]
+		}
+
+		shuffleProof, err := impl.ProveShuffle(impl.NewShuffleInstance(g, pPoint, before[j], after[j]),
+			impl.NewShuffleWitness(permutation, rColumn))
+		require.NoError(t, err)
+		require.True(t, impl.VerifyShuffle(shuffleProof))
+
+		mixMessage.ShuffleProofs = append(mixMessage.ShuffleProofs, *shuffleProof)
+	}
+
+	ballotShuffleProof, err := impl.ProveBallotShuffle(g, pPoint, before, after, permutations[0], rScalars)
+	require.NoError(t, err)
+
+	mixMessage.BallotShuffleProofs = []types.ShuffleProof{*ballotShuffleProof}
+	mixMessage.Votes = mixedVotes
+
+	return mixMessage
+}
+
+// A hop which shuffles each position of the ballots with a different
+// permutation is caught, although the shuffle of each position is valid
+func Test_ZKP_MixProofs_BallotShuffle(t *testing.T) {
+	g := group.P256()
+
+	pPoint := newPublicKey(t, g)
+
+	votes := make([]types.VoteMessage, 3)
+	for i := range votes {
+		votes[i].EncryptedVotes = make([]types.ElGamalCipherText, 2)
+		for j := range votes[i].EncryptedVotes {
+			rScalar := impl.GenerateRandomBigInt(g.Order())
+			votes[i].EncryptedVotes[j] = *mustEncrypt(t, g, &pPoint, &rScalar, big.NewInt(int64((i+j)%2)))
+		}
+	}
+
+	// > the same permutation for both positions
+	mixMessage := mixColumnsSeparately(t, g, pPoint, votes, [][]uint32{{1, 2, 0}, {1, 2, 0}})
+
+	validHops, ok := impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+	require.True(t, ok)
+	require.Equal(t, 1, validHops)
+
+	// > another permutation for the second position
+	mixMessage = mixColumnsSeparately(t, g, pPoint, votes, [][]uint32{{1, 2, 0}, {2, 0, 1}})
+
+	validHops, ok = impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+	require.False(t, ok)
+	require.Equal(t, 0, validHops)
+
+	// > the proof of the whole ballots is missing
+	mixMessage = mixColumnsSeparately(t, g, pPoint, votes, [][]uint32{{1, 2, 0}, {1, 2, 0}})
+	mixMessage.BallotShuffleProofs = nil
+
+	validHops, ok = impl.VerifyMixProofs(g, pPoint, 2, &mixMessage)
+	require.False(t, ok)
+	require.Equal(t, 0, validHops)
+}
+
+// The contests of a multi-contest ballot stay together: a hop which shuffles
+// each contest with its own permutation is caught
+func Test_ZKP_MixProofs_Contests(t *testing.T) {
+	g := group.P256()
+
+	pPoint := newPublicKey(t, g)
+
+	// > a contest of 2 choices, then a contest of 3 choices
+	votes := make([]types.VoteMessage, 3)
+	for i := range votes {
+		votes[i].EncryptedVotes = make([]types.ElGamalCipherText, 5)
+		for j := range votes[i].EncryptedVotes {
+			rScalar := impl.GenerateRandomBigInt(g.Order())
+			votes[i].EncryptedVotes[j] = *mustEncrypt(t, g, &pPoint, &rScalar, big.NewInt(int64((i+j)%2)))
+		}
+	}
+
+	first, second := []uint32{2, 0, 1}, []uint32{0, 2, 1}
+
+	mixMessage := mixColumnsSeparately(t, g, pPoint, votes, [][]uint32{first, first, first, first, first})
+
+	validHops, ok := impl.VerifyMixProofs(g, pPoint, 5, &mixMessage)
+	require.True(t, ok)
+	require.Equal(t, 1, validHops)
+
+	// > the second contest is shuffled apart from the first one
+	mixMessage = mixColumnsSeparately(t, g, pPoint, votes, [][]uint32{first, first, second, second, second})
+
+	validHops, ok = impl.VerifyMixProofs(g, pPoint, 5, &mixMessage)
+	require.False(t, ok)
+	require.Equal(t, 0, validHops)
+}
+
+func Test_MixComplaintSignature(t *testing.T) {
+	for _, g := range zkpGroups {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			secretShare := impl.GenerateRandomBigInt(g.Order())
+			publicShare := g.BaseMul(g.ScalarFromInt(&secretShare)).Point()
+
+			complaint := types.MixComplaintMessage{
+				ElectionID:     "election",
+				MixnetServerID: 1,
+				AccusedID:      0,
+			}
+
+			signature, err := impl.SignMixComplaint(g, &complaint, &secretShare)
+			require.NoError(t, err)
+			complaint.Signature = signature
+
+			require.True(t, impl.VerifyMixComplaint(g, &complaint, &publicShare))
+
+			// > the signature doesn't hold for another accused mixnet server
+			complaint.AccusedID = 2
+			require.False(t, impl.VerifyMixComplaint(g, &complaint, &publicShare))
+		})
+	}
+}
+
+// The identity has its own encoding, which no point of the curve shares
+func Test_GroupIdentityEncoding(t *testing.T) {
+	for _, g := range zkpGroups {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			identity := g.Identity()
+
+			secret, err := g.RandomScalar()
+			require.NoError(t, err)
+			element := g.BaseMul(secret)
+
+			// > e - e is the identity, and decodes back to it
+			for _, e := range []group.Element{identity, element.Sub(element)} {
+				decoded, err := g.ElementFromBytes(e.Bytes())
+				require.NoError(t, err)
+				require.True(t, identity.Equal(decoded))
+			}
+
+			// > the compressed point with x = 0, if any, is not the identity
+			zeroX := make([]byte, 1+g.ScalarLen())
+			zeroX[0] = 2
+			decoded, err := g.ElementFromBytes(zeroX)
+			if err == nil {
+				require.False(t, identity.Equal(decoded))
+				require.NotEqual(t, identity.Bytes(), decoded.Bytes())
+			}
+		})
+	}
+}
+
+// roundTripProof encodes the proof, decodes it back, and checks that the
+// decoded proof has the same encoding
+func roundTripProof(t *testing.T, proof *types.Proof) *types.Proof {
+	encoded, err := impl.EncodeProof(proof)
+	require.NoError(t, err)
+
+	decoded, err := impl.DecodeProof(encoded)
+	require.NoError(t, err)
+
+	reEncoded, err := impl.EncodeProof(decoded)
+	require.NoError(t, err)
+	require.Equal(t, encoded, reEncoded)
+
+	return decoded
+}
+
+// Every proof of zkp.go verifies once decoded from its canonical encoding
+func Test_ZKP_ProofEncoding(t *testing.T) {
+	for _, g := range zkpGroups {
+		t.Run(string(g.Suite()), func(t *testing.T) {
+			proofEncoding(t, g)
+		})
+	}
+}
+
+func proofEncoding(t *testing.T, g group.Group) {
+	secret, err := g.RandomScalar()
+	require.NoError(t, err)
+	pPoint := g.BaseMul(secret).Point()
+
+	otherSecret, err := g.RandomScalar()
+	require.NoError(t, err)
+	bPointOther := g.BaseMul(otherSecret)
+	pPointOther := bPointOther.Mul(secret).Point()
+
+	// > Schnorr's proof
+	proof, err := impl.ProveDlog(secret.Bytes(), pPoint, g)
+	require.NoError(t, err)
+	isTrue, err := impl.VerifyDlog(roundTripProof(t, proof))
+	require.NoError(t, err)
+	require.True(t, isTrue)
+
+	// > Chaum-Pedersen proof
+	proof, err = impl.ProveDlogEq(secret.Bytes(), pPoint, bPointOther.Point(), pPointOther, g)
+	require.NoError(t, err)
+	isTrue, err = impl.VerifyDlogEq(roundTripProof(t, proof))
+	require.NoError(t, err)
+	require.True(t, isTrue)
+
+	// > OR-proofs
+	proof, err = impl.ProveDlogOr(secret.Bytes(), pPoint, otherSecret.Bytes(), bPointOther.Point(), true, g)
+	require.NoError(t, err)
+	isTrue, err = impl.VerifyDlogOr(roundTripProof(t, proof))
+	require.NoError(t, err)
+	require.True(t, isTrue)
+
+	proof, err = impl.ProveDlogEqOr(secret.Bytes(), pPoint, bPointOther.Point(), pPointOther, g, true)
+	require.NoError(t, err)
+	require.True(t, impl.VerifyDlogEqOr(roundTripProof(t, proof)))
+
+	// > ballot proofs
+	rScalar := impl.GenerateRandomBigInt(g.Order())
+	ct := mustEncrypt(t, g, &pPoint, &rScalar, big.NewInt(1))
+
+	proof, err = impl.ProveEncryptedBit(&rScalar, pPoint, *ct, true, g)
+	require.NoError(t, err)
+	require.True(t, impl.VerifyEncryptedBit(roundTripProof(t, proof), g, pPoint, *ct))
+
+	proof, err = impl.ProveEncryptedOne(&rScalar, pPoint, *ct, g)
+	require.NoError(t, err)
+	require.True(t, impl.VerifyEncryptedOne(roundTripProof(t, proof), g, pPoint, *ct))
+
+	rangeProof, err := impl.ProveEncryptedRange(&rScalar, pPoint, *ct, 1, 0, 2, g)
+	require.NoError(t, err)
+	encoded, err := impl.EncodeRangeProof(rangeProof)
+	require.NoError(t, err)
+	decodedRange, err := impl.DecodeRangeProof(encoded)
+	require.NoError(t, err)
+	reEncoded, err := impl.EncodeRangeProof(decodedRange)
+	require.NoError(t, err)
+	require.Equal(t, encoded, reEncoded)
+	require.True(t, impl.VerifyEncryptedRange(decodedRange, g, pPoint, *ct, 0, 2))
+
+	// > shuffle proof
+	mixMessage := mixTwoHops(t, g, pPoint)
+	for i := range mixMessage.ShuffleProofs {
+		encoded, err := impl.EncodeShuffleProof(&mixMessage.ShuffleProofs[i])
+		require.NoError(t, err)
+		decoded, err := impl.DecodeShuffleProof(encoded)
+		require.NoError(t, err)
+		reEncoded, err := impl.EncodeShuffleProof(decoded)
+		require.NoError(t, err)
+		require.Equal(t, encoded, reEncoded)
+		require.True(t, impl.VerifyShuffle(decoded))
+	}
+}
+
+// The proofs are sent in JSON as their canonical encoding, with their suite,
+// and a message without proof leaves it out
+func Test_ZKP_ProofJSON(t *testing.T) {
+	g := group.P384()
+
+	pPoint := newPublicKey(t, g)
+	rScalar := impl.GenerateRandomBigInt(g.Order())
+	ct := mustEncrypt(t, g, &pPoint, &rScalar, big.NewInt(1))
+
+	bitProof, err := impl.ProveEncryptedBit(&rScalar, pPoint, *ct, true, g)
+	require.NoError(t, err)
+	rangeProof, err := impl.ProveEncryptedRange(&rScalar, pPoint, *ct, 1, 0, 2, g)
+	require.NoError(t, err)
+
+	ballot := types.VoteMessage{
+		EncryptedVotes:    []types.ElGamalCipherText{*ct},
+		CorrectVoteProofs: []types.Proof{*bitProof},
+		SelectionsProof:   *rangeProof,
+	}
+
+	buf, err := json.Marshal(&ballot)
+	require.NoError(t, err)
+
+	encoded, err := impl.EncodeProof(bitProof)
+	require.NoError(t, err)
+	require.Contains(t, string(buf), base64.StdEncoding.EncodeToString(encoded))
+
+	decoded := types.VoteMessage{}
+	require.NoError(t, json.Unmarshal(buf, &decoded))
+
+	require.Equal(t, g.Suite(), decoded.CorrectVoteProofs[0].Suite)
+	require.True(t, impl.VerifyEncryptedBit(&decoded.CorrectVoteProofs[0], g, pPoint, *ct))
+	require.True(t, impl.VerifyEncryptedRange(&decoded.SelectionsProof, g, pPoint, *ct, 0, 2))
+
+	// > the zero proofs stay zero
+	require.Equal(t, types.Proof{}, decoded.SumProof)
+
+	// > a proof which doesn't decode fails the message
+	buf = bytes.Replace(buf, []byte(base64.StdEncoding.EncodeToString(encoded)),
+		[]byte(base64.StdEncoding.EncodeToString(encoded[:len(encoded)-1])), 1)
+	require.Error(t, json.Unmarshal(buf, &decoded))
+}
+
+// A proof is only decoded if the encoding is well-formed and its points are in
+// the group
+func Test_ZKP_ProofEncoding_Invalid(t *testing.T) {
+	g := group.P256()
+
+	secret, err := g.RandomScalar()
+	require.NoError(t, err)
+	pPoint := g.BaseMul(secret).Point()
+
+	proof, err := impl.ProveDlog(secret.Bytes(), pPoint, g)
+	require.NoError(t, err)
+
+	encoded, err := impl.EncodeProof(proof)
+	require.NoError(t, err)
+
+	// > unknown version
+	tampered := append([]byte(nil), encoded...)
+	tampered[0] = impl.ProofEncodingVersion + 1
+	_, err = impl.DecodeProof(tampered)
+	require.Error(t, err)
+
+	// > not the encoding of a single proof
+	_, err = impl.DecodeShuffleProof(encoded)
+	require.Error(t, err)
+
+	// > trailing or missing bytes
+	_, err = impl.DecodeProof(append(append([]byte(nil), encoded...), 0))
+	require.Error(t, err)
+	_, err = impl.DecodeProof(encoded[:len(encoded)-1])
+	require.Error(t, err)
+
+	// > the public point is not on the curve
+	tampered = append([]byte(nil), encoded...)
+	tampered[bytes.Index(tampered, proof.PPoint)] = 5
+	_, err = impl.DecodeProof(tampered)
+	require.Error(t, err)
+
+	// > the points are in another group
+	tampered = bytes.Replace(append([]byte(nil), encoded...), []byte(types.P256Suite), []byte(types.P384Suite), 1)
+	_, err = impl.DecodeProof(tampered)
+	require.Error(t, err)
+
+	// > unknown suite
+	proof.Suite = "P-224"
+	_, err = impl.EncodeProof(proof)
+	require.Error(t, err)
+}
@@ -1,17 +1,216 @@
 package peer
 
 import (
+	"context"
 	"time"
 
 	"go.dedis.ch/cs438/types"
 )
 
 type Voting interface {
-	AnnounceElection(title, description string, choices, mixnetServers []string, expirationTime time.Duration) (string, error)
+	// AnnounceElection announces a new election and returns its ID. By
+	// default, the ballots are counted after going through the mixnet servers,
+	// options can change the election parameters.
+	AnnounceElection(title, description string, choices, mixnetServers []string, expirationTime time.Duration,
+		opts ...ElectionOption) (string, error)
 
 	GetElections() []*types.Election
 
-	Vote(electionID string, choiceID int) error
+	// CancelElection aborts an election announced by this peer, the reason is
+	// shown to the voters. It returns an error if this peer is not the
+	// announcer or if the election is already finished.
+	CancelElection(electionID, reason string) error
+
+	// ExtendElection pushes the expiration of an open election announced by
+	// this peer out by extra. It returns an error if this peer is not the
+	// announcer or if the election is not open.
+	ExtendElection(electionID string, extra time.Duration) error
+
+	// Register obtains the anonymous credential of this peer for an election
+	// with anonymous credentials, and must be called before voting. The
+	// announcer blindly signs a fresh pseudonymous key of the peer, which then
+	// signs its ballots. It returns an error if the peer is not on the
+	// eligibility roll, or if the context is done.
+	Register(ctx context.Context, electionID string) error
+
+	// Vote casts a ballot for the choice once the election is open, and returns
+	// the receipt of the mixnet server which stored it. It returns an error if
+	// the election closes before the ballot is acknowledged, or if the context
+	// is done. A peer votes once, unless the election allows re-voting (see
+	// WithRevoting): its last ballot then replaces the previous ones.
+	Vote(ctx context.Context, electionID string, choiceID int) (types.BallotReceiptMessage, error)
+
+	// VoteRanking casts a ranked ballot, like Vote. The ranking holds all the
+	// choice IDs of the election, from the most to the least preferred.
+	VoteRanking(ctx context.Context, electionID string, ranking []int) (types.BallotReceiptMessage, error)
+
+	// VoteApproval casts an approval ballot, like Vote. The selection holds
+	// between 1 and MaxSelections distinct choice IDs of the election.
+	VoteApproval(ctx context.Context, electionID string, selection []int) (types.BallotReceiptMessage, error)
+
+	// VoteContests casts a multi-contest ballot, like Vote. It holds one
+	// selection per contest, in the order of the contests: a single choice ID,
+	// a ranking or an approval selection, depending on the ballot type of the
+	// contest.
+	VoteContests(ctx context.Context, electionID string, selections [][]int) (types.BallotReceiptMessage, error)
+
+	// PrepareBallot encrypts and signs a ballot once the election is open, but
+	// does not send it: the voter then either casts it with CastBallot or
+	// audits it with AuditBallot. The selections hold one selection per contest
+	// (see VoteContests), a single-contest election takes one selection: a
+	// single choice ID, a ranking or an approval selection. A new prepared
+	// ballot replaces the previous one.
+	PrepareBallot(ctx context.Context, electionID string, selections [][]int) (types.PreparedBallot, error)
+
+	// CastBallot casts the prepared ballot of the election, like Vote.
+	CastBallot(ctx context.Context, electionID string) (types.BallotReceiptMessage, error)
+
+	// AuditBallot reveals the randomness of the prepared ballot of the
+	// election and discards it, so that the voter can check its encryption
+	// with impl.VerifyBallotAudit. The voter then prepares a new ballot.
+	AuditBallot(electionID string) (types.BallotAudit, error)
+
+	// GetVoterKey returns the public key which signs the ballots of this peer,
+	// as expected on the eligibility roll of an election (see
+	// WithEligibleVoters)
+	GetVoterKey() []byte
+
+	// GetBulletinBoard returns the public record of the election, which can be
+	// verified offline with impl.VerifyBulletinBoard
+	GetBulletinBoard(electionID string) types.BulletinBoard
+
+	// SubscribePhase returns a channel receiving the current phase of the
+	// election and then each of its phase changes, and a function to
+	// unsubscribe. The channel is closed once the election is finished or
+	// aborted, or when the peer stops.
+	SubscribePhase(electionID string) (<-chan types.Phase, func(), error)
 
 	// VerifyProof(...) ...
 }
+
+// ElectionOption sets a parameter of an election announced with
+// AnnounceElection.
+type ElectionOption func(*types.ElectionBase)
+
+// WithTallyMode sets how the ballots of the election are counted. Defaults to
+// types.TallyMixnet.
+func WithTallyMode(mode types.TallyMode) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.TallyMode = mode
+	}
+}
+
+// WithBallotType sets what the voters express on their ballots. Defaults to
+// types.BallotSingle.
+func WithBallotType(ballotType types.BallotType) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.BallotType = ballotType
+	}
+}
+
+// WithMaxSelections makes the election an approval election, where the voters
+// select between 1 and k choices. Each selected choice gets one vote.
+func WithMaxSelections(k int) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.BallotType = types.BallotApproval
+		base.MaxSelections = k
+	}
+}
+
+// WithContest adds a contest to the election, which then has several
+// contests: the choices passed to AnnounceElection must be empty. The options
+// set the ballot type of the contest, such as WithBallotType and
+// WithMaxSelections.
+func WithContest(title string, choices []string, opts ...ElectionOption) ElectionOption {
+	return func(base *types.ElectionBase) {
+		rules := types.ElectionBase{}
+		for _, opt := range opts {
+			opt(&rules)
+		}
+
+		contestChoices := make([]types.Choice, len(choices))
+		for i, choice := range choices {
+			contestChoices[i] = types.Choice{
+				ChoiceID: i,
+				Name:     choice,
+			}
+		}
+
+		base.Contests = append(base.Contests, types.Contest{
+			ContestID:     len(base.Contests),
+			Title:         title,
+			Choices:       contestChoices,
+			BallotType:    rules.BallotType,
+			MaxSelections: rules.MaxSelections,
+		})
+	}
+}
+
+// WithEligibleVoters restricts the election to the voters with the given keys
+// (see Voting.GetVoterKey). By default, any key may vote.
+func WithEligibleVoters(voterKeys ...[]byte) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.EligibleVoters = append(base.EligibleVoters, voterKeys...)
+	}
+}
+
+// WithVotePolicy sets which ballot counts when a voter sends several ones.
+// Defaults to types.FirstVoteCounts.
+func WithVotePolicy(policy types.VotePolicy) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.VotePolicy = policy
+	}
+}
+
+// WithAnonymousCredentials makes the voters register a pseudonymous key with
+// Voting.Register before voting, so that their ballots can't be linked to
+// them. It needs an eligibility roll (see WithEligibleVoters).
+func WithAnonymousCredentials() ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.AnonymousCredentials = true
+	}
+}
+
+// WithRevoting lets the voters cast new ballots which replace their previous
+// ones, so that a coerced voter can replace its ballot in private. A voter
+// registers an encrypted secret credential once, and its ballots carry the
+// credential encrypted anew instead of its key. Before the mixing, the
+// qualified mixnet servers test the pairs of ballots for plaintext equivalence
+// of their credentials, and keep the last ballot of each credential in the
+// order of the bulletin board. The board tells which ballots share a
+// credential, not whose they are.
+func WithRevoting() ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.Revoting = true
+	}
+}
+
+// WithSchedule opens the election at opening and closes it at closing, instead
+// of opening it as soon as its key is generated for the duration passed to
+// AnnounceElection, which is then ignored. This lets an election be announced
+// ahead of time.
+func WithSchedule(opening, closing time.Time) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.OpeningTime = opening
+		base.Expiration = closing
+		base.Duration = closing.Sub(opening)
+	}
+}
+
+// WithQuorum makes the election invalid if fewer than count ballots are
+// counted: the first mixnet server then publishes that the quorum is not
+// reached instead of mixing the ballots.
+func WithQuorum(count int) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.Quorum.Count = count
+	}
+}
+
+// WithQuorumPercent makes the election invalid if fewer than percent % of the
+// eligible voters cast a ballot, like WithQuorum. It needs an eligibility roll
+// (see WithEligibleVoters).
+func WithQuorumPercent(percent int) ElectionOption {
+	return func(base *types.ElectionBase) {
+		base.Quorum.Percent = percent
+	}
+}
@@ -14,6 +14,8 @@ type Registry interface {
 	RegisterMessageCallback(types.Message, Exec)
 
 	// ProcessPacket executes the registered callback based on the pkt.Message.
+	// Signed packets are dropped if their signature is invalid, or if their
+	// source signed a previous packet with another key.
 	ProcessPacket(pkt transport.Packet) error
 
 	// MarshalMessage transforms the message to a transport.Message. The message
@@ -16,6 +16,7 @@ import (
 func NewRegistry() registry.Registry {
 	return &Registry{
 		handlers: make(map[string]registry.Exec),
+		keys:     make(map[string]string),
 		notif:    notifications{},
 		msgs:     messages{},
 	}
@@ -30,6 +31,9 @@ type Registry struct {
 	handlers map[string]registry.Exec
 	notif    notifications
 	msgs     messages
+
+	// source address -> public key of its first signed packet
+	keys map[string]string
 }
 
 // RegisterMessageCallback implements registry.Registry.
@@ -43,6 +47,11 @@ func (r *Registry) RegisterMessageCallback(m types.Message, exec registry.Exec)
 
 // ProcessPacket implements registry.Registry.
 func (r *Registry) ProcessPacket(pkt transport.Packet) error {
+	err := r.verify(pkt)
+	if err != nil {
+		return xerrors.Errorf("dropped packet %s from %s: %v", pkt.Header.PacketID, pkt.Header.Source, err)
+	}
+
 	msg, err := registry.GlobalRegistry.GetMessage(pkt.Msg)
 	if err != nil {
 		return xerrors.Errorf("failed to get message: %v", err)
@@ -95,6 +104,37 @@ func (r *Registry) ProcessPacket(pkt transport.Packet) error {
 	return nil
 }
 
+// verify checks the signature of a signed packet, and that its source always
+// signs with the same key: the first key seen for a source is kept. Unsigned
+// packets are the ones a peer processes locally, such as the messages of its
+// rumors, or come from peers which don't sign: a peer which requires
+// signatures drops those before they reach the registry.
+func (r *Registry) verify(pkt transport.Packet) error {
+	if pkt.Header == nil || !pkt.Header.IsSigned() {
+		return nil
+	}
+
+	err := pkt.Verify()
+	if err != nil {
+		return err
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	key, ok := r.keys[pkt.Header.Source]
+	if !ok {
+		r.keys[pkt.Header.Source] = string(pkt.Header.PublicKey)
+		return nil
+	}
+
+	if key != string(pkt.Header.PublicKey) {
+		return xerrors.Errorf("%s signed with another key", pkt.Header.Source)
+	}
+
+	return nil
+}
+
 // MarshalMessage implements registry.Registry.
 func (r *Registry) MarshalMessage(msg types.Message) (transport.Message, error) {
 	buf, err := json.Marshal(msg)
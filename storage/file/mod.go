@@ -13,6 +13,9 @@ const (
 	blob       = "blob"
 	naming     = "naming"
 	blockchain = "blockchain"
+	election   = "election"
+	board      = "board"
+	keys       = "keys"
 )
 
 // NewPersistency return a new initialized file-based storage. Opeartions are
@@ -38,11 +41,29 @@ func NewPersistency(folderPath string) (storage.Storage, error) {
 		return nil, xerrors.Errorf("failed to create blockchainStore: %v", err)
 	}
 
+	electionStore, err := newStore(filepath.Join(folderPath, election))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create electionStore: %v", err)
+	}
+
+	boardStore, err := newStore(filepath.Join(folderPath, board))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create boardStore: %v", err)
+	}
+
+	keyStore, err := newStore(filepath.Join(folderPath, keys))
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create keyStore: %v", err)
+	}
+
 	return Storage{
 		folderPath: folderPath,
 		blob:       blobStore,
 		naming:     namingStore,
 		blockchain: blockchainStore,
+		election:   electionStore,
+		board:      boardStore,
+		keys:       keyStore,
 	}, nil
 }
 
@@ -55,6 +76,9 @@ type Storage struct {
 	blob       storage.Store
 	naming     storage.Store
 	blockchain storage.Store
+	election   storage.Store
+	board      storage.Store
+	keys       storage.Store
 }
 
 // GetFolderPath returns the folder path
@@ -77,6 +101,21 @@ func (s Storage) GetBlockchainStore() storage.Store {
 	return s.blockchain
 }
 
+// GetElectionStore implements storage.Storage
+func (s Storage) GetElectionStore() storage.Store {
+	return s.election
+}
+
+// GetBulletinBoardStore implements storage.Storage
+func (s Storage) GetBulletinBoardStore() storage.Store {
+	return s.board
+}
+
+// GetKeyStore implements storage.Storage
+func (s Storage) GetKeyStore() storage.Store {
+	return s.keys
+}
+
 func newStore(folderPath string) (*store, error) {
 	err := os.MkdirAll(folderPath, os.ModePerm)
 	if err != nil {
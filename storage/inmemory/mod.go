@@ -13,6 +13,9 @@ func NewPersistency() storage.Storage {
 		blob:       newStore(),
 		naming:     newStore(),
 		blockchain: newStore(),
+		election:   newStore(),
+		board:      newStore(),
+		keys:       newStore(),
 	}
 }
 
@@ -23,6 +26,9 @@ type Storage struct {
 	blob       storage.Store
 	naming     storage.Store
 	blockchain storage.Store
+	election   storage.Store
+	board      storage.Store
+	keys       storage.Store
 }
 
 // GetDataBlobStore implements storage.Storage
@@ -40,6 +46,21 @@ func (s Storage) GetBlockchainStore() storage.Store {
 	return s.blockchain
 }
 
+// GetElectionStore implements storage.Storage
+func (s Storage) GetElectionStore() storage.Store {
+	return s.election
+}
+
+// GetBulletinBoardStore implements storage.Storage
+func (s Storage) GetBulletinBoardStore() storage.Store {
+	return s.board
+}
+
+// GetKeyStore implements storage.Storage
+func (s Storage) GetKeyStore() storage.Store {
+	return s.keys
+}
+
 func newStore() *store {
 	return &store{
 		data: make(map[string][]byte),
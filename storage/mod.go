@@ -16,6 +16,19 @@ type Storage interface {
 
 	// GetBlockchainStore returns a storage to store the blockchain blocks.
 	GetBlockchainStore() Store
+
+	// GetElectionStore returns a storage to store the elections. The storage
+	// must use election IDs as key, and serialized elections as values.
+	GetElectionStore() Store
+
+	// GetBulletinBoardStore returns a storage to store the bulletin boards.
+	// The storage must use election IDs as key, and serialized boards as
+	// values.
+	GetBulletinBoardStore() Store
+
+	// GetKeyStore returns a storage to store the long-term keys of the peer.
+	// The storage must use key names as key, and encoded keys as values.
+	GetKeyStore() Store
 }
 
 // Store describes the primitives of a simple storage.
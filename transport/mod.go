@@ -150,6 +150,12 @@ type Header struct {
 	// Destination is empty in the case of a broadcast, otherwise contains the
 	// destination address.
 	Destination string
+
+	// PublicKey is the long-term signing key of the packet's creator, and
+	// Signature its signature of the packet (see Packet.Sign). Both are empty
+	// for unsigned packets.
+	PublicKey []byte
+	Signature []byte
 }
 
 func (h Header) String() string {
@@ -163,6 +169,7 @@ func (h Header) String() string {
 	fmt.Fprintf(out, "RelayedBy: %s\n", h.RelayedBy)
 	fmt.Fprintf(out, "Destination: %s\n", h.Destination)
 	fmt.Fprintf(out, "TTL: %d\n", h.TTL)
+	fmt.Fprintf(out, "Signed: %t\n", h.IsSigned())
 
 	return out.String()
 }
@@ -0,0 +1,126 @@
+package transport
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/xerrors"
+)
+
+// ErrUnsigned is returned when verifying a packet without signature
+var ErrUnsigned = errors.New("packet is not signed")
+
+// IsSigned tells if the header carries a signature
+func (h Header) IsSigned() bool {
+	return len(h.Signature) > 0 || len(h.PublicKey) > 0
+}
+
+// Unsigned returns a copy of the header without signature. It is used to
+// process locally the messages embedded in a packet, once the packet itself is
+// verified.
+func (h Header) Unsigned() Header {
+	h.PublicKey = nil
+	h.Signature = nil
+	return h
+}
+
+// Sign signs the packet with the private key of its creator. The signature
+// covers the message and the fields of the header that relayers don't update,
+// that is all but the TTL and RelayedBy.
+func (p Packet) Sign(key *ecdsa.PrivateKey) error {
+	p.Header.PublicKey = MarshalPublicKey(&key.PublicKey)
+
+	signature, err := SignDigest(key, p.digest())
+	if err != nil {
+		return xerrors.Errorf("failed to sign packet: %v", err)
+	}
+
+	p.Header.Signature = signature
+
+	return nil
+}
+
+// Verify checks the signature of the packet against the public key of its
+// header. It returns ErrUnsigned if the packet is not signed.
+func (p Packet) Verify() error {
+	if !p.Header.IsSigned() {
+		return ErrUnsigned
+	}
+
+	return VerifyDigest(p.Header.PublicKey, p.digest(), p.Header.Signature)
+}
+
+// digest returns the hash of the signed content of the packet.
+func (p Packet) digest() []byte {
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(p.Header.Timestamp))
+
+	fields := [][]byte{
+		[]byte(p.Header.PacketID),
+		timestamp[:],
+		[]byte(p.Header.Source),
+		[]byte(p.Header.Destination),
+		p.Header.PublicKey,
+	}
+
+	if p.Msg != nil {
+		fields = append(fields, []byte(p.Msg.Type), p.Msg.Payload)
+	}
+
+	return Digest(fields...)
+}
+
+// Digest hashes the fields, each one prefixed with its length so that the
+// boundaries between fields are part of the digest.
+func Digest(fields ...[]byte) []byte {
+	h := sha256.New()
+
+	for _, field := range fields {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(field)))
+		h.Write(length[:])
+		h.Write(field)
+	}
+
+	return h.Sum(nil)
+}
+
+// MarshalPublicKey encodes a P-256 public key in the format carried by the
+// headers.
+func MarshalPublicKey(key *ecdsa.PublicKey) []byte {
+	return elliptic.Marshal(elliptic.P256(), key.X, key.Y)
+}
+
+// UnmarshalPublicKey decodes a public key encoded by MarshalPublicKey.
+func UnmarshalPublicKey(publicKey []byte) (*ecdsa.PublicKey, error) {
+	x, y := elliptic.Unmarshal(elliptic.P256(), publicKey)
+	if x == nil {
+		return nil, errors.New("invalid public key")
+	}
+
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+}
+
+// SignDigest signs the digest with the key.
+func SignDigest(key *ecdsa.PrivateKey, digest []byte) ([]byte, error) {
+	return ecdsa.SignASN1(rand.Reader, key, digest)
+}
+
+// VerifyDigest checks the signature of the digest against the encoded public
+// key.
+func VerifyDigest(publicKey []byte, digest []byte, signature []byte) error {
+	key, err := UnmarshalPublicKey(publicKey)
+	if err != nil {
+		return err
+	}
+
+	if !ecdsa.VerifyASN1(key, digest, signature) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
@@ -177,12 +177,61 @@ func (p PrivateMessage) Name() string {
 
 // String implements types.Message.
 func (p PrivateMessage) String() string {
+	if p.Payloads != nil {
+		return fmt.Sprintf("encrypted private message for %d recipients", len(p.Payloads))
+	}
 	return fmt.Sprintf("private message for %s", p.Recipients)
 }
 
 // HTML implements types.Message.
 func (p PrivateMessage) HTML() string {
-	return fmt.Sprintf("private message for %s", p.Recipients)
+	return p.String()
+}
+
+// -----------------------------------------------------------------------------
+// NodeKeyMessage
+
+// NewEmpty implements types.Message.
+func (n NodeKeyMessage) NewEmpty() Message {
+	return &NodeKeyMessage{}
+}
+
+// Name implements types.Message.
+func (n NodeKeyMessage) Name() string {
+	return "node-key"
+}
+
+// String implements types.Message.
+func (n NodeKeyMessage) String() string {
+	return fmt.Sprintf("public key of %s", n.Address)
+}
+
+// HTML implements types.Message.
+func (n NodeKeyMessage) HTML() string {
+	return n.String()
+}
+
+// -----------------------------------------------------------------------------
+// NodeKeyRequestMessage
+
+// NewEmpty implements types.Message.
+func (n NodeKeyRequestMessage) NewEmpty() Message {
+	return &NodeKeyRequestMessage{}
+}
+
+// Name implements types.Message.
+func (n NodeKeyRequestMessage) Name() string {
+	return "node-key-request"
+}
+
+// String implements types.Message.
+func (n NodeKeyRequestMessage) String() string {
+	return fmt.Sprintf("request of the public keys of %v", n.Addresses)
+}
+
+// HTML implements types.Message.
+func (n NodeKeyRequestMessage) HTML() string {
+	return n.String()
 }
 
 // -----------------------------------------------------------------------------
@@ -31,6 +31,11 @@ type Rumor struct {
 
 	// The message the rumor embeds.
 	Msg *transport.Message
+
+	// PublicKey is the long-term signing key of the origin, and Signature its
+	// signature of the rumor. Relayers can't alter a signed rumor.
+	PublicKey []byte
+	Signature []byte
 }
 
 // AckMessage is an acknowledgement message sent back when a node receives a
@@ -61,6 +66,9 @@ type EmptyMessage struct{}
 
 // PrivateMessage describes a message intended to some specific recipients.
 //
+// The message is either in cleartext, for the recipients given by address, or
+// end-to-end encrypted, for the recipients given by key fingerprint.
+//
 // - implements types.Message
 // - implemented in HW1
 type PrivateMessage struct {
@@ -69,4 +77,39 @@ type PrivateMessage struct {
 
 	// Msg is the private message to be read by the recipients
 	Msg *transport.Message
+
+	// Sender is the address of the sender, and SenderKey the long-term public
+	// key it published (see NodeKeyMessage). Together with the long-term key
+	// of a recipient, SenderKey gives the key of its payload (ECDH).
+	Sender    string
+	SenderKey *Point
+
+	// Payloads holds Msg encrypted for each recipient (AEAD), indexed by the
+	// fingerprint of the recipient's public key. Recipients and Msg are empty
+	// when the message is encrypted.
+	Payloads map[string][]byte
+}
+
+// NodeKeyMessage announces the long-term public key of a peer, used to send it
+// encrypted private messages.
+//
+// - implements types.Message
+type NodeKeyMessage struct {
+	Address   string
+	PublicKey Point
+
+	// SigningKey is the long-term key the peer signs its packets and rumors
+	// with (see transport.MarshalPublicKey), and Signature its signature of
+	// the message.
+	SigningKey []byte
+	Signature  []byte
+}
+
+// NodeKeyRequestMessage asks the peers at the addresses to publish their keys
+// (see NodeKeyMessage), such as the mixnet servers of an election about to be
+// announced.
+//
+// - implements types.Message
+type NodeKeyRequestMessage struct {
+	Addresses []string
 }
@@ -19,7 +19,7 @@ func (m DKGShareMessage) Name() string {
 
 // String implements types.Message.
 func (m DKGShareMessage) String() string {
-	return fmt.Sprintf("DKG-share: Share: %s, X: %v", m.Share, m.X)
+	return fmt.Sprintf("DKG-share: Share: %s, X: %v", m.Share.String(), m.X)
 }
 
 // HTML implements types.Message.
@@ -29,6 +29,28 @@ func (m DKGShareMessage) HTML() string {
 
 // ---
 
+// NewEmpty implements types.Message.
+func (m DKGCommitmentMessage) NewEmpty() Message {
+	return &DKGCommitmentMessage{}
+}
+
+// Name implements types.Message.
+func (m DKGCommitmentMessage) Name() string {
+	return "dkg-commitment"
+}
+
+// String implements types.Message.
+func (m DKGCommitmentMessage) String() string {
+	return fmt.Sprintf("DKG-commitment: MixnetServerID: %d, X: %v", m.MixnetServerID, m.X)
+}
+
+// HTML implements types.Message.
+func (m DKGCommitmentMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
 // NewEmpty implements types.Message.
 func (m DKGShareValidationMessage) NewEmpty() Message {
 	return &DKGShareValidationMessage{}
@@ -41,8 +63,8 @@ func (m DKGShareValidationMessage) Name() string {
 
 // String implements types.Message.
 func (m DKGShareValidationMessage) String() string {
-	return fmt.Sprintf("DKG-share-validation: electionID: %s; mixnetServerID: %d; isValid: %t",
-		m.ElectionID, m.MixnetServerID, m.IsShareValid)
+	return fmt.Sprintf("DKG-share-validation: electionID: %s; mixnetServerID: %d; validatorID: %d; isValid: %t",
+		m.ElectionID, m.MixnetServerID, m.ValidatorID, m.IsShareValid)
 }
 
 // HTML implements types.Message.
@@ -1,67 +1,101 @@
 package types
 
 import (
+	"encoding/json"
 	"math/big"
 	"time"
 )
 
-// PedersenSuite is a structure which contains all the
-// parameters needed for establishing Pedersen DKG protocol.
-// We assume for simplicity that the initial input of all peers
-// is given in the peer's configuration.
-// p and q are primes and g is a generator of subgroup Gq of
-// order q in Zp, while t is the order of the polynomial f()
-// Reference: Topics in Cryptology – CT-RSA 2003
-// The Cryptographers’ Track at the RSA Conference 2003
-// San Francisco, CA, USA, April 13-17, 2003 Proceedings
-// Threshold Cryptography, Secure Applications of Pedersen’s Distributed
-// Key Generation Protocol (Rosario Gennaro, Stanis9law Jarecki,
-// Hugo Krawczyk, and Tal Rabin)
-//type PedersenSuite struct {
-//	P big.Int
-//	G big.Int
-//	Q big.Int
-//}
-//
-//var DefaultPedersenSuite = PedersenSuite{
-//	P: *big.NewInt(7), // Z*7
-//	G: *big.NewInt(2),                   // <2>
-//	Q: *big.NewInt(3),                   // <2> = {2, 4, 1}
-//}
+// PedersenSuite identifies the prime-order group in which an election runs its
+// cryptography: the Pedersen DKG, the ElGamal encryption of the ballots and the
+// proofs. It is chosen in the peer's configuration and recorded in
+// ElectionBase, so that all the peers of an election use the same group.
+type PedersenSuite string
+
+const (
+	// P256Suite is the group of the points of the NIST P-256 curve
+	P256Suite PedersenSuite = "P-256"
+	// P384Suite is the group of the points of the NIST P-384 curve
+	P384Suite PedersenSuite = "P-384"
+
+	// DefaultPedersenSuite is the group of the elections if none is
+	// configured
+	DefaultPedersenSuite = P256Suite
+)
 
 type Point struct {
 	X big.Int
 	Y big.Int
 }
 
+// MarshalJSON implements json.Marshaler. It has a value receiver so that points
+// which are not addressable, like map values, are encoded too.
+func (p Point) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		X *big.Int
+		Y *big.Int
+	}{&p.X, &p.Y})
+}
+
+// DKGShareMessage is the share a mixnet server deals privately to another one,
+// with the Threshold commitments X to its polynomial. It is signed with the
+// signing key of the dealer, as pinned in the announcement.
 type DKGShareMessage struct {
 	ElectionID     string
 	MixnetServerID int
 	Share          big.Int
 	X              []Point
+	Signature      []byte
+}
+
+// DKGCommitmentMessage publishes the commitments X to the coefficients of the
+// polynomial of a mixnet server on the bulletin board. The shares themselves
+// stay private.
+type DKGCommitmentMessage struct {
+	ElectionID     string
+	MixnetServerID int
+	X              []Point
 }
 
+// DKGShareValidationMessage tells whether the share dealt by MixnetServerID
+// is valid. It is signed by the mixnet server ValidatorID which checked it,
+// with its key pinned in the announcement.
 type DKGShareValidationMessage struct {
 	ElectionID     string
 	MixnetServerID int
+	ValidatorID    int
 	IsShareValid   bool
+	Signature      []byte
 }
 
+// ElectionReadyMessage is broadcast by a mixnet server once it decided which
+// mixnet servers are qualified. It is signed with the signing key of the
+// mixnet server, as pinned in the announcement, which counts once.
 type ElectionReadyMessage struct {
 	ElectionID       string
+	MixnetServerID   int
 	QualifiedServers []int
+	Signature        []byte
 }
 
+// DKGRevealShareMessage reveals the share MixnetServerID dealt to the mixnet
+// server which complained about it. It is signed by MixnetServerID, with its
+// key pinned in the announcement.
 type DKGRevealShareMessage struct {
 	ElectionID          string
 	Share               big.Int
 	MixnetServerID      int
 	ComplainingServerID int
+	Signature           []byte
 }
 
+// StartElectionMessage is broadcast by the initiator, the first qualified
+// mixnet server, with the election key. It is signed with the signing key of
+// the initiator, as pinned in the announcement.
 type StartElectionMessage struct {
 	ElectionID string
 	Expiration time.Time
 	PublicKey  Point
 	Initiator  string
+	Signature  []byte
 }
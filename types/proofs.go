@@ -1,13 +1,75 @@
 package types
 
 import (
-	"crypto/elliptic"
+	"encoding/json"
 	"math/big"
+
+	"golang.org/x/xerrors"
 )
 
+// ProofCodec is the canonical encoding of the proofs, with their suite. The
+// proofs are sent over the network in this encoding (see RegisterProofCodec).
+type ProofCodec interface {
+	EncodeProof(proof *Proof) ([]byte, error)
+	DecodeProof(data []byte) (*Proof, error)
+	EncodeRangeProof(proof *RangeProof) ([]byte, error)
+	DecodeRangeProof(data []byte) (*RangeProof, error)
+	EncodeShuffleProof(proof *ShuffleProof) ([]byte, error)
+	DecodeShuffleProof(data []byte) (*ShuffleProof, error)
+}
+
+// proofCodec encodes the proofs in JSON, it is registered by the package which
+// implements the proofs
+var proofCodec ProofCodec
+
+// RegisterProofCodec sets the encoding of the proofs in JSON
+func RegisterProofCodec(codec ProofCodec) {
+	proofCodec = codec
+}
+
+// marshalProof encodes a proof as the JSON string of its canonical encoding.
+// The zero proof, which a message leaves out, is null.
+func marshalProof(isZero bool, encode func(ProofCodec) ([]byte, error)) ([]byte, error) {
+	if isZero {
+		return []byte("null"), nil
+	}
+
+	if proofCodec == nil {
+		return nil, xerrors.New("no proof codec registered")
+	}
+
+	encoded, err := encode(proofCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(encoded)
+}
+
+// unmarshalProof decodes a proof of marshalProof, null leaves the zero proof
+func unmarshalProof(data []byte, decode func(ProofCodec, []byte) error) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	if proofCodec == nil {
+		return xerrors.New("no proof codec registered")
+	}
+
+	var encoded []byte
+	err := json.Unmarshal(data, &encoded)
+	if err != nil {
+		return err
+	}
+
+	return decode(proofCodec, encoded)
+}
+
 type Proof struct {
-	ProofType   string
-	Curve       elliptic.Curve
+	ProofType string
+	// Suite is sent with the proof, the receiver still verifies it in the
+	// group of its election
+	Suite       PedersenSuite
 	BPointOther []byte //basePointOther (for equality proof)
 	PPoint      []byte //publicPoint
 	PPointOther []byte //publicPointOther (for equality proof)
@@ -26,8 +88,64 @@ type Proof struct {
 	ResultOther      big.Int
 }
 
+// MarshalJSON implements json.Marshaler, see ProofCodec. It has a value
+// receiver so that proofs which are not addressable are encoded too.
+func (p Proof) MarshalJSON() ([]byte, error) {
+	return marshalProof(p.ProofType == "", func(codec ProofCodec) ([]byte, error) {
+		return codec.EncodeProof(&p)
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see ProofCodec
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	*p = Proof{}
+
+	return unmarshalProof(data, func(codec ProofCodec, encoded []byte) error {
+		decoded, err := codec.DecodeProof(encoded)
+		if err != nil {
+			return err
+		}
+		*p = *decoded
+		return nil
+	})
+}
+
+// RangeProof proves that a ciphertext encrypts one of the integers of a range,
+// without revealing which one. It is the OR-composition of one Chaum-Pedersen
+// proof per integer of the range, in increasing order: the challenges of the
+// branches xor to VerifierChall.
+type RangeProof struct {
+	ProofType string
+	// Suite is sent with the proof, see Proof
+	Suite         PedersenSuite
+	VerifierChall []byte
+	Branches      []Proof
+}
+
+// MarshalJSON implements json.Marshaler, see ProofCodec
+func (p RangeProof) MarshalJSON() ([]byte, error) {
+	return marshalProof(p.ProofType == "", func(codec ProofCodec) ([]byte, error) {
+		return codec.EncodeRangeProof(&p)
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see ProofCodec
+func (p *RangeProof) UnmarshalJSON(data []byte) error {
+	*p = RangeProof{}
+
+	return unmarshalProof(data, func(codec ProofCodec, encoded []byte) error {
+		decoded, err := codec.DecodeRangeProof(encoded)
+		if err != nil {
+			return err
+		}
+		*p = *decoded
+		return nil
+	})
+}
+
 type ShuffleInstance struct {
-	Curve    elliptic.Curve
+	// Suite is sent with the proof, see Proof
+	Suite    PedersenSuite
 	PPoint   Point
 	CtBefore []ElGamalCipherText
 	CtAfter  []ElGamalCipherText
@@ -58,3 +176,24 @@ type ShuffleProof struct {
 	SList              []big.Int
 	DScalar            big.Int
 }
+
+// MarshalJSON implements json.Marshaler, see ProofCodec
+func (p ShuffleProof) MarshalJSON() ([]byte, error) {
+	return marshalProof(p.ProofType == "", func(codec ProofCodec) ([]byte, error) {
+		return codec.EncodeShuffleProof(&p)
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, see ProofCodec
+func (p *ShuffleProof) UnmarshalJSON(data []byte) error {
+	*p = ShuffleProof{}
+
+	return unmarshalProof(data, func(codec ProofCodec, encoded []byte) error {
+		decoded, err := codec.DecodeShuffleProof(encoded)
+		if err != nil {
+			return err
+		}
+		*p = *decoded
+		return nil
+	})
+}
@@ -36,7 +36,7 @@ func (m VoteMessage) Name() string {
 
 // String implements types.Message.
 func (m VoteMessage) String() string {
-	return fmt.Sprintf("<%s> - Vote: %s", m.ElectionID, m.EncryptedVote)
+	return fmt.Sprintf("<%s> - Vote: %d encrypted choices", m.ElectionID, len(m.EncryptedVotes))
 }
 
 // HTML implements types.Message.
@@ -58,6 +58,10 @@ func (m ResultMessage) Name() string {
 
 // String implements types.Message.
 func (m ResultMessage) String() string {
+	if m.QuorumNotReached {
+		return fmt.Sprintf("<%s> - Quorum not reached: %d ballots", m.ElectionID, m.BallotCnt)
+	}
+
 	highestCount := uint(0)
 	winner := -1
 
@@ -80,7 +84,7 @@ func (m ResultMessage) HTML() string {
 
 // NewEmpty implements types.Message.
 func (m MixMessage) NewEmpty() Message {
-	return &StartElectionMessage{}
+	return &MixMessage{}
 }
 
 // Name implements types.Message.
@@ -97,3 +101,267 @@ func (m MixMessage) String() string {
 func (m MixMessage) HTML() string {
 	return m.String()
 }
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m MixComplaintMessage) NewEmpty() Message {
+	return &MixComplaintMessage{}
+}
+
+// Name implements types.Message.
+func (m MixComplaintMessage) Name() string {
+	return "mix-complaint"
+}
+
+// String implements types.Message.
+func (m MixComplaintMessage) String() string {
+	return fmt.Sprintf("<%s> - MixComplaint from mixnet server %d against %d", m.ElectionID, m.MixnetServerID, m.AccusedID)
+}
+
+// HTML implements types.Message.
+func (m MixComplaintMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m BallotReceiptMessage) NewEmpty() Message {
+	return &BallotReceiptMessage{}
+}
+
+// Name implements types.Message.
+func (m BallotReceiptMessage) Name() string {
+	return "ballot-receipt"
+}
+
+// String implements types.Message.
+func (m BallotReceiptMessage) String() string {
+	return fmt.Sprintf("<%s> - BallotReceipt from mixnet server %d for ballot %x", m.ElectionID, m.MixnetServerID, m.BallotHash)
+}
+
+// HTML implements types.Message.
+func (m BallotReceiptMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m CredentialRequestMessage) NewEmpty() Message {
+	return &CredentialRequestMessage{}
+}
+
+// Name implements types.Message.
+func (m CredentialRequestMessage) Name() string {
+	return "credential-request"
+}
+
+// String implements types.Message.
+func (m CredentialRequestMessage) String() string {
+	return fmt.Sprintf("<%s> - CredentialRequest from voter %x", m.ElectionID, m.VoterKey)
+}
+
+// HTML implements types.Message.
+func (m CredentialRequestMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m CredentialMessage) NewEmpty() Message {
+	return &CredentialMessage{}
+}
+
+// Name implements types.Message.
+func (m CredentialMessage) Name() string {
+	return "credential"
+}
+
+// String implements types.Message.
+func (m CredentialMessage) String() string {
+	return fmt.Sprintf("<%s> - Credential for blinded request %x", m.ElectionID, m.Blinded)
+}
+
+// HTML implements types.Message.
+func (m CredentialMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m DecryptionRequestMessage) NewEmpty() Message {
+	return &DecryptionRequestMessage{}
+}
+
+// Name implements types.Message.
+func (m DecryptionRequestMessage) Name() string {
+	return "decryption-request"
+}
+
+// String implements types.Message.
+func (m DecryptionRequestMessage) String() string {
+	return fmt.Sprintf("<%s> - DecryptionRequest: %d votes", m.ElectionID, m.VoteCnt)
+}
+
+// HTML implements types.Message.
+func (m DecryptionRequestMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m DecryptShareMessage) NewEmpty() Message {
+	return &DecryptShareMessage{}
+}
+
+// Name implements types.Message.
+func (m DecryptShareMessage) Name() string {
+	return "decrypt-share"
+}
+
+// String implements types.Message.
+func (m DecryptShareMessage) String() string {
+	return fmt.Sprintf("<%s> - DecryptShare from mixnet server %d", m.ElectionID, m.MixnetServerID)
+}
+
+// HTML implements types.Message.
+func (m DecryptShareMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m CancelElectionMessage) NewEmpty() Message {
+	return &CancelElectionMessage{}
+}
+
+// Name implements types.Message.
+func (m CancelElectionMessage) Name() string {
+	return "cancel-election"
+}
+
+// String implements types.Message.
+func (m CancelElectionMessage) String() string {
+	return fmt.Sprintf("<%s> - CancelElection: %s", m.ElectionID, m.Reason)
+}
+
+// HTML implements types.Message.
+func (m CancelElectionMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m ExtendElectionMessage) NewEmpty() Message {
+	return &ExtendElectionMessage{}
+}
+
+// Name implements types.Message.
+func (m ExtendElectionMessage) Name() string {
+	return "extend-election"
+}
+
+// String implements types.Message.
+func (m ExtendElectionMessage) String() string {
+	return fmt.Sprintf("<%s> - ExtendElection: until %s", m.ElectionID, m.Expiration)
+}
+
+// HTML implements types.Message.
+func (m ExtendElectionMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m RegisterCredentialMessage) NewEmpty() Message {
+	return &RegisterCredentialMessage{}
+}
+
+// Name implements types.Message.
+func (m RegisterCredentialMessage) Name() string {
+	return "register-credential"
+}
+
+// String implements types.Message.
+func (m RegisterCredentialMessage) String() string {
+	return fmt.Sprintf("<%s> - RegisterCredential of voter %x", m.ElectionID, m.VoterKey)
+}
+
+// HTML implements types.Message.
+func (m RegisterCredentialMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m PETRequestMessage) NewEmpty() Message {
+	return &PETRequestMessage{}
+}
+
+// Name implements types.Message.
+func (m PETRequestMessage) Name() string {
+	return "pet-request"
+}
+
+// String implements types.Message.
+func (m PETRequestMessage) String() string {
+	return fmt.Sprintf("<%s> - PETRequest: step %d, %d ballots", m.ElectionID, m.Step, m.BallotCnt)
+}
+
+// HTML implements types.Message.
+func (m PETRequestMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m PETShareMessage) NewEmpty() Message {
+	return &PETShareMessage{}
+}
+
+// Name implements types.Message.
+func (m PETShareMessage) Name() string {
+	return "pet-share"
+}
+
+// String implements types.Message.
+func (m PETShareMessage) String() string {
+	return fmt.Sprintf("<%s> - PETShare: step %d from mixnet server %d", m.ElectionID, m.Step, m.MixnetServerID)
+}
+
+// HTML implements types.Message.
+func (m PETShareMessage) HTML() string {
+	return m.String()
+}
+
+// ---
+
+// NewEmpty implements types.Message.
+func (m BulletinBoardMessage) NewEmpty() Message {
+	return &BulletinBoardMessage{}
+}
+
+// Name implements types.Message.
+func (m BulletinBoardMessage) Name() string {
+	return "bulletin-board"
+}
+
+// String implements types.Message.
+func (m BulletinBoardMessage) String() string {
+	return fmt.Sprintf("<%s> - BulletinBoard: %s", m.ElectionID, m.Entry.Type)
+}
+
+// HTML implements types.Message.
+func (m BulletinBoardMessage) HTML() string {
+	return m.String()
+}
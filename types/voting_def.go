@@ -1,23 +1,70 @@
 package types
 
 import (
+	"encoding/json"
+	"fmt"
 	"math/big"
-	"sync"
 	"time"
 )
 
 // --- Election Types ---
 
 type ElectionBase struct {
-	ElectionID  string
-	Announcer   string
-	Title       string
-	Description string
-	Choices     []Choice
-
-	Duration            time.Duration
-	Expiration          time.Time
-	MixnetServers       []string
+	ElectionID string
+	Announcer  string
+	// AnnouncerKey is the signing key of the announcer, which alone may
+	// cancel or extend the election (see CancelElectionMessage)
+	AnnouncerKey []byte
+	Title        string
+	Description  string
+	Choices      []Choice
+	// TallyMode tells whether the ballots are mixed before being counted
+	TallyMode TallyMode
+	// BallotType tells what a ballot expresses: a single choice, a ranking or
+	// a set of approved choices
+	BallotType BallotType
+	// MaxSelections is the most choices an approval ballot may select, it
+	// selects at least one
+	MaxSelections int
+	// Contests are the questions of a multi-contest election, each with its
+	// own choices and ballot type. Choices is then empty, and the results are
+	// reported per contest.
+	Contests []Contest
+	// EligibleVoters are the public keys which may sign a ballot, encoded as
+	// the keys of the packet headers. Any key may vote if empty.
+	EligibleVoters [][]byte
+	// VotePolicy tells which ballot counts when a voter sends several ones
+	VotePolicy VotePolicy
+	// AnonymousCredentials: the voters on the eligibility roll register a
+	// pseudonymous key, blindly signed by the announcer with CredentialKey (a
+	// PKCS #1 RSA public key), and sign their ballots with it. The first
+	// mixnet server can't link a ballot to its voter.
+	AnonymousCredentials bool
+	CredentialKey        []byte
+	// Revoting lets a voter replace its ballot by casting a new one. A voter
+	// registers an encrypted secret credential once (see
+	// RegisterCredentialMessage), its ballots carry the credential encrypted
+	// anew and no voter key. Before the mixing, plaintext equivalence tests
+	// keep the last ballot of each credential (see PETRequestMessage). It
+	// supersedes VotePolicy.
+	Revoting bool
+	// Quorum is the minimum turnout for the election to be valid, no minimum if
+	// zero
+	Quorum Quorum
+	// OpeningTime is when the votes start being accepted, the key generation
+	// runs ahead of it. The election opens as soon as its key is known if
+	// zero.
+	OpeningTime time.Time
+	// Suite is the group of the election's key, ballots and proofs
+	Suite PedersenSuite
+
+	Duration      time.Duration
+	Expiration    time.Time
+	MixnetServers []string
+	// MixnetServerKeys are the signing keys of the mixnet servers, in the
+	// order of MixnetServers, pinned by the announcer. A mixnet server whose
+	// key is nil didn't publish it in time, and can't take part.
+	MixnetServerKeys    [][]byte
 	MixnetServerInfos   []*MixnetServerInfo
 	MixnetServersPoints []int // Incremented when mixnet server is among qualified nodes in types.ElectionReadyMessage
 	Threshold           int
@@ -30,15 +77,229 @@ type ElectionBase struct {
 type Election struct {
 	Base   ElectionBase
 	MyVote int
+	// MyRanking is the ranking of this peer's ballot in a ranked election,
+	// MyVote is then its first preference
+	MyRanking []int
+	// MySelections are the choices of this peer's ballot in an approval
+	// election, MyVote is then the first of them
+	MySelections []int
+	// MyContestSelections are the choices of this peer's ballot in a
+	// multi-contest election, one selection per contest
+	MyContestSelections [][]int
+	// MyCredential is the signature of the announcer on the pseudonymous key of
+	// this peer, nil until registered
+	MyCredential []byte
+	// IssuedCredentials are the blinded requests the announcer signed, by hex
+	// encoded voter key. A voter gets one credential.
+	IssuedCredentials map[string][]byte
+	// MyBallotCounter is the number of ballots this peer prepared in a
+	// re-voting election. It is never sent: the ballots are ordered by the
+	// bulletin board.
+	MyBallotCounter uint64
+	// MyPETBlindShare is the share of this mixnet server at the PETBlind step
+	// of a re-voting election, nil until blinded. A mixnet server blinds once,
+	// and only decrypts sums which hold its share.
+	MyPETBlindShare *PETShareMessage
+	// CancelReason is why the announcer cancelled the election, empty unless
+	// cancelled
+	CancelReason string
+	// QuorumNotReached is true if the election finished without results
+	// because too few ballots were cast
+	QuorumNotReached bool
+	// MyBallotReceipt is the acknowledgement of the mixnet server which stored
+	// the ballot of this peer, nil until received
+	MyBallotReceipt *BallotReceiptMessage
 	// choiceID -> count
-	Results                  map[int]uint
-	Votes                    []VoteMessage
-	VoteWG                   *sync.WaitGroup
+	Results map[int]uint
+	Votes   []VoteMessage
+	// ContestResults are the results of a multi-contest election, in the
+	// order of the contests
+	ContestResults []ContestResult
+	// Phase is the current stage of the election, it only moves forward (see
+	// Phase.CanTransitionTo)
+	Phase Phase
+	// DKGTimedOut is true once the key generation is past its deadline: the
+	// election then starts without the ElectionReadyMessage of the mixnet
+	// servers which didn't answer
+	DKGTimedOut bool
+	// TallyCipherTexts are the ciphertexts of the decryption round, one per
+	// choice, and TallyVoteCnt the number of votes they aggregate
+	TallyCipherTexts []ElGamalCipherText
+	TallyVoteCnt     int
+	// mixnet server ID -> verified decryption shares, one per choice
+	DecryptShares map[int][]Point
+	// mixnet servers caught cheating during the mixing (see MixComplaintMessage)
+	EjectedMixnetServers map[int]struct{}
+	// mixnet servers whose types.ElectionReadyMessage was counted
+	ReadyMixnetServers       map[int]struct{}
 	ElectionStartedTimestamp time.Time
 	MixingStartedTimestamp   time.Time
 	ReceivedResultsTimestamp time.Time
 }
 
+// Phase is a stage of the lifecycle of an election.
+type Phase int
+
+const (
+	// PhaseAnnounced: the election is known, the mixnet servers did not start
+	// the key generation yet
+	PhaseAnnounced Phase = iota
+	// PhaseDKG: the mixnet servers are generating the election key
+	PhaseDKG
+	// PhaseOpen: the election key is known, votes are accepted
+	PhaseOpen
+	// PhaseClosed: the election expired, votes are not accepted anymore
+	PhaseClosed
+	// PhaseMixing: the votes are going through the mixnet servers
+	PhaseMixing
+	// PhaseTallying: the votes are summed and decrypted
+	PhaseTallying
+	// PhaseFinished: the results are known
+	PhaseFinished
+	// PhaseAborted: the election failed or was cancelled
+	PhaseAborted
+)
+
+var phaseNames = []string{"Announced", "DKG", "Open", "Closed", "Mixing", "Tallying", "Finished", "Aborted"}
+
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return fmt.Sprintf("Phase(%d)", int(p))
+	}
+	return phaseNames[p]
+}
+
+// IsFinal returns true if no transition is possible from the phase.
+func (p Phase) IsFinal() bool {
+	return p == PhaseFinished || p == PhaseAborted
+}
+
+// CanTransitionTo returns true if an election can move from p to next. Phases
+// only move forward, but can be skipped since a peer does not witness all of
+// them: a voter never sees the mixing for instance. Any phase which is not
+// final can be aborted.
+func (p Phase) CanTransitionTo(next Phase) bool {
+	if p.IsFinal() || next < PhaseAnnounced || next > PhaseAborted {
+		return false
+	}
+	return next > p
+}
+
+// TallyMode tells how the ballots of an election are counted.
+type TallyMode int
+
+const (
+	// TallyMixnet: the ballots are shuffled by the mixnet servers, then
+	// added together and the sum is decrypted
+	TallyMixnet TallyMode = iota
+	// TallyHomomorphic: the ballots are added together as they are, only their
+	// sum is decrypted. There is no mixing.
+	TallyHomomorphic
+)
+
+var tallyModeNames = []string{"mixnet", "homomorphic"}
+
+func (m TallyMode) String() string {
+	if m < 0 || int(m) >= len(tallyModeNames) {
+		return fmt.Sprintf("TallyMode(%d)", int(m))
+	}
+	return tallyModeNames[m]
+}
+
+// ParseTallyMode returns the tally mode with the given name
+func ParseTallyMode(name string) (TallyMode, error) {
+	for i, modeName := range tallyModeNames {
+		if modeName == name {
+			return TallyMode(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tally mode %q", name)
+}
+
+// BallotType tells what the voters express on their ballots.
+type BallotType int
+
+const (
+	// BallotSingle: the voter selects one choice
+	BallotSingle BallotType = iota
+	// BallotRanked: the voter ranks all the choices, the winner is elected by
+	// instant-runoff. Ranked ballots are decrypted one by one, so they must go
+	// through the mixnet.
+	BallotRanked
+	// BallotApproval: the voter selects between 1 and MaxSelections choices,
+	// each selected choice gets one vote
+	BallotApproval
+)
+
+var ballotTypeNames = []string{"single", "ranked", "approval"}
+
+func (b BallotType) String() string {
+	if b < 0 || int(b) >= len(ballotTypeNames) {
+		return fmt.Sprintf("BallotType(%d)", int(b))
+	}
+	return ballotTypeNames[b]
+}
+
+// ParseBallotType returns the ballot type with the given name
+func ParseBallotType(name string) (BallotType, error) {
+	for i, typeName := range ballotTypeNames {
+		if typeName == name {
+			return BallotType(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown ballot type %q", name)
+}
+
+// VotePolicy tells which ballot of a voter the first mixnet server keeps, a
+// voter being identified by the key which signs its ballots.
+type VotePolicy int
+
+const (
+	// FirstVoteCounts: the later ballots of a voter are rejected
+	FirstVoteCounts VotePolicy = iota
+	// LastVoteCounts: a new ballot replaces the previous one of the voter
+	LastVoteCounts
+)
+
+var votePolicyNames = []string{"first", "last"}
+
+func (p VotePolicy) String() string {
+	if p < 0 || int(p) >= len(votePolicyNames) {
+		return fmt.Sprintf("VotePolicy(%d)", int(p))
+	}
+	return votePolicyNames[p]
+}
+
+// ParseVotePolicy returns the vote policy with the given name
+func ParseVotePolicy(name string) (VotePolicy, error) {
+	for i, policyName := range votePolicyNames {
+		if policyName == name {
+			return VotePolicy(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown vote policy %q", name)
+}
+
+// Quorum is the minimum number of counted ballots of an election. If fewer
+// ballots are cast, the election is invalid: there are no results.
+type Quorum struct {
+	// Count is an absolute number of ballots
+	Count int
+	// Percent is a percentage of the eligible voters, it needs an eligibility
+	// roll
+	Percent int
+}
+
+// Required returns the number of ballots needed out of eligibleCnt eligible
+// voters: the highest of the two minimums.
+func (q Quorum) Required(eligibleCnt int) int {
+	required := (q.Percent*eligibleCnt + 99) / 100
+	if q.Count > required {
+		return q.Count
+	}
+	return required
+}
+
 type ElGamalCipherText struct {
 	Ct1 Point
 	Ct2 Point
@@ -59,7 +320,9 @@ func (election *Election) GetMyMixnetServerID(nodeAddr string) int {
 // IsElectionStarted checks if the election started (that is, one of the trusted mixnet
 // servers initiated the election and the peer is allowed to cast a vote)
 func (election *Election) IsElectionStarted() bool {
-	if election.Base.ElectionReadyCnt != len(election.Base.MixnetServers) {
+	allReady := election.Base.ElectionReadyCnt == len(election.Base.MixnetServers)
+	enoughReady := election.DKGTimedOut && election.Base.ElectionReadyCnt >= election.Base.Threshold
+	if !allReady && !enoughReady {
 		return false
 	}
 	initiator := election.GetFirstQualifiedInitiator()
@@ -84,48 +347,390 @@ func (election *Election) GetPublicKey() Point {
 	return election.Base.Initiators[election.GetFirstQualifiedInitiator()]
 }
 
+// GetBallotSize returns the number of ciphertexts of a ballot: one per choice,
+// or one per choice and rank for a ranked ballot. A multi-contest ballot holds
+// the ciphertexts of each contest, one contest after the other.
+func (election *Election) GetBallotSize() int {
+	if len(election.Base.Contests) > 0 {
+		ballotSize := 0
+		for i := range election.Base.Contests {
+			ballotSize += election.GetContestElection(i).GetBallotSize()
+		}
+		return ballotSize
+	}
+
+	if election.Base.BallotType == BallotRanked {
+		return len(election.Base.Choices) * len(election.Base.Choices)
+	}
+	return len(election.Base.Choices)
+}
+
+// GetContestElection returns a copy of the election restricted to its i-th
+// contest, which the single-contest code handles as a whole election
+func (election *Election) GetContestElection(i int) *Election {
+	contest := election.Base.Contests[i]
+
+	contestElection := *election
+	contestElection.Base.Choices = contest.Choices
+	contestElection.Base.BallotType = contest.BallotType
+	contestElection.Base.MaxSelections = contest.MaxSelections
+	contestElection.Base.Contests = nil
+
+	return &contestElection
+}
+
+// GetChoiceIDs returns the IDs of the election choices
+func (election *Election) GetChoiceIDs() []int {
+	choiceIDs := make([]int, len(election.Base.Choices))
+	for i, choice := range election.Base.Choices {
+		choiceIDs[i] = choice.ChoiceID
+	}
+	return choiceIDs
+}
+
 // GetNextMixHop returns the ID of the next mixnet node for mixing
 func (election *Election) GetNextMixHop(hop int) int {
 	for i := hop + 1; i < len(election.Base.MixnetServersPoints); i++ {
-		if election.Base.MixnetServersPoints[i] >= election.Base.Threshold {
+		if election.IsMixHop(i) {
 			return i
 		}
 	}
 	return -1
 }
 
+// GetPreviousMixHop returns the ID of the mixnet node which mixed the votes
+// right before the given hop, or -1 if hop is the first one
+func (election *Election) GetPreviousMixHop(hop int) int {
+	for i := hop - 1; i >= 0; i-- {
+		if election.IsMixHop(i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsMixHop checks whether the mixnet node takes part in the mixing, that is,
+// it is trusted and it was not ejected for cheating
+func (election *Election) IsMixHop(mixnetServerID int) bool {
+	_, ejected := election.EjectedMixnetServers[mixnetServerID]
+	return election.Base.MixnetServersPoints[mixnetServerID] >= election.Base.Threshold && !ejected
+}
+
 type Choice struct {
 	ChoiceID int
 	Name     string
 }
 
+// Contest is a question of a multi-contest election
+type Contest struct {
+	ContestID     int
+	Title         string
+	Choices       []Choice
+	BallotType    BallotType
+	MaxSelections int
+}
+
 type AnnounceElectionMessage struct {
 	Base ElectionBase
 }
 
+// CancelElectionMessage is broadcast by the announcer to abort the election,
+// for instance if its question was wrong. The signature of the announcer key
+// covers the election ID and the reason.
+type CancelElectionMessage struct {
+	ElectionID string
+	Reason     string
+	Signature  []byte
+}
+
+// ExtendElectionMessage is broadcast by the announcer to push the expiration
+// of the election out to Expiration, it never brings it forward. The
+// signature of the announcer key covers the election ID and the expiration.
+type ExtendElectionMessage struct {
+	ElectionID string
+	Expiration time.Time
+	Signature  []byte
+}
+
+// VoteMessage is a one-hot encrypted ballot: it holds one ciphertext per
+// election choice (in the order of ElectionBase.Choices), each of them
+// encrypting 0 or 1.
+//
+// A ranked ballot is a permutation matrix instead: the ciphertext at
+// rank*len(Choices)+i encrypts 1 if the choice i is at this rank (0 is the
+// first preference), and 0 otherwise.
+//
+// An approval ballot holds one ciphertext per election choice too, each of
+// them encrypting 1 if the choice is selected. Several choices may be selected.
+//
+// A multi-contest ballot holds the ciphertexts of each contest, one contest
+// after the other, so that the mixnet servers shuffle whole ballots: each hop
+// proves that the contests of a ballot stay together (see
+// MixMessage.BallotShuffleProofs). The proofs of each contest are in
+// ContestProofs.
 type VoteMessage struct {
-	ElectionID       string
-	EncryptedVote    ElGamalCipherText
-	CorrectVoteProof Proof
-	CorectEncProof   Proof
+	ElectionID     string
+	EncryptedVotes []ElGamalCipherText
+	// CorrectVoteProofs proves that each ciphertext encrypts either 0 or 1
+	CorrectVoteProofs []Proof
+	// SumProof proves that the ciphertexts add up to exactly one
+	SumProof Proof
+	// RankProofs proves that each row, then each column, of a ranked ballot
+	// adds up to exactly one: each rank holds one choice, and each choice has
+	// one rank
+	RankProofs []Proof
+	// SelectionsProof proves that an approval ballot selects between 1 and
+	// MaxSelections choices
+	SelectionsProof RangeProof
+	// ContestProofs are the proofs of each contest of a multi-contest ballot,
+	// the fields above only hold the proofs that each ciphertext encrypts
+	// either 0 or 1
+	ContestProofs []ContestProofs
+	// VoterKey is the public key of the voter and VoterSignature its
+	// signature of the ballot hash. With anonymous credentials, VoterKey is a
+	// pseudonym and Credential the signature of the announcer on it. The first
+	// mixnet server strips them before the first shuffle. A ballot of a
+	// re-voting election has none of them.
+	VoterKey       []byte
+	VoterSignature []byte
+	Credential     []byte
+	// In a re-voting election, EncryptedCredential is the registered
+	// credential of the voter, encrypted anew for the election key.
+	// CredentialSignature proves the knowledge of the credential and of the
+	// randomness, over the ballot hash, so that it can't be copied to another
+	// ballot. RollProof proves that the credential is the one of a
+	// registration among the first RollSize on the bulletin board, without
+	// telling which.
+	EncryptedCredential ElGamalCipherText
+	CredentialSignature []byte
+	RollSize            int
+	RollProof           []byte
 }
 
-type MixMessage struct {
+// ContestProofs are the proofs of one contest of a multi-contest ballot, see
+// the fields of the same name in VoteMessage
+type ContestProofs struct {
+	SumProof        Proof
+	RankProofs      []Proof
+	SelectionsProof RangeProof
+}
+
+// PreparedBallot is a ballot encrypted and signed by a voter, but not sent yet.
+// The voter either casts it or audits it. BallotHash identifies it, as in the
+// receipt of the mixnet server once it is cast.
+type PreparedBallot struct {
+	ElectionID string
+	Ballot     VoteMessage
+	BallotHash []byte
+}
+
+// BallotAudit reveals the randomness of an audited ballot, which is discarded
+// instead of being cast. The encryption of the selections can be checked
+// against the ballot with impl.VerifyBallotAudit.
+type BallotAudit struct {
 	ElectionID string
-	Votes      []VoteMessage
-	NextHop    int
+	Ballot     VoteMessage
+	// Selections are the selections of the voter, one per contest
+	Selections [][]int
+	// Randomness holds the randomness of each ciphertext of the ballot
+	Randomness []big.Int
+}
+
+// MixMessage is sent privately by a mixnet server, MixnetServerID, to the next
+// hop of the mixing with its output batch. The proofs of all the hops so far
+// are accumulated. The message is signed with the secret key share of the
+// mixnet server, which answers for the batch.
+type MixMessage struct {
+	ElectionID     string
+	MixnetServerID int
+	Votes          []VoteMessage
+	NextHop        int
 
 	// Proofs
-	ShuffleProofs      []ShuffleProof
-	ReEncryptionProofs []Proof
+	ShuffleProofs []ShuffleProof
+	// BallotShuffleProofs hold one proof per hop that the whole ballots are
+	// shuffled with the same permutation (see impl.ProveBallotShuffle)
+	BallotShuffleProofs []ShuffleProof
+	ReEncryptionProofs  []Proof
+
+	Signature []byte
+}
+
+// MixComplaintMessage is broadcast by a mixnet server which received a
+// types.MixMessage with invalid proofs. The accused mixnet server is ejected
+// from the mixing. The complaint is signed with the secret key share of the
+// complaining mixnet server.
+type MixComplaintMessage struct {
+	ElectionID     string
+	MixnetServerID int
+	AccusedID      int
+	Signature      []byte
+}
+
+// BallotReceiptMessage is broadcast by the mixnet server which stored a ballot.
+// The voter recognizes its ballot by the hash of its ciphertexts. The receipt
+// is signed with the secret key share of the mixnet server.
+type BallotReceiptMessage struct {
+	ElectionID     string
+	MixnetServerID int
+	BallotHash     []byte
+	Signature      []byte
+}
+
+// CredentialRequestMessage is sent privately by a voter to the announcer to
+// register for an election with anonymous credentials. Blinded is the blinded
+// hash of the pseudonymous key of the voter, and Signature the signature of
+// the voter's key on the eligibility roll. The credential is sent to Voter,
+// the address which signs with this key.
+type CredentialRequestMessage struct {
+	ElectionID string
+	Voter      string
+	VoterKey   []byte
+	Blinded    []byte
+	Signature  []byte
+}
+
+// CredentialMessage is the answer of the announcer to a
+// CredentialRequestMessage: the signature of the blinded hash.
+type CredentialMessage struct {
+	ElectionID     string
+	Blinded        []byte
+	BlindSignature []byte
+}
+
+// RegisterCredentialMessage is sent privately by a voter of a re-voting
+// election to the first qualified mixnet server, which posts it on the bulletin
+// board. It registers the secret credential s of the voter, as the encryption
+// of s*G for the election key. CredentialSignature proves the knowledge of s
+// and of the randomness. VoterKey, VoterSignature and Credential sign the
+// registration, as a ballot of an election without re-voting: a voter
+// registers once.
+type RegisterCredentialMessage struct {
+	ElectionID          string
+	EncryptedCredential ElGamalCipherText
+	CredentialSignature []byte
+	VoterKey            []byte
+	VoterSignature      []byte
+	Credential          []byte
+}
+
+// PETStep is a step of the plaintext equivalence tests of a
+// PETRequestMessage.
+type PETStep int
+
+const (
+	// PETBlind: each qualified mixnet server raises the quotients to a secret
+	// random exponent
+	PETBlind PETStep = iota
+	// PETDecrypt: the qualified mixnet servers decrypt the sums of the blinded
+	// quotients
+	PETDecrypt
+)
+
+// PETRequestMessage is broadcast by the first qualified mixnet server of a
+// re-voting election before the mixing, to find out which ballots hold the
+// same credential. For each pair of the first BallotCnt ballots on the bulletin
+// board, a plaintext equivalence test decrypts the quotient of their encrypted
+// credentials once blinded: it decrypts to zero if and only if the credentials
+// are the same. The mixnet servers recompute the quotients from the bulletin
+// board, the request doesn't hold them.
+//
+// At the PETDecrypt step, MixnetServerIDs are the mixnet servers whose blinded
+// quotients are added up. The request is signed with the key share of the
+// requesting mixnet server (MixnetServerID).
+type PETRequestMessage struct {
+	ElectionID      string
+	Step            PETStep
+	MixnetServerID  int
+	BallotCnt       int
+	MixnetServerIDs []int
+	Signature       []byte
+}
+
+// PETShareMessage is the answer of a qualified mixnet server to a
+// PETRequestMessage, one share per quotient. At the PETBlind step, a share is
+// z*ct with the commitment z*G in Commitments and two proofs, for the two
+// points of ct. At the PETDecrypt step, a share is a decryption share and its
+// proof. It is signed with the key share of the mixnet server.
+type PETShareMessage struct {
+	ElectionID     string
+	Step           PETStep
+	MixnetServerID int
+	Blinded        []ElGamalCipherText
+	Commitments    []Point
+	DecryptShares  []Point
+	Proofs         []Proof
+	Signature      []byte
+}
+
+// DecryptionRequestMessage is broadcast by the last mixnet server once the
+// votes are mixed. It holds the homomorphic sum of the mixed ballots, one
+// ciphertext per choice, that the qualified mixnet servers decrypt together.
+// It is signed with the key share of the requesting mixnet server
+// (MixnetServerID): the last mix hop, or the first qualified mixnet server in
+// homomorphic mode.
+type DecryptionRequestMessage struct {
+	ElectionID     string
+	MixnetServerID int
+	CipherTexts    []ElGamalCipherText
+	VoteCnt        int
+	Signature      []byte
+}
+
+// DecryptShareMessage holds the partial decryptions of a qualified mixnet
+// server, one per ciphertext of the types.DecryptionRequestMessage, together
+// with the proofs that they were computed with its Pedersen share.
+type DecryptShareMessage struct {
+	ElectionID     string
+	MixnetServerID int
+	DecryptShares  []Point
+	DecryptProofs  []Proof
 }
 
 type ResultMessage struct {
 	ElectionID string
 	Results    map[int]uint
+	// Rankings are the decrypted ranked ballots, in the order of the final mix
+	// batch, and Rounds the instant-runoff rounds computed from them. Both are
+	// empty for single choice ballots.
+	Rankings [][]int
+	Rounds   []RunoffRound
+	// RejectedBallots are the indexes in the final mix batch of the ranked
+	// ballots which don't decrypt to a ranking, they are not counted
+	RejectedBallots []int
+	// Contests are the results of a multi-contest election, in the order of
+	// the contests. The fields above are then empty.
+	Contests []ContestResult
+	// QuorumNotReached: only BallotCnt ballots were counted, fewer than the
+	// quorum of the election, so there are no results. The first mixnet
+	// server (MixnetServerID) signs the outcome with its key share.
+	QuorumNotReached bool
+	BallotCnt        int
+	MixnetServerID   int
+	Signature        []byte
 	// Proof
 }
 
+// ContestResult holds the results of one contest of a multi-contest
+// election, see the fields of the same name in ResultMessage
+type ContestResult struct {
+	ContestID       int
+	Results         map[int]uint
+	Rankings        [][]int
+	Rounds          []RunoffRound
+	RejectedBallots []int
+}
+
+// RunoffRound is a round of an instant-runoff count. Each ballot counts for
+// its preferred choice among the ones which are not eliminated yet.
+type RunoffRound struct {
+	// choiceID -> count, for the choices still in the race
+	Counts map[int]uint
+	// Eliminated is the choice with the fewest votes, eliminated at the end
+	// of the round, or -1 in the final round
+	Eliminated int
+}
+
 // Mixnet qualification status
 const (
 	NOT_DECIDED_YET = iota
@@ -136,9 +741,45 @@ const (
 // MixnetServerInfo contains the data about mixnet server which plays the role
 // in Pedersen DKG protocol
 type MixnetServerInfo struct {
-	ReceivedShare   big.Int
-	X               []Point
-	VerifiedCnt     int
-	ComplainedCnt   int
+	ReceivedShare big.Int
+	X             []Point
+	VerifiedCnt   int
+	ComplainedCnt int
+	// Validations tells, for each mixnet server which checked the share,
+	// whether it verified it or complained: each one counts once
+	Validations     map[int]bool
 	QualifiedStatus int
+	// ShareReceived is true once the share of the mixnet server arrived, it
+	// is disqualified if it misses the deadline (see
+	// peer.Configuration.DKGTimeout)
+	ShareReceived bool
+}
+
+// BulletinBoardEntry is one protocol artifact of an election. Type is the name
+// of the message and Payload its JSON encoding. An artifact which is otherwise
+// sent privately is posted by its Sender, which signs the entry with its
+// signing key. The broadcast messages are recorded as they are, unsigned: they
+// carry their own signature or proofs.
+type BulletinBoardEntry struct {
+	Type      string
+	Payload   json.RawMessage
+	Sender    string
+	Signature []byte
+}
+
+// BulletinBoard is the append-only public record of an election: the
+// announcement, the DKG commitments, the ballots, each mix batch with its
+// proofs, the decryption shares and the results. It can be exported and
+// verified offline.
+type BulletinBoard struct {
+	ElectionID string
+	Entries    []BulletinBoardEntry
+}
+
+// BulletinBoardMessage posts an artifact which is otherwise only sent
+// privately (a ballot, a mix batch, DKG commitments) on the bulletin board
+// of every peer.
+type BulletinBoardMessage struct {
+	ElectionID string
+	Entry      BulletinBoardEntry
 }